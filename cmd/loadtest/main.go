@@ -11,8 +11,10 @@ import (
 	"github.com/anrid/roboviewer/robo/config"
 	"github.com/anrid/roboviewer/robo/dg"
 	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/eventbus"
 	"github.com/anrid/roboviewer/robo/pkg/mqtt"
 	"github.com/anrid/roboviewer/robo/pkg/msgdel"
+	"github.com/anrid/roboviewer/robo/planning"
 	"github.com/anrid/roboviewer/robo/service"
 )
 
@@ -31,7 +33,7 @@ func main() {
 	robotRepo := dg.NewRobotRepository(conn)
 	areaRepo := dg.NewAreaRepository(conn)
 
-	robotSvc := service.NewRobotService(robotRepo)
+	robotSvc := service.NewRobotService(robotRepo, dg.NewSessionRepository(conn), dg.NewDockRepository(conn), eventbus.New())
 	areaSvc := service.NewAreaService(areaRepo)
 
 	del := msgdel.NewMessageDelegator(robotSvc)
@@ -46,10 +48,11 @@ func main() {
 		panic("err")
 	}
 
-	areas, err := areaSvc.List(ctx)
+	res, err := areaSvc.List(ctx, entity.ListAreasArgs{})
 	if err != nil {
 		panic("err")
 	}
+	areas := res.Areas
 
 	println("robots:", len(robots))
 	println("areas:", len(areas))
@@ -94,12 +97,14 @@ func newCleaningSession(id string, wg *sync.WaitGroup, c config.Config, r *entit
 	rc.Publish(c.TopicRobotSessionStart, startSessionMessage(r.UID, a.UID, 0, 0, time.Now().Unix()))
 	time.Sleep(1 * time.Second)
 
-	path := NewRobotSnakePath(200, a.SizeX, a.SizeY, r.Size)
+	// Follow a full coverage plan at 200 mm per update.
+	plan := planning.Boustrophedon(a.SizeX, a.SizeY, r.Size, a.PassesNeeded)
+	path := planning.Walk(plan.Waypoints, 200)
 	var x int
 	var y int
 
-	for move := 1; move <= moves; move++ {
-		x, y = path.NextPosition()
+	for move := 1; move <= moves && move <= len(path); move++ {
+		x, y = path[move-1].X, path[move-1].Y
 
 		println(id, "robot", r.UID, "move", move, "update", x, y)
 		rc.Publish(c.TopicRobotSessionUpdate, updateSessionMessage(r.UID, x, y, time.Now().Unix()))
@@ -121,83 +126,3 @@ func updateSessionMessage(robotID string, x, y int, unixTimestamp int64) string
 func endSessionMessage(robotID string, x, y int, unixTimestamp int64) string {
 	return fmt.Sprintf("%s/%d/%d/%d", robotID, x, y, unixTimestamp)
 }
-
-// RobotSnakePath creates a snake pattern, i.e. left to right, 1 down,
-// right to left, 1 down, then reverse back up again.
-// This path is created within the area given, for a robot with a
-// certain diameter.
-type RobotSnakePath struct {
-	speed       int
-	robotSize   int
-	robotCenter int
-	x           int
-	y           int
-	goRight     bool
-	goDown      bool
-	sizeX       int
-	sizeY       int
-}
-
-// NewRobotSnakePath creates a new RobotSnakePath.
-func NewRobotSnakePath(speed, sizeX, sizeY, robotSize int) *RobotSnakePath {
-	return &RobotSnakePath{
-		speed:       speed,
-		sizeX:       sizeX,
-		sizeY:       sizeY,
-		robotSize:   robotSize,
-		robotCenter: robotSize / 2,
-		goRight:     true,
-		goDown:      true,
-	}
-}
-
-// NextPosition returns the next position along the robot's path.
-func (p *RobotSnakePath) NextPosition() (int, int) {
-	if p.goRight {
-		p.x += p.speed
-		if p.x > (p.sizeX - p.robotCenter) {
-			// Are we past the right wall?
-			p.x = p.sizeX - p.robotCenter
-			p.goRight = false
-
-			// "Teleport" one row down or up.
-			if p.goDown {
-				p.y += p.robotSize
-				if p.y > (p.sizeY - p.robotCenter) {
-					p.y = p.sizeY - p.robotCenter
-					p.goDown = !p.goDown
-				}
-			} else {
-				p.y -= p.robotSize
-				if p.y < p.robotCenter {
-					p.y = p.robotCenter
-					p.goDown = !p.goDown
-				}
-			}
-		}
-	} else {
-		// Subtract from position instead.
-		p.x -= p.speed
-		if p.x < p.robotCenter {
-			// Are we past the left wall?
-			p.x = p.robotCenter
-			p.goRight = true
-
-			// "Teleport" one row down or up.
-			if p.goDown {
-				p.y += p.robotSize
-				if p.y > (p.sizeY - p.robotCenter) {
-					p.y = p.sizeY - p.robotCenter
-					p.goDown = !p.goDown
-				}
-			} else {
-				p.y -= p.robotSize
-				if p.y < p.robotCenter {
-					p.y = p.robotCenter
-					p.goDown = !p.goDown
-				}
-			}
-		}
-	}
-	return p.x, p.y
-}
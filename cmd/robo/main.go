@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/config"
+	"github.com/anrid/roboviewer/robo/dg"
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/render"
+	"github.com/anrid/roboviewer/robo/service"
+)
+
+const usage = `Usage: robo [flags] <command> [command flags]
+
+Commands:
+  replay    Render an animated GIF replay of a cleaning session.
+
+Run 'robo <command> -h' for command flags.
+`
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+
+	c := config.GetConfig()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch args[0] {
+	case "replay":
+		err = replay(c, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command '%s'\n\n", args[0])
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+}
+
+// replay renders an animated GIF replay of a cleaning session to a
+// file.
+func replay(c config.Config, args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	sessionID := fs.String("session", "", "session ID (required)")
+	out := fs.String("o", "replay.gif", "output file")
+	from := fs.String("from", "", "only replay positions passed at or after this time (RFC 3339)")
+	to := fs.String("to", "", "only replay positions passed before this time (RFC 3339)")
+	speedUp := fs.Float64("speed-up", render.DefaultReplaySpeedUp, "number of times faster than real time")
+	fps := fs.Int("fps", render.DefaultReplayFPS, "frames per second")
+	pixelSize := fs.Int("pixel-size", 0, "millimeters per pixel (default: fits the longest side of the area within 800 pixels)")
+	_ = fs.Parse(args)
+
+	if *sessionID == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	a := entity.WithPositionsArgs{SessionID: *sessionID}
+	var err error
+	if a.From, err = parseTime(*from); err != nil {
+		return err
+	}
+	if a.To, err = parseTime(*to); err != nil {
+		return err
+	}
+
+	conn, cancel := dg.Connect(c.DgraphURL)
+	defer cancel()
+
+	svc := service.NewSessionService(dg.NewSessionRepository(conn))
+
+	sess, err := svc.WithPositions(context.Background(), a)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = render.ReplayGIF(f, sess, render.ReplayOptions{
+		Options: render.Options{PixelSize: *pixelSize},
+		SpeedUp: *speedUp,
+		FPS:     *fps,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("wrote replay of session %s with %d positions to %s\n", sess.UID, len(sess.PositionHistory), *out)
+	return nil
+}
+
+func parseTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -1,6 +1,5 @@
 // GENERATED BY THE COMMAND ABOVE; DO NOT EDIT
-// This file was generated by swaggo/swag at
-// 2020-02-16 14:31:26.659743 +0900 JST m=+0.037630201
+// This file was generated by swaggo/swag
 
 package docs
 
@@ -34,21 +33,41 @@ var doc = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/v1/areas": {
+        "/v1/alerts": {
             "get": {
-                "description": "List all areas.",
+                "description": "List fired alerts, latest first, with their delivery status (pending, delivered or failed) and a log of every delivery attempt.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "List all areas.",
+                "summary": "List alerts.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Alerts fired by this rule",
+                        "name": "rule_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Alerts fired for this session",
+                        "name": "session_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max number of alerts to return (default: 100, max: 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/controller.ListAreasResponseV1"
+                            "$ref": "#/definitions/controller.AlertsResponseV1"
                         }
                     },
                     "400": {
@@ -60,35 +79,178 @@ var doc = `{
                 }
             }
         },
-        "/v1/robots": {
+        "/v1/alerts/rules": {
             "get": {
-                "description": "List all robots and their active cleaning session.",
+                "description": "List all alert rules, oldest first. Secrets are not included.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "List all robots and their active cleaning session.",
+                "summary": "List alert rules.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.AlertRulesResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new alert rule. Rules are checked every minute against active sessions and sessions that ended after the rule was created, and fire at most once per session. Rule types and their threshold: session_abandoned (session ended before every square was cleaned), robot_offline (no report in threshold minutes, default 10), low_completion (session ended below threshold percent, default 80), robot_stuck (stuck anomaly detected), long_session (running longer than threshold times the typical time to clean the area, default 1), low_battery (robot reported a battery level below threshold percent, default 20) and robot_error (robot reported an error state or error codes). Alerts are POSTed as JSON to the webhook URL, signed with an HMAC-SHA256 of the body in the X-Roboviewer-Signature header (sha256=\u003chex\u003e), and retried with exponential backoff. The secret is generated unless given and only returned on create.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create a new alert rule.",
+                "parameters": [
+                    {
+                        "description": "Alert rule to create",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.CreateAlertRuleRequestV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.AlertRuleResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/alerts/rules/{rule_id}": {
+            "get": {
+                "description": "Get an alert rule. The secret is not included.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get an alert rule.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Robot ID to filter on",
-                        "name": "robot_id",
-                        "in": "query"
+                        "description": "Alert rule ID",
+                        "name": "rule_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.AlertRuleResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete an alert rule. Alerts it fired that are still pending delivery are dropped.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete an alert rule.",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Robot name to filter on",
-                        "name": "name",
-                        "in": "query"
+                        "description": "Alert rule ID",
+                        "name": "rule_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/controller.ListRobotsResponseV1"
+                            "$ref": "#/definitions/controller.OkResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update an alert rule. Omitted fields are left unchanged. The rule type cannot be changed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update an alert rule.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Alert rule ID",
+                        "name": "rule_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.UpdateAlertRuleRequestV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.AlertRuleResponseV1"
                         }
                     },
                     "400": {
@@ -96,32 +258,49 @@ var doc = `{
                         "schema": {
                             "$ref": "#/definitions/cerr.ErrorResponse"
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/v1/robots/{robot_id}/history": {
+        "/v1/areas": {
             "get": {
-                "description": "Get all historical cleaning sessions for a robot.",
+                "description": "List areas, newest first. Use next_cursor from the response to fetch the next page.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get all historical cleaning sessions for a robot.",
+                "summary": "List areas.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Robot ID to show history for",
-                        "name": "robot_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Fulltext search on area name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include the regions of partitioned areas (default: false)",
+                        "name": "regions",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by a previous call",
+                        "name": "cursor",
+                        "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "Return only max latest number of cleaning sessions for robot (default: 10)",
-                        "name": "max",
+                        "description": "Max number of areas to return (default: 100, max: 1000)",
+                        "name": "limit",
                         "in": "query"
                     }
                 ],
@@ -129,7 +308,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/controller.RobotHistoryResponseV1"
+                            "$ref": "#/definitions/controller.ListAreasResponseV1"
                         }
                     },
                     "400": {
@@ -139,100 +318,4123 @@ var doc = `{
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "cerr.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "string"
-                },
-                "error": {
-                    "type": "string"
-                },
-                "ok": {
-                    "type": "boolean"
+            },
+            "post": {
+                "description": "Create a new area.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create a new area.",
+                "parameters": [
+                    {
+                        "description": "Area to create",
+                        "name": "area",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.CreateAreaRequestV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.AreaResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "controller.ListAreasResponseV1": {
-            "type": "object",
-            "properties": {
-                "areas": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/entity.Area"
+        "/v1/areas/{area_id}": {
+            "get": {
+                "description": "Get an area.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get an area.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Area ID",
+                        "name": "area_id",
+                        "in": "path",
+                        "required": true
                     }
-                },
-                "ok": {
-                    "type": "boolean"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.AreaResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete an area. Past cleaning sessions are not affected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete an area.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Area ID",
+                        "name": "area_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.OkResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update an area. Omitted fields are left unchanged. Past cleaning sessions are not affected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update an area.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Area ID",
+                        "name": "area_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "area",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.UpdateAreaRequestV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.AreaResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/areas/{area_id}/docks": {
+            "get": {
+                "description": "List the docks (charging stations) in an area.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List an area's docks.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Area ID",
+                        "name": "area_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.DocksResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Place a dock (charging station) in an area. Robots pause their cleaning session while docked, optionally at a given dock, and resume it with the same grid when they leave. Time spent docked doesn't count towards the session's duration.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create a dock.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Area ID",
+                        "name": "area_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Dock name and position within the area",
+                        "name": "dock",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.CreateDockRequestV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.DockResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/areas/{area_id}/geojson": {
+            "get": {
+                "description": "Get an area's outline as a GeoJSON FeatureCollection in WGS84, placed using the area's anchor. Fails if the area has no anchor.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get an area as GeoJSON.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Area ID",
+                        "name": "area_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/geo.FeatureCollection"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/areas/{area_id}/partitions": {
+            "get": {
+                "description": "List the partitions of an area, latest first, including their regions.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List an area's partitions.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Area ID",
+                        "name": "area_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.PartitionsResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Split a large area into rectangular regions, one per robot, balanced so that every robot gets about the same number of grid squares given its size. A robot twice the size of another gets a region four times as large. Each region is created as an area of its own with the parent area's id and its offset within the parent area. Defaults to all robots without an active session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Split an area between several robots.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Area ID",
+                        "name": "area_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Robots to split the area between",
+                        "name": "partition",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.CreatePartitionRequestV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.PartitionResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/docks/{dock_id}": {
+            "delete": {
+                "description": "Delete a dock. Sessions paused at it are unaffected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete a dock.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dock ID",
+                        "name": "dock_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.OkResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/export/positions": {
+            "get": {
+                "description": "Stream every position matching the given filters as CSV or newline delimited JSON (one position per line). Positions are grouped by session, latest started session first, and ordered by the time they were passed within each session.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "summary": "Export positions.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv or ndjson (default: csv)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only positions from sessions run by this robot",
+                        "name": "robot_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only positions from sessions cleaning this area",
+                        "name": "area_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only positions from this session",
+                        "name": "session_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only positions passed at or after this time (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only positions passed before this time (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.PositionRecord"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/export/sessions": {
+            "get": {
+                "description": "Stream every cleaning session matching the given filters as CSV or newline delimited JSON (one session per line), latest started first.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "summary": "Export cleaning sessions.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv or ndjson (default: csv)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only sessions run by this robot",
+                        "name": "robot_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only sessions cleaning this area",
+                        "name": "area_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only sessions started at or after this time (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only sessions started before this time (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/controller.SessionExportRowV1"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/partitions/{partition_id}": {
+            "get": {
+                "description": "Get a partition together with the progress of the latest cleaning session in each region. Completion rolls up to the parent area as the share of all squares across all regions that are cleaned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a partition.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Partition ID",
+                        "name": "partition_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.PartitionSummaryResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/partitions/{partition_id}/dispatch": {
+            "post": {
+                "description": "Publish a start_session command over MQTT to every robot in a partition on the topic /robot/command/{robot_id}, with the id of the region's area and the region itself, i.e. its offset and size within the parent area.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Dispatch a partition.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Partition ID",
+                        "name": "partition_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.PartitionResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/partitions/{partition_id}/regions.svg": {
+            "get": {
+                "description": "Draw a partitioned area to scale with every region outlined, showing its robot's grid and filled by the completion of its latest session (green once cleaned).",
+                "produces": [
+                    "image/svg+xml"
+                ],
+                "summary": "Get a partition as an SVG.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Partition ID",
+                        "name": "partition_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Millimeters per pixel (default: fits the longest side of the area within 800 pixels)",
+                        "name": "pixel_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/reports/sessions": {
+            "get": {
+                "description": "Aggregate cleaning sessions started within a time range, grouped by robot, area or day: sessions started, ended, completed (every square cleaned) and abandoned, total cleaning time of ended sessions, mean completion of ended sessions and mean time to clean every square in completed sessions. Days start at midnight in the time zone of from.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a cleaning session report.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group by robot, area or day (default: robot)",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sessions started at or after this time (RFC 3339, default: a week ago)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sessions started before this time (RFC 3339, default: now)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.SessionReportResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/robots": {
+            "get": {
+                "description": "List all robots and their active cleaning session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List all robots and their active cleaning session.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Robot ID to filter on",
+                        "name": "robot_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Robot name to filter on",
+                        "name": "name",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.ListRobotsResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new robot. Robot names must be unique.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create a new robot.",
+                "parameters": [
+                    {
+                        "description": "Robot to create",
+                        "name": "robot",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.CreateRobotRequestV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.RobotResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/robots/{robot_id}": {
+            "get": {
+                "description": "Get a robot and its active cleaning session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a robot and its active cleaning session.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Robot ID",
+                        "name": "robot_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.RobotResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a robot. Its historical cleaning sessions remain available. Fails if the robot has an active cleaning session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete a robot.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Robot ID",
+                        "name": "robot_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.OkResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update a robot's name and / or size. Omitted fields are left unchanged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update a robot.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Robot ID",
+                        "name": "robot_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "robot",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.UpdateRobotRequestV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.RobotResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/robots/{robot_id}/history": {
+            "get": {
+                "description": "Get historical cleaning sessions for a robot, latest started first. Use next_cursor from the response to fetch the next page.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get historical cleaning sessions for a robot.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Robot ID to show history for",
+                        "name": "robot_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Return only max latest number of cleaning sessions for robot (default: 10, max: 100)",
+                        "name": "max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only sessions started at or after this time (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only sessions started before this time (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by a previous call",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include position history (default: true)",
+                        "name": "positions",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include grids (default: true)",
+                        "name": "grid",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.RobotHistoryResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/robots/{robot_id}/status": {
+            "get": {
+                "description": "Get a robot's last reported position and the completion of its latest cleaning session, including an ETA with a confidence between 0 and 1 for active sessions. The ETA blends the recent rate at which squares are cleaned, the passes still needed across the grid and how long past sessions by the same robot in the same area took.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a robot's current position and completion.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Robot ID",
+                        "name": "robot_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.RobotStatusResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/robots/{robot_id}/telemetry": {
+            "get": {
+                "description": "Get the battery level, state, error codes and brush and filter wear a robot has reported together with its position, latest first. The latest telemetry is also included with the robot itself as last_telemetry.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get telemetry reported by a robot.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Robot ID",
+                        "name": "robot_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only telemetry reported during this session",
+                        "name": "session_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only telemetry reported at or after this time (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only telemetry reported before this time (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max number of reports (default: 1000, max: 10000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.RobotTelemetryResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/schedules": {
+            "get": {
+                "description": "List all cleaning schedules, oldest first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List schedules.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.SchedulesResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a recurring cleaning job. At every trigger time of the cron expression (minute, hour, day of month, month, day of week), in the given time zone, a start_session command is published over MQTT to the first idle robot of the schedule on the topic /robot/command/{robot_id}. Every trigger is recorded as a job run, which is marked started with the session id once the robot starts a session in the area, or missed if no robot was idle or no session started within 5 minutes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create a new schedule.",
+                "parameters": [
+                    {
+                        "description": "Schedule to create",
+                        "name": "schedule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.CreateScheduleRequestV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.ScheduleResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/schedules/{schedule_id}": {
+            "get": {
+                "description": "Get a cleaning schedule.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a schedule.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "schedule_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.ScheduleResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a cleaning schedule. It won't trigger again, past runs are kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete a schedule.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "schedule_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.OkResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update a cleaning schedule. Omitted fields are left unchanged, robot ids are replaced. The next run time is recalculated.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update a schedule.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "schedule_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "schedule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.UpdateScheduleRequestV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.ScheduleResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/schedules/{schedule_id}/runs": {
+            "get": {
+                "description": "List runs of a cleaning schedule, latest first, with their status (dispatched, started or missed), the robot dispatched and the session started.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List job runs.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "schedule_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max number of runs to return (default: 100, max: 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.JobRunsResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sessions": {
+            "get": {
+                "description": "Search cleaning sessions across all robots, latest started first. Use next_cursor from the response to fetch the next page.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Search cleaning sessions.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only sessions run by this robot",
+                        "name": "robot_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only sessions cleaning this area",
+                        "name": "area_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only sessions started at or after this time (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only sessions started before this time (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only active (true) or inactive (false) sessions",
+                        "name": "active",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by a previous call",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max number of sessions to return (default: 100, max: 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.ListSessionsResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sessions/{session_id}": {
+            "get": {
+                "description": "Get a cleaning session, the robot that ran it and its progress, including an ETA with a confidence between 0 and 1 for active sessions (see robot status). Does not include the grid or position history.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a cleaning session.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.SessionResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sessions/{session_id}/anomalies": {
+            "get": {
+                "description": "Get the anomalies detected while the robot was reporting its position, oldest first: stuck (no net movement), oscillating (moving back and forth between a few squares), out_of_bounds (outside the area) and jump (moved faster than physically possible). Each anomaly is also published as a session.anomaly event.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a cleaning session's anomalies.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.SessionAnomaliesResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sessions/{session_id}/geojson": {
+            "get": {
+                "description": "Get a cleaning session's area outline, grid squares (with passes and cleaned_at) and the path taken by the robot (with a timestamp per coordinate) as a GeoJSON FeatureCollection in WGS84, placed using the anchor of the session's area. Fails if the area has no anchor.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a cleaning session as GeoJSON.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include grid squares (default: true)",
+                        "name": "grid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only positions passed at or after this time (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only positions passed before this time (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/geo.FeatureCollection"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sessions/{session_id}/grid": {
+            "get": {
+                "description": "Get a cleaning session's cleaning area including every grid square and its number of passes. Pass at to get the grid as it was at a certain point in time, rebuilt by replaying the session's position history.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a cleaning session's grid.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Rebuild the grid as it was at this time (RFC 3339)",
+                        "name": "at",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.SessionGridResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sessions/{session_id}/grid.png": {
+            "get": {
+                "description": "Draw a cleaning session's area to scale with each grid square colored by its number of passes relative to passes needed, cleaned squares in green and the robot's last position in red.",
+                "produces": [
+                    "image/png"
+                ],
+                "summary": "Get a cleaning session's grid as a PNG heatmap.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Millimeters per pixel (default: fits the longest side of the area within 800 pixels)",
+                        "name": "pixel_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sessions/{session_id}/grid/check": {
+            "get": {
+                "description": "Replay a cleaning session's entire position history on a fresh grid and compare the result with the persisted grid. Lists every square whose number of passes or cleaned state differ.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Check a cleaning session's grid for drift.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.GridDriftResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sessions/{session_id}/path.svg": {
+            "get": {
+                "description": "Draw a cleaning session's area to scale with its outline and grid, and the path taken by the robot colored by time (blue to pink) with start and end markers. The path is as wide as the robot.",
+                "produces": [
+                    "image/svg+xml"
+                ],
+                "summary": "Get a cleaning session's path as an SVG.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only positions passed at or after this time (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only positions passed before this time (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Millimeters per pixel (default: fits the longest side of the area within 800 pixels)",
+                        "name": "pixel_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sessions/{session_id}/plan": {
+            "get": {
+                "description": "Get the waypoints a robot should follow to clean a session's area, sweeping back and forth along rows of grid squares one robot diameter apart. By default the plan resumes from the robot's last reported position and visits only the squares not yet cleaned, each the number of passes it still needs. Set full to plan the entire area from the top-left corner instead. Consecutive waypoints always share an x or y coordinate. Robots can also request a plan over MQTT.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a coverage plan for a cleaning session.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Plan the entire area instead of resuming (default: false)",
+                        "name": "full",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.SessionPlanResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sessions/{session_id}/positions": {
+            "get": {
+                "description": "List a cleaning session's position history in the order the positions were passed. Use next_cursor from the response to fetch the next page.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List a cleaning session's positions.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only positions passed at or after this time (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only positions passed before this time (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by a previous call",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max number of positions to return (default: 1000, max: 10000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.ListPositionsResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sessions/{session_id}/replay.gif": {
+            "get": {
+                "description": "Replay how the robot moved and how the grid filled up during a cleaning session as an animated GIF heatmap (see grid.png). Each frame re-applies the session's position history on a fresh grid. Frames are at most 2000 pixels on any side and a replay at most 268435456 pixels across all frames.",
+                "produces": [
+                    "image/gif"
+                ],
+                "summary": "Get an animated replay of a cleaning session.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only replay positions passed at or after this time (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only replay positions passed before this time (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of times faster than real time (default: 60)",
+                        "name": "speed_up",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Frames per second (default: 10, max: 50)",
+                        "name": "fps",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Millimeters per pixel (default: fits the longest side of the area within 800 pixels)",
+                        "name": "pixel_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sessions/{session_id}/stats": {
+            "get": {
+                "description": "Get coverage and efficiency analytics for a cleaning session: distance travelled, area covered, coverage and cleaned percentage, overlap, idle time, average speed and time to reach 25, 50, 75, 90 and 100 percent completion. Stats are computed on demand and cached once the session has ended.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a cleaning session's stats.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.SessionStatsResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/stream": {
+            "get": {
+                "description": "Streams the same session events as the WebSocket endpoint using text/event-stream. Event IDs are formatted as \u003cepoch\u003e-\u003cid\u003e, where the epoch changes when the server restarts. Reconnecting clients sending Last-Event-ID get any missed events replayed, as far back as the server remembers. If events may have been missed, e.g. after a restart, a resync event is sent first, followed by all remembered events.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "summary": "Stream live session events as Server-Sent Events.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated robot IDs to subscribe to",
+                        "name": "robot_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated area IDs to subscribe to",
+                        "name": "area_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resume after this event ID",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/webhooks": {
+            "get": {
+                "description": "List all webhooks, oldest first. Secrets are not included.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List webhooks.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.WebhooksResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Subscribe a URL to session lifecycle events: session.started, session.paused (robot docked), session.resumed (robot left its dock), session.ended, area.cleaned (completion reaches 100%) and robot.offline (an active session's robot hasn't reported in 10 minutes, unless docked). Events are POSTed as JSON, signed with an HMAC-SHA256 of the body in the X-Roboviewer-Signature header (sha256=\u003chex\u003e), and retried with exponential backoff. Events are recorded together with the session change that caused them, so none are lost if the server restarts before delivery. The secret is generated unless given and only returned on create.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create a new webhook.",
+                "parameters": [
+                    {
+                        "description": "Webhook to create",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.CreateWebhookRequestV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.WebhookResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/webhooks/{webhook_id}": {
+            "get": {
+                "description": "Get a webhook. The secret is not included.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a webhook.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.WebhookResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a webhook. Events still pending delivery to it are dropped.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete a webhook.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.OkResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update a webhook. Omitted fields are left unchanged, event types are replaced.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update a webhook.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controller.UpdateWebhookRequestV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.WebhookResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/webhooks/{webhook_id}/deliveries": {
+            "get": {
+                "description": "List events sent to a webhook, latest first, with their delivery status (pending, delivered or failed) and a log of every delivery attempt.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List webhook deliveries.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max number of events to return (default: 100, max: 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.WebhookMessagesResponseV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/cerr.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/ws": {
+            "get": {
+                "description": "Streams session events (session.started, session.paused, session.resumed, session.position, session.square_passed, session.square_cleaned, session.completion_changed, session.ended) as JSON messages. Subscribe to robots and / or areas using query params, or at any time by sending a SubscribeMessageV1. No filter means all events. Clients without an Origin header can always connect; browsers only from the API's own host or an allowed origin (see -allowed-origins).",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Stream live session events over a WebSocket.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated robot IDs to subscribe to",
+                        "name": "robot_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated area IDs to subscribe to",
+                        "name": "area_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Event"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "cerr.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "controller.AlertRuleResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "rule": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.AlertRule"
+                }
+            }
+        },
+        "controller.AlertRulesResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.AlertRule"
+                    }
+                }
+            }
+        },
+        "controller.AlertsResponseV1": {
+            "type": "object",
+            "properties": {
+                "alerts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Alert"
+                    }
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "controller.AreaResponseV1": {
+            "type": "object",
+            "properties": {
+                "area": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.Area"
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "controller.CreateAlertRuleRequestV1": {
+            "type": "object",
+            "required": [
+                "name",
+                "rule_type",
+                "webhook_url"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "rule_type": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "webhook_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "controller.CreateAreaRequestV1": {
+            "type": "object",
+            "required": [
+                "name",
+                "passes_needed",
+                "size_x",
+                "size_y"
+            ],
+            "properties": {
+                "anchor": {
+                    "description": "Where the area's top-left corner is on the globe (optional).",
+                    "type": "object",
+                    "$ref": "#/definitions/entity.GeoAnchor"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "passes_needed": {
+                    "type": "integer"
+                },
+                "size_x": {
+                    "description": "X side size in millimeters.",
+                    "type": "integer"
+                },
+                "size_y": {
+                    "description": "Y side size in millimeters.",
+                    "type": "integer"
+                }
+            }
+        },
+        "controller.CreateDockRequestV1": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "integer"
+                },
+                "y": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controller.CreatePartitionRequestV1": {
+            "type": "object",
+            "properties": {
+                "robot_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "controller.CreateRobotRequestV1": {
+            "type": "object",
+            "required": [
+                "name",
+                "size"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "size": {
+                    "description": "Diameter in millimeters.",
+                    "type": "integer"
+                }
+            }
+        },
+        "controller.CreateScheduleRequestV1": {
+            "type": "object",
+            "required": [
+                "area_id",
+                "cron",
+                "name",
+                "robot_ids"
+            ],
+            "properties": {
+                "area_id": {
+                    "type": "string"
+                },
+                "cron": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "passes_needed": {
+                    "type": "integer"
+                },
+                "robot_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "time_zone": {
+                    "type": "string"
+                }
+            }
+        },
+        "controller.CreateWebhookRequestV1": {
+            "type": "object",
+            "required": [
+                "event_types",
+                "name",
+                "webhook_url"
+            ],
+            "properties": {
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "webhook_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "controller.DockResponseV1": {
+            "type": "object",
+            "properties": {
+                "dock": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.Dock"
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "controller.DocksResponseV1": {
+            "type": "object",
+            "properties": {
+                "docks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Dock"
+                    }
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "controller.GridDriftResponseV1": {
+            "type": "object",
+            "properties": {
+                "check": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.GridDrift"
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "controller.JobRunsResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "runs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.JobRun"
+                    }
+                }
+            }
+        },
+        "controller.ListAreasResponseV1": {
+            "type": "object",
+            "properties": {
+                "areas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Area"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "controller.ListPositionsResponseV1": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "ok": {
+                    "type": "boolean"
+                },
+                "positions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Position"
+                    }
+                }
+            }
+        },
+        "controller.ListRobotsResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "robots": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Robot"
+                    }
+                }
+            }
+        },
+        "controller.ListSessionsResponseV1": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "ok": {
+                    "type": "boolean"
+                },
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.SessionSummary"
+                    }
+                }
+            }
+        },
+        "controller.OkResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "controller.PartitionResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "partition": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.Partition"
+                }
+            }
+        },
+        "controller.PartitionSummaryResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "summary": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.PartitionSummary"
+                }
+            }
+        },
+        "controller.PartitionsResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "partitions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Partition"
+                    }
+                }
+            }
+        },
+        "controller.RobotHistoryResponseV1": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "ok": {
+                    "type": "boolean"
+                },
+                "robot": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.Robot"
+                }
+            }
+        },
+        "controller.RobotResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "robot": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.Robot"
+                }
+            }
+        },
+        "controller.RobotStatusResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.RobotStatus"
+                }
+            }
+        },
+        "controller.RobotTelemetryResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "telemetry": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Telemetry"
+                    }
+                }
+            }
+        },
+        "controller.ScheduleResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "schedule": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.Schedule"
+                }
+            }
+        },
+        "controller.SchedulesResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "schedules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Schedule"
+                    }
+                }
+            }
+        },
+        "controller.SessionAnomaliesResponseV1": {
+            "type": "object",
+            "properties": {
+                "anomalies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Anomaly"
+                    }
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "controller.SessionExportRowV1": {
+            "type": "object",
+            "properties": {
+                "area_id": {
+                    "type": "string"
+                },
+                "area_name": {
+                    "type": "string"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "completion": {
+                    "type": "string"
+                },
+                "duration_sec": {
+                    "type": "integer"
+                },
+                "ended_at": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "last_reported_at": {
+                    "type": "string"
+                },
+                "last_x": {
+                    "type": "integer"
+                },
+                "last_y": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "robot_id": {
+                    "type": "string"
+                },
+                "robot_name": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "squares_cleaned": {
+                    "type": "integer"
+                },
+                "squares_total": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "time_to_complete_sec": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controller.SessionGridResponseV1": {
+            "type": "object",
+            "properties": {
+                "area": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.CleaningArea"
+                },
+                "at": {
+                    "type": "string"
+                },
+                "completion": {
+                    "type": "string"
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "controller.SessionPlanResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "plan": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.Plan"
+                }
+            }
+        },
+        "controller.SessionReportResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "report": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.SessionReport"
+                }
+            }
+        },
+        "controller.SessionResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "progress": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.SessionProgress"
+                },
+                "robot": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.Robot"
+                },
+                "session": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.CleaningSession"
+                }
+            }
+        },
+        "controller.SessionStatsResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "stats": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.SessionStats"
+                }
+            }
+        },
+        "controller.UpdateAlertRuleRequestV1": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "webhook_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "controller.UpdateAreaRequestV1": {
+            "type": "object",
+            "properties": {
+                "anchor": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.GeoAnchor"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "passes_needed": {
+                    "type": "integer"
+                },
+                "size_x": {
+                    "type": "integer"
+                },
+                "size_y": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controller.UpdateRobotRequestV1": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "size": {
+                    "description": "Diameter in millimeters.",
+                    "type": "integer"
+                }
+            }
+        },
+        "controller.UpdateScheduleRequestV1": {
+            "type": "object",
+            "properties": {
+                "area_id": {
+                    "type": "string"
+                },
+                "cron": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "passes_needed": {
+                    "type": "integer"
+                },
+                "robot_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "time_zone": {
+                    "type": "string"
+                }
+            }
+        },
+        "controller.UpdateWebhookRequestV1": {
+            "type": "object",
+            "properties": {
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "webhook_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "controller.WebhookMessagesResponseV1": {
+            "type": "object",
+            "properties": {
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.WebhookMessage"
+                    }
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "controller.WebhookResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "webhook": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.Webhook"
+                }
+            }
+        },
+        "controller.WebhooksResponseV1": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "webhooks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Webhook"
+                    }
+                }
+            }
+        },
+        "entity.Alert": {
+            "type": "object",
+            "properties": {
+                "alert_key": {
+                    "description": "Rule and session id, unique.",
+                    "type": "string"
+                },
+                "area_id": {
+                    "type": "string"
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivery": {
+                    "description": "One per attempt.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Delivery"
+                    }
+                },
+                "delivery_status": {
+                    "type": "string"
+                },
+                "dgraph.type": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "fired_at": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "robot_id": {
+                    "type": "string"
+                },
+                "rule_id": {
+                    "type": "string"
+                },
+                "rule_type": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.AlertRule": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "Rules are soft-deleted.",
+                    "type": "string"
+                },
+                "dgraph.type": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rule_type": {
+                    "type": "string"
+                },
+                "secret": {
+                    "description": "Used to sign webhook requests.",
+                    "type": "string"
+                },
+                "threshold": {
+                    "description": "Meaning depends on the rule type.",
+                    "type": "number"
+                },
+                "uid": {
+                    "type": "string"
+                },
+                "webhook_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Anomaly": {
+            "type": "object",
+            "properties": {
+                "anomaly_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "detected_at": {
+                    "description": "When it happened according to the robot.",
+                    "type": "string"
+                },
+                "dgraph.type": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "integer"
+                },
+                "y": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.Area": {
+            "type": "object",
+            "properties": {
+                "anchor_lat": {
+                    "description": "Optional geo anchor, see GeoAnchor.",
+                    "type": "number"
+                },
+                "anchor_lon": {
+                    "type": "number"
+                },
+                "anchor_rotation": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "Areas are soft-deleted. Past cleaning sessions keep their own\nCleaningArea snapshot so they are unaffected either way.",
+                    "type": "string"
+                },
+                "dgraph.type": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "description": "Each cleaning area should definitely have a name to make reports nicer.",
+                    "type": "string"
+                },
+                "offset_x": {
+                    "description": "X offset within the parent area in millimeters.",
+                    "type": "integer"
+                },
+                "offset_y": {
+                    "description": "Y offset within the parent area in millimeters.",
+                    "type": "integer"
+                },
+                "parent_area_id": {
+                    "description": "Set for areas that are a region of a larger area, see Partition.",
+                    "type": "string"
+                },
+                "passes_needed": {
+                    "description": "Number of grid square passes needed before the square can be considered clean.",
+                    "type": "integer"
+                },
+                "size_x": {
+                    "description": "X side size in millimeters.",
+                    "type": "integer"
+                },
+                "size_y": {
+                    "description": "Y side size in millimeters.",
+                    "type": "integer"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.CleaningArea": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "dgraph.type": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "grid": {
+                    "description": "The size of a grid square. Typically the same size os the diameter of the assigned cleaning robot.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Square"
+                    }
+                },
+                "name": {
+                    "description": "Each cleaning area should definitely have a name to make reports nicer.",
+                    "type": "string"
+                },
+                "passes_needed": {
+                    "description": "Number of grid square passes needed before the square can be considered clean.",
+                    "type": "integer"
+                },
+                "size_x": {
+                    "description": "X side size in millimeters.",
+                    "type": "integer"
+                },
+                "size_y": {
+                    "description": "Y side size in millimeters.",
+                    "type": "integer"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.CleaningSession": {
+            "type": "object",
+            "properties": {
+                "anomaly": {
+                    "description": "Detected while the robot was reporting.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Anomaly"
+                    }
+                },
+                "area": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.CleaningArea"
+                    }
+                },
+                "completed_at": {
+                    "description": "When every square was cleaned.",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "dgraph.type": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "dock_id": {
+                    "description": "Dock of the latest pause, if known.",
+                    "type": "string"
+                },
+                "duration_sec": {
+                    "type": "integer"
+                },
+                "ended_at": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "last_reported_at": {
+                    "type": "string"
+                },
+                "last_x": {
+                    "type": "integer"
+                },
+                "last_y": {
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Optional.",
+                    "type": "string"
+                },
+                "offline_since": {
+                    "description": "Last report before the robot was detected offline.",
+                    "type": "string"
+                },
+                "outbox": {
+                    "description": "Events saved with the session, pending dispatch to webhooks.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.OutboxEvent"
+                    }
+                },
+                "paused_at": {
+                    "description": "Latest time the robot docked, see IsPaused.",
+                    "type": "string"
+                },
+                "paused_sec": {
+                    "description": "Seconds spent docked, excluded from DurationSec.",
+                    "type": "integer"
+                },
+                "position_history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Position"
+                    }
+                },
+                "resumed_at": {
+                    "description": "Latest time the robot left its dock.",
+                    "type": "string"
+                },
+                "source_area": {
+                    "description": "The area that Area is a snapshot of.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Area"
+                    }
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "stats": {
+                    "description": "Cached once the session has ended.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.SessionStats"
+                    }
+                },
+                "telemetry": {
+                    "description": "Reported together with positions.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Telemetry"
+                    }
+                },
+                "time_to_complete_sec": {
+                    "description": "Seconds from start until every square was cleaned.",
+                    "type": "integer"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Delivery": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "type": "integer"
+                },
+                "attempted_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "dgraph.type": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "description": "Zero if we never got a response.",
+                    "type": "integer"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Dock": {
+            "type": "object",
+            "properties": {
+                "area_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "Docks are soft-deleted so that sessions paused at them remain\nreadable.",
+                    "type": "string"
+                },
+                "dgraph.type": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                },
+                "x": {
+                    "description": "Position within the area in millimeters.",
+                    "type": "integer"
+                },
+                "y": {
+                    "description": "Position within the area in millimeters.",
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.Event": {
+            "type": "object",
+            "properties": {
+                "anomaly": {
+                    "description": "Set for anomaly events.",
+                    "type": "object",
+                    "$ref": "#/definitions/entity.Anomaly"
+                },
+                "area_id": {
+                    "type": "string"
+                },
+                "at": {
+                    "description": "When it happened according to the robot.",
+                    "type": "string"
+                },
+                "completion": {
+                    "description": "Current session completion.",
+                    "type": "string"
+                },
+                "epoch": {
+                    "description": "When the event bus started, as IDs start over when the server restarts.",
+                    "type": "integer"
+                },
+                "id": {
+                    "description": "Assigned by the event bus, increases with every event published.",
+                    "type": "integer"
+                },
+                "robot_id": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "square": {
+                    "description": "Set for square events.",
+                    "type": "object",
+                    "$ref": "#/definitions/entity.Square"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "integer"
+                },
+                "y": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.GeoAnchor": {
+            "type": "object",
+            "properties": {
+                "lat": {
+                    "type": "number"
+                },
+                "lon": {
+                    "type": "number"
+                },
+                "rotation": {
+                    "description": "Degrees clockwise.",
+                    "type": "number"
+                }
+            }
+        },
+        "entity.GridDrift": {
+            "type": "object",
+            "properties": {
+                "drift": {
+                    "description": "True if any square differs.",
+                    "type": "boolean"
+                },
+                "persisted_completion": {
+                    "type": "string"
+                },
+                "positions_replayed": {
+                    "type": "integer"
+                },
+                "replayed_completion": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "squares": {
+                    "description": "Squares that differ.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.SquareDrift"
+                    }
+                },
+                "squares_drifted": {
+                    "type": "integer"
+                },
+                "squares_total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.JobRun": {
+            "type": "object",
+            "properties": {
+                "area_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "dgraph.type": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "dispatched_at": {
+                    "type": "string"
+                },
+                "message": {
+                    "description": "Why the run was missed.",
+                    "type": "string"
+                },
+                "robot_id": {
+                    "description": "Empty if no robot was idle.",
+                    "type": "string"
+                },
+                "run_status": {
+                    "type": "string"
+                },
+                "schedule_id": {
+                    "type": "string"
+                },
+                "scheduled_at": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "description": "The session started by the run.",
+                    "type": "string"
+                },
+                "start_by": {
+                    "description": "Missed unless a session starts before this.",
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.OutboxEvent": {
+            "type": "object",
+            "properties": {
+                "completion": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivery_status": {
+                    "description": "Delivered once dispatched to all webhooks.",
+                    "type": "string"
+                },
+                "dgraph.type": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                },
+                "webhook_message": {
+                    "description": "One per subscribed webhook.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.WebhookMessage"
+                    }
+                },
+                "x": {
+                    "type": "integer"
+                },
+                "y": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.Partition": {
+            "type": "object",
+            "properties": {
+                "area_id": {
+                    "description": "The parent area.",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "dgraph.type": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "dispatched_at": {
+                    "description": "Last time start commands were sent to the robots.",
+                    "type": "string"
+                },
+                "region": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Region"
+                    }
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.PartitionSummary": {
+            "type": "object",
+            "properties": {
+                "area": {
+                    "description": "The parent area.",
+                    "type": "object",
+                    "$ref": "#/definitions/entity.Area"
+                },
+                "partition": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.Partition"
+                },
+                "progress": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.SessionProgress"
+                },
+                "regions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.RegionProgress"
+                    }
+                }
+            }
+        },
+        "entity.Plan": {
+            "type": "object",
+            "properties": {
+                "distance_mm": {
+                    "description": "Length of the path from the first waypoint to the last.",
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "passes_needed": {
+                    "type": "integer"
+                },
+                "robot_size": {
+                    "type": "integer"
+                },
+                "size_x": {
+                    "type": "integer"
+                },
+                "size_y": {
+                    "type": "integer"
+                },
+                "squares": {
+                    "description": "Squares covered by the plan.",
+                    "type": "integer"
+                },
+                "waypoints": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Waypoint"
+                    }
+                }
+            }
+        },
+        "entity.Position": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "dgraph.type": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "not_cleaning": {
+                    "description": "The robot got here without cleaning, e.g. when docking or\nleaving its dock. Doesn't count as a pass.",
+                    "type": "boolean"
+                },
+                "passed_at": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "integer"
+                },
+                "y": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.PositionRecord": {
+            "type": "object",
+            "properties": {
+                "area_id": {
+                    "type": "string"
+                },
+                "passed_at": {
+                    "type": "string"
+                },
+                "robot_id": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "integer"
+                },
+                "y": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.Region": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "dgraph.type": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "offset_x": {
+                    "description": "X offset within the parent area in millimeters.",
+                    "type": "integer"
+                },
+                "offset_y": {
+                    "description": "Y offset within the parent area in millimeters.",
+                    "type": "integer"
+                },
+                "order": {
+                    "type": "integer"
+                },
+                "region_area": {
+                    "description": "The region as an area of its own.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Area"
+                    }
+                },
+                "robot_id": {
+                    "type": "string"
+                },
+                "robot_size": {
+                    "type": "integer"
+                },
+                "size_x": {
+                    "type": "integer"
+                },
+                "size_y": {
+                    "type": "integer"
+                },
+                "squares": {
+                    "description": "Grid squares for the robot.",
+                    "type": "integer"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.RegionProgress": {
+            "type": "object",
+            "properties": {
+                "area_id": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "progress": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.SessionProgress"
+                },
+                "region_id": {
+                    "type": "string"
+                },
+                "robot_id": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "description": "Empty until the robot starts a session.",
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Robot": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "Robots are soft-deleted so that their historical cleaning\nsessions remain queryable.",
+                    "type": "string"
+                },
+                "dgraph.type": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "is_cleaning": {
+                    "type": "boolean"
+                },
+                "last_telemetry": {
+                    "description": "The latest telemetry reported by the robot, see Telemetry.",
+                    "type": "object",
+                    "$ref": "#/definitions/entity.Telemetry"
+                },
+                "name": {
+                    "description": "Each robot should have a name to make identification easier and reports nicer.",
+                    "type": "string"
+                },
+                "session": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.CleaningSession"
+                    }
+                },
+                "size": {
+                    "description": "The diameter of the robot in millimeters. We assume all robots are have a circle shape.",
+                    "type": "integer"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.RobotStatus": {
+            "type": "object",
+            "properties": {
+                "completion": {
+                    "type": "string"
+                },
+                "dock_id": {
+                    "description": "Dock the robot is paused at, if known.",
+                    "type": "string"
+                },
+                "eta": {
+                    "description": "Estimated time of completion, only set for active sessions\nwith something to base an estimate on, see ETA.",
+                    "type": "string"
+                },
+                "eta_confidence": {
+                    "type": "number"
+                },
+                "eta_sec": {
+                    "type": "integer"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "is_paused": {
+                    "description": "Docked during an active session.",
+                    "type": "boolean"
+                },
+                "last_reported_at": {
+                    "type": "string"
+                },
+                "last_x": {
+                    "type": "integer"
+                },
+                "last_y": {
+                    "type": "integer"
+                },
+                "paused_sec": {
+                    "description": "Time spent docked during the session so far.",
+                    "type": "integer"
+                },
+                "robot_id": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "squares_cleaned": {
+                    "type": "integer"
+                },
+                "squares_total": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Schedule": {
+            "type": "object",
+            "properties": {
+                "area_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "cron": {
+                    "description": "Standard 5-field cron expression.",
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "Schedules are soft-deleted.",
+                    "type": "string"
+                },
+                "dgraph.type": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "job_run": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.JobRun"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "next_run_at": {
+                    "description": "Nil if the schedule never triggers again.",
+                    "type": "string"
+                },
+                "passes_needed": {
+                    "description": "Zero to use the area's.",
+                    "type": "integer"
+                },
+                "robot_ids": {
+                    "description": "A single robot or a pool of robots.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "time_zone": {
+                    "description": "IANA time zone the cron expression is in, e.g. Europe/Stockholm.",
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
                 }
             }
         },
-        "controller.ListRobotsResponseV1": {
+        "entity.SessionProgress": {
             "type": "object",
             "properties": {
-                "ok": {
-                    "type": "boolean"
+                "completion": {
+                    "type": "string"
                 },
-                "robots": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/entity.Robot"
-                    }
+                "eta": {
+                    "description": "Estimated time of completion, only set for active sessions\nwith something to base an estimate on, see ETA.",
+                    "type": "string"
+                },
+                "eta_confidence": {
+                    "type": "number"
+                },
+                "eta_sec": {
+                    "type": "integer"
+                },
+                "squares_cleaned": {
+                    "type": "integer"
+                },
+                "squares_total": {
+                    "type": "integer"
                 }
             }
         },
-        "controller.RobotHistoryResponseV1": {
+        "entity.SessionReport": {
             "type": "object",
             "properties": {
-                "ok": {
-                    "type": "boolean"
+                "from": {
+                    "type": "string"
                 },
-                "robot": {
+                "group_by": {
+                    "type": "string"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.SessionReportRow"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                },
+                "total": {
+                    "description": "All rows combined.",
                     "type": "object",
-                    "$ref": "#/definitions/entity.Robot"
+                    "$ref": "#/definitions/entity.SessionReportRow"
                 }
             }
         },
-        "entity.Area": {
+        "entity.SessionReportRow": {
             "type": "object",
             "properties": {
-                "created_at": {
+                "cleaning_sec": {
+                    "description": "Total duration of ended sessions.",
+                    "type": "integer"
+                },
+                "group": {
+                    "description": "Robot UID, area UID or day (YYYY-MM-DD).",
                     "type": "string"
                 },
-                "dgraph.type": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "mean_completion_pct": {
+                    "type": "number"
+                },
+                "mean_time_to_complete_sec": {
+                    "description": "Mean time to clean every square in completed sessions.",
+                    "type": "number"
                 },
                 "name": {
-                    "description": "Each cleaning area should definitely have a name to make reports nicer.",
+                    "description": "Robot or area name.",
                     "type": "string"
                 },
-                "passes_needed": {
-                    "description": "Number of grid square passes needed before the square can be considered clean.",
+                "sessions_abandoned": {
+                    "description": "Ended sessions where some squares were not cleaned.",
                     "type": "integer"
                 },
-                "size_x": {
-                    "description": "X side size in millimeters.",
+                "sessions_completed": {
+                    "description": "Ended sessions where every square was cleaned.",
                     "type": "integer"
                 },
-                "size_y": {
-                    "description": "Y side size in millimeters.",
+                "sessions_ended": {
                     "type": "integer"
                 },
-                "uid": {
-                    "type": "string"
+                "sessions_started": {
+                    "type": "integer"
                 }
             }
         },
-        "entity.CleaningArea": {
+        "entity.SessionStats": {
             "type": "object",
             "properties": {
+                "active_sec": {
+                    "description": "Time between the first and last position.",
+                    "type": "integer"
+                },
+                "area_covered_m2": {
+                    "description": "Area of all squares passed at least once.",
+                    "type": "number"
+                },
+                "avg_speed_mm_per_sec": {
+                    "description": "Average speed while moving.",
+                    "type": "number"
+                },
+                "cleaned_pct": {
+                    "description": "Percentage of squares cleaned.",
+                    "type": "number"
+                },
+                "computed_at": {
+                    "type": "string"
+                },
+                "coverage_pct": {
+                    "description": "Percentage of squares passed at least once.",
+                    "type": "number"
+                },
                 "created_at": {
                     "type": "string"
                 },
@@ -242,27 +4444,36 @@ var doc = `{
                         "type": "string"
                     }
                 },
-                "grid": {
-                    "description": "The size of a grid square. Typically the same size os the diameter of the assigned cleaning robot.",
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/entity.Square"
-                    }
+                "distance_mm": {
+                    "description": "Distance travelled in millimeters.",
+                    "type": "integer"
                 },
-                "name": {
-                    "description": "Each cleaning area should definitely have a name to make reports nicer.",
-                    "type": "string"
+                "idle_sec": {
+                    "description": "Time spent without moving.",
+                    "type": "integer"
                 },
-                "passes_needed": {
-                    "description": "Number of grid square passes needed before the square can be considered clean.",
+                "overlap_ratio": {
+                    "description": "Total passes relative to passes needed across the grid.",
+                    "type": "number"
+                },
+                "positions": {
+                    "description": "Number of positions reported while cleaning.",
                     "type": "integer"
                 },
-                "size_x": {
-                    "description": "X side size in millimeters.",
+                "time_to_100_pct_sec": {
                     "type": "integer"
                 },
-                "size_y": {
-                    "description": "Y side size in millimeters.",
+                "time_to_25_pct_sec": {
+                    "description": "Time from the start of the session until a certain completion\npercentage was reached, not counting time spent docked. Nil if\nnever reached.",
+                    "type": "integer"
+                },
+                "time_to_50_pct_sec": {
+                    "type": "integer"
+                },
+                "time_to_75_pct_sec": {
+                    "type": "integer"
+                },
+                "time_to_90_pct_sec": {
                     "type": "integer"
                 },
                 "uid": {
@@ -270,14 +4481,28 @@ var doc = `{
                 }
             }
         },
-        "entity.CleaningSession": {
+        "entity.SessionSummary": {
             "type": "object",
             "properties": {
-                "area": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/entity.CleaningArea"
-                    }
+                "progress": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.SessionProgress"
+                },
+                "robot": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.Robot"
+                },
+                "session": {
+                    "type": "object",
+                    "$ref": "#/definitions/entity.CleaningSession"
+                }
+            }
+        },
+        "entity.Square": {
+            "type": "object",
+            "properties": {
+                "cleaned_at": {
+                    "type": "string"
                 },
                 "created_at": {
                     "type": "string"
@@ -288,45 +4513,65 @@ var doc = `{
                         "type": "string"
                     }
                 },
-                "duration_sec": {
+                "has_robot_present": {
+                    "description": "Is the robot currently on the square?",
+                    "type": "boolean"
+                },
+                "order": {
+                    "description": "To ensure we can retrieve all grid squares in the order they were created.",
                     "type": "integer"
                 },
-                "ended_at": {
-                    "type": "string"
+                "passes": {
+                    "type": "integer"
                 },
-                "is_active": {
-                    "type": "boolean"
+                "size": {
+                    "type": "integer"
                 },
-                "last_reported_at": {
+                "uid": {
                     "type": "string"
                 },
-                "last_x": {
+                "x": {
                     "type": "integer"
                 },
-                "last_y": {
+                "y": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.SquareDrift": {
+            "type": "object",
+            "properties": {
+                "cleaned": {
+                    "type": "boolean"
+                },
+                "passes": {
                     "type": "integer"
                 },
-                "name": {
-                    "description": "Optional.",
-                    "type": "string"
+                "replayed_cleaned": {
+                    "type": "boolean"
                 },
-                "position_history": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/entity.Position"
-                    }
+                "replayed_passes": {
+                    "type": "integer"
                 },
-                "started_at": {
-                    "type": "string"
+                "x": {
+                    "type": "integer"
                 },
-                "uid": {
-                    "type": "string"
+                "y": {
+                    "type": "integer"
                 }
             }
         },
-        "entity.Position": {
+        "entity.Telemetry": {
             "type": "object",
             "properties": {
+                "battery": {
+                    "description": "Percent, 0 - 100.",
+                    "type": "integer"
+                },
+                "brush_wear": {
+                    "description": "Wear counter as reported by the robot, e.g. brush hours.",
+                    "type": "integer"
+                },
                 "created_at": {
                     "type": "string"
                 },
@@ -336,12 +4581,36 @@ var doc = `{
                         "type": "string"
                     }
                 },
-                "passed_at": {
+                "error_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "filter_wear": {
+                    "description": "Wear counter as reported by the robot, e.g. filter hours.",
+                    "type": "integer"
+                },
+                "reported_at": {
                     "type": "string"
                 },
-                "uid": {
+                "robot_id": {
                     "type": "string"
                 },
+                "robot_state": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Waypoint": {
+            "type": "object",
+            "properties": {
                 "x": {
                     "type": "integer"
                 },
@@ -350,77 +4619,124 @@ var doc = `{
                 }
             }
         },
-        "entity.Robot": {
+        "entity.Webhook": {
             "type": "object",
             "properties": {
                 "created_at": {
                     "type": "string"
                 },
+                "deleted_at": {
+                    "description": "Webhooks are soft-deleted.",
+                    "type": "string"
+                },
                 "dgraph.type": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "is_cleaning": {
-                    "type": "boolean"
-                },
-                "name": {
-                    "description": "Each robot should have a name to make identification easier and reports nicer.",
-                    "type": "string"
-                },
-                "session": {
+                "event_types": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entity.CleaningSession"
+                        "type": "string"
                     }
                 },
-                "size": {
-                    "description": "The diameter of the robot in millimeters. We assume all robots are have a circle shape.",
-                    "type": "integer"
+                "name": {
+                    "type": "string"
+                },
+                "secret": {
+                    "description": "Used to sign webhook requests.",
+                    "type": "string"
                 },
                 "uid": {
                     "type": "string"
+                },
+                "webhook_url": {
+                    "type": "string"
                 }
             }
         },
-        "entity.Square": {
+        "entity.WebhookMessage": {
             "type": "object",
             "properties": {
-                "cleaned_at": {
-                    "type": "string"
+                "attempts": {
+                    "type": "integer"
                 },
                 "created_at": {
                     "type": "string"
                 },
+                "delivery": {
+                    "description": "One per attempt.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Delivery"
+                    }
+                },
+                "delivery_status": {
+                    "type": "string"
+                },
                 "dgraph.type": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "has_robot_present": {
-                    "description": "Is the robot currently on the square?",
-                    "type": "boolean"
-                },
-                "order": {
-                    "description": "To ensure we can retrieve all grid squares in the order they were created.",
-                    "type": "integer"
+                "event_type": {
+                    "type": "string"
                 },
-                "passes": {
-                    "type": "integer"
+                "next_attempt_at": {
+                    "type": "string"
                 },
-                "size": {
-                    "type": "integer"
+                "payload": {
+                    "description": "JSON body, fixed when the event is dispatched.",
+                    "type": "string"
                 },
                 "uid": {
                     "type": "string"
                 },
-                "x": {
-                    "type": "integer"
+                "webhook_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "geo.Feature": {
+            "type": "object",
+            "properties": {
+                "geometry": {
+                    "type": "object",
+                    "$ref": "#/definitions/geo.Geometry"
                 },
-                "y": {
-                    "type": "integer"
+                "properties": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "geo.FeatureCollection": {
+            "type": "object",
+            "properties": {
+                "features": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/geo.Feature"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "geo.Geometry": {
+            "type": "object",
+            "properties": {
+                "coordinates": {
+                    "type": "object"
+                },
+                "type": {
+                    "type": "string"
                 }
             }
         }
@@ -22,7 +22,7 @@ require (
 	github.com/swaggo/swag v1.6.7
 	github.com/valyala/fasttemplate v1.2.0 // indirect
 	golang.org/x/crypto v0.0.0-20200728195943-123391ffb6de // indirect
-	golang.org/x/net v0.0.0-20200707034311-ab3426394381 // indirect
+	golang.org/x/net v0.0.0-20200707034311-ab3426394381
 	golang.org/x/sys v0.0.0-20200812155832-6a926be9bd1d // indirect
 	golang.org/x/tools v0.0.0-20200812231640-9176cd30088c // indirect
 	google.golang.org/genproto v0.0.0-20200813001606-1ccf2a5ae4fd // indirect
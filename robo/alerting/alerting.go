@@ -0,0 +1,199 @@
+// Package alerting evaluates alert rules against cleaning sessions in
+// the background and delivers the resulting alerts as signed webhooks
+// with retries.
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/webhook"
+	"github.com/pkg/errors"
+)
+
+// Config holds the worker settings.
+type Config struct {
+	// Interval is how often rules are evaluated and alerts delivered.
+	Interval time.Duration
+	// Lookback is how far back we look for ended sessions.
+	Lookback time.Duration
+	// MaxAttempts is the number of delivery attempts before we give
+	// up on an alert.
+	MaxAttempts int
+	// BackoffBase is the time to wait before the first retry, doubled
+	// for every retry up to BackoffMax.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// Timeout is the timeout for a single webhook request.
+	Timeout time.Duration
+	// BatchSize is the max number of alerts delivered per tick.
+	BatchSize int
+}
+
+// DefaultConfig returns the default worker settings.
+func DefaultConfig() Config {
+	return Config{
+		Interval:    time.Minute,
+		Lookback:    24 * time.Hour,
+		MaxAttempts: 6,
+		BackoffBase: 30 * time.Second,
+		BackoffMax:  time.Hour,
+		Timeout:     webhook.DefaultTimeout,
+		BatchSize:   100,
+	}
+}
+
+// Payload is the JSON body of an alert webhook.
+type Payload struct {
+	AlertID   string               `json:"alert_id"`
+	RuleID    string               `json:"rule_id"`
+	RuleName  string               `json:"rule_name"`
+	RuleType  entity.AlertRuleType `json:"rule_type"`
+	SessionID string               `json:"session_id"`
+	RobotID   string               `json:"robot_id,omitempty"`
+	AreaID    string               `json:"area_id,omitempty"`
+	Message   string               `json:"message"`
+	FiredAt   *time.Time           `json:"fired_at"`
+}
+
+// Worker evaluates alert rules and delivers alerts. Alerts and their
+// delivery state are persisted, so retries survive restarts. Only one
+// worker should run against a database at a time.
+type Worker struct {
+	a      entity.AlertRepository
+	s      entity.SessionRepository
+	c      Config
+	client *http.Client
+}
+
+// NewWorker creates a new worker.
+func NewWorker(a entity.AlertRepository, s entity.SessionRepository, c Config) *Worker {
+	return &Worker{a, s, c, &http.Client{Timeout: c.Timeout}}
+}
+
+// Run evaluates rules and delivers alerts every interval until the
+// context is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	t := time.NewTicker(w.c.Interval)
+	defer t.Stop()
+	for {
+		if err := w.Tick(ctx, time.Now()); err != nil {
+			log.Printf("alerting: %s", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Tick evaluates all rules and then delivers all alerts due.
+func (w *Worker) Tick(ctx context.Context, now time.Time) error {
+	if _, err := w.Evaluate(ctx, now); err != nil {
+		return errors.Wrap(err, "could not evaluate alert rules")
+	}
+	if _, err := w.Deliver(ctx, now); err != nil {
+		return errors.Wrap(err, "could not deliver alerts")
+	}
+	return nil
+}
+
+// Deliver attempts to deliver all alerts due and returns the number
+// delivered. Failed attempts are retried with exponential backoff
+// until MaxAttempts, and every attempt is logged on the alert.
+func (w *Worker) Deliver(ctx context.Context, now time.Time) (int, error) {
+	due, err := w.a.Due(ctx, now, w.c.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(due) == 0 {
+		return 0, nil
+	}
+
+	rules, err := w.rules(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var delivered int
+	for _, al := range due {
+		update := &entity.Alert{
+			Attempts: al.Attempts + 1,
+			Common:   entity.Common{UID: al.UID},
+		}
+
+		rule, ok := rules[al.RuleID]
+		if !ok {
+			// The rule was deleted after the alert fired.
+			update.Status = entity.DeliveryFailed
+			update.Deliveries = []*entity.Delivery{
+				entity.NewDelivery(update.Attempts, 0, errors.New("alert rule has been deleted"), now, 0),
+			}
+		} else {
+			status, took, err := w.send(ctx, rule, al)
+			update.Deliveries = []*entity.Delivery{entity.NewDelivery(update.Attempts, status, err, now, took)}
+			switch {
+			case err == nil:
+				update.Status = entity.DeliveryDelivered
+				delivered++
+			case update.Attempts >= w.c.MaxAttempts:
+				update.Status = entity.DeliveryFailed
+			default:
+				next := now.Add(webhook.Backoff(update.Attempts, w.c.BackoffBase, w.c.BackoffMax))
+				update.Status = entity.DeliveryPending
+				update.NextAttemptAt = &next
+			}
+		}
+
+		if _, err := w.a.Save(ctx, update); err != nil {
+			return delivered, errors.Wrapf(err, "could not persist delivery of alert %s", al.UID)
+		}
+	}
+	return delivered, nil
+}
+
+// send posts an alert to its rule's webhook.
+func (w *Worker) send(ctx context.Context, rule *entity.AlertRule, al *entity.Alert) (int, time.Duration, error) {
+	body, err := json.Marshal(Payload{
+		AlertID:   al.UID,
+		RuleID:    rule.UID,
+		RuleName:  rule.Name,
+		RuleType:  al.RuleType,
+		SessionID: al.SessionID,
+		RobotID:   al.RobotID,
+		AreaID:    al.AreaID,
+		Message:   al.Message,
+		FiredAt:   al.FiredAt,
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	t := time.Now()
+	status, err := webhook.Send(ctx, w.client, webhook.Request{
+		URL:        rule.WebhookURL,
+		Secret:     rule.Secret,
+		Event:      "alert." + string(al.RuleType),
+		DeliveryID: al.UID,
+		Body:       body,
+	})
+	return status, time.Since(t), err
+}
+
+// rules returns all rules by id.
+func (w *Worker) rules(ctx context.Context) (map[string]*entity.AlertRule, error) {
+	list, err := w.a.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rules := make(map[string]*entity.AlertRule, len(list))
+	for _, r := range list {
+		rules[r.UID] = r
+	}
+	return rules, nil
+}
@@ -0,0 +1,343 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/webhook"
+	"github.com/stretchr/testify/require"
+)
+
+// stubAlerts is an in-memory entity.AlertRepository.
+type stubAlerts struct {
+	rules  []*entity.AlertRule
+	alerts []*entity.Alert
+	nextID int
+}
+
+func (r *stubAlerts) ListRules(ctx context.Context) ([]*entity.AlertRule, error) {
+	return r.rules, nil
+}
+
+func (r *stubAlerts) GetRule(ctx context.Context, ruleID string) (*entity.AlertRule, error) {
+	for _, rule := range r.rules {
+		if rule.UID == ruleID {
+			return rule, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *stubAlerts) List(ctx context.Context, a entity.ListAlertsArgs) ([]*entity.Alert, error) {
+	return r.alerts, nil
+}
+
+func (r *stubAlerts) Fired(ctx context.Context, keys []string) (map[string]bool, error) {
+	fired := make(map[string]bool)
+	for _, a := range r.alerts {
+		fired[a.Key] = true
+	}
+	return fired, nil
+}
+
+func (r *stubAlerts) Due(ctx context.Context, now time.Time, limit int) ([]*entity.Alert, error) {
+	var due []*entity.Alert
+	for _, a := range r.alerts {
+		if a.Status == entity.DeliveryPending && !a.NextAttemptAt.After(now) {
+			due = append(due, a)
+		}
+	}
+	return due, nil
+}
+
+// Save stores new alerts and merges updates into existing ones, like
+// Dgraph would.
+func (r *stubAlerts) Save(ctx context.Context, o interface{}) (map[string]string, error) {
+	a := o.(*entity.Alert)
+	if strings.HasPrefix(a.UID, "_:") {
+		r.nextID++
+		a.UID = "0x" + strconv.Itoa(r.nextID)
+		r.alerts = append(r.alerts, a)
+		return map[string]string{entity.AlertUID: a.UID}, nil
+	}
+	for _, cur := range r.alerts {
+		if cur.UID == a.UID {
+			cur.Status = a.Status
+			cur.Attempts = a.Attempts
+			if a.NextAttemptAt != nil {
+				cur.NextAttemptAt = a.NextAttemptAt
+			}
+			cur.Deliveries = append(cur.Deliveries, a.Deliveries...)
+		}
+	}
+	return map[string]string{}, nil
+}
+
+// stubSessions is an entity.SessionRepository with canned sessions.
+type stubSessions struct {
+	entity.SessionRepository
+	sessions  []*entity.SessionSummary
+	anomalies map[string][]*entity.Anomaly
+}
+
+func (r *stubSessions) List(ctx context.Context, a entity.ListSessionsArgs) (*entity.ListSessionsResult, error) {
+	res := &entity.ListSessionsResult{}
+	for _, s := range r.sessions {
+		// Like Dgraph, select on ended_at rather than is_active.
+		ended := s.Session.EndedAt
+		if a.Active != nil && (ended == nil) != *a.Active {
+			continue
+		}
+		if a.EndedAfter != nil && ended != nil && ended.Before(*a.EndedAfter) {
+			continue
+		}
+		if a.AreaID != "" && s.Session.SourceArea[0].UID != a.AreaID {
+			continue
+		}
+		res.Sessions = append(res.Sessions, s)
+	}
+	return res, nil
+}
+
+func (r *stubSessions) Anomalies(ctx context.Context, sessionID string) ([]*entity.Anomaly, error) {
+	return r.anomalies[sessionID], nil
+}
+
+var now = time.Date(2020, 2, 16, 12, 0, 0, 0, time.UTC)
+
+func ago(d time.Duration) *time.Time {
+	t := now.Add(-d)
+	return &t
+}
+
+// summary returns a session that is still active unless ended is
+// given, in which case it's ended as the robot service would.
+func summary(id, areaID string, started, lastReported, ended *time.Time, cleaned int) *entity.SessionSummary {
+	sess := &entity.CleaningSession{
+		IsActive:       true,
+		StartedAt:      started,
+		LastReportedAt: lastReported,
+		SourceArea:     []*entity.Area{{Common: entity.Common{UID: areaID}}},
+		Common:         entity.Common{UID: id},
+	}
+	if ended != nil {
+		sess.End(*ended)
+		// Saving an ended session doesn't clear is_active in Dgraph.
+		sess.IsActive = true
+	}
+	return &entity.SessionSummary{
+		Session: sess,
+		Robot:   &entity.Robot{Common: entity.Common{UID: "0xr"}},
+		Progress: entity.SessionProgress{
+			Completion:     entity.Completion(cleaned, 10),
+			SquaresCleaned: cleaned,
+			SquaresTotal:   10,
+		},
+	}
+}
+
+func rule(id string, t entity.AlertRuleType, url string) *entity.AlertRule {
+	r := entity.NewAlertRule(id, t, 0, url, "s3cr3t")
+	r.UID = id
+	r.CreatedAt = ago(2 * time.Hour)
+	return r
+}
+
+func TestEvaluate(t *testing.T) {
+	completed := func(s *entity.SessionSummary, sec int) *entity.SessionSummary {
+		s.Session.CompletedAt = s.Session.EndedAt
+		s.Session.TimeToCompleteSec = sec
+		return s
+	}
+	paused := func(s *entity.SessionSummary, at *time.Time) *entity.SessionSummary {
+		s.Session.PausedAt = at
+		return s
+	}
+
+	sessions := &stubSessions{
+		sessions: []*entity.SessionSummary{
+			// Active, reported 20 minutes ago, running for 3 hours in an
+			// area that typically takes an hour.
+			summary("s1", "a1", ago(3*time.Hour), ago(20*time.Minute), nil, 5),
+			// Active and healthy, but stuck.
+			summary("s2", "a2", ago(time.Minute), ago(time.Second), nil, 1),
+			// Ended an hour ago at 50%.
+			summary("s3", "a2", ago(90*time.Minute), ago(time.Hour), ago(time.Hour), 5),
+			// Ended at 90% before the rules were created.
+			summary("s4", "a2", ago(5*time.Hour), ago(4*time.Hour), ago(4*time.Hour), 9),
+			// Docked for 30 minutes, not offline.
+			paused(summary("s5", "a2", ago(time.Hour), ago(30*time.Minute), nil, 5), ago(30*time.Minute)),
+			// Ended two days ago without reporting since, long past
+			// Lookback.
+			summary("s6", "a2", ago(49*time.Hour), ago(48*time.Hour), ago(48*time.Hour), 5),
+			// Completed half an hour ago after running for 3.5 hours in
+			// a1 and getting stuck, without reporting since 70 minutes ago.
+			completed(summary("s7", "a1", ago(4*time.Hour), ago(70*time.Minute), ago(30*time.Minute), 10), 12600),
+			// Past completed sessions in a1.
+			completed(summary("p1", "a1", ago(30*time.Hour), nil, ago(29*time.Hour), 10), 3600),
+			completed(summary("p2", "a1", ago(40*time.Hour), nil, ago(39*time.Hour), 10), 3000),
+			completed(summary("p3", "a1", ago(50*time.Hour), nil, ago(49*time.Hour), 10), 4000),
+		},
+		anomalies: map[string][]*entity.Anomaly{
+			"s2": {{Type: entity.AnomalyStuck}},
+			"s7": {{Type: entity.AnomalyStuck}},
+		},
+	}
+	alerts := &stubAlerts{
+		rules: []*entity.AlertRule{
+			rule("r1", entity.AlertRobotOffline, ""),
+			rule("r2", entity.AlertRobotStuck, ""),
+			rule("r3", entity.AlertSessionAbandoned, ""),
+			rule("r4", entity.AlertLowCompletion, ""),
+			rule("r5", entity.AlertLongSession, ""),
+		},
+	}
+	w := NewWorker(alerts, sessions, DefaultConfig())
+
+	n, err := w.Evaluate(context.Background(), now)
+	require.NoError(t, err)
+
+	var fired []string
+	for _, a := range alerts.alerts {
+		fired = append(fired, a.Key)
+		require.Equal(t, entity.DeliveryPending, a.Status)
+		require.NotEmpty(t, a.Message)
+	}
+	require.Equal(t, []string{"r1:s1", "r2:s2", "r3:s3", "r4:s3", "r5:s1"}, fired)
+	require.Equal(t, 5, n)
+	require.Equal(t, "0xr", alerts.alerts[0].RobotID)
+	require.Equal(t, "a1", alerts.alerts[0].AreaID)
+
+	n, err = w.Evaluate(context.Background(), now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 0, n, "should fire once per rule and session")
+}
+
+func TestEvaluateTelemetry(t *testing.T) {
+	battery := func(s *entity.SessionSummary, pct int, state entity.RobotState, codes ...string) *entity.SessionSummary {
+		s.Robot.LastTelemetry = &entity.Telemetry{
+			SessionID:  s.Session.UID,
+			Battery:    &pct,
+			State:      state,
+			ErrorCodes: codes,
+		}
+		return s
+	}
+
+	ended := battery(summary("s3", "a1", ago(time.Hour), ago(time.Hour), ago(time.Hour), 10), 5, entity.RobotError)
+	ended.Session.CompletedAt = ended.Session.EndedAt
+	stale := battery(summary("s4", "a1", ago(time.Hour), ago(time.Second), nil, 1), 5, entity.RobotError)
+	stale.Robot.LastTelemetry.SessionID = "s0"
+
+	sessions := &stubSessions{
+		sessions: []*entity.SessionSummary{
+			// Low on battery.
+			battery(summary("s1", "a1", ago(time.Hour), ago(time.Second), nil, 1), 15, entity.RobotCleaning),
+			// Fine on battery, but reporting an error.
+			battery(summary("s2", "a2", ago(time.Hour), ago(time.Second), nil, 1), 80, entity.RobotCleaning, "E12"),
+			// Ended, telemetry no longer matters.
+			ended,
+			// Telemetry from a previous session.
+			stale,
+		},
+	}
+	alerts := &stubAlerts{
+		rules: []*entity.AlertRule{
+			rule("r1", entity.AlertLowBattery, ""),
+			rule("r2", entity.AlertRobotError, ""),
+		},
+	}
+	w := NewWorker(alerts, sessions, DefaultConfig())
+
+	n, err := w.Evaluate(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	var fired []string
+	for _, a := range alerts.alerts {
+		fired = append(fired, a.Key)
+	}
+	require.Equal(t, []string{"r1:s1", "r2:s2"}, fired)
+	require.Contains(t, alerts.alerts[1].Message, "E12")
+}
+
+func TestDeliver(t *testing.T) {
+	var mu sync.Mutex
+	var got []Payload
+	fail := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		body, _ := ioutil.ReadAll(r.Body)
+		if !webhook.Verify("s3cr3t", body, r.Header.Get(webhook.SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if fail {
+			fail = false
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var p Payload
+		json.Unmarshal(body, &p)
+		got = append(got, p)
+	}))
+	defer srv.Close()
+
+	sessions := &stubSessions{
+		sessions: []*entity.SessionSummary{
+			summary("s1", "a1", ago(time.Hour), ago(20*time.Minute), nil, 5),
+		},
+	}
+	alerts := &stubAlerts{
+		rules: []*entity.AlertRule{rule("r1", entity.AlertRobotOffline, srv.URL)},
+	}
+	c := DefaultConfig()
+	c.MaxAttempts = 2
+	w := NewWorker(alerts, sessions, c)
+	ctx := context.Background()
+
+	require.NoError(t, w.Tick(ctx, now))
+	require.Equal(t, 1, len(alerts.alerts))
+	al := alerts.alerts[0]
+	require.Equal(t, entity.DeliveryPending, al.Status, "should retry after a failed attempt")
+	require.Equal(t, 1, al.Attempts)
+	require.Equal(t, now.Add(c.BackoffBase), *al.NextAttemptAt, "should back off")
+	require.Equal(t, http.StatusServiceUnavailable, al.Deliveries[0].StatusCode, "should log the attempt")
+	require.NotEmpty(t, al.Deliveries[0].Error)
+
+	n, err := w.Deliver(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, 0, n, "should wait for the backoff")
+
+	n, err = w.Deliver(ctx, now.Add(c.BackoffBase))
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, entity.DeliveryDelivered, al.Status)
+	require.Equal(t, 2, len(al.Deliveries), "should log every attempt")
+	require.Equal(t, http.StatusOK, al.Deliveries[1].StatusCode)
+
+	require.Equal(t, 1, len(got), "should deliver a signed payload")
+	require.Equal(t, "s1", got[0].SessionID)
+	require.Equal(t, entity.AlertRobotOffline, got[0].RuleType)
+	require.Equal(t, al.UID, got[0].AlertID)
+
+	// Give up after max attempts.
+	srv.Close()
+	alerts.alerts = nil
+	sessions.sessions[0].Session.UID = "s2"
+	require.NoError(t, w.Tick(ctx, now))
+	require.NoError(t, w.Tick(ctx, now.Add(time.Hour)))
+	require.Equal(t, entity.DeliveryFailed, alerts.alerts[0].Status, "should give up after max attempts")
+	require.Equal(t, 2, len(alerts.alerts[0].Deliveries))
+}
@@ -0,0 +1,251 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+)
+
+const (
+	// sessionsPageSize is the page size used when scanning sessions.
+	sessionsPageSize = 1000
+	// typicalSessions is the number of past sessions in an area we look
+	// at to find the typical time it takes to clean it.
+	typicalSessions = 20
+	// minTypicalSessions is the number of completed past sessions
+	// needed to know what's typical.
+	minTypicalSessions = 3
+)
+
+// Evaluate checks all rules against active sessions and sessions that
+// ended within Lookback, and saves a pending alert for every rule that
+// fires. Each rule fires at most once per session, and rules about
+// ended sessions only fire for sessions that ended after the rule was
+// created. Returns the number of alerts fired.
+func (w *Worker) Evaluate(ctx context.Context, now time.Time) (int, error) {
+	rules, err := w.a.ListRules(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(rules) == 0 {
+		return 0, nil
+	}
+
+	sessions, err := w.sessions(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	// Skip rules that already fired before doing any work.
+	var keys []string
+	for _, r := range rules {
+		for _, s := range sessions {
+			keys = append(keys, entity.AlertKey(r.UID, s.Session.UID))
+		}
+	}
+	fired, err := w.a.Fired(ctx, keys)
+	if err != nil {
+		return 0, err
+	}
+
+	ev := &evaluation{
+		w:       w,
+		now:     now,
+		typical: make(map[string]time.Duration),
+		stuck:   make(map[string]bool),
+	}
+	var n int
+	for _, r := range rules {
+		for _, s := range sessions {
+			if fired[entity.AlertKey(r.UID, s.Session.UID)] {
+				continue
+			}
+			msg, err := ev.check(ctx, r, s)
+			if err != nil {
+				return n, err
+			}
+			if msg == "" {
+				continue
+			}
+			if _, err := w.a.Save(ctx, entity.NewAlert(r, s, msg, now)); err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+	return n, nil
+}
+
+// sessions returns all active sessions and sessions that ended within
+// Lookback.
+func (w *Worker) sessions(ctx context.Context, now time.Time) ([]*entity.SessionSummary, error) {
+	endedAfter := now.Add(-w.c.Lookback)
+	a := entity.ListSessionsArgs{
+		EndedAfter: &endedAfter,
+		Limit:      sessionsPageSize,
+	}
+
+	var all []*entity.SessionSummary
+	for {
+		res, err := w.s.List(ctx, a)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, res.Sessions...)
+		if res.NextCursor == "" {
+			break
+		}
+		a.Cursor = res.NextCursor
+	}
+	return all, nil
+}
+
+// evaluation caches what we look up while evaluating rules once.
+type evaluation struct {
+	w       *Worker
+	now     time.Time
+	typical map[string]time.Duration // By area id, zero if unknown.
+	stuck   map[string]bool          // By session id.
+}
+
+// check checks a rule against a session and returns a message
+// describing why it fired, or an empty string if it didn't.
+func (ev *evaluation) check(ctx context.Context, r *entity.AlertRule, s *entity.SessionSummary) (string, error) {
+	sess := s.Session
+	threshold := r.Threshold
+	if threshold == 0 {
+		threshold = entity.DefaultAlertThreshold(r.RuleType)
+	}
+
+	// Sessions have ended once ended_at is set, see
+	// entity.CleaningSession.End.
+	active := sess.EndedAt == nil
+	endedSinceRule := !active && (r.CreatedAt == nil || !sess.EndedAt.Before(*r.CreatedAt))
+
+	switch r.RuleType {
+	case entity.AlertSessionAbandoned:
+		if endedSinceRule && sess.CompletedAt == nil {
+			return fmt.Sprintf("session ended at %s before every square was cleaned (%s%%)", sess.EndedAt.Format(time.RFC3339), s.Progress.Completion), nil
+		}
+
+	case entity.AlertLowCompletion:
+		if endedSinceRule && s.Progress.SquaresTotal > 0 {
+			pct := float64(s.Progress.SquaresCleaned) * 100 / float64(s.Progress.SquaresTotal)
+			if pct < threshold {
+				return fmt.Sprintf("session ended with %s%% completion, below %g%%", s.Progress.Completion, threshold), nil
+			}
+		}
+
+	case entity.AlertRobotOffline:
+		last := sess.LastReportedAt
+		if last == nil {
+			last = sess.StartedAt
+		}
+		if active && !sess.IsPaused() && last != nil {
+			offline := ev.now.Sub(*last)
+			if offline > time.Duration(threshold*float64(time.Minute)) {
+				return fmt.Sprintf("robot has not reported in %d minutes", int(offline/time.Minute)), nil
+			}
+		}
+
+	case entity.AlertRobotStuck:
+		if active {
+			stuck, err := ev.isStuck(ctx, sess.UID)
+			if err != nil || !stuck {
+				return "", err
+			}
+			return "robot is stuck", nil
+		}
+
+	case entity.AlertLongSession:
+		if active && sess.StartedAt != nil && len(sess.SourceArea) > 0 {
+			typical, err := ev.typicalDuration(ctx, sess.SourceArea[0].UID)
+			if err != nil || typical == 0 {
+				return "", err
+			}
+			// Time spent docked doesn't count.
+			running := time.Duration(sess.RunningSec(ev.now)) * time.Second
+			if running > time.Duration(threshold*float64(typical)) {
+				return fmt.Sprintf("session has run for %d minutes, typically done in %d minutes", int(running/time.Minute), int(typical/time.Minute)), nil
+			}
+		}
+
+	case entity.AlertLowBattery:
+		if t := ev.telemetry(s); t != nil && t.Battery != nil && float64(*t.Battery) < threshold {
+			return fmt.Sprintf("robot reported %d%% battery, below %g%%", *t.Battery, threshold), nil
+		}
+
+	case entity.AlertRobotError:
+		if t := ev.telemetry(s); t != nil && t.HasError() {
+			return fmt.Sprintf("robot reported state '%s' with errors %v", t.State, t.ErrorCodes), nil
+		}
+	}
+	return "", nil
+}
+
+// telemetry returns the robot's latest telemetry if it was reported
+// during the session and the session is still active.
+func (ev *evaluation) telemetry(s *entity.SessionSummary) *entity.Telemetry {
+	if s.Session.EndedAt != nil || s.Robot == nil || s.Robot.LastTelemetry == nil {
+		return nil
+	}
+	if t := s.Robot.LastTelemetry; t.SessionID == s.Session.UID {
+		return t
+	}
+	return nil
+}
+
+// isStuck returns true if a stuck anomaly was detected during the
+// session.
+func (ev *evaluation) isStuck(ctx context.Context, sessionID string) (bool, error) {
+	if stuck, ok := ev.stuck[sessionID]; ok {
+		return stuck, nil
+	}
+	as, err := ev.w.s.Anomalies(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+	var stuck bool
+	for _, a := range as {
+		if a.Type == entity.AnomalyStuck {
+			stuck = true
+			break
+		}
+	}
+	ev.stuck[sessionID] = stuck
+	return stuck, nil
+}
+
+// typicalDuration returns the median time it took recent completed
+// sessions to clean an area, or zero if there are too few.
+func (ev *evaluation) typicalDuration(ctx context.Context, areaID string) (time.Duration, error) {
+	if d, ok := ev.typical[areaID]; ok {
+		return d, nil
+	}
+	inactive := false
+	res, err := ev.w.s.List(ctx, entity.ListSessionsArgs{
+		AreaID: areaID,
+		Active: &inactive,
+		Limit:  typicalSessions,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var ds []time.Duration
+	for _, s := range res.Sessions {
+		if s.Session.CompletedAt != nil {
+			ds = append(ds, time.Duration(s.Session.TimeToCompleteSec)*time.Second)
+		}
+	}
+	var d time.Duration
+	if len(ds) >= minTypicalSessions {
+		sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+		d = ds[len(ds)/2]
+	}
+	ev.typical[areaID] = d
+	return d, nil
+}
@@ -0,0 +1,183 @@
+// Package analytics computes coverage and efficiency metrics for
+// cleaning sessions.
+package analytics
+
+import (
+	"math"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/anrid/roboviewer/robo/replay"
+	"github.com/pkg/errors"
+)
+
+// IdleDistanceMM is the max distance a robot can move between two
+// positions and still be considered idle.
+const IdleDistanceMM = 10
+
+// SessionStats computes stats for a session. The session must include
+// its cleaning area, the entire grid and position history.
+//
+// Coverage, cleaned percentage and overlap are based on the persisted
+// grid, while the time to reach each completion milestone is found by
+// replaying the position history (see replay.Replayer).
+func SessionStats(sess *entity.CleaningSession) (*entity.SessionStats, error) {
+	if len(sess.Area) == 0 {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find a cleaning area for session %s", sess.UID)
+	}
+	a := sess.Area[0]
+
+	st := entity.NewSessionStats()
+	grid(st, a)
+	path(st, sess.PositionHistory)
+
+	if err := milestones(st, sess); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// grid computes coverage and overlap from a cleaning area's grid.
+func grid(st *entity.SessionStats, a *entity.CleaningArea) {
+	if len(a.Grid) == 0 {
+		return
+	}
+
+	var passed, cleaned, passes int
+	var coveredMM2 float64
+	for _, s := range a.Grid {
+		passes += s.Passes
+		if s.CleanedAt != nil {
+			cleaned++
+		}
+		if s.Passes > 0 {
+			passed++
+			// Squares along the right and bottom edges may extend past
+			// the area.
+			w := math.Min(float64(s.Size), float64(a.SizeX-s.X))
+			h := math.Min(float64(s.Size), float64(a.SizeY-s.Y))
+			coveredMM2 += w * h
+		}
+	}
+
+	total := len(a.Grid)
+	st.AreaCoveredM2 = round(coveredMM2 / 1e6)
+	st.CoveragePct = pct(passed, total)
+	st.CleanedPct = pct(cleaned, total)
+	if a.PassesNeeded > 0 {
+		st.OverlapRatio = round(float64(passes) / float64(total*a.PassesNeeded))
+	}
+}
+
+// path computes distance, speed and idle time from a position history.
+// The way to positions the robot got to without cleaning, e.g. to and
+// from its dock, is skipped.
+func path(st *entity.SessionStats, ps []*entity.Position) {
+	for _, p := range ps {
+		if !p.NotCleaning {
+			st.Positions++
+		}
+	}
+
+	var distance float64
+	var idle, moving time.Duration
+	for i := 1; i < len(ps); i++ {
+		prev, cur := ps[i-1], ps[i]
+		if cur.NotCleaning {
+			continue
+		}
+		d := math.Hypot(float64(cur.X-prev.X), float64(cur.Y-prev.Y))
+		distance += d
+
+		if prev.PassedAt == nil || cur.PassedAt == nil {
+			continue
+		}
+		dt := cur.PassedAt.Sub(*prev.PassedAt)
+		if d < IdleDistanceMM {
+			idle += dt
+		} else {
+			moving += dt
+		}
+	}
+
+	st.DistanceMM = int(math.Round(distance))
+	st.ActiveSec = int((idle + moving) / time.Second)
+	st.IdleSec = int(idle / time.Second)
+	if moving > 0 {
+		st.AvgSpeedMMPerSec = round(distance / moving.Seconds())
+	}
+}
+
+// milestones finds the time it took to reach 25, 50, 75, 90 and 100
+// percent completion. Like the session's running time, it excludes
+// time spent docked or interrupted, i.e. the time leading up to each
+// position the robot got to without cleaning.
+func milestones(st *entity.SessionStats, sess *entity.CleaningSession) error {
+	a := sess.Area[0]
+	if len(a.Grid) == 0 || len(sess.PositionHistory) == 0 {
+		return nil
+	}
+
+	start := sess.StartedAt
+	if start == nil {
+		start = sess.PositionHistory[0].PassedAt
+	}
+	if start == nil {
+		return nil
+	}
+
+	ms := []struct {
+		pct int
+		sec **int
+	}{
+		{25, &st.TimeTo25PctSec},
+		{50, &st.TimeTo50PctSec},
+		{75, &st.TimeTo75PctSec},
+		{90, &st.TimeTo90PctSec},
+		{100, &st.TimeTo100PctSec},
+	}
+
+	r, err := replay.New(a, sess.PositionHistory)
+	if err != nil {
+		return err
+	}
+
+	total := len(a.Grid)
+	var cleaned, next int
+	var excluded time.Duration
+	prev := start
+	for p, sq := r.Step(); p != nil && next < len(ms); p, sq = r.Step() {
+		if p.PassedAt != nil {
+			if p.NotCleaning && p.PassedAt.After(*prev) {
+				excluded += p.PassedAt.Sub(*prev)
+			}
+			prev = p.PassedAt
+		}
+		if sq == nil || sq.Passes != a.PassesNeeded {
+			continue
+		}
+		cleaned++
+		if p.PassedAt == nil {
+			continue
+		}
+		for ; next < len(ms) && cleaned*100 >= ms[next].pct*total; next++ {
+			sec := int((p.PassedAt.Sub(*start) - excluded) / time.Second)
+			*ms[next].sec = &sec
+		}
+	}
+	return nil
+}
+
+// pct returns n as a percentage of total with 2 decimals.
+func pct(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return round(float64(n) * 100 / float64(total))
+}
+
+// round rounds to 2 decimals.
+func round(f float64) float64 {
+	return math.Round(f*100) / 100
+}
@@ -0,0 +1,103 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionStats(t *testing.T) {
+	r := entity.NewRobot("Johnny 5", 500)
+	// 4x1 grid where the last square is only 250 mm wide.
+	a := entity.NewArea("Tiny Room", 1750, 500, 2)
+	sess := entity.NewCleaningSession(r, a, "test")
+	start := time.Date(2020, 2, 16, 10, 0, 0, 0, time.UTC)
+	sess.StartedAt = &start
+
+	// Robot moves right 500 mm every 10 seconds, idles for 20 seconds
+	// and moves back again.
+	moves := []struct {
+		x   int
+		sec int
+	}{
+		{250, 0}, {750, 10}, {1250, 20}, {1600, 30},
+		{1600, 40}, {1600, 50},
+		{1250, 60}, {750, 70}, {250, 80},
+	}
+	for _, m := range moves {
+		at := start.Add(time.Duration(m.sec) * time.Second)
+		sess.PositionHistory = append(sess.PositionHistory, entity.NewPosition(m.x, 250, at))
+		update(sess.Area[0], m.x, 250, at)
+	}
+
+	st, err := SessionStats(sess)
+	require.NoError(t, err)
+
+	require.Equal(t, 9, st.Positions)
+	require.Equal(t, 2*1350, st.DistanceMM, "should travel 1350 mm in each direction")
+	require.Equal(t, 80, st.ActiveSec)
+	require.Equal(t, 20, st.IdleSec, "should count time without movement as idle")
+	require.Equal(t, 45.0, st.AvgSpeedMMPerSec, "should only count time spent moving")
+
+	require.Equal(t, 0.88, st.AreaCoveredM2, "should clip squares to the area")
+	require.Equal(t, 100.0, st.CoveragePct)
+	require.Equal(t, 100.0, st.CleanedPct)
+	require.Equal(t, 1.13, st.OverlapRatio, "should have 9 passes out of 8 needed")
+
+	// Squares are cleaned at 40s (the last, where the robot idles),
+	// 60s (third), 70s (second) and 80s (first).
+	require.Equal(t, 40, *st.TimeTo25PctSec)
+	require.Equal(t, 60, *st.TimeTo50PctSec)
+	require.Equal(t, 70, *st.TimeTo75PctSec)
+	require.Equal(t, 80, *st.TimeTo90PctSec)
+	require.Equal(t, 80, *st.TimeTo100PctSec)
+}
+
+// update applies a position to the grid the way a robot's update is
+// applied, i.e. to a grid loaded without the robot being present.
+func update(a *entity.CleaningArea, x, y int, at time.Time) {
+	for _, s := range a.Grid {
+		s.HasRobotPresent = false
+	}
+	a.VisitAt(x, y, at)
+}
+
+func TestSessionStatsNotCleaning(t *testing.T) {
+	r := entity.NewRobot("Johnny 5", 500)
+	a := entity.NewArea("Tiny Room", 1500, 500, 1)
+	sess := entity.NewCleaningSession(r, a, "test")
+	start := time.Date(2020, 2, 16, 10, 0, 0, 0, time.UTC)
+	sess.StartedAt = &start
+
+	// Robot cleans for 10 seconds, docks 5 meters away and comes back
+	// an hour later to clean for another 10 seconds.
+	sess.PositionHistory = []*entity.Position{
+		entity.NewPosition(250, 250, start),
+		entity.NewPosition(750, 250, start.Add(10*time.Second)),
+		entity.NewNonCleaningPosition(5750, 250, start.Add(time.Minute)),
+		entity.NewNonCleaningPosition(750, 250, start.Add(time.Hour)),
+		entity.NewPosition(1250, 250, start.Add(time.Hour+10*time.Second)),
+	}
+	for _, p := range sess.PositionHistory {
+		if !p.NotCleaning {
+			update(sess.Area[0], p.X, p.Y, *p.PassedAt)
+		}
+	}
+
+	st, err := SessionStats(sess)
+	require.NoError(t, err)
+
+	require.Equal(t, 3, st.Positions, "should not count docking and leaving the dock")
+	require.Equal(t, 2*500, st.DistanceMM, "should skip the way to and from the dock")
+	require.Equal(t, 20, st.ActiveSec)
+	require.Equal(t, 0, st.IdleSec)
+
+	// Squares are cleaned at 0s, 10s and 20s, not counting the time
+	// spent on the way to, at and from the dock.
+	require.Equal(t, 0, *st.TimeTo25PctSec)
+	require.Equal(t, 10, *st.TimeTo50PctSec)
+	require.Equal(t, 20, *st.TimeTo75PctSec)
+	require.Equal(t, 20, *st.TimeTo100PctSec)
+}
@@ -0,0 +1,156 @@
+package analytics
+
+import (
+	"math"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+)
+
+const (
+	// ETARecentWindow is how far back we look to find the current rate
+	// at which squares are cleaned.
+	ETARecentWindow = 10 * time.Minute
+	// ETAFullSquares is the number of squares cleaned within the recent
+	// window needed for the recent rate to get full weight.
+	ETAFullSquares = 10
+	// ETAFullHistory is the number of past sessions needed for the
+	// historical estimate to get full weight.
+	ETAFullHistory = 5
+)
+
+// ETAArgs are the args we pass to ETA().
+type ETAArgs struct {
+	// Counted grid squares and passes, including the squares cleaned
+	// between RecentSince(StartedAt, Now) and Now.
+	Coverage  *entity.SessionCoverage
+	StartedAt time.Time // When the session started.
+	Now       time.Time // Typically the time of the last report.
+	// Seconds the session has been running at Now, excluding time
+	// spent docked, see entity.CleaningSession.RunningSec.
+	RunningSec int
+
+	// How long past sessions by the same robot in the same area took
+	// to complete.
+	History []time.Duration
+}
+
+// RecentSince returns the start of the window within which squares
+// cleaned count towards the recent rate, i.e. ETARecentWindow before
+// now but not before the session started.
+func RecentSince(startedAt, now time.Time) time.Time {
+	since := now.Add(-ETARecentWindow)
+	if since.Before(startedAt) {
+		since = startedAt
+	}
+	return since
+}
+
+// estimate is a single estimate of the time remaining.
+type estimate struct {
+	remaining time.Duration
+	weight    float64 // 0 - 1, how much data the estimate is based on.
+}
+
+// ETA estimates when a session will have cleaned its entire area by
+// blending up to three estimates, each weighted by how much data it
+// is based on:
+//
+//   - The rate at which squares reached the passes needed within the
+//     last ETARecentWindow.
+//   - The rate of passes over the time the session has been running
+//     so far, excluding time spent docked.
+//   - How long past sessions took, scaled to the passes remaining.
+//
+// Confidence grows with the amount of data and shrinks when the
+// estimates disagree. Returns nil if there is nothing to base an
+// estimate on.
+func ETA(a ETAArgs) *entity.ETA {
+	c := a.Coverage
+	if c == nil || c.SquaresTotal == 0 || c.PassesNeeded <= 0 {
+		return nil
+	}
+
+	remaining := c.RemainingPasses
+	if remaining == 0 {
+		return &entity.ETA{At: a.Now, Confidence: 1}
+	}
+	total := c.SquaresTotal * c.PassesNeeded
+	done := total - remaining
+	cleanedRecently := c.CleanedRecently
+	since := RecentSince(a.StartedAt, a.Now)
+
+	var es []estimate
+
+	// Recent rate, each cleaned square took PassesNeeded passes.
+	if window := a.Now.Sub(since); cleanedRecently > 0 && window > 0 {
+		passesPerSec := float64(cleanedRecently*c.PassesNeeded) / window.Seconds()
+		es = append(es, estimate{
+			remaining: seconds(float64(remaining) / passesPerSec),
+			weight:    math.Min(1, float64(cleanedRecently)/ETAFullSquares),
+		})
+	}
+
+	// Overall rate. Counts all passes, not just those on cleaned
+	// squares, but reacts slowly to changes so it only gets half the
+	// weight.
+	if elapsed := time.Duration(a.RunningSec) * time.Second; done > 0 && elapsed > 0 {
+		passesPerSec := float64(done) / elapsed.Seconds()
+		es = append(es, estimate{
+			remaining: seconds(float64(remaining) / passesPerSec),
+			weight:    0.5 * math.Min(1, float64(done)/float64(ETAFullSquares*c.PassesNeeded)),
+		})
+	}
+
+	// History, assuming the time needed is proportional to the passes
+	// remaining.
+	if len(a.History) > 0 {
+		var sum time.Duration
+		for _, d := range a.History {
+			sum += d
+		}
+		mean := sum / time.Duration(len(a.History))
+		es = append(es, estimate{
+			remaining: seconds(mean.Seconds() * float64(remaining) / float64(total)),
+			weight:    math.Min(1, float64(len(a.History))/ETAFullHistory),
+		})
+	}
+
+	if len(es) == 0 {
+		return nil
+	}
+
+	var weights, weighted float64
+	for _, e := range es {
+		weights += e.weight
+		weighted += e.weight * e.remaining.Seconds()
+	}
+	mean := weighted / weights
+
+	// Agreement is 1 minus the weighted coefficient of variation. A
+	// single estimate can't be checked against anything.
+	agreement := 0.5
+	if len(es) > 1 && mean > 0 {
+		var variance float64
+		for _, e := range es {
+			d := e.remaining.Seconds() - mean
+			variance += e.weight * d * d
+		}
+		agreement = 1 - math.Min(1, math.Sqrt(variance/weights)/mean)
+	}
+	// Two estimates with full weight are enough for full confidence.
+	data := math.Min(1, weights/2)
+
+	left := seconds(mean)
+	return &entity.ETA{
+		At:              a.Now.Add(left),
+		Sec:             int(left / time.Second),
+		Confidence:      round(data * agreement),
+		RemainingPasses: remaining,
+	}
+}
+
+// seconds converts seconds to a duration rounded to the second.
+func seconds(sec float64) time.Duration {
+	return time.Duration(math.Round(sec)) * time.Second
+}
@@ -0,0 +1,69 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/stretchr/testify/require"
+)
+
+// etaCoverage returns the coverage of a 40 square area where n squares
+// were cleaned, all within the recent window.
+func etaCoverage(n, passesNeeded int) *entity.SessionCoverage {
+	return &entity.SessionCoverage{
+		PassesNeeded:    passesNeeded,
+		SquaresTotal:    40,
+		RemainingPasses: (40 - n) * passesNeeded,
+		CleanedRecently: n,
+	}
+}
+
+func TestETA(t *testing.T) {
+	start := time.Date(2020, 2, 16, 10, 0, 0, 0, time.UTC)
+	now := start.Add(10 * time.Minute)
+
+	require.Equal(t, start, RecentSince(start, now), "should not look back before the session started")
+	require.Equal(t, now, RecentSince(start, now.Add(ETARecentWindow)))
+
+	// Recent and overall rates agree, one square cleaned per minute.
+	c := etaCoverage(10, 1)
+	eta := ETA(ETAArgs{Coverage: c, StartedAt: start, Now: now, RunningSec: 600})
+	require.NotNil(t, eta)
+	require.Equal(t, 30*60, eta.Sec, "should need 30 more minutes at 1 square per minute")
+	require.Equal(t, now.Add(30*time.Minute), eta.At)
+	require.Equal(t, 30, eta.RemainingPasses)
+	require.Equal(t, 0.75, eta.Confidence, "should be fairly confident with agreeing rates")
+
+	// History agrees as well.
+	history := []time.Duration{40 * time.Minute, 40 * time.Minute, 40 * time.Minute, 40 * time.Minute, 40 * time.Minute}
+	eta = ETA(ETAArgs{Coverage: c, StartedAt: start, Now: now, RunningSec: 600, History: history})
+	require.Equal(t, 30*60, eta.Sec)
+	require.Equal(t, 1.0, eta.Confidence, "should be fully confident with agreeing rates and history")
+
+	// History disagrees.
+	history = []time.Duration{120 * time.Minute}
+	eta = ETA(ETAArgs{Coverage: c, StartedAt: start, Now: now, RunningSec: 600, History: history})
+	require.True(t, eta.Sec > 30*60, "should take history into account")
+	require.True(t, eta.Confidence < 0.75, "should be less confident when estimates disagree")
+
+	// Half the time was spent docked, which doubles the overall rate.
+	eta = ETA(ETAArgs{Coverage: c, StartedAt: start, Now: now, RunningSec: 300})
+	require.Equal(t, 25*60, eta.Sec, "should not count time docked as time spent cleaning")
+
+	// Only history.
+	c = etaCoverage(0, 2)
+	c.RemainingPasses-- // The first square has been passed once.
+	eta = ETA(ETAArgs{Coverage: c, StartedAt: start, Now: start, History: []time.Duration{80 * time.Minute}})
+	require.Equal(t, 79, eta.RemainingPasses, "should count partial passes")
+	require.Equal(t, 79*60, eta.Sec)
+	require.Equal(t, 0.05, eta.Confidence, "should have low confidence with a single past session")
+
+	// Nothing to go on.
+	require.Nil(t, ETA(ETAArgs{Coverage: etaCoverage(0, 1), StartedAt: start, Now: now, RunningSec: 600}))
+
+	// Done.
+	eta = ETA(ETAArgs{Coverage: etaCoverage(40, 1), StartedAt: start, Now: now, RunningSec: 600})
+	require.Equal(t, 0, eta.Sec)
+	require.Equal(t, 1.0, eta.Confidence)
+}
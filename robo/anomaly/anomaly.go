@@ -0,0 +1,259 @@
+// Package anomaly detects robots that are stuck or behave oddly while
+// reporting their position during a cleaning session.
+package anomaly
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+)
+
+// Config holds the detector thresholds.
+type Config struct {
+	// StuckAfter is how long a robot can report without any net
+	// movement before it's considered stuck.
+	StuckAfter time.Duration
+	// OscillationPositions is the number of consecutive positions we
+	// look at to detect oscillation.
+	OscillationPositions int
+	// OscillationMaxSquares is the max number of distinct squares a
+	// robot visits within OscillationPositions positions and still be
+	// considered oscillating.
+	OscillationMaxSquares int
+	// MaxSpeedMMPerSec is the fastest a robot can possibly move.
+	MaxSpeedMMPerSec int
+	// ForgetAfter is how long we keep track of sessions that haven't
+	// been updated, e.g. robots that went offline without ending
+	// their session.
+	ForgetAfter time.Duration
+}
+
+// DefaultConfig returns the default detector thresholds.
+func DefaultConfig() Config {
+	return Config{
+		StuckAfter:            60 * time.Second,
+		OscillationPositions:  20,
+		OscillationMaxSquares: 3,
+		MaxSpeedMMPerSec:      1000,
+		ForgetAfter:           time.Hour,
+	}
+}
+
+// Point is a position reported at a certain time.
+type Point struct {
+	X, Y int
+	At   time.Time
+}
+
+// Sample is a position reported by a robot during a session.
+type Sample struct {
+	SessionID    string
+	RobotSize    int // Diameter in millimeters, also the size of a grid square.
+	SizeX, SizeY int // Area size in millimeters.
+	Point
+
+	// Last is the session's previous position, used when the detector
+	// hasn't seen the session before, e.g. after a restart (optional).
+	Last *Point
+}
+
+// square is a grid square index.
+type square struct{ x, y int }
+
+// track is what we remember about a session.
+type track struct {
+	last     Point
+	still    Point    // Where the robot was when it last moved.
+	squares  []square // Squares of the most recent positions.
+	seen     time.Time
+	stuck    bool // Flagged as stuck since it last moved.
+	osc      bool // Flagged as oscillating since it last left the squares.
+	outside  bool // Flagged as out of bounds since it was last inside.
+	hasStill bool
+}
+
+// Detector detects anomalies in the positions reported during
+// cleaning sessions. It keeps a little state per active session in
+// memory and is safe for concurrent use.
+//
+// Stuck, oscillating and out of bounds robots are flagged once until
+// they recover, while every impossible jump is flagged.
+type Detector struct {
+	mu        sync.Mutex
+	c         Config
+	sessions  map[string]*track
+	lastSweep time.Time
+}
+
+// New creates a new detector.
+func New(c Config) *Detector {
+	return &Detector{c: c, sessions: make(map[string]*track)}
+}
+
+// Check checks a sample and returns the anomalies detected, if any.
+func (d *Detector) Check(s Sample) []*entity.Anomaly {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.sweep()
+
+	t := d.sessions[s.SessionID]
+	if t == nil {
+		t = &track{}
+		if s.Last != nil {
+			t.last = *s.Last
+			t.still = *s.Last
+			t.hasStill = true
+			t.squares = append(t.squares, squareOf(s.Last.X, s.Last.Y, s.RobotSize))
+		}
+		d.sessions[s.SessionID] = t
+	}
+	t.seen = time.Now()
+
+	var found []*entity.Anomaly
+	if an := d.bounds(t, s); an != nil {
+		found = append(found, an)
+	}
+	if an := d.jump(t, s); an != nil {
+		found = append(found, an)
+	}
+	if an := d.stuck(t, s); an != nil {
+		found = append(found, an)
+	}
+	if an := d.oscillation(t, s); an != nil {
+		found = append(found, an)
+	}
+
+	t.last = s.Point
+	return found
+}
+
+// Forget drops all state kept for a session, e.g. when it ends.
+func (d *Detector) Forget(sessionID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.sessions, sessionID)
+}
+
+// sweep forgets sessions that haven't been updated in a while, at
+// most once a minute.
+func (d *Detector) sweep() {
+	now := time.Now()
+	if now.Sub(d.lastSweep) < time.Minute {
+		return
+	}
+	d.lastSweep = now
+	for id, t := range d.sessions {
+		if now.Sub(t.seen) > d.c.ForgetAfter {
+			delete(d.sessions, id)
+		}
+	}
+}
+
+// bounds flags positions outside of the area.
+func (d *Detector) bounds(t *track, s Sample) *entity.Anomaly {
+	inside := s.X >= 0 && s.Y >= 0 && s.X <= s.SizeX && s.Y <= s.SizeY
+	if inside {
+		t.outside = false
+		return nil
+	}
+	if t.outside {
+		return nil
+	}
+	t.outside = true
+	return entity.NewAnomaly(entity.AnomalyOutOfBounds, s.X, s.Y, s.At,
+		"position %d,%d is outside of the %d x %d mm area", s.X, s.Y, s.SizeX, s.SizeY)
+}
+
+// jump flags positions the robot can't have reached at max speed
+// since its last position. We allow for an error of one robot
+// diameter.
+func (d *Detector) jump(t *track, s Sample) *entity.Anomaly {
+	if t.last.At.IsZero() {
+		return nil
+	}
+	dist := distance(t.last, s.Point)
+	dt := s.At.Sub(t.last.At).Seconds()
+	if dt < 0 {
+		dt = 0
+	}
+	allowed := float64(d.c.MaxSpeedMMPerSec)*dt + float64(s.RobotSize)
+	if dist <= allowed {
+		return nil
+	}
+	return entity.NewAnomaly(entity.AnomalyJump, s.X, s.Y, s.At,
+		"moved %.0f mm from %d,%d in %.1f seconds, max is %.0f mm", dist, t.last.X, t.last.Y, dt, allowed)
+}
+
+// stuck flags robots that haven't moved more than half a robot
+// diameter in StuckAfter.
+func (d *Detector) stuck(t *track, s Sample) *entity.Anomaly {
+	if !t.hasStill || distance(t.still, s.Point) > float64(s.RobotSize)/2 {
+		t.still = s.Point
+		t.hasStill = true
+		t.stuck = false
+		return nil
+	}
+	still := s.At.Sub(t.still.At)
+	if t.stuck || still < d.c.StuckAfter {
+		return nil
+	}
+	t.stuck = true
+	return entity.NewAnomaly(entity.AnomalyStuck, s.X, s.Y, s.At,
+		"no net movement from %d,%d in %d seconds", t.still.X, t.still.Y, int(still/time.Second))
+}
+
+// oscillation flags robots that keep moving between a few squares.
+// Robots sitting still in a single square are left to stuck().
+func (d *Detector) oscillation(t *track, s Sample) *entity.Anomaly {
+	n := d.c.OscillationPositions
+	t.squares = append(t.squares, squareOf(s.X, s.Y, s.RobotSize))
+	if len(t.squares) > n {
+		t.squares = t.squares[len(t.squares)-n:]
+	}
+	if len(t.squares) < n {
+		return nil
+	}
+
+	distinct := map[square]bool{}
+	var moves int
+	for i, sq := range t.squares {
+		distinct[sq] = true
+		if i > 0 && sq != t.squares[i-1] {
+			moves++
+		}
+	}
+	if len(distinct) > d.c.OscillationMaxSquares || moves < n/2 {
+		t.osc = false
+		return nil
+	}
+	if t.osc {
+		return nil
+	}
+	t.osc = true
+	return entity.NewAnomaly(entity.AnomalyOscillating, s.X, s.Y, s.At,
+		"moved between %d squares %d times in the last %d positions", len(distinct), moves, n)
+}
+
+func squareOf(x, y, size int) square {
+	if size <= 0 {
+		return square{x, y}
+	}
+	return square{floorDiv(x, size), floorDiv(y, size)}
+}
+
+// floorDiv divides rounding towards negative infinity so that out of
+// bounds positions get their own squares.
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+func distance(a, b Point) float64 {
+	return math.Hypot(float64(b.X-a.X), float64(b.Y-a.Y))
+}
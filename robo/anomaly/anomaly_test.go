@@ -0,0 +1,101 @@
+package anomaly
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2020, 2, 16, 10, 0, 0, 0, time.UTC)
+
+// sample returns a sample for a 500 mm robot in a 5 x 5 m area.
+func sample(x, y, sec int) Sample {
+	return Sample{
+		SessionID: "0x1",
+		RobotSize: 500,
+		SizeX:     5000,
+		SizeY:     5000,
+		Point:     Point{X: x, Y: y, At: start.Add(time.Duration(sec) * time.Second)},
+	}
+}
+
+func types(as []*entity.Anomaly) []entity.AnomalyType {
+	var ts []entity.AnomalyType
+	for _, a := range as {
+		ts = append(ts, a.Type)
+	}
+	return ts
+}
+
+func TestStuck(t *testing.T) {
+	d := New(DefaultConfig())
+
+	// Spinning in place, jittering a little.
+	for sec := 0; sec < 60; sec += 5 {
+		require.Empty(t, d.Check(sample(1000+sec%2*10, 1000, sec)))
+	}
+	found := d.Check(sample(1000, 1010, 60))
+	require.Equal(t, []entity.AnomalyType{entity.AnomalyStuck}, types(found), "should flag no net movement for 60 seconds")
+	require.Equal(t, start.Add(time.Minute), *found[0].DetectedAt)
+	require.NotEmpty(t, found[0].Message)
+
+	require.Empty(t, d.Check(sample(1000, 1000, 65)), "should only flag once")
+
+	// Moves and gets stuck again.
+	require.Empty(t, d.Check(sample(2000, 1000, 70)))
+	require.Empty(t, d.Check(sample(2000, 1000, 100)))
+	require.Equal(t, []entity.AnomalyType{entity.AnomalyStuck}, types(d.Check(sample(2000, 1000, 130))), "should flag again after moving")
+}
+
+func TestOscillation(t *testing.T) {
+	d := New(DefaultConfig())
+
+	// Back and forth between two squares.
+	var found []*entity.Anomaly
+	for i := 0; i < 20; i++ {
+		found = append(found, d.Check(sample(250+i%2*500, 250, i))...)
+	}
+	require.Equal(t, []entity.AnomalyType{entity.AnomalyOscillating}, types(found), "should flag oscillation between two squares")
+
+	require.Empty(t, d.Check(sample(250, 250, 20)), "should only flag once")
+
+	// Moving back and forth across the area is fine.
+	d = New(DefaultConfig())
+	for i := 0; i < 30; i++ {
+		col := i % 10
+		if i/10%2 == 1 {
+			col = 9 - col
+		}
+		require.Empty(t, d.Check(sample(250+col*500, 250+i/10*500, i)))
+	}
+}
+
+func TestOutOfBounds(t *testing.T) {
+	d := New(DefaultConfig())
+
+	require.Empty(t, d.Check(sample(4900, 250, 0)))
+	require.Equal(t, []entity.AnomalyType{entity.AnomalyOutOfBounds}, types(d.Check(sample(5100, 250, 1))), "should flag positions outside of the area")
+	require.Empty(t, d.Check(sample(5200, 250, 2)), "should only flag once while outside")
+	require.Empty(t, d.Check(sample(4900, 250, 3)))
+	require.Equal(t, []entity.AnomalyType{entity.AnomalyOutOfBounds}, types(d.Check(sample(4900, -100, 4))), "should flag again after getting back inside")
+}
+
+func TestJump(t *testing.T) {
+	d := New(DefaultConfig())
+
+	require.Empty(t, d.Check(sample(250, 250, 0)))
+	require.Empty(t, d.Check(sample(1250, 250, 1)), "should allow max speed plus a robot diameter")
+	require.Equal(t, []entity.AnomalyType{entity.AnomalyJump}, types(d.Check(sample(4250, 250, 2))), "should flag impossible jumps")
+	require.Equal(t, []entity.AnomalyType{entity.AnomalyJump}, types(d.Check(sample(250, 250, 2))), "should flag every jump")
+
+	// Picks up where the session left off.
+	d = New(DefaultConfig())
+	s := sample(4250, 4250, 1)
+	s.Last = &Point{X: 250, Y: 250, At: start}
+	require.Equal(t, []entity.AnomalyType{entity.AnomalyJump}, types(d.Check(s)), "should use the last known position")
+
+	d.Forget("0x1")
+	require.Empty(t, d.Check(sample(250, 250, 2)), "should forget sessions")
+}
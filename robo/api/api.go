@@ -11,13 +11,17 @@ import (
 
 	_ "github.com/anrid/roboviewer/docs" // Swag way of doing things.
 	docs "github.com/anrid/roboviewer/docs"
+	"github.com/anrid/roboviewer/robo/alerting"
 	"github.com/anrid/roboviewer/robo/config"
 	"github.com/anrid/roboviewer/robo/controller"
 	"github.com/anrid/roboviewer/robo/dg"
 	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/outbox"
+	"github.com/anrid/roboviewer/robo/pkg/eventbus"
 	"github.com/anrid/roboviewer/robo/pkg/httpserver"
 	"github.com/anrid/roboviewer/robo/pkg/mqtt"
 	"github.com/anrid/roboviewer/robo/pkg/msgdel"
+	"github.com/anrid/roboviewer/robo/scheduler"
 	"github.com/anrid/roboviewer/robo/service"
 	"github.com/labstack/echo/v4"
 	echoSwagger "github.com/swaggo/echo-swagger"
@@ -60,20 +64,59 @@ func Run() {
 
 	// Setup repositories.
 	repos := struct {
-		Robot entity.RobotRepository
-		Area  entity.AreaRepository
+		Robot     entity.RobotRepository
+		Area      entity.AreaRepository
+		Session   entity.SessionRepository
+		Report    entity.ReportRepository
+		Alert     entity.AlertRepository
+		Webhook   entity.WebhookRepository
+		Schedule  entity.ScheduleRepository
+		Partition entity.PartitionRepository
+		Dock      entity.DockRepository
 	}{
-		Robot: dg.NewRobotRepository(conn),
-		Area:  dg.NewAreaRepository(conn),
+		Robot:     dg.NewRobotRepository(conn),
+		Area:      dg.NewAreaRepository(conn),
+		Session:   dg.NewSessionRepository(conn),
+		Report:    dg.NewReportRepository(conn),
+		Alert:     dg.NewAlertRepository(conn),
+		Webhook:   dg.NewWebhookRepository(conn),
+		Schedule:  dg.NewScheduleRepository(conn),
+		Partition: dg.NewPartitionRepository(conn),
+		Dock:      dg.NewDockRepository(conn),
 	}
 
+	// Setup event bus used to stream session events to API
+	// clients.
+	bus := eventbus.New()
+
+	// Connect to the MQTT broker, used to receive messages from robots
+	// and to send commands to robots.
+	broker := mqtt.NewClient(c.MQTTBrokerURL)
+	commands := mqtt.NewCommandPublisher(broker, c.TopicRobotCommand)
+
 	// Setup services.
 	svcs := struct {
-		Robot entity.RobotService
-		Area  entity.AreaService
+		Robot     entity.RobotService
+		Area      entity.AreaService
+		Session   entity.SessionService
+		Report    entity.ReportService
+		Export    entity.ExportService
+		Alert     entity.AlertService
+		Webhook   entity.WebhookService
+		Schedule  entity.ScheduleService
+		Partition entity.PartitionService
+		Dock      entity.DockService
 	}{
-		Robot: service.NewRobotService(repos.Robot),
-		Area:  service.NewAreaService(repos.Area),
+		Robot:     service.NewRobotService(repos.Robot, repos.Session, repos.Dock, bus),
+		Area:      service.NewAreaService(repos.Area),
+		Session:   service.NewSessionService(repos.Session),
+		Report:    service.NewReportService(repos.Report),
+		Export:    service.NewExportService(repos.Session),
+		Alert:     service.NewAlertService(repos.Alert),
+		Webhook:   service.NewWebhookService(repos.Webhook),
+		Schedule:  service.NewScheduleService(repos.Schedule, repos.Robot, repos.Area),
+		Partition: service.NewPartitionService(repos.Partition, repos.Area, repos.Robot, repos.Session, commands),
+		Dock:      service.NewDockService(repos.Dock, repos.Area),
 	}
 
 	// New HTTP server.
@@ -82,16 +125,42 @@ func Run() {
 	// Setup controllers.
 	controller.NewRobotController(svcs.Robot).SetupRoutes(serv.Echo)
 	controller.NewAreaController(svcs.Area).SetupRoutes(serv.Echo)
+	controller.NewSessionController(svcs.Session).SetupRoutes(serv.Echo)
+	controller.NewReportController(svcs.Report).SetupRoutes(serv.Echo)
+	controller.NewExportController(svcs.Export).SetupRoutes(serv.Echo)
+	controller.NewAlertController(svcs.Alert).SetupRoutes(serv.Echo)
+	controller.NewWebhookController(svcs.Webhook).SetupRoutes(serv.Echo)
+	controller.NewScheduleController(svcs.Schedule).SetupRoutes(serv.Echo)
+	controller.NewPartitionController(svcs.Partition).SetupRoutes(serv.Echo)
+	controller.NewDockController(svcs.Dock).SetupRoutes(serv.Echo)
+	controller.NewStreamController(bus, append([]string{c.APIURL}, c.AllowedOrigins...)).SetupRoutes(serv.Echo)
 
 	// Wire up our message delegator to MQTT broker to handle
 	// incoming MQTT messages from robots.
-	broker := mqtt.NewClient(c.MQTTBrokerURL)
-
 	delegator := msgdel.NewMessageDelegator(svcs.Robot)
 
 	broker.Subscribe(c.TopicRobotSessionStart, delegator.HandleStartSession)
 	broker.Subscribe(c.TopicRobotSessionUpdate, delegator.HandleUpdateSession)
 	broker.Subscribe(c.TopicRobotSessionEnd, delegator.HandleEndSession)
+	broker.Subscribe(c.TopicRobotSessionPause, delegator.HandlePauseSession)
+	broker.Subscribe(c.TopicRobotSessionResume, delegator.HandleResumeSession)
+
+	// Evaluate alert rules and deliver alerts in the background.
+	workerCtx, stopWorker := context.WithCancel(ctx)
+	defer stopWorker()
+	go alerting.NewWorker(repos.Alert, repos.Session, alerting.DefaultConfig()).Run(workerCtx)
+
+	// Deliver session lifecycle events from the outbox to webhooks.
+	go outbox.NewWorker(repos.Webhook, svcs.Robot, outbox.DefaultConfig()).Run(workerCtx)
+
+	// Trigger cleaning schedules, sending start commands to robots
+	// over MQTT.
+	go scheduler.NewWorker(repos.Schedule, repos.Robot, repos.Session, commands, scheduler.DefaultConfig()).Run(workerCtx)
+
+	// Reply to coverage plan requests from robots over the same
+	// command channel.
+	planner := msgdel.NewPlanDelegator(svcs.Session, commands)
+	broker.Subscribe(c.TopicRobotPlanRequest, planner.HandlePlanRequest)
 
 	// Setup Swagger documentation.
 	docs.SwaggerInfo.Host = c.Host
@@ -130,7 +199,7 @@ func Run() {
 
 	// Wait for interrupt signal to gracefully shutdown the
 	// server with a timeout of 10 seconds.
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
 	<-quit
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -2,6 +2,7 @@ package config
 
 import (
 	"flag"
+	"strings"
 	"sync"
 )
 
@@ -24,6 +25,18 @@ type Config struct {
 	// MQTT topic that robots use to update their position during
 	// a cleaning session.
 	TopicRobotSessionUpdate string `json:"topic_robot_session_update"`
+	// MQTT topic that robots use to signal that they've docked,
+	// pausing their cleaning session.
+	TopicRobotSessionPause string `json:"topic_robot_session_pause"`
+	// MQTT topic that robots use to signal that they've left their
+	// dock, resuming their cleaning session.
+	TopicRobotSessionResume string `json:"topic_robot_session_resume"`
+	// MQTT topic that robots use to request a coverage plan for their
+	// active cleaning session. Plans are sent back as commands.
+	TopicRobotPlanRequest string `json:"topic_robot_plan_request"`
+	// MQTT topic prefix we send commands to robots on, each robot
+	// subscribes to "<prefix>/<robotID>".
+	TopicRobotCommand string `json:"topic_robot_command"`
 
 	// DgraphURL points to a running Dgraph server.
 	DgraphURL string `json:"dgraph_url"`
@@ -39,6 +52,9 @@ type Config struct {
 	APIURL string `json:"api_url"`
 	// Host is the API hostname and port, e.g. api.example.com:3000
 	Host string `json:"host"`
+	// AllowedOrigins are the origins, besides APIURL, that browsers may
+	// open WebSockets from, e.g. https://app.example.com
+	AllowedOrigins []string `json:"allowed_origins"`
 }
 
 // GetConfig returns a singleton instance of the backend config.
@@ -49,15 +65,28 @@ func GetConfig() Config {
 		flag.StringVar(&config.TopicRobotSessionStart, "topic-start", "/robot/session/start", "set MQTT topic for cleaning session start")
 		flag.StringVar(&config.TopicRobotSessionEnd, "topic-end", "/robot/session/end", "set MQTT topic for cleaning session end")
 		flag.StringVar(&config.TopicRobotSessionUpdate, "topic-update", "/robot/session/update", "set MQTT topic for robot session update")
+		flag.StringVar(&config.TopicRobotSessionPause, "topic-pause", "/robot/session/pause", "set MQTT topic for cleaning session pause when docked")
+		flag.StringVar(&config.TopicRobotSessionResume, "topic-resume", "/robot/session/resume", "set MQTT topic for cleaning session resume when undocked")
+		flag.StringVar(&config.TopicRobotPlanRequest, "topic-plan", "/robot/plan/request", "set MQTT topic for coverage plan requests")
+		flag.StringVar(&config.TopicRobotCommand, "topic-command", "/robot/command", "set MQTT topic prefix for commands to robots")
 
 		flag.BoolVar(&config.DropAll, "drop-all", false, "drop all tables and recreate schema")
 		flag.BoolVar(&config.Migrate, "migrate", false, "migrate schema changes")
 
+		var origins string
+		flag.StringVar(&origins, "allowed-origins", "", "set comma-separated origins, besides the API URL, allowed to open WebSockets, e.g. https://app.example.com")
+
 		config.DgraphURL = "127.0.0.1:9080"
 		config.APIURL = "http://localhost:3000"
 		config.Host = "localhost:3000"
 
 		flag.Parse()
+
+		for _, o := range strings.Split(origins, ",") {
+			if o = strings.TrimSpace(o); o != "" {
+				config.AllowedOrigins = append(config.AllowedOrigins, o)
+			}
+		}
 	})
 	return config
 }
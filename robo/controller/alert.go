@@ -0,0 +1,247 @@
+package controller
+
+import (
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/httpserver"
+	"github.com/labstack/echo/v4"
+)
+
+// AlertController holds all the route handlers (endpoints)
+// related to alert rules and alerts.
+type AlertController struct {
+	svc entity.AlertService
+}
+
+// NewAlertController creates a new alert controller instance.
+func NewAlertController(svc entity.AlertService) *AlertController {
+	return &AlertController{svc}
+}
+
+// ListRules returns all alert rules.
+// @Summary     List alert rules.
+// @Description List all alert rules, oldest first. Secrets are not included.
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} controller.AlertRulesResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Router      /v1/alerts/rules [get]
+func (co *AlertController) ListRules(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	rules, err := co.svc.ListRules(ctx)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	for i, r := range rules {
+		rules[i] = withoutSecret(r)
+	}
+
+	return httpserver.Ok(c, AlertRulesResponseV1{
+		Ok:    true,
+		Rules: rules,
+	})
+}
+
+// AlertRulesResponseV1 ...
+type AlertRulesResponseV1 struct {
+	Ok    bool                `json:"ok"`
+	Rules []*entity.AlertRule `json:"rules"`
+}
+
+// GetRule returns an alert rule.
+// @Summary     Get an alert rule.
+// @Description Get an alert rule. The secret is not included.
+// @Accept      json
+// @Produce     json
+// @Param       rule_id path string true "Alert rule ID"
+// @Success     200 {object} controller.AlertRuleResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/alerts/rules/{rule_id} [get]
+func (co *AlertController) GetRule(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	rule, err := co.svc.GetRule(ctx, c.Param("rule_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, AlertRuleResponseV1{
+		Ok:   true,
+		Rule: withoutSecret(rule),
+	})
+}
+
+// AlertRuleResponseV1 ...
+type AlertRuleResponseV1 struct {
+	Ok   bool              `json:"ok"`
+	Rule *entity.AlertRule `json:"rule"`
+}
+
+// CreateRule creates a new alert rule.
+// @Summary     Create a new alert rule.
+// @Description Create a new alert rule. Rules are checked every minute against active sessions and sessions that ended after the rule was created, and fire at most once per session. Rule types and their threshold: session_abandoned (session ended before every square was cleaned), robot_offline (no report in threshold minutes, default 10), low_completion (session ended below threshold percent, default 80), robot_stuck (stuck anomaly detected), long_session (running longer than threshold times the typical time to clean the area, default 1), low_battery (robot reported a battery level below threshold percent, default 20) and robot_error (robot reported an error state or error codes). Alerts are POSTed as JSON to the webhook URL, signed with an HMAC-SHA256 of the body in the X-Roboviewer-Signature header (sha256=<hex>), and retried with exponential backoff. The secret is generated unless given and only returned on create.
+// @Accept      json
+// @Produce     json
+// @Param       rule body controller.CreateAlertRuleRequestV1 true "Alert rule to create"
+// @Success     200 {object} controller.AlertRuleResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Router      /v1/alerts/rules [post]
+func (co *AlertController) CreateRule(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	r := &CreateAlertRuleRequestV1{}
+	err := httpserver.Bind(c, r)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	rule, err := co.svc.CreateRule(ctx, entity.CreateAlertRuleArgs{
+		Name:       r.Name,
+		RuleType:   entity.AlertRuleType(r.RuleType),
+		Threshold:  r.Threshold,
+		WebhookURL: r.WebhookURL,
+		Secret:     r.Secret,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, AlertRuleResponseV1{
+		Ok:   true,
+		Rule: rule,
+	})
+}
+
+// CreateAlertRuleRequestV1 ...
+type CreateAlertRuleRequestV1 struct {
+	Name       string   `json:"name" validate:"required"`
+	RuleType   string   `json:"rule_type" validate:"required"`
+	Threshold  *float64 `json:"threshold" validate:"omitempty,gte=0"`
+	WebhookURL string   `json:"webhook_url" validate:"required"`
+	Secret     string   `json:"secret"`
+}
+
+// UpdateRule updates an alert rule.
+// @Summary     Update an alert rule.
+// @Description Update an alert rule. Omitted fields are left unchanged. The rule type cannot be changed.
+// @Accept      json
+// @Produce     json
+// @Param       rule_id path string true "Alert rule ID"
+// @Param       rule body controller.UpdateAlertRuleRequestV1 true "Fields to update"
+// @Success     200 {object} controller.AlertRuleResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/alerts/rules/{rule_id} [patch]
+func (co *AlertController) UpdateRule(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	r := &UpdateAlertRuleRequestV1{}
+	err := httpserver.Bind(c, r)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	rule, err := co.svc.UpdateRule(ctx, entity.UpdateAlertRuleArgs{
+		RuleID:     c.Param("rule_id"),
+		Name:       r.Name,
+		Threshold:  r.Threshold,
+		WebhookURL: r.WebhookURL,
+		Secret:     r.Secret,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, AlertRuleResponseV1{
+		Ok:   true,
+		Rule: withoutSecret(rule),
+	})
+}
+
+// UpdateAlertRuleRequestV1 ...
+type UpdateAlertRuleRequestV1 struct {
+	Name       *string  `json:"name" validate:"omitempty,min=1"`
+	Threshold  *float64 `json:"threshold" validate:"omitempty,gte=0"`
+	WebhookURL *string  `json:"webhook_url" validate:"omitempty,min=1"`
+	Secret     *string  `json:"secret" validate:"omitempty,min=1"`
+}
+
+// DeleteRule deletes an alert rule.
+// @Summary     Delete an alert rule.
+// @Description Delete an alert rule. Alerts it fired that are still pending delivery are dropped.
+// @Accept      json
+// @Produce     json
+// @Param       rule_id path string true "Alert rule ID"
+// @Success     200 {object} controller.OkResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/alerts/rules/{rule_id} [delete]
+func (co *AlertController) DeleteRule(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	err := co.svc.DeleteRule(ctx, c.Param("rule_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, OkResponseV1{Ok: true})
+}
+
+// List returns fired alerts and their delivery log.
+// @Summary     List alerts.
+// @Description List fired alerts, latest first, with their delivery status (pending, delivered or failed) and a log of every delivery attempt.
+// @Accept      json
+// @Produce     json
+// @Param       rule_id query string false "Alerts fired by this rule"
+// @Param       session_id query string false "Alerts fired for this session"
+// @Param       limit query integer false "Max number of alerts to return (default: 100, max: 1000)"
+// @Success     200 {object} controller.AlertsResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Router      /v1/alerts [get]
+func (co *AlertController) List(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	limit, err := queryInt(c, "limit", 0)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	alerts, err := co.svc.List(ctx, entity.ListAlertsArgs{
+		RuleID:    c.QueryParam("rule_id"),
+		SessionID: c.QueryParam("session_id"),
+		Limit:     limit,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, AlertsResponseV1{
+		Ok:     true,
+		Alerts: alerts,
+	})
+}
+
+// AlertsResponseV1 ...
+type AlertsResponseV1 struct {
+	Ok     bool            `json:"ok"`
+	Alerts []*entity.Alert `json:"alerts"`
+}
+
+// withoutSecret returns a copy of a rule without its secret.
+func withoutSecret(r *entity.AlertRule) *entity.AlertRule {
+	cp := *r
+	cp.Secret = ""
+	return &cp
+}
+
+// SetupRoutes wires up the routes to the echo server.
+func (co *AlertController) SetupRoutes(e *echo.Echo) {
+	e.GET("/v1/alerts", co.List)
+	e.GET("/v1/alerts/rules", co.ListRules)
+	e.POST("/v1/alerts/rules", co.CreateRule)
+	e.GET("/v1/alerts/rules/:rule_id", co.GetRule)
+	e.PATCH("/v1/alerts/rules/:rule_id", co.UpdateRule)
+	e.DELETE("/v1/alerts/rules/:rule_id", co.DeleteRule)
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/httpserver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertRules(t *testing.T) {
+	ts := setupTests()
+
+	// Create a rule with a generated secret and default threshold.
+	created := &AlertRuleResponseV1{}
+	status, body := httpserver.Call(http.MethodPost, "/v1/alerts/rules", ts.Server, &CreateAlertRuleRequestV1{
+		Name:       "Robot offline",
+		RuleType:   string(entity.AlertRobotOffline),
+		WebhookURL: "https://example.com/hooks/robo",
+	}, created)
+	require.Equal(t, http.StatusOK, status, body)
+	require.NotEmpty(t, created.Rule.Secret, "should generate a secret")
+	require.Equal(t, entity.DefaultAlertThreshold(entity.AlertRobotOffline), created.Rule.Threshold)
+
+	// The secret is never returned again.
+	got := &AlertRuleResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/alerts/rules/"+created.Rule.UID, ts.Server, nil, got)
+	require.Equal(t, http.StatusOK, status, body)
+	require.Empty(t, got.Rule.Secret)
+
+	threshold := 30.0
+	updated := &AlertRuleResponseV1{}
+	status, body = httpserver.Call(http.MethodPatch, "/v1/alerts/rules/"+created.Rule.UID, ts.Server, &UpdateAlertRuleRequestV1{
+		Threshold: &threshold,
+	}, updated)
+	require.Equal(t, http.StatusOK, status, body)
+	require.Equal(t, threshold, updated.Rule.Threshold)
+	require.Equal(t, "Robot offline", updated.Rule.Name)
+
+	list := &AlertRulesResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/alerts/rules", ts.Server, nil, list)
+	require.Equal(t, http.StatusOK, status, body)
+	require.NotEmpty(t, list.Rules)
+
+	// Unknown rule types and relative URLs are rejected.
+	status, _ = httpserver.Call(http.MethodPost, "/v1/alerts/rules", ts.Server, &CreateAlertRuleRequestV1{
+		Name:       "Bad",
+		RuleType:   "robot_on_fire",
+		WebhookURL: "https://example.com/hooks/robo",
+	}, nil)
+	require.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = httpserver.Call(http.MethodPost, "/v1/alerts/rules", ts.Server, &CreateAlertRuleRequestV1{
+		Name:       "Bad",
+		RuleType:   string(entity.AlertRobotStuck),
+		WebhookURL: "/hooks/robo",
+	}, nil)
+	require.Equal(t, http.StatusBadRequest, status)
+
+	status, body = httpserver.Call(http.MethodDelete, "/v1/alerts/rules/"+created.Rule.UID, ts.Server, nil, nil)
+	require.Equal(t, http.StatusOK, status, body)
+
+	status, _ = httpserver.Call(http.MethodGet, "/v1/alerts/rules/"+created.Rule.UID, ts.Server, nil, nil)
+	require.Equal(t, http.StatusNotFound, status)
+}
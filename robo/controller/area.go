@@ -2,6 +2,7 @@ package controller
 
 import (
 	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/geo"
 	"github.com/anrid/roboviewer/robo/pkg/httpserver"
 	"github.com/labstack/echo/v4"
 )
@@ -17,35 +18,230 @@ func NewAreaController(svc entity.AreaService) *AreaController {
 	return &AreaController{svc}
 }
 
-// ListAreas returns a list of all areas.
-// @Summary     List all areas.
-// @Description List all areas.
+// ListAreas returns a page of areas.
+// @Summary     List areas.
+// @Description List areas, newest first. Use next_cursor from the response to fetch the next page.
 // @Accept      json
 // @Produce     json
+// @Param       name query string false "Fulltext search on area name"
+// @Param       regions query boolean false "Include the regions of partitioned areas (default: false)"
+// @Param       cursor query string false "Cursor returned by a previous call"
+// @Param       limit query integer false "Max number of areas to return (default: 100, max: 1000)"
 // @Success     200 {object} controller.ListAreasResponseV1
 // @Failure     400 {object} cerr.ErrorResponse
 // @Router      /v1/areas [get]
 func (co *AreaController) ListAreas(c echo.Context) error {
 	ctx := c.Request().Context()
 
-	areas, err := co.svc.List(ctx)
+	limit, err := queryInt(c, "limit", 0)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	regions, err := queryBool(c, "regions")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	res, err := co.svc.List(ctx, entity.ListAreasArgs{
+		Name:    c.QueryParam("name"),
+		Regions: regions != nil && *regions,
+		Cursor:  c.QueryParam("cursor"),
+		Limit:   limit,
+	})
 	if err != nil {
 		return httpserver.Fail(c, err)
 	}
 
 	return httpserver.Ok(c, ListAreasResponseV1{
-		Ok:    true,
-		Areas: areas,
+		Ok:         true,
+		Areas:      res.Areas,
+		NextCursor: res.NextCursor,
 	})
 }
 
 // ListAreasResponseV1 ...
 type ListAreasResponseV1 struct {
-	Ok    bool           `json:"ok"`
-	Areas []*entity.Area `json:"areas"`
+	Ok         bool           `json:"ok"`
+	Areas      []*entity.Area `json:"areas"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// GetArea returns an area.
+// @Summary     Get an area.
+// @Description Get an area.
+// @Accept      json
+// @Produce     json
+// @Param       area_id path string true "Area ID"
+// @Success     200 {object} controller.AreaResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/areas/{area_id} [get]
+func (co *AreaController) GetArea(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	area, err := co.svc.Get(ctx, c.Param("area_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, AreaResponseV1{
+		Ok:   true,
+		Area: area,
+	})
+}
+
+// AreaResponseV1 ...
+type AreaResponseV1 struct {
+	Ok   bool         `json:"ok"`
+	Area *entity.Area `json:"area"`
+}
+
+// CreateArea creates a new area.
+// @Summary     Create a new area.
+// @Description Create a new area.
+// @Accept      json
+// @Produce     json
+// @Param       area body controller.CreateAreaRequestV1 true "Area to create"
+// @Success     200 {object} controller.AreaResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Router      /v1/areas [post]
+func (co *AreaController) CreateArea(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	r := &CreateAreaRequestV1{}
+	err := httpserver.Bind(c, r)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	area, err := co.svc.Create(ctx, entity.CreateAreaArgs{
+		Name:         r.Name,
+		SizeX:        r.SizeX,
+		SizeY:        r.SizeY,
+		PassesNeeded: r.PassesNeeded,
+		Anchor:       r.Anchor,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, AreaResponseV1{
+		Ok:   true,
+		Area: area,
+	})
+}
+
+// CreateAreaRequestV1 ...
+type CreateAreaRequestV1 struct {
+	Name         string `json:"name" validate:"required"`
+	SizeX        int    `json:"size_x" validate:"required,gt=0"` // X side size in millimeters.
+	SizeY        int    `json:"size_y" validate:"required,gt=0"` // Y side size in millimeters.
+	PassesNeeded int    `json:"passes_needed" validate:"required,gt=0"`
+
+	// Where the area's top-left corner is on the globe (optional).
+	Anchor *entity.GeoAnchor `json:"anchor" validate:"omitempty"`
+}
+
+// UpdateArea updates an area.
+// @Summary     Update an area.
+// @Description Update an area. Omitted fields are left unchanged. Past cleaning sessions are not affected.
+// @Accept      json
+// @Produce     json
+// @Param       area_id path string true "Area ID"
+// @Param       area body controller.UpdateAreaRequestV1 true "Fields to update"
+// @Success     200 {object} controller.AreaResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/areas/{area_id} [patch]
+func (co *AreaController) UpdateArea(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	r := &UpdateAreaRequestV1{}
+	err := httpserver.Bind(c, r)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	area, err := co.svc.Update(ctx, entity.UpdateAreaArgs{
+		AreaID:       c.Param("area_id"),
+		Name:         r.Name,
+		SizeX:        r.SizeX,
+		SizeY:        r.SizeY,
+		PassesNeeded: r.PassesNeeded,
+		Anchor:       r.Anchor,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, AreaResponseV1{
+		Ok:   true,
+		Area: area,
+	})
+}
+
+// UpdateAreaRequestV1 ...
+type UpdateAreaRequestV1 struct {
+	Name         *string `json:"name" validate:"omitempty,min=1"`
+	SizeX        *int    `json:"size_x" validate:"omitempty,gt=0"`
+	SizeY        *int    `json:"size_y" validate:"omitempty,gt=0"`
+	PassesNeeded *int    `json:"passes_needed" validate:"omitempty,gt=0"`
+
+	Anchor *entity.GeoAnchor `json:"anchor" validate:"omitempty"`
+}
+
+// DeleteArea soft-deletes an area.
+// @Summary     Delete an area.
+// @Description Delete an area. Past cleaning sessions are not affected.
+// @Accept      json
+// @Produce     json
+// @Param       area_id path string true "Area ID"
+// @Success     200 {object} controller.OkResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/areas/{area_id} [delete]
+func (co *AreaController) DeleteArea(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	err := co.svc.Delete(ctx, c.Param("area_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, OkResponseV1{Ok: true})
+}
+
+// GeoJSON returns an area as GeoJSON.
+// @Summary     Get an area as GeoJSON.
+// @Description Get an area's outline as a GeoJSON FeatureCollection in WGS84, placed using the area's anchor. Fails if the area has no anchor.
+// @Produce     json
+// @Param       area_id path string true "Area ID"
+// @Success     200 {object} geo.FeatureCollection
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/areas/{area_id}/geojson [get]
+func (co *AreaController) GeoJSON(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	area, err := co.svc.Get(ctx, c.Param("area_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	fc, err := geo.Area(area)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return geoJSON(c, fc)
 }
 
 // SetupRoutes wires up the routes to the echo server.
 func (co *AreaController) SetupRoutes(e *echo.Echo) {
 	e.GET("/v1/areas", co.ListAreas)
+	e.POST("/v1/areas", co.CreateArea)
+	e.GET("/v1/areas/:area_id", co.GetArea)
+	e.GET("/v1/areas/:area_id/geojson", co.GeoJSON)
+	e.PATCH("/v1/areas/:area_id", co.UpdateArea)
+	e.DELETE("/v1/areas/:area_id", co.DeleteArea)
 }
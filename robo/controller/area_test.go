@@ -1,9 +1,14 @@
 package controller
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
 	"testing"
+	"time"
 
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/geo"
 	"github.com/anrid/roboviewer/robo/pkg/httpserver"
 	"github.com/stretchr/testify/require"
 )
@@ -17,3 +22,96 @@ func TestListAreas(t *testing.T) {
 	require.Equal(t, http.StatusOK, status, "should succeed")
 	require.Equal(t, 2, len(out.Areas), "should list 2 areas")
 }
+
+func TestAreaCRUD(t *testing.T) {
+	ts := setupTests()
+
+	name := fmt.Sprintf("Test CRUD Room %d", time.Now().UnixNano())
+
+	var areas []*entity.Area
+	// Create areas.
+	for i := 0; i < 3; i++ {
+		out := &AreaResponseV1{}
+
+		in := &CreateAreaRequestV1{Name: name, SizeX: 1000 * (i + 1), SizeY: 2000, PassesNeeded: 2}
+		status, _ := httpserver.Call(http.MethodPost, "/v1/areas", ts.Server, in, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.NotEmpty(t, out.Area.UID, "should assign a uid")
+
+		areas = append(areas, out.Area)
+	}
+
+	// Validation.
+	{
+		in := &CreateAreaRequestV1{Name: name, SizeX: 1000, SizeY: 2000, PassesNeeded: 0}
+		status, body := httpserver.Call(http.MethodPost, "/v1/areas", ts.Server, in, nil)
+		require.Equal(t, http.StatusBadRequest, status, "should fail on invalid passes needed")
+		require.Contains(t, body, "validation_failed")
+
+		status, body = httpserver.Call(http.MethodGet, "/v1/areas?limit=abc", ts.Server, nil, nil)
+		require.Equal(t, http.StatusBadRequest, status, "should fail on invalid limit")
+		require.Contains(t, body, "validation_failed")
+	}
+
+	// Search and page through areas by name.
+	{
+		out := &ListAreasResponseV1{}
+
+		status, _ := httpserver.Call(http.MethodGet, "/v1/areas?limit=2&name="+url.QueryEscape(name), ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, 2, len(out.Areas), "should return first page")
+		require.Equal(t, areas[2].UID, out.Areas[0].UID, "should list newest first")
+		require.NotEmpty(t, out.NextCursor, "should have a next page")
+
+		next := &ListAreasResponseV1{}
+		status, _ = httpserver.Call(http.MethodGet, "/v1/areas?limit=2&name="+url.QueryEscape(name)+"&cursor="+out.NextCursor, ts.Server, nil, next)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, 1, len(next.Areas), "should return last page")
+		require.Equal(t, areas[0].UID, next.Areas[0].UID)
+		require.Empty(t, next.NextCursor, "should not have a next page")
+	}
+
+	// Update area.
+	{
+		out := &AreaResponseV1{}
+
+		passes := 5
+		status, _ := httpserver.Call(http.MethodPatch, "/v1/areas/"+areas[0].UID, ts.Server, &UpdateAreaRequestV1{PassesNeeded: &passes}, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, 5, out.Area.PassesNeeded, "should update passes needed")
+		require.Equal(t, 1000, out.Area.SizeX, "should keep size")
+	}
+
+	// Anchor area and export it as GeoJSON.
+	{
+		status, body := httpserver.Call(http.MethodGet, "/v1/areas/"+areas[0].UID+"/geojson", ts.Server, nil, nil)
+		require.Equal(t, http.StatusBadRequest, status, "should fail without an anchor")
+		require.Contains(t, body, "validation_failed")
+
+		in := &UpdateAreaRequestV1{Anchor: &entity.GeoAnchor{Lat: 100, Lon: 18.0686}}
+		status, _ = httpserver.Call(http.MethodPatch, "/v1/areas/"+areas[0].UID, ts.Server, in, nil)
+		require.Equal(t, http.StatusBadRequest, status, "should fail on invalid anchor")
+
+		out := &AreaResponseV1{}
+		in = &UpdateAreaRequestV1{Anchor: &entity.GeoAnchor{Lat: 59.3293, Lon: 18.0686, Rotation: 15}}
+		status, _ = httpserver.Call(http.MethodPatch, "/v1/areas/"+areas[0].UID, ts.Server, in, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, 15.0, out.Area.Anchor().Rotation, "should set anchor")
+
+		fc := &geo.FeatureCollection{}
+		status, _ = httpserver.Call(http.MethodGet, "/v1/areas/"+areas[0].UID+"/geojson", ts.Server, nil, fc)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, "FeatureCollection", fc.Type)
+		require.Equal(t, 1, len(fc.Features), "should have the area outline")
+		require.Equal(t, "Polygon", fc.Features[0].Geometry.Type)
+	}
+
+	// Delete areas.
+	for _, a := range areas {
+		status, _ := httpserver.Call(http.MethodDelete, "/v1/areas/"+a.UID, ts.Server, nil, nil)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+
+		status, _ = httpserver.Call(http.MethodGet, "/v1/areas/"+a.UID, ts.Server, nil, nil)
+		require.Equal(t, http.StatusNotFound, status, "should no longer find deleted area")
+	}
+}
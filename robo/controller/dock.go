@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/httpserver"
+	"github.com/labstack/echo/v4"
+)
+
+// DockController holds all the route handlers (endpoints)
+// related to docks.
+type DockController struct {
+	svc entity.DockService
+}
+
+// NewDockController creates a new dock controller instance.
+func NewDockController(svc entity.DockService) *DockController {
+	return &DockController{svc}
+}
+
+// List returns the docks in an area.
+// @Summary     List an area's docks.
+// @Description List the docks (charging stations) in an area.
+// @Accept      json
+// @Produce     json
+// @Param       area_id path string true "Area ID"
+// @Success     200 {object} controller.DocksResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/areas/{area_id}/docks [get]
+func (co *DockController) List(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	ds, err := co.svc.List(ctx, c.Param("area_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, DocksResponseV1{
+		Ok:    true,
+		Docks: ds,
+	})
+}
+
+// DocksResponseV1 ...
+type DocksResponseV1 struct {
+	Ok    bool           `json:"ok"`
+	Docks []*entity.Dock `json:"docks"`
+}
+
+// Create places a new dock in an area.
+// @Summary     Create a dock.
+// @Description Place a dock (charging station) in an area. Robots pause their cleaning session while docked, optionally at a given dock, and resume it with the same grid when they leave. Time spent docked doesn't count towards the session's duration.
+// @Accept      json
+// @Produce     json
+// @Param       area_id path string true "Area ID"
+// @Param       dock body controller.CreateDockRequestV1 true "Dock name and position within the area"
+// @Success     200 {object} controller.DockResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/areas/{area_id}/docks [post]
+func (co *DockController) Create(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	r := &CreateDockRequestV1{}
+	err := httpserver.Bind(c, r)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	d, err := co.svc.Create(ctx, entity.CreateDockArgs{
+		AreaID: c.Param("area_id"),
+		Name:   r.Name,
+		X:      r.X,
+		Y:      r.Y,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, DockResponseV1{
+		Ok:   true,
+		Dock: d,
+	})
+}
+
+// CreateDockRequestV1 ...
+type CreateDockRequestV1 struct {
+	Name string `json:"name" validate:"required"`
+	X    int    `json:"x" validate:"gte=0"`
+	Y    int    `json:"y" validate:"gte=0"`
+}
+
+// DockResponseV1 ...
+type DockResponseV1 struct {
+	Ok   bool         `json:"ok"`
+	Dock *entity.Dock `json:"dock"`
+}
+
+// Delete deletes a dock.
+// @Summary     Delete a dock.
+// @Description Delete a dock. Sessions paused at it are unaffected.
+// @Accept      json
+// @Produce     json
+// @Param       dock_id path string true "Dock ID"
+// @Success     200 {object} controller.OkResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/docks/{dock_id} [delete]
+func (co *DockController) Delete(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	err := co.svc.Delete(ctx, c.Param("dock_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, OkResponseV1{Ok: true})
+}
+
+// SetupRoutes wires up the routes to the echo server.
+func (co *DockController) SetupRoutes(e *echo.Echo) {
+	e.GET("/v1/areas/:area_id/docks", co.List)
+	e.POST("/v1/areas/:area_id/docks", co.Create)
+	e.DELETE("/v1/docks/:dock_id", co.Delete)
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/httpserver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocks(t *testing.T) {
+	ts := setupTests()
+
+	ctx := context.Background()
+
+	robots, err := ts.Service.Robot.List(ctx, "", "")
+	require.NoError(t, err)
+	robot := robots[0]
+
+	area, err := ts.Service.Area.Create(ctx, entity.CreateAreaArgs{
+		Name:         "Test - Docks",
+		SizeX:        4000,
+		SizeY:        4000,
+		PassesNeeded: 1,
+	})
+	require.NoError(t, err)
+
+	created := &DockResponseV1{}
+	status, body := httpserver.Call(http.MethodPost, "/v1/areas/"+area.UID+"/docks", ts.Server, &CreateDockRequestV1{
+		Name: "Charger",
+		X:    0,
+		Y:    2000,
+	}, created)
+	require.Equal(t, http.StatusOK, status, body)
+	dock := created.Dock
+	require.NotEmpty(t, dock.UID)
+
+	list := &DocksResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/areas/"+area.UID+"/docks", ts.Server, nil, list)
+	require.Equal(t, http.StatusOK, status, body)
+	require.Equal(t, 1, len(list.Docks))
+	require.Equal(t, 2000, list.Docks[0].Y)
+
+	// Clean a square, dock for an hour and come back.
+	startedAt := time.Now().Add(-2 * time.Hour)
+	sess, err := ts.Service.Robot.StartSession(ctx, entity.StartSessionArgs{
+		RobotID:   robot.UID,
+		AreaID:    area.UID,
+		StartedAt: startedAt,
+	})
+	require.NoError(t, err)
+	_, err = ts.Service.Robot.UpdateSession(ctx, entity.UpdateSessionArgs{
+		RobotID:    robot.UID,
+		RobotX:     robot.Size / 2,
+		RobotY:     robot.Size / 2,
+		ReportedAt: startedAt.Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	_, err = ts.Service.Robot.PauseSession(ctx, entity.PauseSessionArgs{
+		RobotID:  robot.UID,
+		DockID:   dock.UID,
+		PausedAt: startedAt.Add(2 * time.Minute),
+	})
+	require.NoError(t, err)
+
+	st := &RobotStatusResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/robots/"+robot.UID+"/status", ts.Server, nil, st)
+	require.Equal(t, http.StatusOK, status, body)
+	require.True(t, st.Status.IsPaused)
+	require.Equal(t, dock.UID, st.Status.DockID)
+	require.Equal(t, 2000, st.Status.LastY, "should be at the dock")
+
+	// Starting over in the same area resumes the paused session.
+	resumed, err := ts.Service.Robot.StartSession(ctx, entity.StartSessionArgs{
+		RobotID:   robot.UID,
+		AreaID:    area.UID,
+		RobotX:    robot.Size / 2,
+		RobotY:    2000,
+		StartedAt: startedAt.Add(62 * time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, sess.UID, resumed.UID, "should carry on with the same session")
+
+	ended, err := ts.Service.Robot.EndSession(ctx, entity.UpdateSessionArgs{
+		RobotID:    robot.UID,
+		RobotX:     robot.Size + robot.Size/2,
+		RobotY:     2000,
+		ReportedAt: startedAt.Add(63 * time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3*60, ended.DurationSec, "should not count time docked")
+	require.Equal(t, 60*60, ended.PausedSec)
+
+	got := &SessionResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/sessions/"+sess.UID, ts.Server, nil, got)
+	require.Equal(t, http.StatusOK, status, body)
+	require.True(t, got.Progress.SquaresCleaned >= 2, "should keep the grid across the pause")
+
+	// Docking and leaving the dock don't pass any squares.
+	positions := &ListPositionsResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/sessions/"+sess.UID+"/positions", ts.Server, nil, positions)
+	require.Equal(t, http.StatusOK, status, body)
+	var notCleaning int
+	for _, p := range positions.Positions {
+		if p.NotCleaning {
+			notCleaning++
+		}
+	}
+	require.Equal(t, 3, notCleaning, "should mark the start, dock and resume positions as not cleaning")
+
+	check := &GridDriftResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/sessions/"+sess.UID+"/grid/check", ts.Server, nil, check)
+	require.Equal(t, http.StatusOK, status, body)
+	require.False(t, check.Check.Drift, "should replay to the persisted grid")
+
+	// Docks must be within the area.
+	status, body = httpserver.Call(http.MethodPost, "/v1/areas/"+area.UID+"/docks", ts.Server, &CreateDockRequestV1{
+		Name: "Outside",
+		X:    5000,
+	}, nil)
+	require.Equal(t, http.StatusBadRequest, status, body)
+
+	status, body = httpserver.Call(http.MethodDelete, "/v1/docks/"+dock.UID, ts.Server, nil, nil)
+	require.Equal(t, http.StatusOK, status, body)
+	status, _ = httpserver.Call(http.MethodDelete, "/v1/docks/"+dock.UID, ts.Server, nil, nil)
+	require.Equal(t, http.StatusNotFound, status)
+}
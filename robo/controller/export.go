@@ -0,0 +1,310 @@
+package controller
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/anrid/roboviewer/robo/pkg/httpserver"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// Export formats.
+const (
+	ExportCSV    = "csv"
+	ExportNDJSON = "ndjson"
+)
+
+// exportFlushEvery is the number of rows written between each flush
+// to the client.
+const exportFlushEvery = 1000
+
+// ExportController holds all the route handlers (endpoints)
+// related to exporting raw data.
+type ExportController struct {
+	svc entity.ExportService
+}
+
+// NewExportController creates a new export controller instance.
+func NewExportController(svc entity.ExportService) *ExportController {
+	return &ExportController{svc}
+}
+
+// Sessions streams sessions as CSV or NDJSON.
+// @Summary     Export cleaning sessions.
+// @Description Stream every cleaning session matching the given filters as CSV or newline delimited JSON (one session per line), latest started first.
+// @Produce     text/csv
+// @Produce     application/x-ndjson
+// @Param       format query string false "csv or ndjson (default: csv)"
+// @Param       robot_id query string false "Only sessions run by this robot"
+// @Param       area_id query string false "Only sessions cleaning this area"
+// @Param       from query string false "Only sessions started at or after this time (RFC 3339)"
+// @Param       to query string false "Only sessions started before this time (RFC 3339)"
+// @Success     200 {array} controller.SessionExportRowV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Router      /v1/export/sessions [get]
+func (co *ExportController) Sessions(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	a, w, err := exportRequest(c, "sessions", sessionExportHeader)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	err = co.svc.Sessions(ctx, a, func(s *entity.SessionSummary) error {
+		return w.write(newSessionExportRow(s))
+	})
+	return w.close(err)
+}
+
+// Positions streams positions as CSV or NDJSON.
+// @Summary     Export positions.
+// @Description Stream every position matching the given filters as CSV or newline delimited JSON (one position per line). Positions are grouped by session, latest started session first, and ordered by the time they were passed within each session.
+// @Produce     text/csv
+// @Produce     application/x-ndjson
+// @Param       format query string false "csv or ndjson (default: csv)"
+// @Param       robot_id query string false "Only positions from sessions run by this robot"
+// @Param       area_id query string false "Only positions from sessions cleaning this area"
+// @Param       session_id query string false "Only positions from this session"
+// @Param       from query string false "Only positions passed at or after this time (RFC 3339)"
+// @Param       to query string false "Only positions passed before this time (RFC 3339)"
+// @Success     200 {array} entity.PositionRecord
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/export/positions [get]
+func (co *ExportController) Positions(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	a, w, err := exportRequest(c, "positions", positionExportHeader)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	a.SessionID = c.QueryParam("session_id")
+
+	err = co.svc.Positions(ctx, a, func(p *entity.PositionRecord) error {
+		return w.write(positionExportRow{p})
+	})
+	return w.close(err)
+}
+
+// SetupRoutes wires up the routes to the echo server.
+func (co *ExportController) SetupRoutes(e *echo.Echo) {
+	e.GET("/v1/export/sessions", co.Sessions)
+	e.GET("/v1/export/positions", co.Positions)
+}
+
+// exportRequest parses the common export query params.
+func exportRequest(c echo.Context, name string, header []string) (entity.ExportArgs, *exportWriter, error) {
+	a := entity.ExportArgs{
+		RobotID: c.QueryParam("robot_id"),
+		AreaID:  c.QueryParam("area_id"),
+	}
+
+	var err error
+	if a.From, err = queryTime(c, "from"); err != nil {
+		return a, nil, err
+	}
+	if a.To, err = queryTime(c, "to"); err != nil {
+		return a, nil, err
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = ExportCSV
+	}
+	if format != ExportCSV && format != ExportNDJSON {
+		return a, nil, errors.Wrapf(cerr.ErrValidationFailed, "format must be %s or %s, got '%s'", ExportCSV, ExportNDJSON, format)
+	}
+
+	return a, &exportWriter{c: c, format: format, name: name, header: header}, nil
+}
+
+// exportRow is a row that can be written as CSV or JSON.
+type exportRow interface {
+	csv() []string
+}
+
+// exportWriter streams rows to the client. The response is started
+// when the first row is written so that errors that occur before
+// that can still be returned as a proper error response.
+type exportWriter struct {
+	c       echo.Context
+	format  string
+	name    string
+	header  []string
+	started bool
+	rows    int
+	bw      *bufio.Writer
+	cw      *csv.Writer
+	je      *json.Encoder
+}
+
+func (w *exportWriter) start() error {
+	w.started = true
+
+	res := w.c.Response()
+	contentType := "text/csv; charset=UTF-8"
+	if w.format == ExportNDJSON {
+		contentType = "application/x-ndjson"
+	}
+	res.Header().Set(echo.HeaderContentType, contentType)
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+w.name+"."+w.format+`"`)
+	res.WriteHeader(http.StatusOK)
+
+	w.bw = bufio.NewWriter(res)
+	if w.format == ExportCSV {
+		w.cw = csv.NewWriter(w.bw)
+		return w.cw.Write(w.header)
+	}
+	w.je = json.NewEncoder(w.bw)
+	return nil
+}
+
+func (w *exportWriter) write(row exportRow) error {
+	if !w.started {
+		if err := w.start(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if w.cw != nil {
+		err = w.cw.Write(row.csv())
+	} else {
+		err = w.je.Encode(row)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.rows++
+	if w.rows%exportFlushEvery == 0 {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *exportWriter) flush() error {
+	if w.cw != nil {
+		w.cw.Flush()
+		if err := w.cw.Error(); err != nil {
+			return err
+		}
+	}
+	if err := w.bw.Flush(); err != nil {
+		return err
+	}
+	w.c.Response().Flush()
+	return nil
+}
+
+// close finishes the export. If the export failed before anything was
+// written an error response is returned, otherwise the response is
+// cut short since the status has already been sent.
+func (w *exportWriter) close(err error) error {
+	if err != nil {
+		if !w.started {
+			return httpserver.Fail(w.c, err)
+		}
+		log.Printf("export of %s failed after %d rows: %s", w.name, w.rows, err.Error())
+		return nil
+	}
+	if !w.started {
+		// No rows, still send the CSV header.
+		if err := w.start(); err != nil {
+			return err
+		}
+	}
+	return w.flush()
+}
+
+var sessionExportHeader = []string{
+	"session_id", "name", "robot_id", "robot_name", "area_id", "area_name",
+	"is_active", "started_at", "ended_at", "duration_sec", "completed_at", "time_to_complete_sec",
+	"last_x", "last_y", "last_reported_at", "completion", "squares_cleaned", "squares_total",
+}
+
+// SessionExportRowV1 is an exported session.
+type SessionExportRowV1 struct {
+	SessionID         string     `json:"session_id"`
+	Name              string     `json:"name"`
+	RobotID           string     `json:"robot_id"`
+	RobotName         string     `json:"robot_name"`
+	AreaID            string     `json:"area_id"`
+	AreaName          string     `json:"area_name"`
+	IsActive          bool       `json:"is_active"`
+	StartedAt         *time.Time `json:"started_at"`
+	EndedAt           *time.Time `json:"ended_at"`
+	DurationSec       int        `json:"duration_sec"`
+	CompletedAt       *time.Time `json:"completed_at"`
+	TimeToCompleteSec int        `json:"time_to_complete_sec"`
+	LastX             int        `json:"last_x"`
+	LastY             int        `json:"last_y"`
+	LastReportedAt    *time.Time `json:"last_reported_at"`
+	Completion        string     `json:"completion"`
+	SquaresCleaned    int        `json:"squares_cleaned"`
+	SquaresTotal      int        `json:"squares_total"`
+}
+
+func newSessionExportRow(s *entity.SessionSummary) *SessionExportRowV1 {
+	sess := s.Session
+	row := &SessionExportRowV1{
+		SessionID:         sess.UID,
+		Name:              sess.Name,
+		IsActive:          sess.IsActive,
+		StartedAt:         sess.StartedAt,
+		EndedAt:           sess.EndedAt,
+		DurationSec:       sess.DurationSec,
+		CompletedAt:       sess.CompletedAt,
+		TimeToCompleteSec: sess.TimeToCompleteSec,
+		LastX:             sess.LastX,
+		LastY:             sess.LastY,
+		LastReportedAt:    sess.LastReportedAt,
+		Completion:        s.Progress.Completion,
+		SquaresCleaned:    s.Progress.SquaresCleaned,
+		SquaresTotal:      s.Progress.SquaresTotal,
+	}
+	if s.Robot != nil {
+		row.RobotID = s.Robot.UID
+		row.RobotName = s.Robot.Name
+	}
+	if len(sess.SourceArea) > 0 {
+		row.AreaID = sess.SourceArea[0].UID
+	}
+	if len(sess.Area) > 0 {
+		row.AreaName = sess.Area[0].Name
+	}
+	return row
+}
+
+func (r *SessionExportRowV1) csv() []string {
+	return []string{
+		r.SessionID, r.Name, r.RobotID, r.RobotName, r.AreaID, r.AreaName,
+		strconv.FormatBool(r.IsActive), csvTime(r.StartedAt), csvTime(r.EndedAt), strconv.Itoa(r.DurationSec), csvTime(r.CompletedAt), strconv.Itoa(r.TimeToCompleteSec),
+		strconv.Itoa(r.LastX), strconv.Itoa(r.LastY), csvTime(r.LastReportedAt), r.Completion, strconv.Itoa(r.SquaresCleaned), strconv.Itoa(r.SquaresTotal),
+	}
+}
+
+var positionExportHeader = []string{"session_id", "robot_id", "area_id", "x", "y", "passed_at"}
+
+type positionExportRow struct {
+	*entity.PositionRecord
+}
+
+func (r positionExportRow) csv() []string {
+	return []string{r.SessionID, r.RobotID, r.AreaID, strconv.Itoa(r.X), strconv.Itoa(r.Y), csvTime(r.PassedAt)}
+}
+
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/anrid/roboviewer/robo/pkg/httpserver"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// stubExportService exports a fixed number of positions and fails
+// after failAfter positions if set.
+type stubExportService struct {
+	positions int
+	failAfter int
+	args      entity.ExportArgs
+}
+
+func (s *stubExportService) Sessions(ctx context.Context, a entity.ExportArgs, fn func(*entity.SessionSummary) error) error {
+	return nil
+}
+
+func (s *stubExportService) Positions(ctx context.Context, a entity.ExportArgs, fn func(*entity.PositionRecord) error) error {
+	s.args = a
+	if a.SessionID == "missing" {
+		return errors.Wrap(cerr.ErrNotFound, "could not find session")
+	}
+	at := time.Date(2020, 2, 16, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < s.positions; i++ {
+		if s.failAfter > 0 && i == s.failAfter {
+			return errors.New("boom")
+		}
+		passedAt := at.Add(time.Duration(i) * time.Second)
+		err := fn(&entity.PositionRecord{SessionID: "0x1", RobotID: "0x2", AreaID: "0x3", X: i, Y: i * 2, PassedAt: &passedAt})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExportPositions(t *testing.T) {
+	svc := &stubExportService{positions: 2500}
+	serv := httpserver.NewServer()
+	NewExportController(svc).SetupRoutes(serv.Echo)
+
+	// CSV.
+	status, body := httpserver.Call(http.MethodGet, "/v1/export/positions?robot_id=0x2&from=2020-02-16T10:00:00Z", serv, nil, nil)
+	require.Equal(t, http.StatusOK, status, "should succeed")
+	require.Equal(t, "0x2", svc.args.RobotID)
+	require.NotNil(t, svc.args.From)
+
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	require.Equal(t, 2501, len(lines), "should write a header and every position")
+	require.Equal(t, "session_id,robot_id,area_id,x,y,passed_at", lines[0])
+	require.Equal(t, "0x1,0x2,0x3,1,2,2020-02-16T10:00:01Z", lines[2])
+
+	// NDJSON.
+	status, body = httpserver.Call(http.MethodGet, "/v1/export/positions?format=ndjson&session_id=0x1", serv, nil, nil)
+	require.Equal(t, http.StatusOK, status, "should succeed")
+	require.Equal(t, "0x1", svc.args.SessionID)
+
+	var n int
+	sc := bufio.NewScanner(strings.NewReader(body))
+	for sc.Scan() {
+		p := &entity.PositionRecord{}
+		require.NoError(t, json.Unmarshal(sc.Bytes(), p), "should write one JSON object per line")
+		require.Equal(t, n, p.X)
+		n++
+	}
+	require.Equal(t, 2500, n)
+
+	// No positions.
+	svc.positions = 0
+	status, body = httpserver.Call(http.MethodGet, "/v1/export/positions", serv, nil, nil)
+	require.Equal(t, http.StatusOK, status, "should succeed")
+	require.Equal(t, "session_id,robot_id,area_id,x,y,passed_at\n", body, "should only write the header")
+
+	// Errors before and after the response has started.
+	status, _ = httpserver.Call(http.MethodGet, "/v1/export/positions?format=xml", serv, nil, nil)
+	require.Equal(t, http.StatusBadRequest, status, "should fail on unknown format")
+
+	status, _ = httpserver.Call(http.MethodGet, "/v1/export/positions?session_id=missing", serv, nil, nil)
+	require.Equal(t, http.StatusNotFound, status, "should fail before streaming")
+
+	svc.positions, svc.failAfter = 2500, 1500
+	status, body = httpserver.Call(http.MethodGet, "/v1/export/positions?format=ndjson", serv, nil, nil)
+	require.Equal(t, http.StatusOK, status, "should have already sent the status")
+	require.True(t, strings.Count(body, "\n") < 1500, "should cut the export short")
+}
+
+func TestExportSessions(t *testing.T) {
+	ts := setupTests()
+
+	ctx := context.Background()
+
+	robots, err := ts.Service.Robot.List(ctx, "", "")
+	require.NoError(t, err)
+	robot := robots[0]
+
+	areas, err := ts.Service.Area.List(ctx, entity.ListAreasArgs{})
+	require.NoError(t, err)
+	area := areas.Areas[0]
+
+	startedAt := time.Now().Truncate(time.Second)
+	sess, err := ts.Service.Robot.StartSession(ctx, entity.StartSessionArgs{
+		RobotID:   robot.UID,
+		AreaID:    area.UID,
+		StartedAt: startedAt,
+	})
+	require.NoError(t, err)
+
+	status, body := httpserver.Call(http.MethodGet, "/v1/export/sessions?robot_id="+robot.UID, ts.Server, nil, nil)
+	require.Equal(t, http.StatusOK, status, "should succeed")
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	require.True(t, strings.HasPrefix(lines[0], "session_id,name,robot_id"), "should write a header")
+	require.True(t, strings.HasPrefix(lines[1], sess.UID+","), "should start with the latest session")
+
+	status, body = httpserver.Call(http.MethodGet, "/v1/export/positions?format=ndjson&session_id="+sess.UID, ts.Server, nil, nil)
+	require.Equal(t, http.StatusOK, status, "should succeed")
+	p := &entity.PositionRecord{}
+	require.NoError(t, json.Unmarshal([]byte(strings.Split(body, "\n")[0]), p))
+	require.Equal(t, robot.UID, p.RobotID)
+	require.Equal(t, area.UID, p.AreaID)
+}
@@ -0,0 +1,18 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/anrid/roboviewer/robo/geo"
+	"github.com/labstack/echo/v4"
+)
+
+// geoJSON responds with a GeoJSON feature collection.
+func geoJSON(c echo.Context, fc *geo.FeatureCollection) error {
+	b, err := json.Marshal(fc)
+	if err != nil {
+		return err
+	}
+	return c.Blob(http.StatusOK, "application/geo+json", b)
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// queryInt parses an optional integer query param. Returns def if
+// the param is missing.
+func queryInt(c echo.Context, name string, def int) (int, error) {
+	s := c.QueryParam(name)
+	if s == "" {
+		return def, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(cerr.ErrValidationFailed, "invalid %s '%s', should be an integer", name, s)
+	}
+	return v, nil
+}
+
+// queryTime parses an optional RFC 3339 timestamp query param.
+// Returns nil if the param is missing.
+func queryTime(c echo.Context, name string) (*time.Time, error) {
+	s := c.QueryParam(name)
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "invalid %s '%s', should be an RFC 3339 timestamp, e.g. 2020-02-16T14:30:00Z", name, s)
+	}
+	return &t, nil
+}
+
+// queryBool parses an optional boolean query param. Returns nil if
+// the param is missing.
+func queryBool(c echo.Context, name string) (*bool, error) {
+	s := c.QueryParam(name)
+	if s == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "invalid %s '%s', should be true or false", name, s)
+	}
+	return &b, nil
+}
+
+// queryList returns all values of a repeated and / or comma-separated
+// query param.
+func queryList(c echo.Context, name string) []string {
+	var list []string
+	for _, v := range c.QueryParams()[name] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+	}
+	return list
+}
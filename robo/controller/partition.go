@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/httpserver"
+	"github.com/anrid/roboviewer/robo/render"
+	"github.com/labstack/echo/v4"
+)
+
+// PartitionController holds all the route handlers (endpoints)
+// related to splitting an area between several robots.
+type PartitionController struct {
+	svc entity.PartitionService
+}
+
+// NewPartitionController creates a new partition controller instance.
+func NewPartitionController(svc entity.PartitionService) *PartitionController {
+	return &PartitionController{svc}
+}
+
+// List returns the partitions of an area.
+// @Summary     List an area's partitions.
+// @Description List the partitions of an area, latest first, including their regions.
+// @Accept      json
+// @Produce     json
+// @Param       area_id path string true "Area ID"
+// @Success     200 {object} controller.PartitionsResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/areas/{area_id}/partitions [get]
+func (co *PartitionController) List(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	ps, err := co.svc.List(ctx, c.Param("area_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, PartitionsResponseV1{
+		Ok:         true,
+		Partitions: ps,
+	})
+}
+
+// PartitionsResponseV1 ...
+type PartitionsResponseV1 struct {
+	Ok         bool                `json:"ok"`
+	Partitions []*entity.Partition `json:"partitions"`
+}
+
+// Create splits an area between several robots.
+// @Summary     Split an area between several robots.
+// @Description Split a large area into rectangular regions, one per robot, balanced so that every robot gets about the same number of grid squares given its size. A robot twice the size of another gets a region four times as large. Each region is created as an area of its own with the parent area's id and its offset within the parent area. Defaults to all robots without an active session.
+// @Accept      json
+// @Produce     json
+// @Param       area_id path string true "Area ID"
+// @Param       partition body controller.CreatePartitionRequestV1 true "Robots to split the area between"
+// @Success     200 {object} controller.PartitionResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/areas/{area_id}/partitions [post]
+func (co *PartitionController) Create(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	r := &CreatePartitionRequestV1{}
+	err := httpserver.Bind(c, r)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	p, err := co.svc.Create(ctx, entity.CreatePartitionArgs{
+		AreaID:   c.Param("area_id"),
+		RobotIDs: r.RobotIDs,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, PartitionResponseV1{
+		Ok:        true,
+		Partition: p,
+	})
+}
+
+// CreatePartitionRequestV1 ...
+type CreatePartitionRequestV1 struct {
+	RobotIDs []string `json:"robot_ids"`
+}
+
+// PartitionResponseV1 ...
+type PartitionResponseV1 struct {
+	Ok        bool              `json:"ok"`
+	Partition *entity.Partition `json:"partition"`
+}
+
+// Get returns a partition and its progress.
+// @Summary     Get a partition.
+// @Description Get a partition together with the progress of the latest cleaning session in each region. Completion rolls up to the parent area as the share of all squares across all regions that are cleaned.
+// @Accept      json
+// @Produce     json
+// @Param       partition_id path string true "Partition ID"
+// @Success     200 {object} controller.PartitionSummaryResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/partitions/{partition_id} [get]
+func (co *PartitionController) Get(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	s, err := co.svc.Get(ctx, c.Param("partition_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, PartitionSummaryResponseV1{
+		Ok:      true,
+		Summary: s,
+	})
+}
+
+// PartitionSummaryResponseV1 ...
+type PartitionSummaryResponseV1 struct {
+	Ok      bool                     `json:"ok"`
+	Summary *entity.PartitionSummary `json:"summary"`
+}
+
+// SVG renders a partition as an SVG.
+// @Summary     Get a partition as an SVG.
+// @Description Draw a partitioned area to scale with every region outlined, showing its robot's grid and filled by the completion of its latest session (green once cleaned).
+// @Produce     image/svg+xml
+// @Param       partition_id path string true "Partition ID"
+// @Param       pixel_size query integer false "Millimeters per pixel (default: fits the longest side of the area within 800 pixels)"
+// @Success     200 {file} binary
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/partitions/{partition_id}/regions.svg [get]
+func (co *PartitionController) SVG(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	pixelSize, err := queryInt(c, "pixel_size", 0)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	s, err := co.svc.Get(ctx, c.Param("partition_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	var buf bytes.Buffer
+	if err := render.PartitionSVG(&buf, s, render.Options{PixelSize: pixelSize}); err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return c.Blob(http.StatusOK, "image/svg+xml", buf.Bytes())
+}
+
+// Dispatch tells every robot in a partition to clean its region.
+// @Summary     Dispatch a partition.
+// @Description Publish a start_session command over MQTT to every robot in a partition on the topic /robot/command/{robot_id}, with the id of the region's area and the region itself, i.e. its offset and size within the parent area.
+// @Accept      json
+// @Produce     json
+// @Param       partition_id path string true "Partition ID"
+// @Success     200 {object} controller.PartitionResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/partitions/{partition_id}/dispatch [post]
+func (co *PartitionController) Dispatch(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	p, err := co.svc.Dispatch(ctx, c.Param("partition_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, PartitionResponseV1{
+		Ok:        true,
+		Partition: p,
+	})
+}
+
+// SetupRoutes wires up the routes to the echo server.
+func (co *PartitionController) SetupRoutes(e *echo.Echo) {
+	e.GET("/v1/areas/:area_id/partitions", co.List)
+	e.POST("/v1/areas/:area_id/partitions", co.Create)
+	e.GET("/v1/partitions/:partition_id", co.Get)
+	e.GET("/v1/partitions/:partition_id/regions.svg", co.SVG)
+	e.POST("/v1/partitions/:partition_id/dispatch", co.Dispatch)
+}
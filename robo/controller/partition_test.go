@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/httpserver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitions(t *testing.T) {
+	ts := setupTests()
+
+	ctx := context.Background()
+
+	robots, err := ts.Service.Robot.List(ctx, "", "")
+	require.NoError(t, err)
+	small, large := robots[0], robots[1]
+	if small.Size > large.Size {
+		small, large = large, small
+	}
+
+	hall, err := ts.Service.Area.Create(ctx, entity.CreateAreaArgs{
+		Name:         "Test - Big Hall",
+		SizeX:        15000,
+		SizeY:        10000,
+		PassesNeeded: 1,
+	})
+	require.NoError(t, err)
+
+	created := &PartitionResponseV1{}
+	status, body := httpserver.Call(http.MethodPost, "/v1/areas/"+hall.UID+"/partitions", ts.Server, &CreatePartitionRequestV1{
+		RobotIDs: []string{small.UID, large.UID},
+	}, created)
+	require.Equal(t, http.StatusOK, status, body)
+	p := created.Partition
+	require.Equal(t, 2, len(p.Regions))
+	require.True(t, p.Regions[1].SizeX*p.Regions[1].SizeY > p.Regions[0].SizeX*p.Regions[0].SizeY, "should give the larger robot a larger region")
+
+	// Each region is an area of its own.
+	region := p.Regions[0]
+	sub, err := ts.Service.Area.Get(ctx, region.AreaID())
+	require.NoError(t, err)
+	require.Equal(t, hall.UID, sub.ParentAreaID)
+	require.Equal(t, region.SizeX, sub.SizeX)
+
+	list := &PartitionsResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/areas/"+hall.UID+"/partitions", ts.Server, nil, list)
+	require.Equal(t, http.StatusOK, status, body)
+	require.Equal(t, p.UID, list.Partitions[0].UID)
+
+	// Dispatch start commands.
+	before := len(ts.Commands.Commands())
+	status, body = httpserver.Call(http.MethodPost, "/v1/partitions/"+p.UID+"/dispatch", ts.Server, nil, created)
+	require.Equal(t, http.StatusOK, status, body)
+	require.NotNil(t, created.Partition.DispatchedAt)
+	cmds := ts.Commands.Commands()[before:]
+	require.Equal(t, 2, len(cmds))
+	require.Equal(t, entity.CommandStartSession, cmds[0].Type)
+	require.Equal(t, small.UID, cmds[0].RobotID)
+	require.Equal(t, region.AreaID(), cmds[0].AreaID)
+	require.Equal(t, p.UID, cmds[0].PartitionID)
+
+	// Clean a bit of the first region and check that completion rolls up.
+	startedAt := time.Now()
+	first, err := ts.Service.Robot.StartSession(ctx, entity.StartSessionArgs{
+		RobotID:   small.UID,
+		AreaID:    region.AreaID(),
+		StartedAt: startedAt,
+	})
+	require.NoError(t, err)
+	_, err = ts.Service.Robot.UpdateSession(ctx, entity.UpdateSessionArgs{
+		RobotID:    small.UID,
+		RobotX:     small.Size + small.Size/2,
+		ReportedAt: startedAt.Add(time.Second),
+	})
+	require.NoError(t, err)
+	_, err = ts.Service.Robot.EndSession(ctx, entity.UpdateSessionArgs{
+		RobotID:    small.UID,
+		RobotX:     small.Size + small.Size/2,
+		ReportedAt: startedAt.Add(2 * time.Second),
+	})
+	require.NoError(t, err)
+
+	got := &PartitionSummaryResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/partitions/"+p.UID, ts.Server, nil, got)
+	require.Equal(t, http.StatusOK, status, body)
+	s := got.Summary
+	require.Equal(t, 2, len(s.Regions))
+	require.NotEmpty(t, s.Regions[0].SessionID)
+	cleaned := s.Regions[0].Progress.SquaresCleaned
+	require.True(t, cleaned > 0)
+	require.Empty(t, s.Regions[1].SessionID)
+	require.Equal(t, p.Regions[0].Squares+p.Regions[1].Squares, s.Progress.SquaresTotal)
+	require.Equal(t, cleaned, s.Progress.SquaresCleaned, "should roll up completion to the parent area")
+	require.False(t, s.Regions[0].IsActive, "should not report an ended session as active")
+
+	// Resuming an older session after a newer one ended reports the
+	// resumed session.
+	_, err = ts.Service.Robot.StartSession(ctx, entity.StartSessionArgs{
+		RobotID:   small.UID,
+		AreaID:    region.AreaID(),
+		StartedAt: startedAt.Add(3 * time.Second),
+	})
+	require.NoError(t, err)
+	_, err = ts.Service.Robot.EndSession(ctx, entity.UpdateSessionArgs{
+		RobotID:    small.UID,
+		ReportedAt: startedAt.Add(4 * time.Second),
+	})
+	require.NoError(t, err)
+	_, err = ts.Service.Robot.StartSession(ctx, entity.StartSessionArgs{
+		RobotID:   small.UID,
+		AreaID:    region.AreaID(),
+		StartedAt: startedAt.Add(5 * time.Second),
+		SessionID: first.UID,
+	})
+	require.NoError(t, err)
+
+	status, body = httpserver.Call(http.MethodGet, "/v1/partitions/"+p.UID, ts.Server, nil, got)
+	require.Equal(t, http.StatusOK, status, body)
+	require.Equal(t, first.UID, got.Summary.Regions[0].SessionID, "should report the active session rather than the latest")
+	require.True(t, got.Summary.Regions[0].IsActive)
+	require.Equal(t, cleaned, got.Summary.Regions[0].Progress.SquaresCleaned)
+
+	_, err = ts.Service.Robot.EndSession(ctx, entity.UpdateSessionArgs{
+		RobotID:    small.UID,
+		RobotX:     small.Size + small.Size/2,
+		ReportedAt: startedAt.Add(6 * time.Second),
+	})
+	require.NoError(t, err)
+
+	// Regions are only listed with the rest of the areas if asked for.
+	areas := &ListAreasResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/areas?name=Big+Hall", ts.Server, nil, areas)
+	require.Equal(t, http.StatusOK, status, body)
+	require.NotEmpty(t, areas.Areas)
+	for _, a := range areas.Areas {
+		require.Empty(t, a.ParentAreaID, "should not list regions by default")
+	}
+	status, body = httpserver.Call(http.MethodGet, "/v1/areas?name=Big+Hall&regions=true", ts.Server, nil, areas)
+	require.Equal(t, http.StatusOK, status, body)
+	var regions int
+	for _, a := range areas.Areas {
+		if a.ParentAreaID == hall.UID {
+			regions++
+		}
+	}
+	require.Equal(t, 2, regions, "should list regions when asked for")
+
+	// Robots whose sessions have ended are idle and get a region by
+	// default.
+	yard, err := ts.Service.Area.Create(ctx, entity.CreateAreaArgs{
+		Name:         "Test - Yard",
+		SizeX:        5000,
+		SizeY:        5000,
+		PassesNeeded: 1,
+	})
+	require.NoError(t, err)
+	created = &PartitionResponseV1{}
+	status, body = httpserver.Call(http.MethodPost, "/v1/areas/"+yard.UID+"/partitions", ts.Server, &CreatePartitionRequestV1{}, created)
+	require.Equal(t, http.StatusOK, status, body)
+	var assigned bool
+	for _, r := range created.Partition.Regions {
+		assigned = assigned || r.RobotID == small.UID
+	}
+	require.True(t, assigned, "should split the area between idle robots")
+
+	status, body = httpserver.Call(http.MethodGet, "/v1/partitions/"+p.UID+"/regions.svg", ts.Server, nil, nil)
+	require.Equal(t, http.StatusOK, status)
+	require.True(t, strings.HasPrefix(body, "<svg"))
+
+	// Validation.
+	status, body = httpserver.Call(http.MethodPost, "/v1/areas/"+region.AreaID()+"/partitions", ts.Server, &CreatePartitionRequestV1{
+		RobotIDs: []string{small.UID},
+	}, nil)
+	require.Equal(t, http.StatusBadRequest, status, "should not split a region again: "+body)
+
+	status, body = httpserver.Call(http.MethodPost, "/v1/areas/"+hall.UID+"/partitions", ts.Server, &CreatePartitionRequestV1{
+		RobotIDs: []string{small.UID, small.UID},
+	}, nil)
+	require.Equal(t, http.StatusBadRequest, status, body)
+
+	status, _ = httpserver.Call(http.MethodGet, "/v1/partitions/0x0", ts.Server, nil, nil)
+	require.Equal(t, http.StatusNotFound, status)
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/httpserver"
+	"github.com/labstack/echo/v4"
+)
+
+// ReportController holds all the route handlers (endpoints)
+// related to reports.
+type ReportController struct {
+	svc entity.ReportService
+}
+
+// NewReportController creates a new report controller instance.
+func NewReportController(svc entity.ReportService) *ReportController {
+	return &ReportController{svc}
+}
+
+// Sessions aggregates cleaning sessions.
+// @Summary     Get a cleaning session report.
+// @Description Aggregate cleaning sessions started within a time range, grouped by robot, area or day: sessions started, ended, completed (every square cleaned) and abandoned, total cleaning time of ended sessions, mean completion of ended sessions and mean time to clean every square in completed sessions. Days start at midnight in the time zone of from.
+// @Accept      json
+// @Produce     json
+// @Param       group_by query string false "Group by robot, area or day (default: robot)"
+// @Param       from query string false "Sessions started at or after this time (RFC 3339, default: a week ago)"
+// @Param       to query string false "Sessions started before this time (RFC 3339, default: now)"
+// @Success     200 {object} controller.SessionReportResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Router      /v1/reports/sessions [get]
+func (co *ReportController) Sessions(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	a := entity.SessionReportArgs{GroupBy: c.QueryParam("group_by")}
+
+	from, err := queryTime(c, "from")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	if from != nil {
+		a.From = *from
+	}
+	to, err := queryTime(c, "to")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	if to != nil {
+		a.To = *to
+	}
+
+	rep, err := co.svc.Sessions(ctx, a)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, SessionReportResponseV1{
+		Ok:     true,
+		Report: rep,
+	})
+}
+
+// SessionReportResponseV1 ...
+type SessionReportResponseV1 struct {
+	Ok     bool                  `json:"ok"`
+	Report *entity.SessionReport `json:"report"`
+}
+
+// SetupRoutes wires up the routes to the echo server.
+func (co *ReportController) SetupRoutes(e *echo.Echo) {
+	e.GET("/v1/reports/sessions", co.Sessions)
+}
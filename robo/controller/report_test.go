@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/httpserver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionReport(t *testing.T) {
+	ts := setupTests()
+
+	ctx := context.Background()
+
+	robots, err := ts.Service.Robot.List(ctx, "", "")
+	require.NoError(t, err)
+	robot := robots[0]
+
+	areas, err := ts.Service.Area.List(ctx, entity.ListAreasArgs{})
+	require.NoError(t, err)
+	area := areas.Areas[0]
+
+	// Run a short session that ends without cleaning anything.
+	startedAt := time.Now().Truncate(time.Second)
+	_, err = ts.Service.Robot.StartSession(ctx, entity.StartSessionArgs{
+		RobotID:   robot.UID,
+		AreaID:    area.UID,
+		StartedAt: startedAt,
+	})
+	require.NoError(t, err)
+	_, err = ts.Service.Robot.EndSession(ctx, entity.UpdateSessionArgs{
+		RobotID:    robot.UID,
+		RobotX:     robot.Size / 2,
+		RobotY:     robot.Size / 2,
+		ReportedAt: startedAt.Add(10 * time.Second),
+	})
+	require.NoError(t, err)
+
+	from := url.QueryEscape(startedAt.Add(-time.Minute).Format(time.RFC3339))
+	to := url.QueryEscape(startedAt.Add(time.Minute).Format(time.RFC3339))
+
+	find := func(rows []*entity.SessionReportRow, group string) *entity.SessionReportRow {
+		for _, row := range rows {
+			if row.Group == group {
+				return row
+			}
+		}
+		return nil
+	}
+
+	for _, groupBy := range []string{entity.GroupByRobot, entity.GroupByArea, entity.GroupByDay} {
+		out := &SessionReportResponseV1{}
+
+		status, body := httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/reports/sessions?group_by=%s&from=%s&to=%s", groupBy, from, to), ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed: %s", body)
+		require.Equal(t, groupBy, out.Report.GroupBy)
+		require.True(t, out.Report.Total.SessionsEnded >= 1, "should count the ended session")
+		require.True(t, out.Report.Total.SessionsAbandoned >= 1, "should count the session as abandoned")
+		require.True(t, out.Report.Total.CleaningSec >= 10, "should sum session durations")
+
+		switch groupBy {
+		case entity.GroupByRobot:
+			require.NotNil(t, find(out.Report.Rows, robot.UID), "should have a row for the robot")
+		case entity.GroupByArea:
+			require.NotNil(t, find(out.Report.Rows, area.UID), "should have a row for the area")
+		case entity.GroupByDay:
+			require.NotEmpty(t, out.Report.Rows, "should have a row for today")
+		}
+	}
+
+	status, _ := httpserver.Call(http.MethodGet, "/v1/reports/sessions?group_by=week", ts.Server, nil, nil)
+	require.Equal(t, http.StatusBadRequest, status, "should fail validation")
+}
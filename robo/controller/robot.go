@@ -1,8 +1,6 @@
 package controller
 
 import (
-	"strconv"
-
 	"github.com/anrid/roboviewer/robo/entity"
 	"github.com/anrid/roboviewer/robo/pkg/httpserver"
 	"github.com/labstack/echo/v4"
@@ -52,45 +50,300 @@ type ListRobotsResponseV1 struct {
 	Robots []*entity.Robot `json:"robots"`
 }
 
-// History returns all historical data for a robot.
-// @Summary     Get all historical cleaning sessions for a robot.
-// @Description Get all historical cleaning sessions for a robot.
+// Get returns a robot.
+// @Summary     Get a robot and its active cleaning session.
+// @Description Get a robot and its active cleaning session.
+// @Accept      json
+// @Produce     json
+// @Param       robot_id path string true "Robot ID"
+// @Success     200 {object} controller.RobotResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/robots/{robot_id} [get]
+func (co *RobotController) Get(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	robot, err := co.svc.Get(ctx, c.Param("robot_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, RobotResponseV1{
+		Ok:    true,
+		Robot: robot,
+	})
+}
+
+// RobotResponseV1 ...
+type RobotResponseV1 struct {
+	Ok    bool          `json:"ok"`
+	Robot *entity.Robot `json:"robot"`
+}
+
+// Status returns a robot's current position and completion.
+// @Summary     Get a robot's current position and completion.
+// @Description Get a robot's last reported position and the completion of its latest cleaning session, including an ETA with a confidence between 0 and 1 for active sessions. The ETA blends the recent rate at which squares are cleaned, the passes still needed across the grid and how long past sessions by the same robot in the same area took.
+// @Accept      json
+// @Produce     json
+// @Param       robot_id path string true "Robot ID"
+// @Success     200 {object} controller.RobotStatusResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/robots/{robot_id}/status [get]
+func (co *RobotController) Status(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	st, err := co.svc.Status(ctx, c.Param("robot_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, RobotStatusResponseV1{
+		Ok:     true,
+		Status: st,
+	})
+}
+
+// RobotStatusResponseV1 ...
+type RobotStatusResponseV1 struct {
+	Ok     bool                `json:"ok"`
+	Status *entity.RobotStatus `json:"status"`
+}
+
+// Create registers a new robot.
+// @Summary     Create a new robot.
+// @Description Create a new robot. Robot names must be unique.
+// @Accept      json
+// @Produce     json
+// @Param       robot body controller.CreateRobotRequestV1 true "Robot to create"
+// @Success     200 {object} controller.RobotResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Router      /v1/robots [post]
+func (co *RobotController) Create(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	r := &CreateRobotRequestV1{}
+	err := httpserver.Bind(c, r)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	robot, err := co.svc.Create(ctx, entity.CreateRobotArgs{
+		Name: r.Name,
+		Size: r.Size,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, RobotResponseV1{
+		Ok:    true,
+		Robot: robot,
+	})
+}
+
+// CreateRobotRequestV1 ...
+type CreateRobotRequestV1 struct {
+	Name string `json:"name" validate:"required"`
+	Size int    `json:"size" validate:"required,gt=0"` // Diameter in millimeters.
+}
+
+// Update updates a robot.
+// @Summary     Update a robot.
+// @Description Update a robot's name and / or size. Omitted fields are left unchanged.
+// @Accept      json
+// @Produce     json
+// @Param       robot_id path string true "Robot ID"
+// @Param       robot body controller.UpdateRobotRequestV1 true "Fields to update"
+// @Success     200 {object} controller.RobotResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/robots/{robot_id} [patch]
+func (co *RobotController) Update(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	r := &UpdateRobotRequestV1{}
+	err := httpserver.Bind(c, r)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	robot, err := co.svc.Update(ctx, entity.UpdateRobotArgs{
+		RobotID: c.Param("robot_id"),
+		Name:    r.Name,
+		Size:    r.Size,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, RobotResponseV1{
+		Ok:    true,
+		Robot: robot,
+	})
+}
+
+// UpdateRobotRequestV1 ...
+type UpdateRobotRequestV1 struct {
+	Name *string `json:"name" validate:"omitempty,min=1"`
+	Size *int    `json:"size" validate:"omitempty,gt=0"` // Diameter in millimeters.
+}
+
+// Delete soft-deletes a robot.
+// @Summary     Delete a robot.
+// @Description Delete a robot. Its historical cleaning sessions remain available. Fails if the robot has an active cleaning session.
+// @Accept      json
+// @Produce     json
+// @Param       robot_id path string true "Robot ID"
+// @Success     200 {object} controller.OkResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Failure     409 {object} cerr.ErrorResponse
+// @Router      /v1/robots/{robot_id} [delete]
+func (co *RobotController) Delete(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	err := co.svc.Delete(ctx, c.Param("robot_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, OkResponseV1{Ok: true})
+}
+
+// OkResponseV1 ...
+type OkResponseV1 struct {
+	Ok bool `json:"ok"`
+}
+
+// History returns historical data for a robot.
+// @Summary     Get historical cleaning sessions for a robot.
+// @Description Get historical cleaning sessions for a robot, latest started first. Use next_cursor from the response to fetch the next page.
 // @Accept      json
 // @Produce     json
 // @Param       robot_id path string true "Robot ID to show history for"
-// @Param       max query integer false "Return only max latest number of cleaning sessions for robot (default: 10)"
+// @Param       max query integer false "Return only max latest number of cleaning sessions for robot (default: 10, max: 100)"
+// @Param       from query string false "Only sessions started at or after this time (RFC 3339)"
+// @Param       to query string false "Only sessions started before this time (RFC 3339)"
+// @Param       cursor query string false "Cursor returned by a previous call"
+// @Param       positions query boolean false "Include position history (default: true)"
+// @Param       grid query boolean false "Include grids (default: true)"
 // @Success     200 {object} controller.RobotHistoryResponseV1
 // @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
 // @Router      /v1/robots/{robot_id}/history [get]
 func (co *RobotController) History(c echo.Context) error {
 	ctx := c.Request().Context()
 
-	robotID := c.Param("robot_id")
-	maxStr := c.QueryParam("max")
-	max := 10
-	if maxStr != "" {
-		max, _ = strconv.Atoi(maxStr)
+	max, err := queryInt(c, "max", 10)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	from, err := queryTime(c, "from")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	to, err := queryTime(c, "to")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	positions, err := queryBool(c, "positions")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	grid, err := queryBool(c, "grid")
+	if err != nil {
+		return httpserver.Fail(c, err)
 	}
 
-	robot, err := co.svc.History(ctx, robotID, max)
+	res, err := co.svc.History(ctx, entity.HistoryArgs{
+		RobotID:          c.Param("robot_id"),
+		From:             from,
+		To:               to,
+		Cursor:           c.QueryParam("cursor"),
+		Max:              max,
+		IncludePositions: positions == nil || *positions,
+		IncludeGrid:      grid == nil || *grid,
+	})
 	if err != nil {
 		return httpserver.Fail(c, err)
 	}
 
 	return httpserver.Ok(c, RobotHistoryResponseV1{
-		Ok:    true,
-		Robot: robot,
+		Ok:         true,
+		Robot:      res.Robot,
+		NextCursor: res.NextCursor,
 	})
 }
 
 // RobotHistoryResponseV1 ...
 type RobotHistoryResponseV1 struct {
-	Ok    bool          `json:"ok"`
-	Robot *entity.Robot `json:"robot"`
+	Ok         bool          `json:"ok"`
+	Robot      *entity.Robot `json:"robot"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// Telemetry returns telemetry reported by a robot.
+// @Summary     Get telemetry reported by a robot.
+// @Description Get the battery level, state, error codes and brush and filter wear a robot has reported together with its position, latest first. The latest telemetry is also included with the robot itself as last_telemetry.
+// @Accept      json
+// @Produce     json
+// @Param       robot_id path string true "Robot ID"
+// @Param       session_id query string false "Only telemetry reported during this session"
+// @Param       from query string false "Only telemetry reported at or after this time (RFC 3339)"
+// @Param       to query string false "Only telemetry reported before this time (RFC 3339)"
+// @Param       limit query integer false "Max number of reports (default: 1000, max: 10000)"
+// @Success     200 {object} controller.RobotTelemetryResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/robots/{robot_id}/telemetry [get]
+func (co *RobotController) Telemetry(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	limit, err := queryInt(c, "limit", 0)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	from, err := queryTime(c, "from")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	to, err := queryTime(c, "to")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	ts, err := co.svc.Telemetry(ctx, entity.ListTelemetryArgs{
+		RobotID:   c.Param("robot_id"),
+		SessionID: c.QueryParam("session_id"),
+		From:      from,
+		To:        to,
+		Limit:     limit,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, RobotTelemetryResponseV1{
+		Ok:        true,
+		Telemetry: ts,
+	})
+}
+
+// RobotTelemetryResponseV1 ...
+type RobotTelemetryResponseV1 struct {
+	Ok        bool                `json:"ok"`
+	Telemetry []*entity.Telemetry `json:"telemetry"`
 }
 
 // SetupRoutes wires up the routes to the echo server.
 func (co *RobotController) SetupRoutes(e *echo.Echo) {
 	e.GET("/v1/robots", co.List)
+	e.POST("/v1/robots", co.Create)
+	e.GET("/v1/robots/:robot_id", co.Get)
+	e.PATCH("/v1/robots/:robot_id", co.Update)
+	e.DELETE("/v1/robots/:robot_id", co.Delete)
+	e.GET("/v1/robots/:robot_id/status", co.Status)
 	e.GET("/v1/robots/:robot_id/history", co.History)
+	e.GET("/v1/robots/:robot_id/telemetry", co.Telemetry)
 }
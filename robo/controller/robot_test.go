@@ -1,9 +1,11 @@
 package controller
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/anrid/roboviewer/robo/entity"
 	"github.com/anrid/roboviewer/robo/pkg/httpserver"
@@ -25,12 +27,387 @@ func TestRobots(t *testing.T) {
 		robots = out.Robots
 	}
 
+	// Get status.
+	{
+		out := &RobotStatusResponseV1{}
+
+		status, _ := httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/robots/%s/status", robots[0].UID), ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, robots[0].UID, out.Status.RobotID)
+		require.NotEmpty(t, out.Status.SessionID, "should have a session")
+		require.Greater(t, out.Status.SquaresTotal, 0, "should count grid squares")
+	}
+
 	// Get history data.
 	{
+		ctx := context.Background()
+		areas, err := ts.Service.Area.List(ctx, entity.ListAreasArgs{})
+		require.NoError(t, err)
+		startedAt := time.Now()
+		_, err = ts.Service.Robot.StartSession(ctx, entity.StartSessionArgs{
+			RobotID:   robots[0].UID,
+			AreaID:    areas.Areas[0].UID,
+			StartedAt: startedAt,
+		})
+		require.NoError(t, err)
+		ended, err := ts.Service.Robot.EndSession(ctx, entity.UpdateSessionArgs{
+			RobotID:    robots[0].UID,
+			ReportedAt: startedAt.Add(time.Second),
+		})
+		require.NoError(t, err)
+
 		out := &RobotHistoryResponseV1{}
 
 		status, _ := httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/robots/%s/history", robots[0].UID), ts.Server, nil, out)
 		require.Equal(t, http.StatusOK, status, "should succeed")
 		require.NotEmpty(t, out.Robot, "should get 1 robot")
+
+		// Page through history one session at a time without positions
+		// and grids.
+		out = &RobotHistoryResponseV1{}
+		status, _ = httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/robots/%s/history?max=1&positions=false&grid=false", robots[0].UID), ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, 1, len(out.Robot.Session), "should get 1 session")
+		require.Equal(t, ended.UID, out.Robot.Session[0].UID, "should get the latest session first")
+		require.False(t, out.Robot.Session[0].IsActive, "should not report an ended session as active")
+		require.Empty(t, out.Robot.Session[0].PositionHistory, "should not include positions")
+		require.Empty(t, out.Robot.Session[0].Area[0].Grid, "should not include grid")
+
+		if out.NextCursor != "" {
+			next := &RobotHistoryResponseV1{}
+			status, _ = httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/robots/%s/history?max=1&cursor=%s", robots[0].UID, out.NextCursor), ts.Server, nil, next)
+			require.Equal(t, http.StatusOK, status, "should succeed")
+			require.NotEqual(t, out.Robot.Session[0].UID, next.Robot.Session[0].UID, "should get the next session")
+		}
+
+		for _, q := range []string{"max=abc", "max=0", "from=yesterday", "positions=maybe", "cursor=abc"} {
+			status, body := httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/robots/%s/history?%s", robots[0].UID, q), ts.Server, nil, nil)
+			require.Equal(t, http.StatusBadRequest, status, "should fail on invalid param %s", q)
+			require.Contains(t, body, "validation_failed")
+		}
+	}
+}
+
+func TestRobotCRUD(t *testing.T) {
+	ts := setupTests()
+
+	name := fmt.Sprintf("Test - CRUD %d", time.Now().UnixNano())
+
+	var robot *entity.Robot
+	// Create robot.
+	{
+		out := &RobotResponseV1{}
+
+		status, _ := httpserver.Call(http.MethodPost, "/v1/robots", ts.Server, &CreateRobotRequestV1{Name: name, Size: 300}, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.NotEmpty(t, out.Robot.UID, "should assign a uid")
+		require.Equal(t, 300, out.Robot.Size)
+
+		robot = out.Robot
 	}
+
+	// Validation.
+	{
+		status, body := httpserver.Call(http.MethodPost, "/v1/robots", ts.Server, &CreateRobotRequestV1{Name: name, Size: 300}, nil)
+		require.Equal(t, http.StatusBadRequest, status, "should fail on duplicate name")
+		require.Contains(t, body, "validation_failed")
+
+		status, body = httpserver.Call(http.MethodPost, "/v1/robots", ts.Server, &CreateRobotRequestV1{Name: name + " 2", Size: -1}, nil)
+		require.Equal(t, http.StatusBadRequest, status, "should fail on invalid size")
+		require.Contains(t, body, "validation_failed")
+	}
+
+	// Get and update robot.
+	{
+		out := &RobotResponseV1{}
+
+		status, _ := httpserver.Call(http.MethodGet, "/v1/robots/"+robot.UID, ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, name, out.Robot.Name)
+
+		size := 450
+		status, _ = httpserver.Call(http.MethodPatch, "/v1/robots/"+robot.UID, ts.Server, &UpdateRobotRequestV1{Size: &size}, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, name, out.Robot.Name, "should keep name")
+		require.Equal(t, 450, out.Robot.Size, "should update size")
+	}
+
+	// Delete robot after its session has ended.
+	{
+		ctx := context.Background()
+
+		areas, err := ts.Service.Area.List(ctx, entity.ListAreasArgs{})
+		require.NoError(t, err)
+
+		startedAt := time.Now().Truncate(time.Second)
+		_, err = ts.Service.Robot.StartSession(ctx, entity.StartSessionArgs{
+			RobotID:   robot.UID,
+			AreaID:    areas.Areas[0].UID,
+			StartedAt: startedAt,
+		})
+		require.NoError(t, err)
+
+		status, body := httpserver.Call(http.MethodDelete, "/v1/robots/"+robot.UID, ts.Server, nil, nil)
+		require.Equal(t, http.StatusConflict, status, "should fail while session is active")
+		require.Contains(t, body, "conflict")
+
+		_, err = ts.Service.Robot.EndSession(ctx, entity.UpdateSessionArgs{
+			RobotID:    robot.UID,
+			RobotX:     robot.Size / 2,
+			RobotY:     robot.Size / 2,
+			ReportedAt: startedAt.Add(10 * time.Second),
+		})
+		require.NoError(t, err)
+
+		st := &RobotStatusResponseV1{}
+		status, body = httpserver.Call(http.MethodGet, "/v1/robots/"+robot.UID+"/status", ts.Server, nil, st)
+		require.Equal(t, http.StatusOK, status, body)
+		require.False(t, st.Status.IsActive, "should no longer be active once the session has ended")
+		require.Nil(t, st.Status.ETA, "should not estimate completion of an ended session")
+
+		status, _ = httpserver.Call(http.MethodDelete, "/v1/robots/"+robot.UID, ts.Server, nil, nil)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+
+		status, _ = httpserver.Call(http.MethodGet, "/v1/robots/"+robot.UID, ts.Server, nil, nil)
+		require.Equal(t, http.StatusNotFound, status, "should no longer find deleted robot")
+	}
+}
+
+func TestRobotTelemetry(t *testing.T) {
+	ts := setupTests()
+
+	ctx := context.Background()
+
+	robots, err := ts.Service.Robot.List(ctx, "", "")
+	require.NoError(t, err)
+	robot := robots[0]
+
+	area, err := ts.Service.Area.Create(ctx, entity.CreateAreaArgs{
+		Name:         "Test - Telemetry",
+		SizeX:        5000,
+		SizeY:        5000,
+		PassesNeeded: 1,
+	})
+	require.NoError(t, err)
+
+	startedAt := time.Now()
+	sess, err := ts.Service.Robot.StartSession(ctx, entity.StartSessionArgs{
+		RobotID:   robot.UID,
+		AreaID:    area.UID,
+		StartedAt: startedAt,
+	})
+	require.NoError(t, err)
+
+	battery, brush := 87, 120
+	for i, b := range []int{battery, battery - 1} {
+		_, err = ts.Service.Robot.UpdateSession(ctx, entity.UpdateSessionArgs{
+			RobotID:    robot.UID,
+			RobotX:     100 * (i + 1),
+			RobotY:     100,
+			ReportedAt: startedAt.Add(time.Duration(i+1) * time.Second),
+			Telemetry: &entity.Telemetry{
+				Battery:    &b,
+				State:      entity.RobotError,
+				ErrorCodes: []string{"E12"},
+				BrushWear:  &brush,
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	// Invalid telemetry is rejected.
+	bad := 101
+	_, err = ts.Service.Robot.UpdateSession(ctx, entity.UpdateSessionArgs{
+		RobotID:    robot.UID,
+		ReportedAt: startedAt.Add(3 * time.Second),
+		Telemetry:  &entity.Telemetry{Battery: &bad},
+	})
+	require.Error(t, err)
+
+	out := &RobotTelemetryResponseV1{}
+	status, body := httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/robots/%s/telemetry?session_id=%s", robot.UID, sess.UID), ts.Server, nil, out)
+	require.Equal(t, http.StatusOK, status, body)
+	require.Equal(t, 2, len(out.Telemetry))
+	latest := out.Telemetry[0]
+	require.Equal(t, battery-1, *latest.Battery, "should return the latest first")
+	require.Equal(t, entity.RobotError, latest.State)
+	require.Equal(t, []string{"E12"}, latest.ErrorCodes)
+	require.Equal(t, brush, *latest.BrushWear)
+	require.Nil(t, latest.FilterWear)
+
+	got := &RobotResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/robots/"+robot.UID, ts.Server, nil, got)
+	require.Equal(t, http.StatusOK, status, body)
+	require.NotNil(t, got.Robot.LastTelemetry)
+	require.Equal(t, battery-1, *got.Robot.LastTelemetry.Battery)
+	require.Equal(t, sess.UID, got.Robot.LastTelemetry.SessionID)
+
+	status, _ = httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/robots/%s/telemetry?limit=0", robot.UID), ts.Server, nil, nil)
+	require.Equal(t, http.StatusOK, status)
+	status, _ = httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/robots/%s/telemetry?limit=-1", robot.UID), ts.Server, nil, nil)
+	require.Equal(t, http.StatusBadRequest, status)
+	status, _ = httpserver.Call(http.MethodGet, "/v1/robots/0x0/telemetry", ts.Server, nil, nil)
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestResumeSession(t *testing.T) {
+	ts := setupTests()
+
+	ctx := context.Background()
+
+	robots, err := ts.Service.Robot.List(ctx, "", "")
+	require.NoError(t, err)
+	robot := robots[0]
+
+	var areas []*entity.Area
+	for _, name := range []string{"Test - Resume", "Test - Elsewhere"} {
+		a, err := ts.Service.Area.Create(ctx, entity.CreateAreaArgs{
+			Name:         name,
+			SizeX:        4000,
+			SizeY:        4000,
+			PassesNeeded: 1,
+		})
+		require.NoError(t, err)
+		areas = append(areas, a)
+	}
+
+	// Clean a square, then the robot reboots and the session ends.
+	startedAt := time.Now().Add(-time.Hour)
+	sess, err := ts.Service.Robot.StartSession(ctx, entity.StartSessionArgs{
+		RobotID:   robot.UID,
+		AreaID:    areas[0].UID,
+		StartedAt: startedAt,
+	})
+	require.NoError(t, err)
+	_, err = ts.Service.Robot.EndSession(ctx, entity.UpdateSessionArgs{
+		RobotID:    robot.UID,
+		RobotX:     robot.Size / 2,
+		RobotY:     robot.Size / 2,
+		ReportedAt: startedAt.Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	// Updates need an active session.
+	_, err = ts.Service.Robot.UpdateSession(ctx, entity.UpdateSessionArgs{
+		RobotID:    robot.UID,
+		RobotX:     robot.Size / 2,
+		RobotY:     robot.Size / 2,
+		ReportedAt: startedAt.Add(2 * time.Minute),
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not_found")
+
+	// Stats are cached once the session has ended.
+	stats := &SessionStatsResponseV1{}
+	status, body := httpserver.Call(http.MethodGet, "/v1/sessions/"+sess.UID+"/stats", ts.Server, nil, stats)
+	require.Equal(t, http.StatusOK, status, body)
+	require.Equal(t, 1, stats.Stats.Positions)
+	require.Equal(t, 60, stats.Stats.ActiveSec)
+
+	// Resuming in another area is a mistake.
+	_, err = ts.Service.Robot.StartSession(ctx, entity.StartSessionArgs{
+		RobotID:   robot.UID,
+		AreaID:    areas[1].UID,
+		StartedAt: startedAt.Add(5 * time.Minute),
+		Resume:    true,
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "validation_failed")
+
+	resumed, err := ts.Service.Robot.StartSession(ctx, entity.StartSessionArgs{
+		RobotID:   robot.UID,
+		AreaID:    areas[0].UID,
+		StartedAt: startedAt.Add(5 * time.Minute),
+		SessionID: sess.UID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, sess.UID, resumed.UID, "should continue the same session")
+	require.True(t, resumed.IsActive)
+
+	st := &RobotStatusResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/robots/"+robot.UID+"/status", ts.Server, nil, st)
+	require.Equal(t, http.StatusOK, status, body)
+	require.Equal(t, sess.UID, st.Status.SessionID)
+	require.True(t, st.Status.IsActive)
+	require.Equal(t, 1, st.Status.SquaresCleaned, "should keep the grid")
+
+	ended, err := ts.Service.Robot.EndSession(ctx, entity.UpdateSessionArgs{
+		RobotID:    robot.UID,
+		RobotX:     robot.Size / 2,
+		RobotY:     robot.Size / 2,
+		ReportedAt: startedAt.Add(6 * time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, sess.UID, ended.UID)
+	require.Equal(t, 2*60, ended.DurationSec, "should not count time between the reboot and resuming")
+
+	status, body = httpserver.Call(http.MethodGet, "/v1/sessions/"+sess.UID+"/stats", ts.Server, nil, stats)
+	require.Equal(t, http.StatusOK, status, body)
+	require.Equal(t, 2, stats.Stats.Positions, "should not return the stats cached before resuming")
+	require.Equal(t, 2*60, stats.Stats.ActiveSec)
+
+	// Starting over doesn't end the session again.
+	_, err = ts.Service.Robot.StartSession(ctx, entity.StartSessionArgs{
+		RobotID:   robot.UID,
+		AreaID:    areas[1].UID,
+		StartedAt: startedAt.Add(8 * time.Minute),
+	})
+	require.NoError(t, err)
+
+	got := &SessionResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/sessions/"+sess.UID, ts.Server, nil, got)
+	require.Equal(t, http.StatusOK, status, body)
+	require.True(t, ended.EndedAt.Equal(*got.Session.EndedAt), "should keep when the session ended")
+	require.Equal(t, 2*60, got.Session.DurationSec)
+
+	// Resuming moves the robot without cleaning.
+	positions := &ListPositionsResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/sessions/"+sess.UID+"/positions", ts.Server, nil, positions)
+	require.Equal(t, http.StatusOK, status, body)
+	var notCleaning int
+	for _, p := range positions.Positions {
+		if p.NotCleaning {
+			notCleaning++
+		}
+	}
+	require.Equal(t, 2, notCleaning, "should mark the start and resume positions as not cleaning")
+
+	check := &GridDriftResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/sessions/"+sess.UID+"/grid/check", ts.Server, nil, check)
+	require.Equal(t, http.StatusOK, status, body)
+	require.False(t, check.Check.Drift, "should replay to the persisted grid")
+
+	_, err = ts.Service.Robot.StartSession(ctx, entity.StartSessionArgs{
+		RobotID:   robot.UID,
+		AreaID:    areas[0].UID,
+		StartedAt: startedAt.Add(7 * time.Minute),
+		SessionID: "0x0",
+	})
+	require.Error(t, err)
+
+	// Resuming an older session after a newer one ended reports the
+	// resumed session.
+	_, err = ts.Service.Robot.EndSession(ctx, entity.UpdateSessionArgs{
+		RobotID:    robot.UID,
+		ReportedAt: startedAt.Add(9 * time.Minute),
+	})
+	require.NoError(t, err)
+	_, err = ts.Service.Robot.StartSession(ctx, entity.StartSessionArgs{
+		RobotID:   robot.UID,
+		AreaID:    areas[0].UID,
+		StartedAt: startedAt.Add(10 * time.Minute),
+		SessionID: sess.UID,
+	})
+	require.NoError(t, err)
+
+	status, body = httpserver.Call(http.MethodGet, "/v1/robots/"+robot.UID+"/status", ts.Server, nil, st)
+	require.Equal(t, http.StatusOK, status, body)
+	require.Equal(t, sess.UID, st.Status.SessionID, "should report the active session rather than the latest")
+	require.True(t, st.Status.IsActive)
+
+	_, err = ts.Service.Robot.EndSession(ctx, entity.UpdateSessionArgs{
+		RobotID:    robot.UID,
+		ReportedAt: startedAt.Add(11 * time.Minute),
+	})
+	require.NoError(t, err)
 }
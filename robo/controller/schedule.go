@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/httpserver"
+	"github.com/labstack/echo/v4"
+)
+
+// ScheduleController holds all the route handlers (endpoints)
+// related to cleaning schedules.
+type ScheduleController struct {
+	svc entity.ScheduleService
+}
+
+// NewScheduleController creates a new schedule controller instance.
+func NewScheduleController(svc entity.ScheduleService) *ScheduleController {
+	return &ScheduleController{svc}
+}
+
+// List returns all schedules.
+// @Summary     List schedules.
+// @Description List all cleaning schedules, oldest first.
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} controller.SchedulesResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Router      /v1/schedules [get]
+func (co *ScheduleController) List(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	schedules, err := co.svc.List(ctx)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, SchedulesResponseV1{
+		Ok:        true,
+		Schedules: schedules,
+	})
+}
+
+// SchedulesResponseV1 ...
+type SchedulesResponseV1 struct {
+	Ok        bool               `json:"ok"`
+	Schedules []*entity.Schedule `json:"schedules"`
+}
+
+// Get returns a schedule.
+// @Summary     Get a schedule.
+// @Description Get a cleaning schedule.
+// @Accept      json
+// @Produce     json
+// @Param       schedule_id path string true "Schedule ID"
+// @Success     200 {object} controller.ScheduleResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/schedules/{schedule_id} [get]
+func (co *ScheduleController) Get(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	s, err := co.svc.Get(ctx, c.Param("schedule_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, ScheduleResponseV1{
+		Ok:       true,
+		Schedule: s,
+	})
+}
+
+// ScheduleResponseV1 ...
+type ScheduleResponseV1 struct {
+	Ok       bool             `json:"ok"`
+	Schedule *entity.Schedule `json:"schedule"`
+}
+
+// Create creates a new schedule.
+// @Summary     Create a new schedule.
+// @Description Create a recurring cleaning job. At every trigger time of the cron expression (minute, hour, day of month, month, day of week), in the given time zone, a start_session command is published over MQTT to the first idle robot of the schedule on the topic /robot/command/{robot_id}. Every trigger is recorded as a job run, which is marked started with the session id once the robot starts a session in the area, or missed if no robot was idle or no session started within 5 minutes.
+// @Accept      json
+// @Produce     json
+// @Param       schedule body controller.CreateScheduleRequestV1 true "Schedule to create"
+// @Success     200 {object} controller.ScheduleResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Router      /v1/schedules [post]
+func (co *ScheduleController) Create(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	r := &CreateScheduleRequestV1{}
+	err := httpserver.Bind(c, r)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	s, err := co.svc.Create(ctx, entity.CreateScheduleArgs{
+		Name:         r.Name,
+		AreaID:       r.AreaID,
+		RobotIDs:     r.RobotIDs,
+		Cron:         r.Cron,
+		TimeZone:     r.TimeZone,
+		PassesNeeded: r.PassesNeeded,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, ScheduleResponseV1{
+		Ok:       true,
+		Schedule: s,
+	})
+}
+
+// CreateScheduleRequestV1 ...
+type CreateScheduleRequestV1 struct {
+	Name         string   `json:"name" validate:"required"`
+	AreaID       string   `json:"area_id" validate:"required"`
+	RobotIDs     []string `json:"robot_ids" validate:"required,min=1"`
+	Cron         string   `json:"cron" validate:"required"`
+	TimeZone     string   `json:"time_zone"`
+	PassesNeeded int      `json:"passes_needed" validate:"gte=0"`
+}
+
+// Update updates a schedule.
+// @Summary     Update a schedule.
+// @Description Update a cleaning schedule. Omitted fields are left unchanged, robot ids are replaced. The next run time is recalculated.
+// @Accept      json
+// @Produce     json
+// @Param       schedule_id path string true "Schedule ID"
+// @Param       schedule body controller.UpdateScheduleRequestV1 true "Fields to update"
+// @Success     200 {object} controller.ScheduleResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/schedules/{schedule_id} [patch]
+func (co *ScheduleController) Update(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	r := &UpdateScheduleRequestV1{}
+	err := httpserver.Bind(c, r)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	s, err := co.svc.Update(ctx, entity.UpdateScheduleArgs{
+		ScheduleID:   c.Param("schedule_id"),
+		Name:         r.Name,
+		AreaID:       r.AreaID,
+		RobotIDs:     r.RobotIDs,
+		Cron:         r.Cron,
+		TimeZone:     r.TimeZone,
+		PassesNeeded: r.PassesNeeded,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, ScheduleResponseV1{
+		Ok:       true,
+		Schedule: s,
+	})
+}
+
+// UpdateScheduleRequestV1 ...
+type UpdateScheduleRequestV1 struct {
+	Name         *string  `json:"name" validate:"omitempty,min=1"`
+	AreaID       *string  `json:"area_id" validate:"omitempty,min=1"`
+	RobotIDs     []string `json:"robot_ids" validate:"omitempty,min=1"`
+	Cron         *string  `json:"cron" validate:"omitempty,min=1"`
+	TimeZone     *string  `json:"time_zone" validate:"omitempty,min=1"`
+	PassesNeeded *int     `json:"passes_needed" validate:"omitempty,gte=0"`
+}
+
+// Delete deletes a schedule.
+// @Summary     Delete a schedule.
+// @Description Delete a cleaning schedule. It won't trigger again, past runs are kept.
+// @Accept      json
+// @Produce     json
+// @Param       schedule_id path string true "Schedule ID"
+// @Success     200 {object} controller.OkResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/schedules/{schedule_id} [delete]
+func (co *ScheduleController) Delete(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	err := co.svc.Delete(ctx, c.Param("schedule_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, OkResponseV1{Ok: true})
+}
+
+// Runs returns a schedule's runs.
+// @Summary     List job runs.
+// @Description List runs of a cleaning schedule, latest first, with their status (dispatched, started or missed), the robot dispatched and the session started.
+// @Accept      json
+// @Produce     json
+// @Param       schedule_id path string true "Schedule ID"
+// @Param       limit query integer false "Max number of runs to return (default: 100, max: 1000)"
+// @Success     200 {object} controller.JobRunsResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/schedules/{schedule_id}/runs [get]
+func (co *ScheduleController) Runs(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	limit, err := queryInt(c, "limit", 0)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	runs, err := co.svc.Runs(ctx, entity.ListJobRunsArgs{
+		ScheduleID: c.Param("schedule_id"),
+		Limit:      limit,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, JobRunsResponseV1{
+		Ok:   true,
+		Runs: runs,
+	})
+}
+
+// JobRunsResponseV1 ...
+type JobRunsResponseV1 struct {
+	Ok   bool             `json:"ok"`
+	Runs []*entity.JobRun `json:"runs"`
+}
+
+// SetupRoutes wires up the routes to the echo server.
+func (co *ScheduleController) SetupRoutes(e *echo.Echo) {
+	e.GET("/v1/schedules", co.List)
+	e.POST("/v1/schedules", co.Create)
+	e.GET("/v1/schedules/:schedule_id", co.Get)
+	e.PATCH("/v1/schedules/:schedule_id", co.Update)
+	e.DELETE("/v1/schedules/:schedule_id", co.Delete)
+	e.GET("/v1/schedules/:schedule_id/runs", co.Runs)
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/httpserver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedules(t *testing.T) {
+	ts := setupTests()
+
+	ctx := context.Background()
+
+	robots, err := ts.Service.Robot.List(ctx, "", "")
+	require.NoError(t, err)
+
+	areas, err := ts.Service.Area.List(ctx, entity.ListAreasArgs{})
+	require.NoError(t, err)
+	area := areas.Areas[0]
+
+	created := &ScheduleResponseV1{}
+	status, body := httpserver.Call(http.MethodPost, "/v1/schedules", ts.Server, &CreateScheduleRequestV1{
+		Name:     "Weekday mornings",
+		AreaID:   area.UID,
+		RobotIDs: []string{robots[0].UID, robots[1].UID},
+		Cron:     "30 8 * * MON-FRI",
+		TimeZone: "Europe/Stockholm",
+	}, created)
+	require.Equal(t, http.StatusOK, status, body)
+	require.NotNil(t, created.Schedule.NextRunAt, "should calculate the next run")
+	require.Equal(t, 2, len(created.Schedule.RobotIDs))
+
+	// Robot ids are replaced on update.
+	updated := &ScheduleResponseV1{}
+	status, body = httpserver.Call(http.MethodPatch, "/v1/schedules/"+created.Schedule.UID, ts.Server, &UpdateScheduleRequestV1{
+		RobotIDs: []string{robots[1].UID},
+	}, updated)
+	require.Equal(t, http.StatusOK, status, body)
+
+	got := &ScheduleResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/schedules/"+created.Schedule.UID, ts.Server, nil, got)
+	require.Equal(t, http.StatusOK, status, body)
+	require.Equal(t, []string{robots[1].UID}, got.Schedule.RobotIDs)
+	require.Equal(t, "Europe/Stockholm", got.Schedule.TimeZone)
+
+	runs := &JobRunsResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/schedules/"+created.Schedule.UID+"/runs", ts.Server, nil, runs)
+	require.Equal(t, http.StatusOK, status, body)
+	require.Equal(t, 0, len(runs.Runs))
+
+	for _, r := range []*CreateScheduleRequestV1{
+		{Name: "Bad cron", AreaID: area.UID, RobotIDs: []string{robots[0].UID}, Cron: "every day"},
+		{Name: "Never", AreaID: area.UID, RobotIDs: []string{robots[0].UID}, Cron: "0 0 30 2 *"},
+		{Name: "Bad zone", AreaID: area.UID, RobotIDs: []string{robots[0].UID}, Cron: "@daily", TimeZone: "Mars/Olympus"},
+		{Name: "Bad robot", AreaID: area.UID, RobotIDs: []string{area.UID}, Cron: "@daily"},
+	} {
+		status, body = httpserver.Call(http.MethodPost, "/v1/schedules", ts.Server, r, nil)
+		require.Equal(t, http.StatusBadRequest, status, r.Name+": "+body)
+	}
+
+	status, body = httpserver.Call(http.MethodDelete, "/v1/schedules/"+created.Schedule.UID, ts.Server, nil, nil)
+	require.Equal(t, http.StatusOK, status, body)
+
+	status, _ = httpserver.Call(http.MethodGet, "/v1/schedules/"+created.Schedule.UID, ts.Server, nil, nil)
+	require.Equal(t, http.StatusNotFound, status)
+}
@@ -0,0 +1,549 @@
+package controller
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/geo"
+	"github.com/anrid/roboviewer/robo/pkg/httpserver"
+	"github.com/anrid/roboviewer/robo/render"
+	"github.com/labstack/echo/v4"
+)
+
+// SessionController holds all the route handlers (endpoints)
+// related to cleaning sessions.
+type SessionController struct {
+	svc entity.SessionService
+}
+
+// NewSessionController creates a new session controller instance.
+func NewSessionController(svc entity.SessionService) *SessionController {
+	return &SessionController{svc}
+}
+
+// List searches cleaning sessions across all robots.
+// @Summary     Search cleaning sessions.
+// @Description Search cleaning sessions across all robots, latest started first. Use next_cursor from the response to fetch the next page.
+// @Accept      json
+// @Produce     json
+// @Param       robot_id query string false "Only sessions run by this robot"
+// @Param       area_id query string false "Only sessions cleaning this area"
+// @Param       from query string false "Only sessions started at or after this time (RFC 3339)"
+// @Param       to query string false "Only sessions started before this time (RFC 3339)"
+// @Param       active query boolean false "Only active (true) or inactive (false) sessions"
+// @Param       cursor query string false "Cursor returned by a previous call"
+// @Param       limit query integer false "Max number of sessions to return (default: 100, max: 1000)"
+// @Success     200 {object} controller.ListSessionsResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Router      /v1/sessions [get]
+func (co *SessionController) List(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	from, err := queryTime(c, "from")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	to, err := queryTime(c, "to")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	active, err := queryBool(c, "active")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	limit, err := queryInt(c, "limit", 0)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	res, err := co.svc.List(ctx, entity.ListSessionsArgs{
+		RobotID: c.QueryParam("robot_id"),
+		AreaID:  c.QueryParam("area_id"),
+		From:    from,
+		To:      to,
+		Active:  active,
+		Cursor:  c.QueryParam("cursor"),
+		Limit:   limit,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, ListSessionsResponseV1{
+		Ok:         true,
+		Sessions:   res.Sessions,
+		NextCursor: res.NextCursor,
+	})
+}
+
+// ListSessionsResponseV1 ...
+type ListSessionsResponseV1 struct {
+	Ok         bool                     `json:"ok"`
+	Sessions   []*entity.SessionSummary `json:"sessions"`
+	NextCursor string                   `json:"next_cursor,omitempty"`
+}
+
+// Get returns a cleaning session.
+// @Summary     Get a cleaning session.
+// @Description Get a cleaning session, the robot that ran it and its progress, including an ETA with a confidence between 0 and 1 for active sessions (see robot status). Does not include the grid or position history.
+// @Accept      json
+// @Produce     json
+// @Param       session_id path string true "Session ID"
+// @Success     200 {object} controller.SessionResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/sessions/{session_id} [get]
+func (co *SessionController) Get(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	s, err := co.svc.Get(ctx, c.Param("session_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, SessionResponseV1{
+		Ok:       true,
+		Session:  s.Session,
+		Robot:    s.Robot,
+		Progress: s.Progress,
+	})
+}
+
+// SessionResponseV1 ...
+type SessionResponseV1 struct {
+	Ok       bool                    `json:"ok"`
+	Session  *entity.CleaningSession `json:"session"`
+	Robot    *entity.Robot           `json:"robot,omitempty"`
+	Progress entity.SessionProgress  `json:"progress"`
+}
+
+// Positions returns a page of a session's position history.
+// @Summary     List a cleaning session's positions.
+// @Description List a cleaning session's position history in the order the positions were passed. Use next_cursor from the response to fetch the next page.
+// @Accept      json
+// @Produce     json
+// @Param       session_id path string true "Session ID"
+// @Param       from query string false "Only positions passed at or after this time (RFC 3339)"
+// @Param       to query string false "Only positions passed before this time (RFC 3339)"
+// @Param       cursor query string false "Cursor returned by a previous call"
+// @Param       limit query integer false "Max number of positions to return (default: 1000, max: 10000)"
+// @Success     200 {object} controller.ListPositionsResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/sessions/{session_id}/positions [get]
+func (co *SessionController) Positions(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	from, err := queryTime(c, "from")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	to, err := queryTime(c, "to")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	limit, err := queryInt(c, "limit", 0)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	res, err := co.svc.Positions(ctx, entity.ListPositionsArgs{
+		SessionID: c.Param("session_id"),
+		From:      from,
+		To:        to,
+		Cursor:    c.QueryParam("cursor"),
+		Limit:     limit,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, ListPositionsResponseV1{
+		Ok:         true,
+		Positions:  res.Positions,
+		NextCursor: res.NextCursor,
+	})
+}
+
+// ListPositionsResponseV1 ...
+type ListPositionsResponseV1 struct {
+	Ok         bool               `json:"ok"`
+	Positions  []*entity.Position `json:"positions"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// Grid returns a session's cleaning area and grid.
+// @Summary     Get a cleaning session's grid.
+// @Description Get a cleaning session's cleaning area including every grid square and its number of passes. Pass at to get the grid as it was at a certain point in time, rebuilt by replaying the session's position history.
+// @Accept      json
+// @Produce     json
+// @Param       session_id path string true "Session ID"
+// @Param       at query string false "Rebuild the grid as it was at this time (RFC 3339)"
+// @Success     200 {object} controller.SessionGridResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/sessions/{session_id}/grid [get]
+func (co *SessionController) Grid(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	at, err := queryTime(c, "at")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	var area *entity.CleaningArea
+	if at != nil {
+		area, err = co.svc.GridAt(ctx, c.Param("session_id"), *at)
+	} else {
+		area, err = co.svc.Grid(ctx, c.Param("session_id"))
+	}
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, SessionGridResponseV1{
+		Ok:         true,
+		Area:       area,
+		At:         at,
+		Completion: area.Completion(),
+	})
+}
+
+// SessionGridResponseV1 ...
+type SessionGridResponseV1 struct {
+	Ok         bool                 `json:"ok"`
+	Area       *entity.CleaningArea `json:"area"`
+	At         *time.Time           `json:"at,omitempty"`
+	Completion string               `json:"completion"`
+}
+
+// CheckGrid compares a session's persisted grid with its replayed
+// position history.
+// @Summary     Check a cleaning session's grid for drift.
+// @Description Replay a cleaning session's entire position history on a fresh grid and compare the result with the persisted grid. Lists every square whose number of passes or cleaned state differ.
+// @Accept      json
+// @Produce     json
+// @Param       session_id path string true "Session ID"
+// @Success     200 {object} controller.GridDriftResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/sessions/{session_id}/grid/check [get]
+func (co *SessionController) CheckGrid(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	d, err := co.svc.CheckGrid(ctx, c.Param("session_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, GridDriftResponseV1{
+		Ok:    true,
+		Check: d,
+	})
+}
+
+// GridDriftResponseV1 ...
+type GridDriftResponseV1 struct {
+	Ok    bool              `json:"ok"`
+	Check *entity.GridDrift `json:"check"`
+}
+
+// Stats returns coverage and efficiency analytics for a session.
+// @Summary     Get a cleaning session's stats.
+// @Description Get coverage and efficiency analytics for a cleaning session: distance travelled, area covered, coverage and cleaned percentage, overlap, idle time, average speed and time to reach 25, 50, 75, 90 and 100 percent completion. Stats are computed on demand and cached once the session has ended.
+// @Accept      json
+// @Produce     json
+// @Param       session_id path string true "Session ID"
+// @Success     200 {object} controller.SessionStatsResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/sessions/{session_id}/stats [get]
+func (co *SessionController) Stats(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	st, err := co.svc.Stats(ctx, c.Param("session_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, SessionStatsResponseV1{
+		Ok:    true,
+		Stats: st,
+	})
+}
+
+// SessionStatsResponseV1 ...
+type SessionStatsResponseV1 struct {
+	Ok    bool                 `json:"ok"`
+	Stats *entity.SessionStats `json:"stats"`
+}
+
+// Anomalies returns the anomalies detected during a session.
+// @Summary     Get a cleaning session's anomalies.
+// @Description Get the anomalies detected while the robot was reporting its position, oldest first: stuck (no net movement), oscillating (moving back and forth between a few squares), out_of_bounds (outside the area) and jump (moved faster than physically possible). Each anomaly is also published as a session.anomaly event.
+// @Accept      json
+// @Produce     json
+// @Param       session_id path string true "Session ID"
+// @Success     200 {object} controller.SessionAnomaliesResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/sessions/{session_id}/anomalies [get]
+func (co *SessionController) Anomalies(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	as, err := co.svc.Anomalies(ctx, c.Param("session_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, SessionAnomaliesResponseV1{
+		Ok:        true,
+		Anomalies: as,
+	})
+}
+
+// SessionAnomaliesResponseV1 ...
+type SessionAnomaliesResponseV1 struct {
+	Ok        bool              `json:"ok"`
+	Anomalies []*entity.Anomaly `json:"anomalies"`
+}
+
+// Plan returns a coverage plan for a session.
+// @Summary     Get a coverage plan for a cleaning session.
+// @Description Get the waypoints a robot should follow to clean a session's area, sweeping back and forth along rows of grid squares one robot diameter apart. By default the plan resumes from the robot's last reported position and visits only the squares not yet cleaned, each the number of passes it still needs. Set full to plan the entire area from the top-left corner instead. Consecutive waypoints always share an x or y coordinate. Robots can also request a plan over MQTT.
+// @Accept      json
+// @Produce     json
+// @Param       session_id path string true "Session ID"
+// @Param       full query boolean false "Plan the entire area instead of resuming (default: false)"
+// @Success     200 {object} controller.SessionPlanResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/sessions/{session_id}/plan [get]
+func (co *SessionController) Plan(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	full, err := queryBool(c, "full")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	p, err := co.svc.Plan(ctx, entity.PlanArgs{
+		SessionID: c.Param("session_id"),
+		Full:      full != nil && *full,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, SessionPlanResponseV1{
+		Ok:   true,
+		Plan: p,
+	})
+}
+
+// SessionPlanResponseV1 ...
+type SessionPlanResponseV1 struct {
+	Ok   bool         `json:"ok"`
+	Plan *entity.Plan `json:"plan"`
+}
+
+// GridPNG renders a session's grid as a PNG heatmap.
+// @Summary     Get a cleaning session's grid as a PNG heatmap.
+// @Description Draw a cleaning session's area to scale with each grid square colored by its number of passes relative to passes needed, cleaned squares in green and the robot's last position in red.
+// @Produce     png
+// @Param       session_id path string true "Session ID"
+// @Param       pixel_size query integer false "Millimeters per pixel (default: fits the longest side of the area within 800 pixels)"
+// @Success     200 {file} binary
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/sessions/{session_id}/grid.png [get]
+func (co *SessionController) GridPNG(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	pixelSize, err := queryInt(c, "pixel_size", 0)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	sess, err := co.svc.WithGrid(ctx, c.Param("session_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	// Render into a buffer first so that we can still respond with a
+	// proper error if rendering fails.
+	var buf bytes.Buffer
+	if err := render.HeatmapPNG(&buf, sess, render.Options{PixelSize: pixelSize}); err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return c.Blob(http.StatusOK, "image/png", buf.Bytes())
+}
+
+// PathSVG renders a session's path as an SVG.
+// @Summary     Get a cleaning session's path as an SVG.
+// @Description Draw a cleaning session's area to scale with its outline and grid, and the path taken by the robot colored by time (blue to pink) with start and end markers. The path is as wide as the robot.
+// @Produce     image/svg+xml
+// @Param       session_id path string true "Session ID"
+// @Param       from query string false "Only positions passed at or after this time (RFC 3339)"
+// @Param       to query string false "Only positions passed before this time (RFC 3339)"
+// @Param       pixel_size query integer false "Millimeters per pixel (default: fits the longest side of the area within 800 pixels)"
+// @Success     200 {file} binary
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/sessions/{session_id}/path.svg [get]
+func (co *SessionController) PathSVG(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	from, err := queryTime(c, "from")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	to, err := queryTime(c, "to")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	pixelSize, err := queryInt(c, "pixel_size", 0)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	sess, err := co.svc.WithPositions(ctx, entity.WithPositionsArgs{
+		SessionID: c.Param("session_id"),
+		From:      from,
+		To:        to,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	var buf bytes.Buffer
+	if err := render.PathSVG(&buf, sess, render.Options{PixelSize: pixelSize}); err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return c.Blob(http.StatusOK, "image/svg+xml", buf.Bytes())
+}
+
+// ReplayGIF renders an animated replay of a session as a GIF.
+// @Summary     Get an animated replay of a cleaning session.
+// @Description Replay how the robot moved and how the grid filled up during a cleaning session as an animated GIF heatmap (see grid.png). Each frame re-applies the session's position history on a fresh grid. Frames are at most 2000 pixels on any side and a replay at most 268435456 pixels across all frames.
+// @Produce     gif
+// @Param       session_id path string true "Session ID"
+// @Param       from query string false "Only replay positions passed at or after this time (RFC 3339)"
+// @Param       to query string false "Only replay positions passed before this time (RFC 3339)"
+// @Param       speed_up query integer false "Number of times faster than real time (default: 60)"
+// @Param       fps query integer false "Frames per second (default: 10, max: 50)"
+// @Param       pixel_size query integer false "Millimeters per pixel (default: fits the longest side of the area within 800 pixels)"
+// @Success     200 {file} binary
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/sessions/{session_id}/replay.gif [get]
+func (co *SessionController) ReplayGIF(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	from, err := queryTime(c, "from")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	to, err := queryTime(c, "to")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	speedUp, err := queryInt(c, "speed_up", 0)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	fps, err := queryInt(c, "fps", 0)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	pixelSize, err := queryInt(c, "pixel_size", 0)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	sess, err := co.svc.WithPositions(ctx, entity.WithPositionsArgs{
+		SessionID: c.Param("session_id"),
+		From:      from,
+		To:        to,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	var buf bytes.Buffer
+	err = render.ReplayGIF(&buf, sess, render.ReplayOptions{
+		Options: render.Options{PixelSize: pixelSize},
+		SpeedUp: float64(speedUp),
+		FPS:     fps,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return c.Blob(http.StatusOK, "image/gif", buf.Bytes())
+}
+
+// GeoJSON returns a session as GeoJSON.
+// @Summary     Get a cleaning session as GeoJSON.
+// @Description Get a cleaning session's area outline, grid squares (with passes and cleaned_at) and the path taken by the robot (with a timestamp per coordinate) as a GeoJSON FeatureCollection in WGS84, placed using the anchor of the session's area. Fails if the area has no anchor.
+// @Produce     json
+// @Param       session_id path string true "Session ID"
+// @Param       grid query boolean false "Include grid squares (default: true)"
+// @Param       from query string false "Only positions passed at or after this time (RFC 3339)"
+// @Param       to query string false "Only positions passed before this time (RFC 3339)"
+// @Success     200 {object} geo.FeatureCollection
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/sessions/{session_id}/geojson [get]
+func (co *SessionController) GeoJSON(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	grid, err := queryBool(c, "grid")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	from, err := queryTime(c, "from")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+	to, err := queryTime(c, "to")
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	sess, err := co.svc.WithPositions(ctx, entity.WithPositionsArgs{
+		SessionID: c.Param("session_id"),
+		From:      from,
+		To:        to,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	fc, err := geo.Session(sess, geo.SessionOptions{Grid: grid == nil || *grid})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return geoJSON(c, fc)
+}
+
+// SetupRoutes wires up the routes to the echo server.
+func (co *SessionController) SetupRoutes(e *echo.Echo) {
+	e.GET("/v1/sessions", co.List)
+	e.GET("/v1/sessions/:session_id", co.Get)
+	e.GET("/v1/sessions/:session_id/positions", co.Positions)
+	e.GET("/v1/sessions/:session_id/grid", co.Grid)
+	e.GET("/v1/sessions/:session_id/grid.png", co.GridPNG)
+	e.GET("/v1/sessions/:session_id/grid/check", co.CheckGrid)
+	e.GET("/v1/sessions/:session_id/stats", co.Stats)
+	e.GET("/v1/sessions/:session_id/anomalies", co.Anomalies)
+	e.GET("/v1/sessions/:session_id/plan", co.Plan)
+	e.GET("/v1/sessions/:session_id/path.svg", co.PathSVG)
+	e.GET("/v1/sessions/:session_id/replay.gif", co.ReplayGIF)
+	e.GET("/v1/sessions/:session_id/geojson", co.GeoJSON)
+}
@@ -0,0 +1,251 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"image/gif"
+	"image/png"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/httpserver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessions(t *testing.T) {
+	ts := setupTests()
+
+	ctx := context.Background()
+
+	robots, err := ts.Service.Robot.List(ctx, "", "")
+	require.NoError(t, err)
+	robot := robots[0]
+
+	areas, err := ts.Service.Area.List(ctx, entity.ListAreasArgs{})
+	require.NoError(t, err)
+	area := areas.Areas[0]
+
+	// Start a session and move around a bit.
+	startedAt := time.Now().Truncate(time.Second)
+	sess, err := ts.Service.Robot.StartSession(ctx, entity.StartSessionArgs{
+		RobotID:   robot.UID,
+		AreaID:    area.UID,
+		StartedAt: startedAt,
+	})
+	require.NoError(t, err)
+	for i := 1; i <= 5; i++ {
+		_, err = ts.Service.Robot.UpdateSession(ctx, entity.UpdateSessionArgs{
+			RobotID:    robot.UID,
+			RobotX:     robot.Size / 2,
+			RobotY:     i * 10,
+			ReportedAt: startedAt.Add(time.Duration(i) * time.Second),
+		})
+		require.NoError(t, err)
+	}
+
+	// Search sessions.
+	{
+		out := &ListSessionsResponseV1{}
+
+		status, _ := httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions?robot_id=%s&active=true&limit=1", robot.UID), ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, 1, len(out.Sessions), "should find 1 active session")
+		require.Equal(t, sess.UID, out.Sessions[0].Session.UID)
+		require.Equal(t, robot.UID, out.Sessions[0].Robot.UID)
+
+		status, _ = httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions?area_id=%s&limit=1", area.UID), ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, sess.UID, out.Sessions[0].Session.UID, "should find latest session for area")
+
+		status, body := httpserver.Call(http.MethodGet, "/v1/sessions?from=yesterday", ts.Server, nil, nil)
+		require.Equal(t, http.StatusBadRequest, status, "should fail on invalid from")
+		require.Contains(t, body, "validation_failed")
+	}
+
+	// Get session.
+	{
+		out := &SessionResponseV1{}
+
+		status, _ := httpserver.Call(http.MethodGet, "/v1/sessions/"+sess.UID, ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, sess.UID, out.Session.UID)
+		require.Equal(t, len(sess.Area[0].Grid), out.Progress.SquaresTotal)
+	}
+
+	// Page through positions.
+	{
+		out := &ListPositionsResponseV1{}
+
+		status, _ := httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/positions?limit=4", sess.UID), ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, 4, len(out.Positions), "should return first page")
+		require.NotEmpty(t, out.NextCursor)
+
+		next := &ListPositionsResponseV1{}
+		status, _ = httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/positions?limit=4&cursor=%s", sess.UID, out.NextCursor), ts.Server, nil, next)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, 2, len(next.Positions), "should return the start position and 5 updates in total")
+		require.Empty(t, next.NextCursor)
+	}
+
+	// Get grid.
+	{
+		out := &SessionGridResponseV1{}
+
+		status, _ := httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/grid", sess.UID), ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, len(sess.Area[0].Grid), len(out.Area.Grid))
+	}
+
+	// Get grid at a point in time.
+	{
+		out := &SessionGridResponseV1{}
+
+		at := startedAt.Add(-time.Second).Format(time.RFC3339)
+		status, _ := httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/grid?at=%s", sess.UID, url.QueryEscape(at)), ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, len(sess.Area[0].Grid), len(out.Area.Grid))
+		require.Equal(t, 0, out.Area.Grid[0].Passes, "should have a fresh grid before the session started")
+
+		at = startedAt.Add(time.Second).Format(time.RFC3339)
+		status, _ = httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/grid?at=%s", sess.UID, url.QueryEscape(at)), ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, 1, out.Area.Grid[0].Passes, "should have passed the first square once")
+
+		status, _ = httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/grid?at=yesterday", sess.UID), ts.Server, nil, nil)
+		require.Equal(t, http.StatusBadRequest, status, "should fail validation")
+	}
+
+	// Check grid for drift.
+	{
+		out := &GridDriftResponseV1{}
+
+		status, _ := httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/grid/check", sess.UID), ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, len(sess.Area[0].Grid), out.Check.SquaresTotal)
+		require.Equal(t, 6, out.Check.PositionsReplayed, "should replay the start position and 5 updates")
+		require.False(t, out.Check.Drift, "should replay to the persisted grid")
+	}
+
+	// Get a coverage plan.
+	{
+		out := &SessionPlanResponseV1{}
+
+		status, _ := httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/plan", sess.UID), ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, entity.PlanResume, out.Plan.Kind)
+		require.NotEmpty(t, out.Plan.Waypoints)
+		resume := out.Plan.Squares
+
+		status, _ = httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/plan?full=true", sess.UID), ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, entity.PlanFull, out.Plan.Kind)
+		require.Equal(t, len(sess.Area[0].Grid), out.Plan.Squares)
+		require.True(t, resume <= out.Plan.Squares, "should skip cleaned squares when resuming")
+
+		status, _ = httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/plan?full=maybe", sess.UID), ts.Server, nil, nil)
+		require.Equal(t, http.StatusBadRequest, status, "should fail validation")
+	}
+
+	// Get stats.
+	{
+		out := &SessionStatsResponseV1{}
+
+		status, _ := httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/stats", sess.UID), ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, 5, out.Stats.Positions, "should count the updates but not the start position")
+		require.Equal(t, 5, out.Stats.ActiveSec)
+		require.Empty(t, out.Stats.UID, "should not cache stats for an active session")
+
+		// End the session and fetch stats twice.
+		_, err := ts.Service.Robot.EndSession(ctx, entity.UpdateSessionArgs{
+			RobotID:    robot.UID,
+			RobotX:     robot.Size / 2,
+			RobotY:     50,
+			ReportedAt: startedAt.Add(6 * time.Second),
+		})
+		require.NoError(t, err)
+
+		status, _ = httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/stats", sess.UID), ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.NotEmpty(t, out.Stats.UID, "should cache stats once the session has ended")
+		uid := out.Stats.UID
+
+		status, _ = httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/stats", sess.UID), ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, uid, out.Stats.UID, "should return cached stats")
+		require.Equal(t, 6, out.Stats.Positions)
+	}
+
+	// Search sessions once the session has ended.
+	{
+		out := &ListSessionsResponseV1{}
+
+		status, _ := httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions?robot_id=%s&active=true", robot.UID), ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		for _, s := range out.Sessions {
+			require.NotEqual(t, sess.UID, s.Session.UID, "should not find ended session among active sessions")
+		}
+
+		out = &ListSessionsResponseV1{}
+		status, _ = httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions?robot_id=%s&active=false&limit=1", robot.UID), ts.Server, nil, out)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.Equal(t, 1, len(out.Sessions), "should find 1 inactive session")
+		require.Equal(t, sess.UID, out.Sessions[0].Session.UID)
+		require.False(t, out.Sessions[0].Session.IsActive)
+	}
+
+	// Get grid as a PNG heatmap.
+	{
+		status, body := httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/grid.png?pixel_size=100", sess.UID), ts.Server, nil, nil)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+
+		img, err := png.Decode(strings.NewReader(body))
+		require.NoError(t, err, "should return a valid PNG")
+		require.Equal(t, (sess.Area[0].SizeX+99)/100, img.Bounds().Dx(), "should draw area to scale")
+
+		status, _ = httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/grid.png?pixel_size=abc", sess.UID), ts.Server, nil, nil)
+		require.Equal(t, http.StatusBadRequest, status, "should fail validation")
+	}
+
+	// Get path as an SVG.
+	{
+		status, body := httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/path.svg", sess.UID), ts.Server, nil, nil)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+		require.True(t, strings.HasPrefix(body, "<svg "), "should return an SVG")
+		require.Contains(t, body, "<polyline", "should draw the path")
+
+		status, _ = httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/path.svg?from=yesterday", sess.UID), ts.Server, nil, nil)
+		require.Equal(t, http.StatusBadRequest, status, "should fail validation")
+	}
+
+	// Get an animated replay.
+	{
+		status, body := httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/replay.gif?speed_up=1&fps=1&pixel_size=100", sess.UID), ts.Server, nil, nil)
+		require.Equal(t, http.StatusOK, status, "should succeed")
+
+		g, err := gif.DecodeAll(strings.NewReader(body))
+		require.NoError(t, err, "should return a valid GIF")
+		require.True(t, len(g.Image) > 1, "should return multiple frames")
+
+		status, _ = httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/replay.gif?fps=1000", sess.UID), ts.Server, nil, nil)
+		require.Equal(t, http.StatusBadRequest, status, "should fail validation")
+
+		status, _ = httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/replay.gif?speed_up=1&fps=50&pixel_size=1", sess.UID), ts.Server, nil, nil)
+		require.Equal(t, http.StatusBadRequest, status, "should fail on too large a replay")
+	}
+
+	// Get as GeoJSON, test areas have no anchor.
+	{
+		status, body := httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/geojson", sess.UID), ts.Server, nil, nil)
+		require.Equal(t, http.StatusBadRequest, status, "should fail without an anchor")
+		require.Contains(t, body, "validation_failed")
+
+		status, _ = httpserver.Call(http.MethodGet, fmt.Sprintf("/v1/sessions/%s/geojson?grid=maybe", sess.UID), ts.Server, nil, nil)
+		require.Equal(t, http.StatusBadRequest, status, "should fail validation")
+	}
+}
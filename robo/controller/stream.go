@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/anrid/roboviewer/robo/pkg/httpserver"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"golang.org/x/net/websocket"
+)
+
+// SSEKeepAlive is how often we send a comment to idle Server-Sent
+// Events clients.
+const SSEKeepAlive = 15 * time.Second
+
+// StreamController holds all the route handlers (endpoints)
+// streaming live session events to clients.
+type StreamController struct {
+	bus     entity.EventBus
+	origins []string
+}
+
+// NewStreamController creates a new stream controller instance.
+// Browsers may open WebSockets from the API's own host and from the
+// given origins, e.g. https://app.example.com
+func NewStreamController(bus entity.EventBus, origins []string) *StreamController {
+	return &StreamController{bus, origins}
+}
+
+// checkOrigin allows WebSocket clients that send no Origin, e.g. CLIs
+// and server-side consumers, and browsers on the API's own host or on
+// one of the allowed origins.
+func (co *StreamController) checkOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return errors.Wrapf(cerr.ErrValidationFailed, "invalid origin '%s'", origin)
+	}
+	config.Origin = u
+	if u.Host == r.Host {
+		return nil
+	}
+	for _, o := range co.origins {
+		if strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return nil
+		}
+	}
+	return errors.Wrapf(cerr.ErrValidationFailed, "origin '%s' is not allowed", origin)
+}
+
+// WebSocket streams session events over a WebSocket.
+// @Summary     Stream live session events over a WebSocket.
+// @Description Streams session events (session.started, session.paused, session.resumed, session.position, session.square_passed, session.square_cleaned, session.completion_changed, session.ended) as JSON messages. Subscribe to robots and / or areas using query params, or at any time by sending a SubscribeMessageV1. No filter means all events. Clients without an Origin header can always connect; browsers only from the API's own host or an allowed origin (see -allowed-origins).
+// @Produce     json
+// @Param       robot_id query string false "Comma-separated robot IDs to subscribe to"
+// @Param       area_id query string false "Comma-separated area IDs to subscribe to"
+// @Success     101 {object} entity.Event
+// @Router      /v1/ws [get]
+func (co *StreamController) WebSocket(c echo.Context) error {
+	filter := eventFilter(c)
+
+	s := websocket.Server{Handshake: co.checkOrigin}
+	s.Handler = func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		sub := co.bus.Subscribe(filter)
+		defer sub.Close()
+
+		// Let clients change their subscription.
+		go func() {
+			for {
+				m := &SubscribeMessageV1{}
+				if err := websocket.JSON.Receive(ws, m); err != nil {
+					// Client went away.
+					sub.Close()
+					return
+				}
+				sub.SetFilter(entity.EventFilter{
+					RobotIDs: m.RobotIDs,
+					AreaIDs:  m.AreaIDs,
+				})
+			}
+		}()
+
+		for e := range sub.Events() {
+			if err := websocket.JSON.Send(ws, e); err != nil {
+				log.Printf("could not send event to websocket client: %s", err.Error())
+				return
+			}
+		}
+	}
+	s.ServeHTTP(c.Response(), c.Request())
+
+	return nil
+}
+
+// SSE streams session events as Server-Sent Events.
+// @Summary     Stream live session events as Server-Sent Events.
+// @Description Streams the same session events as the WebSocket endpoint using text/event-stream. Event IDs are formatted as <epoch>-<id>, where the epoch changes when the server restarts. Reconnecting clients sending Last-Event-ID get any missed events replayed, as far back as the server remembers. If events may have been missed, e.g. after a restart, a resync event is sent first, followed by all remembered events.
+// @Produce     text/event-stream
+// @Param       robot_id query string false "Comma-separated robot IDs to subscribe to"
+// @Param       area_id query string false "Comma-separated area IDs to subscribe to"
+// @Param       Last-Event-ID header string false "Resume after this event ID"
+// @Success     200 {object} entity.Event
+// @Failure     400 {object} cerr.ErrorResponse
+// @Router      /v1/stream [get]
+func (co *StreamController) SSE(c echo.Context) error {
+	ctx := c.Request().Context()
+	filter := eventFilter(c)
+
+	var sub entity.EventSubscription
+	resumed := true
+	if s := c.Request().Header.Get("Last-Event-ID"); s != "" {
+		epoch, lastEventID, err := parseEventID(s)
+		if err != nil {
+			return httpserver.Fail(c, errors.Wrapf(cerr.ErrValidationFailed, "invalid Last-Event-ID '%s'", s))
+		}
+		sub, resumed = co.bus.SubscribeAfter(filter, epoch, lastEventID)
+	} else {
+		sub = co.bus.Subscribe(filter)
+	}
+	defer sub.Close()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no") // Disable proxy buffering in nginx.
+	res.WriteHeader(http.StatusOK)
+	if !resumed {
+		// Tell the client to reload whatever state it built from events
+		// it may have missed, e.g. as the server restarted.
+		if _, err := fmt.Fprint(res, "event: resync\ndata: {}\n\n"); err != nil {
+			return nil
+		}
+	}
+	res.Flush()
+
+	keepAlive := time.NewTicker(SSEKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Client went away.
+			return nil
+		case e, ok := <-sub.Events():
+			if !ok {
+				// Dropped by the bus for not keeping up.
+				log.Printf("dropped slow server-sent events client %s", c.RealIP())
+				return nil
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(res, "id: %d-%d\nevent: %s\ndata: %s\n\n", e.Epoch, e.ID, e.Type, data); err != nil {
+				return nil
+			}
+			res.Flush()
+		case <-keepAlive.C:
+			// Comment lines keep idle connections from being closed by
+			// proxies.
+			if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
+
+// parseEventID parses a Server-Sent Events id formatted as
+// <epoch>-<id>. A plain id is taken to be from an unknown epoch.
+func parseEventID(s string) (epoch int64, id uint64, err error) {
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		epoch, err = strconv.ParseInt(s[:i], 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		s = s[i+1:]
+	}
+	id, err = strconv.ParseUint(s, 10, 64)
+	return epoch, id, err
+}
+
+// SubscribeMessageV1 is sent by WebSocket clients to replace their
+// subscription.
+type SubscribeMessageV1 struct {
+	RobotIDs []string `json:"robot_ids"`
+	AreaIDs  []string `json:"area_ids"`
+}
+
+// eventFilter creates an event filter from the robot_id and area_id
+// query params. Both params can be repeated or comma-separated.
+func eventFilter(c echo.Context) entity.EventFilter {
+	return entity.EventFilter{
+		RobotIDs: queryList(c, "robot_id"),
+		AreaIDs:  queryList(c, "area_id"),
+	}
+}
+
+// SetupRoutes wires up the routes to the echo server.
+func (co *StreamController) SetupRoutes(e *echo.Echo) {
+	e.GET("/v1/ws", co.WebSocket)
+	e.GET("/v1/stream", co.SSE)
+}
@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/eventbus"
+	"github.com/anrid/roboviewer/robo/pkg/httpserver"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func TestWebSocket(t *testing.T) {
+	bus := eventbus.New()
+	serv := httpserver.NewServer()
+	NewStreamController(bus, nil).SetupRoutes(serv.Echo)
+
+	hs := httptest.NewServer(serv.Echo)
+	defer hs.Close()
+
+	url := "ws" + strings.TrimPrefix(hs.URL, "http") + "/v1/ws?robot_id=0x1,0x2"
+	ws, err := websocket.Dial(url, "", hs.URL)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	// Wait for the server to subscribe.
+	waitForSubscribers(t, bus)
+
+	bus.Publish(&entity.Event{Type: entity.EventPosition, RobotID: "0x3"})
+	bus.Publish(&entity.Event{Type: entity.EventPosition, RobotID: "0x2", X: 10, Y: 20})
+
+	e := &entity.Event{}
+	require.NoError(t, websocket.JSON.Receive(ws, e))
+	require.Equal(t, "0x2", e.RobotID, "should only receive subscribed robot events")
+	require.Equal(t, 10, e.X)
+
+	// Change subscription.
+	require.NoError(t, websocket.JSON.Send(ws, &SubscribeMessageV1{AreaIDs: []string{"0x9"}}))
+	time.Sleep(50 * time.Millisecond)
+
+	bus.Publish(&entity.Event{Type: entity.EventPosition, RobotID: "0x2"})
+	bus.Publish(&entity.Event{Type: entity.EventSessionEnded, RobotID: "0x3", AreaID: "0x9"})
+
+	require.NoError(t, websocket.JSON.Receive(ws, e))
+	require.Equal(t, entity.EventSessionEnded, e.Type, "should receive events matching new subscription")
+	require.Equal(t, "0x9", e.AreaID)
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	bus := eventbus.New()
+	serv := httpserver.NewServer()
+	NewStreamController(bus, []string{"https://app.example.com"}).SetupRoutes(serv.Echo)
+
+	hs := httptest.NewServer(serv.Echo)
+	defer hs.Close()
+
+	addr := "ws" + strings.TrimPrefix(hs.URL, "http") + "/v1/ws"
+
+	// CLIs and server-side consumers send no Origin.
+	config, err := websocket.NewConfig(addr, hs.URL)
+	require.NoError(t, err)
+	config.Origin = &url.URL{}
+	ws, err := websocket.DialConfig(config)
+	require.NoError(t, err, "should accept clients without an Origin")
+	waitForSubscribers(t, bus)
+	bus.Publish(&entity.Event{Type: entity.EventPosition, RobotID: "0x1"})
+	e := &entity.Event{}
+	require.NoError(t, websocket.JSON.Receive(ws, e))
+	require.Equal(t, "0x1", e.RobotID)
+	ws.Close()
+
+	ws, err = websocket.Dial(addr, "", "https://app.example.com")
+	require.NoError(t, err, "should accept allowed origins")
+	ws.Close()
+
+	_, err = websocket.Dial(addr, "", "https://evil.example.com")
+	require.Error(t, err, "should reject other origins")
+}
+
+// waitForSubscribers waits until someone has subscribed to the bus.
+func waitForSubscribers(t *testing.T, bus *eventbus.Bus) {
+	for i := 0; i < 100; i++ {
+		if bus.Subscribers() > 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("no subscribers")
+}
+
+func TestSSE(t *testing.T) {
+	bus := eventbus.New()
+	serv := httpserver.NewServer()
+	NewStreamController(bus, nil).SetupRoutes(serv.Echo)
+
+	hs := httptest.NewServer(serv.Echo)
+	defer hs.Close()
+
+	// Events published before the client connects.
+	first := &entity.Event{Type: entity.EventSessionStarted, RobotID: "0x1"}
+	bus.Publish(first)
+	bus.Publish(&entity.Event{Type: entity.EventPosition, RobotID: "0x2"})
+	bus.Publish(&entity.Event{Type: entity.EventPosition, RobotID: "0x1", X: 10})
+
+	req, err := http.NewRequest(http.MethodGet, hs.URL+"/v1/stream?robot_id=0x1", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", fmt.Sprintf("%d-1", first.Epoch))
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	r := bufio.NewReader(resp.Body)
+
+	// Should resume after event 1, skipping robot 0x2.
+	id, typ, e := readSSE(t, r)
+	require.Equal(t, fmt.Sprintf("%d-3", first.Epoch), id)
+	require.Equal(t, "session.position", typ)
+	require.Equal(t, 10, e.X)
+
+	// Followed by live events.
+	bus.Publish(&entity.Event{Type: entity.EventSessionEnded, RobotID: "0x1"})
+	id, typ, _ = readSSE(t, r)
+	require.Equal(t, fmt.Sprintf("%d-4", first.Epoch), id)
+	require.Equal(t, "session.ended", typ)
+
+	// Last-Event-ID from before the server restarted.
+	req.Header.Set("Last-Event-ID", fmt.Sprintf("%d-3", first.Epoch-1))
+	resp3, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp3.Body.Close()
+	require.Equal(t, http.StatusOK, resp3.StatusCode)
+
+	r = bufio.NewReader(resp3.Body)
+
+	// Should resync, replaying all remembered events.
+	_, typ, _ = readSSE(t, r)
+	require.Equal(t, "resync", typ)
+	for _, want := range []int{1, 3, 4} {
+		id, _, _ = readSSE(t, r)
+		require.Equal(t, fmt.Sprintf("%d-%d", first.Epoch, want), id)
+	}
+
+	// Invalid Last-Event-ID.
+	req.Header.Set("Last-Event-ID", "abc")
+	resp2, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp2.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp2.StatusCode)
+}
+
+// readSSE reads a single server-sent event.
+func readSSE(t *testing.T, r *bufio.Reader) (id, typ string, e *entity.Event) {
+	e = &entity.Event{}
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			return
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			typ = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), e))
+		}
+	}
+}
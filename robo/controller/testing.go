@@ -17,6 +17,15 @@ func setupTests() *testserver.TS {
 		ts = testserver.Get()
 		NewRobotController(ts.Service.Robot).SetupRoutes(ts.Server.Echo)
 		NewAreaController(ts.Service.Area).SetupRoutes(ts.Server.Echo)
+		NewSessionController(ts.Service.Session).SetupRoutes(ts.Server.Echo)
+		NewReportController(ts.Service.Report).SetupRoutes(ts.Server.Echo)
+		NewExportController(ts.Service.Export).SetupRoutes(ts.Server.Echo)
+		NewAlertController(ts.Service.Alert).SetupRoutes(ts.Server.Echo)
+		NewWebhookController(ts.Service.Webhook).SetupRoutes(ts.Server.Echo)
+		NewScheduleController(ts.Service.Schedule).SetupRoutes(ts.Server.Echo)
+		NewPartitionController(ts.Service.Partition).SetupRoutes(ts.Server.Echo)
+		NewDockController(ts.Service.Dock).SetupRoutes(ts.Server.Echo)
+		NewStreamController(ts.EventBus, nil).SetupRoutes(ts.Server.Echo)
 	})
 	return ts
 }
@@ -0,0 +1,256 @@
+package controller
+
+import (
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/httpserver"
+	"github.com/labstack/echo/v4"
+)
+
+// WebhookController holds all the route handlers (endpoints)
+// related to webhooks.
+type WebhookController struct {
+	svc entity.WebhookService
+}
+
+// NewWebhookController creates a new webhook controller instance.
+func NewWebhookController(svc entity.WebhookService) *WebhookController {
+	return &WebhookController{svc}
+}
+
+// List returns all webhooks.
+// @Summary     List webhooks.
+// @Description List all webhooks, oldest first. Secrets are not included.
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} controller.WebhooksResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Router      /v1/webhooks [get]
+func (co *WebhookController) List(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	hooks, err := co.svc.List(ctx)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	for i, w := range hooks {
+		hooks[i] = webhookWithoutSecret(w)
+	}
+
+	return httpserver.Ok(c, WebhooksResponseV1{
+		Ok:       true,
+		Webhooks: hooks,
+	})
+}
+
+// WebhooksResponseV1 ...
+type WebhooksResponseV1 struct {
+	Ok       bool              `json:"ok"`
+	Webhooks []*entity.Webhook `json:"webhooks"`
+}
+
+// Get returns a webhook.
+// @Summary     Get a webhook.
+// @Description Get a webhook. The secret is not included.
+// @Accept      json
+// @Produce     json
+// @Param       webhook_id path string true "Webhook ID"
+// @Success     200 {object} controller.WebhookResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/webhooks/{webhook_id} [get]
+func (co *WebhookController) Get(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	w, err := co.svc.Get(ctx, c.Param("webhook_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, WebhookResponseV1{
+		Ok:      true,
+		Webhook: webhookWithoutSecret(w),
+	})
+}
+
+// WebhookResponseV1 ...
+type WebhookResponseV1 struct {
+	Ok      bool            `json:"ok"`
+	Webhook *entity.Webhook `json:"webhook"`
+}
+
+// Create creates a new webhook.
+// @Summary     Create a new webhook.
+// @Description Subscribe a URL to session lifecycle events: session.started, session.paused (robot docked), session.resumed (robot left its dock), session.ended, area.cleaned (completion reaches 100%) and robot.offline (an active session's robot hasn't reported in 10 minutes, unless docked). Events are POSTed as JSON, signed with an HMAC-SHA256 of the body in the X-Roboviewer-Signature header (sha256=<hex>), and retried with exponential backoff. Events are recorded together with the session change that caused them, so none are lost if the server restarts before delivery. The secret is generated unless given and only returned on create.
+// @Accept      json
+// @Produce     json
+// @Param       webhook body controller.CreateWebhookRequestV1 true "Webhook to create"
+// @Success     200 {object} controller.WebhookResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Router      /v1/webhooks [post]
+func (co *WebhookController) Create(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	r := &CreateWebhookRequestV1{}
+	err := httpserver.Bind(c, r)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	w, err := co.svc.Create(ctx, entity.CreateWebhookArgs{
+		Name:       r.Name,
+		URL:        r.URL,
+		Secret:     r.Secret,
+		EventTypes: eventTypes(r.EventTypes),
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, WebhookResponseV1{
+		Ok:      true,
+		Webhook: w,
+	})
+}
+
+// CreateWebhookRequestV1 ...
+type CreateWebhookRequestV1 struct {
+	Name       string   `json:"name" validate:"required"`
+	URL        string   `json:"webhook_url" validate:"required"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types" validate:"required,min=1"`
+}
+
+// Update updates a webhook.
+// @Summary     Update a webhook.
+// @Description Update a webhook. Omitted fields are left unchanged, event types are replaced.
+// @Accept      json
+// @Produce     json
+// @Param       webhook_id path string true "Webhook ID"
+// @Param       webhook body controller.UpdateWebhookRequestV1 true "Fields to update"
+// @Success     200 {object} controller.WebhookResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/webhooks/{webhook_id} [patch]
+func (co *WebhookController) Update(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	r := &UpdateWebhookRequestV1{}
+	err := httpserver.Bind(c, r)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	w, err := co.svc.Update(ctx, entity.UpdateWebhookArgs{
+		WebhookID:  c.Param("webhook_id"),
+		Name:       r.Name,
+		URL:        r.URL,
+		Secret:     r.Secret,
+		EventTypes: eventTypes(r.EventTypes),
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, WebhookResponseV1{
+		Ok:      true,
+		Webhook: webhookWithoutSecret(w),
+	})
+}
+
+// UpdateWebhookRequestV1 ...
+type UpdateWebhookRequestV1 struct {
+	Name       *string  `json:"name" validate:"omitempty,min=1"`
+	URL        *string  `json:"webhook_url" validate:"omitempty,min=1"`
+	Secret     *string  `json:"secret" validate:"omitempty,min=1"`
+	EventTypes []string `json:"event_types" validate:"omitempty,min=1"`
+}
+
+// Delete deletes a webhook.
+// @Summary     Delete a webhook.
+// @Description Delete a webhook. Events still pending delivery to it are dropped.
+// @Accept      json
+// @Produce     json
+// @Param       webhook_id path string true "Webhook ID"
+// @Success     200 {object} controller.OkResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/webhooks/{webhook_id} [delete]
+func (co *WebhookController) Delete(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	err := co.svc.Delete(ctx, c.Param("webhook_id"))
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, OkResponseV1{Ok: true})
+}
+
+// Messages returns events sent to a webhook and their delivery log.
+// @Summary     List webhook deliveries.
+// @Description List events sent to a webhook, latest first, with their delivery status (pending, delivered or failed) and a log of every delivery attempt.
+// @Accept      json
+// @Produce     json
+// @Param       webhook_id path string true "Webhook ID"
+// @Param       limit query integer false "Max number of events to return (default: 100, max: 1000)"
+// @Success     200 {object} controller.WebhookMessagesResponseV1
+// @Failure     400 {object} cerr.ErrorResponse
+// @Failure     404 {object} cerr.ErrorResponse
+// @Router      /v1/webhooks/{webhook_id}/deliveries [get]
+func (co *WebhookController) Messages(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	limit, err := queryInt(c, "limit", 0)
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	msgs, err := co.svc.Messages(ctx, entity.ListWebhookMessagesArgs{
+		WebhookID: c.Param("webhook_id"),
+		Limit:     limit,
+	})
+	if err != nil {
+		return httpserver.Fail(c, err)
+	}
+
+	return httpserver.Ok(c, WebhookMessagesResponseV1{
+		Ok:       true,
+		Messages: msgs,
+	})
+}
+
+// WebhookMessagesResponseV1 ...
+type WebhookMessagesResponseV1 struct {
+	Ok       bool                     `json:"ok"`
+	Messages []*entity.WebhookMessage `json:"messages"`
+}
+
+// eventTypes converts event type names, keeping nil as nil.
+func eventTypes(names []string) []entity.EventType {
+	if names == nil {
+		return nil
+	}
+	types := make([]entity.EventType, 0, len(names))
+	for _, n := range names {
+		types = append(types, entity.EventType(n))
+	}
+	return types
+}
+
+// webhookWithoutSecret returns a copy of a webhook without its secret.
+func webhookWithoutSecret(w *entity.Webhook) *entity.Webhook {
+	cp := *w
+	cp.Secret = ""
+	return &cp
+}
+
+// SetupRoutes wires up the routes to the echo server.
+func (co *WebhookController) SetupRoutes(e *echo.Echo) {
+	e.GET("/v1/webhooks", co.List)
+	e.POST("/v1/webhooks", co.Create)
+	e.GET("/v1/webhooks/:webhook_id", co.Get)
+	e.PATCH("/v1/webhooks/:webhook_id", co.Update)
+	e.DELETE("/v1/webhooks/:webhook_id", co.Delete)
+	e.GET("/v1/webhooks/:webhook_id/deliveries", co.Messages)
+}
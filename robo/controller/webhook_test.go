@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/httpserver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks(t *testing.T) {
+	ts := setupTests()
+
+	// Create a webhook with a generated secret.
+	created := &WebhookResponseV1{}
+	status, body := httpserver.Call(http.MethodPost, "/v1/webhooks", ts.Server, &CreateWebhookRequestV1{
+		Name:       "Ticketing",
+		URL:        "https://example.com/hooks/robo",
+		EventTypes: []string{"session.started", "session.ended"},
+	}, created)
+	require.Equal(t, http.StatusOK, status, body)
+	require.NotEmpty(t, created.Webhook.Secret, "should generate a secret")
+
+	// The secret is never returned again.
+	got := &WebhookResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/webhooks/"+created.Webhook.UID, ts.Server, nil, got)
+	require.Equal(t, http.StatusOK, status, body)
+	require.Empty(t, got.Webhook.Secret)
+	require.Equal(t, []entity.EventType{entity.EventSessionStarted, entity.EventSessionEnded}, got.Webhook.EventTypes)
+
+	// Event types are replaced on update.
+	status, body = httpserver.Call(http.MethodPatch, "/v1/webhooks/"+created.Webhook.UID, ts.Server, &UpdateWebhookRequestV1{
+		EventTypes: []string{"area.cleaned"},
+	}, nil)
+	require.Equal(t, http.StatusOK, status, body)
+
+	got = &WebhookResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/webhooks/"+created.Webhook.UID, ts.Server, nil, got)
+	require.Equal(t, http.StatusOK, status, body)
+	require.Equal(t, []entity.EventType{entity.EventAreaCleaned}, got.Webhook.EventTypes)
+	require.Equal(t, "Ticketing", got.Webhook.Name)
+
+	msgs := &WebhookMessagesResponseV1{}
+	status, body = httpserver.Call(http.MethodGet, "/v1/webhooks/"+created.Webhook.UID+"/deliveries", ts.Server, nil, msgs)
+	require.Equal(t, http.StatusOK, status, body)
+
+	// Unknown event types and relative URLs are rejected.
+	status, _ = httpserver.Call(http.MethodPost, "/v1/webhooks", ts.Server, &CreateWebhookRequestV1{
+		Name:       "Bad",
+		URL:        "https://example.com/hooks/robo",
+		EventTypes: []string{"session.position"},
+	}, nil)
+	require.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = httpserver.Call(http.MethodPost, "/v1/webhooks", ts.Server, &CreateWebhookRequestV1{
+		Name:       "Bad",
+		URL:        "/hooks/robo",
+		EventTypes: []string{"robot.offline"},
+	}, nil)
+	require.Equal(t, http.StatusBadRequest, status)
+
+	status, body = httpserver.Call(http.MethodDelete, "/v1/webhooks/"+created.Webhook.UID, ts.Server, nil, nil)
+	require.Equal(t, http.StatusOK, status, body)
+
+	status, _ = httpserver.Call(http.MethodGet, "/v1/webhooks/"+created.Webhook.UID, ts.Server, nil, nil)
+	require.Equal(t, http.StatusNotFound, status)
+}
@@ -0,0 +1,235 @@
+package dg
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/dgraph-io/dgo/v2"
+)
+
+// AlertRepository ...
+type AlertRepository struct {
+	Repository
+}
+
+// NewAlertRepository creates a new repository.
+func NewAlertRepository(c *dgo.Dgraph) *AlertRepository {
+	return &AlertRepository{Repository: Repository{c}}
+}
+
+// alertRuleFields are the fields we fetch for an alert rule.
+const alertRuleFields = `
+			uid
+			name
+			rule_type
+			threshold
+			webhook_url
+			secret
+			created_at
+`
+
+// alertFields are the fields we fetch for an alert.
+const alertFields = `
+			uid
+			alert_key
+			rule_id
+			rule_type
+			session_id
+			robot_id
+			area_id
+			message
+			fired_at
+			delivery_status
+			attempts
+			next_attempt_at
+			created_at
+`
+
+// ListRules returns all alert rules, oldest first.
+func (r *AlertRepository) ListRules(ctx context.Context) ([]*entity.AlertRule, error) {
+	qb := NewQB(`
+	{
+		rules(func: type(AlertRule), orderasc: created_at) @filter(NOT has(deleted_at)) {
+			` + alertRuleFields + `
+		}
+	}
+	`)
+	query := qb.Query()
+
+	resp, err := r.c.NewTxn().Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Rules []*entity.AlertRule `json:"rules"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Rules == nil {
+		res.Rules = []*entity.AlertRule{}
+	}
+	return res.Rules, nil
+}
+
+// GetRule returns an alert rule by id. Returns nil if the rule does
+// not exist or has been deleted.
+func (r *AlertRepository) GetRule(ctx context.Context, ruleID string) (*entity.AlertRule, error) {
+	qb := NewQB(`
+	query q($ruleID: string) {
+		rules(func: uid($ruleID)) @filter(type(AlertRule) AND NOT has(deleted_at)) {
+			` + alertRuleFields + `
+		}
+	}
+	`)
+	query := qb.Query()
+
+	vars := map[string]string{
+		"$ruleID": ruleID,
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Rules []*entity.AlertRule `json:"rules"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Rules) == 0 {
+		return nil, nil
+	}
+	return res.Rules[0], nil
+}
+
+// List returns alerts including their delivery log, latest fired
+// first.
+func (r *AlertRepository) List(ctx context.Context, a entity.ListAlertsArgs) ([]*entity.Alert, error) {
+	qb := NewQB(`
+	query q($ruleID: string, $sessionID: string, $first: int) {
+		alerts(func: type(Alert), first: $first, orderdesc: fired_at) <FILTERS> {
+			` + alertFields + `
+			delivery (orderasc: attempt) {
+				uid
+				attempt
+				status_code
+				error
+				attempted_at
+				duration_ms
+			}
+		}
+	}
+	`)
+
+	vars := map[string]string{
+		"$ruleID":    a.RuleID,
+		"$sessionID": a.SessionID,
+		"$first":     strconv.Itoa(a.Limit),
+	}
+	if a.RuleID != "" {
+		qb.Filter(`eq(rule_id, $ruleID)`)
+	}
+	if a.SessionID != "" {
+		qb.Filter(`eq(session_id, $sessionID)`)
+	}
+	query := qb.Query()
+	// println(query)
+
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Alerts []*entity.Alert `json:"alerts"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Alerts == nil {
+		res.Alerts = []*entity.Alert{}
+	}
+	return res.Alerts, nil
+}
+
+// Fired returns the subset of the given alert keys that have already
+// fired.
+func (r *AlertRepository) Fired(ctx context.Context, keys []string) (map[string]bool, error) {
+	fired := make(map[string]bool)
+	if len(keys) == 0 {
+		return fired, nil
+	}
+
+	// Keys are made up of uids so they are safe to inline as a JSON
+	// list of strings.
+	b, err := json.Marshal(keys)
+	if err != nil {
+		return nil, err
+	}
+	qb := NewQB(`
+	{
+		alerts(func: eq(alert_key, ` + string(b) + `)) @filter(type(Alert)) {
+			alert_key
+		}
+	}
+	`)
+	query := qb.Query()
+
+	resp, err := r.c.NewTxn().Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Alerts []*entity.Alert `json:"alerts"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range res.Alerts {
+		fired[a.Key] = true
+	}
+	return fired, nil
+}
+
+// Due returns alerts pending delivery that are due to be attempted,
+// oldest first.
+func (r *AlertRepository) Due(ctx context.Context, now time.Time, limit int) ([]*entity.Alert, error) {
+	qb := NewQB(`
+	query q($pending: string, $now: string, $first: int) {
+		alerts(func: eq(delivery_status, $pending), first: $first, orderasc: next_attempt_at) @filter(type(Alert) AND le(next_attempt_at, $now)) {
+			` + alertFields + `
+		}
+	}
+	`)
+	query := qb.Query()
+
+	vars := map[string]string{
+		"$pending": string(entity.DeliveryPending),
+		"$now":     now.Format(time.RFC3339Nano),
+		"$first":   strconv.Itoa(limit),
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Alerts []*entity.Alert `json:"alerts"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res.Alerts, nil
+}
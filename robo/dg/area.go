@@ -2,6 +2,8 @@ package dg
 
 import (
 	"context"
+	"strconv"
+	"time"
 
 	"github.com/anrid/roboviewer/robo/entity"
 	"github.com/dgraph-io/dgo/v2"
@@ -17,24 +19,55 @@ func NewAreaRepository(c *dgo.Dgraph) *AreaRepository {
 	return &AreaRepository{Repository: Repository{c}}
 }
 
-// List returns a list of areas.
-func (r *AreaRepository) List(ctx context.Context) (*entity.ListAreasResult, error) {
+// List returns a page of areas, newest first.
+func (r *AreaRepository) List(ctx context.Context, a entity.ListAreasArgs) (*entity.ListAreasResult, error) {
 	qb := NewQB(`
-	query {
-		areas(func: type(Area), first: 100, orderdesc: created_at) {
+	query q($name: string, $cursor: string, $skip: int, $first: int) {
+		areas(func: type(Area), first: $first, offset: $skip, orderdesc: created_at) <FILTERS> {
 			uid
 			name
 			size_x
 			size_y
 			passes_needed
+			anchor_lat
+			anchor_lon
+			anchor_rotation
+			parent_area_id
+			offset_x
+			offset_y
+			created_at
 		}
 	}
 	`)
 
+	vars := map[string]string{
+		"$name":  a.Name,
+		"$skip":  "0",
+		"$first": strconv.Itoa(a.Limit + 1), // Fetch one extra area to find out if there's a next page.
+	}
+
+	qb.Filter(`NOT has(deleted_at)`)
+	if !a.Regions {
+		qb.Filter(`NOT has(parent_area_id)`)
+	}
+	if a.Name != "" {
+		qb.Filter(`alloftext(name, $name)`)
+	}
+	var cur *cursor
+	if a.Cursor != "" {
+		var err error
+		cur, err = decodeCursor(a.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		qb.Filter(`le(created_at, $cursor)`)
+		vars["$cursor"] = cur.at()
+		vars["$skip"] = strconv.Itoa(cur.Skip)
+	}
 	query := qb.Query()
 	// println(query)
 
-	resp, err := r.c.NewTxn().Query(ctx, query)
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
 	if err != nil {
 		return nil, err
 	}
@@ -46,5 +79,60 @@ func (r *AreaRepository) List(ctx context.Context) (*entity.ListAreasResult, err
 		return nil, err
 	}
 
+	if len(res.Areas) > a.Limit {
+		res.Areas = res.Areas[:a.Limit]
+		var times []*time.Time
+		for _, area := range res.Areas {
+			times = append(times, area.CreatedAt)
+		}
+		res.NextCursor = nextCursor(cur, times)
+	}
+
 	return res, nil
 }
+
+// Get returns an area by id. Returns nil if the area does not
+// exist or has been deleted.
+func (r *AreaRepository) Get(ctx context.Context, areaID string) (*entity.Area, error) {
+	qb := NewQB(`
+	query q($areaID: string) {
+		areas(func: uid($areaID)) @filter(type(Area) AND NOT has(deleted_at)) {
+			uid
+			name
+			size_x
+			size_y
+			passes_needed
+			anchor_lat
+			anchor_lon
+			anchor_rotation
+			parent_area_id
+			offset_x
+			offset_y
+			created_at
+			dgraph.type
+		}
+	}
+	`)
+	query := qb.Query()
+	// println(query)
+
+	vars := map[string]string{
+		"$areaID": areaID,
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+	// println(string(resp.Json))
+
+	res := &entity.ListAreasResult{}
+	err = json.Unmarshal(resp.Json, res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Areas) == 0 {
+		return nil, nil
+	}
+	return res.Areas[0], nil
+}
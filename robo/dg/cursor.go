@@ -0,0 +1,76 @@
+package dg
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/pkg/errors"
+)
+
+// cursor points at a position in a list of results ordered by a
+// timestamp, e.g. created_at. We page by timestamp since Dgraph's
+// `after` only works on uid order. Timestamps aren't unique (robots
+// report in whole seconds) so Skip holds the number of results at
+// exactly At that have already been returned.
+type cursor struct {
+	At   time.Time
+	Skip int
+}
+
+// encode returns the cursor as an opaque string.
+func (c *cursor) encode() string {
+	s := c.At.UTC().Format(time.RFC3339Nano) + "|" + strconv.Itoa(c.Skip)
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// at returns the cursor timestamp formatted for use in a Dgraph
+// query.
+func (c *cursor) at() string {
+	return c.At.UTC().Format(time.RFC3339Nano)
+}
+
+// decodeCursor decodes a cursor created by cursor.encode.
+func decodeCursor(s string) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "invalid cursor '%s'", s)
+	}
+	parts := strings.SplitN(string(b), "|", 2)
+	if len(parts) != 2 {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "invalid cursor '%s'", s)
+	}
+	at, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "invalid cursor '%s'", s)
+	}
+	skip, err := strconv.Atoi(parts[1])
+	if err != nil || skip < 0 {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "invalid cursor '%s'", s)
+	}
+	return &cursor{At: at, Skip: skip}, nil
+}
+
+// nextCursor returns the cursor pointing past a page of results,
+// given the timestamps of the results in order and the cursor used
+// to fetch the page (nil for the first page).
+func nextCursor(prev *cursor, times []*time.Time) string {
+	if len(times) == 0 || times[len(times)-1] == nil {
+		return ""
+	}
+	last := *times[len(times)-1]
+
+	var skip int
+	for i := len(times) - 1; i >= 0 && times[i] != nil && times[i].Equal(last); i-- {
+		skip++
+	}
+	if prev != nil && skip == len(times) && prev.At.Equal(last) {
+		// The entire page shares the previous cursor's timestamp.
+		skip += prev.Skip
+	}
+
+	c := &cursor{At: last, Skip: skip}
+	return c.encode()
+}
@@ -0,0 +1,44 @@
+package dg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	ts := time.Date(2020, 2, 16, 14, 31, 26, 659743000, time.UTC)
+
+	c := &cursor{At: ts, Skip: 2}
+	s := c.encode()
+	require.NotContains(t, s, "2020", "should be opaque")
+
+	dec, err := decodeCursor(s)
+	require.NoError(t, err)
+	require.Equal(t, "2020-02-16T14:31:26.659743Z", dec.at(), "should round trip with nanosecond precision")
+	require.Equal(t, 2, dec.Skip)
+
+	_, err = decodeCursor("not-a-cursor!")
+	require.Equal(t, cerr.ErrValidationFailed, errors.Cause(err), "should fail validation")
+}
+
+func TestNextCursor(t *testing.T) {
+	t1 := time.Date(2020, 2, 16, 10, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Second)
+
+	require.Equal(t, "", nextCursor(nil, nil), "should return an empty cursor for an empty page")
+
+	// Last two results share a timestamp.
+	c, err := decodeCursor(nextCursor(nil, []*time.Time{&t1, &t2, &t2}))
+	require.NoError(t, err)
+	require.True(t, t2.Equal(c.At))
+	require.Equal(t, 2, c.Skip, "should skip results already returned at the same timestamp")
+
+	// Entire next page shares the same timestamp as well.
+	c, err = decodeCursor(nextCursor(c, []*time.Time{&t2, &t2}))
+	require.NoError(t, err)
+	require.Equal(t, 4, c.Skip, "should add up skips across pages")
+}
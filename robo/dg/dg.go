@@ -58,7 +58,7 @@ func Run(dgraphURL string, dropAll bool) {
 		println("robot:", robot.UID, robot.Name)
 	}
 
-	res3, err := ar.List(ctx)
+	res3, err := ar.List(ctx, entity.ListAreasArgs{Limit: 100})
 	if err != nil {
 		log.Panicf("could not list areas: %s", err.Error())
 	}
@@ -0,0 +1,95 @@
+package dg
+
+import (
+	"context"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/dgraph-io/dgo/v2"
+)
+
+// DockRepository ...
+type DockRepository struct {
+	Repository
+}
+
+// NewDockRepository creates a new repository.
+func NewDockRepository(c *dgo.Dgraph) *DockRepository {
+	return &DockRepository{Repository: Repository{c}}
+}
+
+// dockFields are the fields we fetch for a dock.
+const dockFields = `
+			uid
+			name
+			area_id
+			x
+			y
+			created_at
+`
+
+// List returns the docks in an area, oldest first. Deleted docks are
+// excluded.
+func (r *DockRepository) List(ctx context.Context, areaID string) ([]*entity.Dock, error) {
+	qb := NewQB(`
+	query q($areaID: string) {
+		docks(func: eq(area_id, $areaID), orderasc: created_at) @filter(type(Dock) AND NOT has(deleted_at)) {
+			` + dockFields + `
+		}
+	}
+	`)
+	query := qb.Query()
+
+	vars := map[string]string{
+		"$areaID": areaID,
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Docks []*entity.Dock `json:"docks"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Docks == nil {
+		res.Docks = []*entity.Dock{}
+	}
+	return res.Docks, nil
+}
+
+// Get returns a dock by id. Returns nil if the dock does not exist or
+// has been deleted.
+func (r *DockRepository) Get(ctx context.Context, dockID string) (*entity.Dock, error) {
+	qb := NewQB(`
+	query q($dockID: string) {
+		docks(func: uid($dockID)) @filter(type(Dock) AND NOT has(deleted_at)) {
+			` + dockFields + `
+		}
+	}
+	`)
+	query := qb.Query()
+
+	vars := map[string]string{
+		"$dockID": dockID,
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Docks []*entity.Dock `json:"docks"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Docks) == 0 {
+		return nil, nil
+	}
+	return res.Docks[0], nil
+}
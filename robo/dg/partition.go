@@ -0,0 +1,116 @@
+package dg
+
+import (
+	"context"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/dgraph-io/dgo/v2"
+)
+
+// PartitionRepository ...
+type PartitionRepository struct {
+	Repository
+}
+
+// NewPartitionRepository creates a new repository.
+func NewPartitionRepository(c *dgo.Dgraph) *PartitionRepository {
+	return &PartitionRepository{Repository: Repository{c}}
+}
+
+// partitionFields are the fields we fetch for a partition, including
+// its regions.
+const partitionFields = `
+			uid
+			area_id
+			dispatched_at
+			created_at
+			region (orderasc: order) {
+				uid
+				robot_id
+				robot_size
+				offset_x
+				offset_y
+				size_x
+				size_y
+				squares
+				order
+				created_at
+				region_area {
+					uid
+					name
+					size_x
+					size_y
+					passes_needed
+					parent_area_id
+					offset_x
+					offset_y
+					created_at
+				}
+			}
+`
+
+// List returns the partitions of an area, latest first.
+func (r *PartitionRepository) List(ctx context.Context, areaID string) ([]*entity.Partition, error) {
+	qb := NewQB(`
+	query q($areaID: string) {
+		partitions(func: eq(area_id, $areaID), orderdesc: created_at) @filter(type(Partition)) {
+			` + partitionFields + `
+		}
+	}
+	`)
+	query := qb.Query()
+
+	vars := map[string]string{
+		"$areaID": areaID,
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Partitions []*entity.Partition `json:"partitions"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Partitions == nil {
+		res.Partitions = []*entity.Partition{}
+	}
+	return res.Partitions, nil
+}
+
+// Get returns a partition by id. Returns nil if the partition does not
+// exist.
+func (r *PartitionRepository) Get(ctx context.Context, partitionID string) (*entity.Partition, error) {
+	qb := NewQB(`
+	query q($partitionID: string) {
+		partitions(func: uid($partitionID)) @filter(type(Partition)) {
+			` + partitionFields + `
+		}
+	}
+	`)
+	query := qb.Query()
+
+	vars := map[string]string{
+		"$partitionID": partitionID,
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Partitions []*entity.Partition `json:"partitions"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Partitions) == 0 {
+		return nil, nil
+	}
+	return res.Partitions[0], nil
+}
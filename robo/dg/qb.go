@@ -6,6 +6,7 @@ import "strings"
 type QB struct {
 	query   string
 	filters []string
+	vars    []string
 }
 
 // NewQB creates a new query builder.
@@ -18,7 +19,13 @@ func (q *QB) Filter(f string) {
 	q.filters = append(q.filters, f)
 }
 
-// Query returns the query with all filters applied.
+// Var adds a var block to the query builder, e.g. to collect
+// uids to filter on.
+func (q *QB) Var(v string) {
+	q.vars = append(q.vars, v)
+}
+
+// Query returns the query with all filters and var blocks applied.
 func (q *QB) Query() string {
 	var f1 string
 	var f2 string
@@ -29,5 +36,6 @@ func (q *QB) Query() string {
 	}
 	query := strings.ReplaceAll(q.query, "<FILTERS>", f1)
 	query = strings.ReplaceAll(query, "<AND_FILTERS>", f2)
+	query = strings.ReplaceAll(query, "<VARS>", strings.Join(q.vars, "\n"))
 	return query
 }
@@ -0,0 +1,237 @@
+package dg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/dgraph-io/dgo/v2"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+)
+
+// ReportRepository is a repository for reports.
+// All numbers are aggregated by Dgraph, grids are never loaded.
+type ReportRepository struct {
+	Repository
+}
+
+// NewReportRepository creates a new repository.
+func NewReportRepository(c *dgo.Dgraph) *ReportRepository {
+	return &ReportRepository{Repository: Repository{c}}
+}
+
+// reportSessionVars collects value variables for each ended session:
+// its duration, time to complete and completion percentage based on
+// the number of cleaned grid squares. Variable names are suffixed
+// with <N> to allow several blocks in a single query.
+const reportSessionVars = `
+			d<N> as duration_sec
+			tc<N> as time_to_complete_sec
+			area {
+				t<N> as count(grid)
+				c<N> as count(grid @filter(has(cleaned_at)))
+			}
+			st<N> as sum(val(t<N>))
+			sc<N> as sum(val(c<N>))
+			pc<N> as math(sc<N> * 100.0 / max(st<N>, 1.0))
+`
+
+// reportRow is a row as returned by Dgraph.
+type reportRow struct {
+	UID                   string  `json:"uid"`
+	Name                  string  `json:"name"`
+	SessionsStarted       int     `json:"sessions_started"`
+	SessionsEnded         int     `json:"sessions_ended"`
+	SessionsCompleted     int     `json:"sessions_completed"`
+	CleaningSec           float64 `json:"cleaning_sec"`
+	MeanCompletionPct     float64 `json:"mean_completion_pct"`
+	MeanTimeToCompleteSec float64 `json:"mean_time_to_complete_sec"`
+}
+
+func (r *reportRow) toEntity(group string) *entity.SessionReportRow {
+	return &entity.SessionReportRow{
+		Group:                 group,
+		Name:                  r.Name,
+		SessionsStarted:       r.SessionsStarted,
+		SessionsEnded:         r.SessionsEnded,
+		SessionsCompleted:     r.SessionsCompleted,
+		SessionsAbandoned:     r.SessionsEnded - r.SessionsCompleted,
+		CleaningSec:           int(r.CleaningSec),
+		MeanCompletionPct:     r.MeanCompletionPct,
+		MeanTimeToCompleteSec: r.MeanTimeToCompleteSec,
+	}
+}
+
+// Sessions aggregates sessions started within a time range, grouped
+// by robot, area or day. Groups without any sessions are left out.
+func (r *ReportRepository) Sessions(ctx context.Context, a entity.SessionReportArgs) ([]*entity.SessionReportRow, error) {
+	switch a.GroupBy {
+	case entity.GroupByRobot:
+		return r.sessionsByNode(ctx, a, "Robot", "session")
+	case entity.GroupByArea:
+		return r.sessionsByNode(ctx, a, "Area", "~source_area")
+	case entity.GroupByDay:
+		return r.sessionsByDay(ctx, a)
+	}
+	return nil, errors.Wrapf(cerr.ErrValidationFailed, "cannot group sessions by '%s'", a.GroupBy)
+}
+
+// sessionsByNode aggregates sessions for each node of the given type,
+// reached through the given edge. Session values are aggregated at the
+// parent (robot or area) level.
+func (r *ReportRepository) sessionsByNode(ctx context.Context, a entity.SessionReportArgs, nodeType, edge string) ([]*entity.SessionReportRow, error) {
+	const started = `ge(started_at, $from) AND lt(started_at, $to)`
+	const ended = started + ` AND has(ended_at)`
+
+	query := `
+	query q($from: string, $to: string) {
+		var(func: type(` + nodeType + `)) {
+			` + edge + ` @filter(` + ended + `) {
+				` + strings.ReplaceAll(reportSessionVars, "<N>", "") + `
+			}
+			cs as sum(val(d))
+			mc as avg(val(pc))
+			mt as avg(val(tc))
+		}
+
+		rows(func: type(` + nodeType + `)) @filter(has(` + edge + `)) {
+			uid
+			name
+			sessions_started: count(` + edge + ` @filter(` + started + `))
+			sessions_ended: count(` + edge + ` @filter(` + ended + `))
+			sessions_completed: count(` + edge + ` @filter(` + ended + ` AND has(completed_at)))
+			cleaning_sec: val(cs)
+			mean_completion_pct: val(mc)
+			mean_time_to_complete_sec: val(mt)
+		}
+	}
+	`
+	// println(query)
+
+	vars := map[string]string{
+		"$from": a.From.Format(time.RFC3339Nano),
+		"$to":   a.To.Format(time.RFC3339Nano),
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+	// println(string(resp.Json))
+
+	res := struct {
+		Rows []*reportRow `json:"rows"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*entity.SessionReportRow
+	for _, row := range res.Rows {
+		if row.SessionsStarted == 0 {
+			continue
+		}
+		rows = append(rows, row.toEntity(row.UID))
+	}
+	return rows, nil
+}
+
+// sessionsByDay aggregates sessions for each day in the time range.
+// Days start at midnight in the time zone of the range's start time.
+// Each day gets its own set of blocks with values aggregated at the
+// root level.
+func (r *ReportRepository) sessionsByDay(ctx context.Context, a entity.SessionReportArgs) ([]*entity.SessionReportRow, error) {
+	days := Days(a.From, a.To)
+
+	var blocks []string
+	for i, d := range days {
+		n := fmt.Sprint(i)
+		started := fmt.Sprintf(`type(CleaningSession) AND lt(started_at, "%s")`, d.To.UTC().Format(time.RFC3339Nano))
+		ended := started + ` AND has(ended_at)`
+		root := fmt.Sprintf(`ge(started_at, "%s")`, d.From.UTC().Format(time.RFC3339Nano))
+
+		blocks = append(blocks, `
+		var(func: `+root+`) @filter(`+ended+`) {
+			`+strings.ReplaceAll(reportSessionVars, "<N>", n)+`
+		}
+		day`+n+`_sum() {
+			cleaning_sec: sum(val(d`+n+`))
+			mean_completion_pct: avg(val(pc`+n+`))
+			mean_time_to_complete_sec: avg(val(tc`+n+`))
+		}
+		day`+n+`_started(func: `+root+`) @filter(`+started+`) {
+			sessions_started: count(uid)
+		}
+		day`+n+`_ended(func: `+root+`) @filter(`+ended+`) {
+			sessions_ended: count(uid)
+		}
+		day`+n+`_completed(func: `+root+`) @filter(`+ended+` AND has(completed_at)) {
+			sessions_completed: count(uid)
+		}`)
+	}
+	query := "{\n" + strings.Join(blocks, "\n") + "\n}"
+	// println(query)
+
+	resp, err := r.c.NewTxn().Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	// println(string(resp.Json))
+
+	// Every aggregate is returned as a separate object in a list, e.g.
+	// "day0_sum": [{"cleaning_sec": 10}, {"mean_completion_pct": 50}],
+	// so we merge them all into a single row per day.
+	res := make(map[string][]jsoniter.RawMessage)
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*entity.SessionReportRow
+	for i, d := range days {
+		row := &reportRow{}
+		for _, suffix := range []string{"sum", "started", "ended", "completed"} {
+			for _, obj := range res[fmt.Sprintf("day%d_%s", i, suffix)] {
+				if err := json.Unmarshal(obj, row); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if row.SessionsStarted == 0 {
+			continue
+		}
+		rows = append(rows, row.toEntity(d.From.Format("2006-01-02")))
+	}
+	return rows, nil
+}
+
+// Day is a time range within a single day.
+type Day struct {
+	From time.Time
+	To   time.Time
+}
+
+// Days splits a time range into days, starting at midnight in the time
+// zone of from. The first and last days are clipped to the time range.
+func Days(from, to time.Time) []Day {
+	var days []Day
+	y, m, d := from.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, from.Location())
+	for start.Before(to) {
+		end := start.AddDate(0, 0, 1)
+		day := Day{From: start, To: end}
+		if day.From.Before(from) {
+			day.From = from
+		}
+		if day.To.After(to) {
+			day.To = to
+		}
+		days = append(days, day)
+		start = end
+	}
+	return days
+}
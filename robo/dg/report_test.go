@@ -0,0 +1,23 @@
+package dg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDays(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	from := time.Date(2020, 2, 16, 10, 0, 0, 0, jst)
+	to := time.Date(2020, 2, 18, 12, 0, 0, 0, jst)
+
+	days := Days(from, to)
+	require.Equal(t, 3, len(days))
+	require.Equal(t, from, days[0].From, "should clip the first day")
+	require.Equal(t, time.Date(2020, 2, 17, 0, 0, 0, 0, jst), days[0].To, "should split at midnight in the time zone of from")
+	require.Equal(t, time.Date(2020, 2, 17, 0, 0, 0, 0, jst), days[1].From)
+	require.Equal(t, to, days[2].To, "should clip the last day")
+
+	require.Empty(t, Days(to, from), "should return no days for an empty range")
+}
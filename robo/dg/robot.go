@@ -3,12 +3,27 @@ package dg
 import (
 	"context"
 	"strconv"
+	"time"
 
 	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
 	"github.com/dgraph-io/dgo/v2"
 	"github.com/pkg/errors"
 )
 
+// telemetryFields are the fields we fetch for telemetry.
+const telemetryFields = `
+				uid
+				robot_id
+				session_id
+				battery
+				robot_state
+				error_codes
+				brush_wear
+				filter_wear
+				reported_at
+`
+
 // RobotRepository ...
 type RobotRepository struct {
 	Repository
@@ -28,7 +43,10 @@ func (r *RobotRepository) List(ctx context.Context, a entity.ListRobotsArgs) (*e
 			uid
 			name
 			size
-			session @filter(eq(is_active, true)) (first: 1) (orderdesc: created_at) {
+			last_telemetry {
+				` + telemetryFields + `
+			}
+			session @filter(` + activeSession + `) (first: 1) (orderdesc: created_at) {
 				uid
 				name
 				is_active
@@ -37,6 +55,15 @@ func (r *RobotRepository) List(ctx context.Context, a entity.ListRobotsArgs) (*e
 				last_x
 				last_y
 				last_reported_at
+				completed_at
+				time_to_complete_sec
+				paused_at
+				resumed_at
+				paused_sec
+				dock_id
+				source_area {
+					uid
+				}
 				area {
 					uid
 					name
@@ -58,6 +85,7 @@ func (r *RobotRepository) List(ctx context.Context, a entity.ListRobotsArgs) (*e
 	}
 	`)
 
+	qb.Filter(`NOT has(deleted_at)`)
 	if a.RobotID != "" {
 		qb.Filter(`uid($robotID)`)
 	}
@@ -82,16 +110,179 @@ func (r *RobotRepository) List(ctx context.Context, a entity.ListRobotsArgs) (*e
 	if err != nil {
 		return nil, err
 	}
+	for _, robot := range res.Robots {
+		setActive(robot.Session)
+	}
 
 	return res, nil
 }
 
+// Get returns a robot by id together with its currently active
+// cleaning session, without loading the session's grid.
+// Returns nil if the robot does not exist or has been deleted.
+func (r *RobotRepository) Get(ctx context.Context, robotID string) (*entity.Robot, error) {
+	qb := NewQB(`
+	query q($robotID: string) {
+		robots(func: uid($robotID)) @filter(type(Robot) AND NOT has(deleted_at)) {
+			uid
+			name
+			size
+			is_cleaning
+			created_at
+			dgraph.type
+			last_telemetry {
+				` + telemetryFields + `
+			}
+			session @filter(` + activeSession + `) (first: 1) (orderdesc: created_at) {
+				uid
+				name
+				is_active
+				started_at
+				ended_at
+				last_x
+				last_y
+				last_reported_at
+				paused_at
+				resumed_at
+				dock_id
+			}
+		}
+	}
+	`)
+	query := qb.Query()
+	// println(query)
+
+	vars := map[string]string{
+		"$robotID": robotID,
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+	// println(string(resp.Json))
+
+	res := &entity.ListRobotsResult{}
+	err = json.Unmarshal(resp.Json, res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Robots) == 0 {
+		return nil, nil
+	}
+	setActive(res.Robots[0].Session)
+	return res.Robots[0], nil
+}
+
+// statusSessionFields are the session fields we fetch in Status().
+const statusSessionFields = `
+				uid
+				is_active
+				started_at
+				ended_at
+				last_x
+				last_y
+				last_reported_at
+				paused_at
+				resumed_at
+				paused_sec
+				dock_id
+				area {
+					squares_total: count(grid)
+					squares_cleaned: count(grid @filter(has(cleaned_at)))
+				}
+`
+
+// Status returns the last reported position and progress of the
+// robot's active cleaning session, or of its latest session if none
+// is active, e.g. when an older session has been resumed after a
+// newer one ended. Grid squares are counted by Dgraph rather than
+// loaded. Returns nil if the robot does not exist or has been
+// deleted.
+func (r *RobotRepository) Status(ctx context.Context, robotID string) (*entity.RobotStatus, error) {
+	qb := NewQB(`
+	query q($robotID: string) {
+		robots(func: uid($robotID)) @filter(type(Robot) AND NOT has(deleted_at)) {
+			uid
+			active: session @filter(` + activeSession + `) (first: 1) (orderdesc: created_at) {
+				` + statusSessionFields + `
+			}
+			session (first: 1) (orderdesc: created_at) {
+				` + statusSessionFields + `
+			}
+		}
+	}
+	`)
+	query := qb.Query()
+	// println(query)
+
+	vars := map[string]string{
+		"$robotID": robotID,
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+	// println(string(resp.Json))
+
+	type statusSession struct {
+		entity.CleaningSession
+		Area []struct {
+			SquaresTotal   int `json:"squares_total"`
+			SquaresCleaned int `json:"squares_cleaned"`
+		} `json:"area"`
+	}
+	res := struct {
+		Robots []struct {
+			UID     string           `json:"uid"`
+			Active  []*statusSession `json:"active"`
+			Session []*statusSession `json:"session"`
+		} `json:"robots"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Robots) == 0 {
+		return nil, nil
+	}
+	robot := res.Robots[0]
+	if len(robot.Active) > 0 {
+		robot.Session = robot.Active
+	}
+
+	st := &entity.RobotStatus{RobotID: robot.UID}
+	if len(robot.Session) > 0 {
+		sess := robot.Session[0]
+		sess.IsActive = sess.EndedAt == nil
+		st.SessionID = sess.UID
+		st.IsActive = sess.IsActive
+		st.LastX = sess.LastX
+		st.LastY = sess.LastY
+		st.StartedAt = sess.StartedAt
+		st.LastReportedAt = sess.LastReportedAt
+		st.PausedSec = sess.PausedSec
+		if sess.IsPaused() {
+			st.IsPaused = true
+			st.DockID = sess.DockID
+		}
+		if len(sess.Area) > 0 {
+			st.SquaresTotal = sess.Area[0].SquaresTotal
+			st.SquaresCleaned = sess.Area[0].SquaresCleaned
+		}
+	}
+	st.Completion = entity.Completion(st.SquaresCleaned, st.SquaresTotal)
+
+	return st, nil
+}
+
 // ByName returns one or more robots matching the
 // name exactly.
 func (r *RobotRepository) ByName(ctx context.Context, name string) (*entity.ListRobotsResult, error) {
 	qb := NewQB(`
 	query q($name: string) {
-		robots(func: type(Robot), orderdesc: created_at) @filter(eq(name, $name)) {
+		robots(func: type(Robot), orderdesc: created_at) @filter(eq(name, $name) AND NOT has(deleted_at)) {
 			uid
 			name
 			is_cleaning
@@ -125,22 +316,28 @@ func (r *RobotRepository) ByName(ctx context.Context, name string) (*entity.List
 func (r *RobotRepository) GetRobotAndArea(ctx context.Context, robotID, areaID string) (*entity.GetRobotAndAreaResult, error) {
 	qb := NewQB(`
 	query q($robotID: string, $areaID: string) {
-		robots(func: type(Robot)) @filter(uid($robotID)) {
+		robots(func: type(Robot)) @filter(uid($robotID) AND NOT has(deleted_at)) {
 			uid
 			name
 			size
 			created_at
 			dgraph.type
-			session @filter(eq(is_active, true)) (first: 1) (orderdesc: created_at) {
+			session @filter(` + activeSession + `) (first: 1) (orderdesc: created_at) {
 				uid
 				name
 				is_active
 				started_at
 				ended_at
+				paused_at
+				resumed_at
+				paused_sec
 				dgraph.type
+				source_area {
+					uid
+				}
 			}
 		}
-		areas(func: type(Area)) @filter(uid($areaID)) {
+		areas(func: type(Area)) @filter(uid($areaID) AND NOT has(deleted_at)) {
 			uid
 			name
 			size_x
@@ -168,19 +365,57 @@ func (r *RobotRepository) GetRobotAndArea(ctx context.Context, robotID, areaID s
 	if err != nil {
 		return nil, err
 	}
+	for _, rb := range res.Robots {
+		setActive(rb.Session)
+	}
 
 	return res, nil
 }
 
-// History returns all historial data for the given robot.
-func (r *RobotRepository) History(ctx context.Context, robotID string, max int) (*entity.Robot, error) {
+// historyPositionFields are the fields we fetch when including
+// position history in History().
+const historyPositionFields = `
+				position_history (orderasc: passed_at) {
+					x
+					y
+					passed_at
+					not_cleaning
+				}
+`
+
+// historyGridFields are the fields we fetch when including grids in
+// History().
+const historyGridFields = `
+					grid (orderasc: order) {
+						uid
+						x
+						y
+						size
+						passes
+						cleaned_at
+						order
+					}
+`
+
+// History returns a page of historial data for the given robot.
+// Position history and grids are only loaded if asked for.
+func (r *RobotRepository) History(ctx context.Context, a entity.HistoryArgs) (*entity.HistoryResult, error) {
+	var positions, grid string
+	if a.IncludePositions {
+		positions = historyPositionFields
+	}
+	if a.IncludeGrid {
+		grid = historyGridFields
+	}
+
 	qb := NewQB(`
-	query q($robotID: string, $max: int) {
-		robots(func: uid($robotID)) {
+	query q($robotID: string, $from: string, $to: string, $cursor: string, $skip: int, $max: int) {
+		robots(func: uid($robotID)) @filter(type(Robot)) {
 			uid
 			name
 			size
-			session (first: $max) (orderdesc: created_at) {
+			deleted_at
+			session (first: $max) (offset: $skip) (orderdesc: started_at) <FILTERS> {
 				uid
 				name
 				is_active
@@ -189,39 +424,52 @@ func (r *RobotRepository) History(ctx context.Context, robotID string, max int)
 				last_x
 				last_y
 				last_reported_at
+				paused_sec
 				duration_sec
-				position_history (orderasc: passed_at) {
-					x
-					y
-					passed_at	
-				}
+				completed_at
+				time_to_complete_sec
+				` + positions + `
 				area {
 					uid
 					name
 					size_x
 					size_y
 					passes_needed
-					grid (orderasc: order) {
-						uid
-						x
-						y
-						size
-						passes
-						cleaned_at
-						order
-					}
+					` + grid + `
 				}
 			}
 		}
 	}
 	`)
-	query := qb.Query()
-	// println(query)
 
 	vars := map[string]string{
-		"$robotID": robotID,
-		"$max":     strconv.Itoa(max),
+		"$robotID": a.RobotID,
+		"$skip":    "0",
+		"$max":     strconv.Itoa(a.Max + 1), // Fetch one extra session to find out if there's a next page.
+	}
+
+	if a.From != nil {
+		qb.Filter(`ge(started_at, $from)`)
+		vars["$from"] = a.From.Format(time.RFC3339Nano)
 	}
+	if a.To != nil {
+		qb.Filter(`lt(started_at, $to)`)
+		vars["$to"] = a.To.Format(time.RFC3339Nano)
+	}
+	var cur *cursor
+	if a.Cursor != "" {
+		var err error
+		cur, err = decodeCursor(a.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		qb.Filter(`le(started_at, $cursor)`)
+		vars["$cursor"] = cur.at()
+		vars["$skip"] = strconv.Itoa(cur.Skip)
+	}
+	query := qb.Query()
+	// println(query)
+
 	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
 	if err != nil {
 		return nil, err
@@ -235,7 +483,68 @@ func (r *RobotRepository) History(ctx context.Context, robotID string, max int)
 	}
 
 	if len(res.Robots) != 1 {
-		return nil, errors.Errorf("could not find robot %s", robotID)
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find robot %s", a.RobotID)
 	}
-	return res.Robots[0], nil
+	robot := res.Robots[0]
+	setActive(robot.Session)
+
+	hr := &entity.HistoryResult{Robot: robot}
+	if len(robot.Session) > a.Max {
+		robot.Session = robot.Session[:a.Max]
+		var times []*time.Time
+		for _, s := range robot.Session {
+			times = append(times, s.StartedAt)
+		}
+		hr.NextCursor = nextCursor(cur, times)
+	}
+	return hr, nil
+}
+
+// Telemetry returns telemetry reported by a robot, latest first.
+func (r *RobotRepository) Telemetry(ctx context.Context, a entity.ListTelemetryArgs) ([]*entity.Telemetry, error) {
+	qb := NewQB(`
+	query q($robotID: string, $sessionID: string, $from: string, $to: string, $first: int) {
+		telemetry(func: eq(robot_id, $robotID), first: $first, orderdesc: reported_at) <FILTERS> {
+			` + telemetryFields + `
+		}
+	}
+	`)
+
+	vars := map[string]string{
+		"$robotID":   a.RobotID,
+		"$sessionID": a.SessionID,
+		"$first":     strconv.Itoa(a.Limit),
+	}
+
+	qb.Filter(`type(Telemetry)`)
+	if a.SessionID != "" {
+		qb.Filter(`eq(session_id, $sessionID)`)
+	}
+	if a.From != nil {
+		qb.Filter(`ge(reported_at, $from)`)
+		vars["$from"] = a.From.Format(time.RFC3339Nano)
+	}
+	if a.To != nil {
+		qb.Filter(`lt(reported_at, $to)`)
+		vars["$to"] = a.To.Format(time.RFC3339Nano)
+	}
+	query := qb.Query()
+	// println(query)
+
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Telemetry []*entity.Telemetry `json:"telemetry"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Telemetry == nil {
+		res.Telemetry = []*entity.Telemetry{}
+	}
+	return res.Telemetry, nil
 }
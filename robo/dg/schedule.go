@@ -0,0 +1,239 @@
+package dg
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/dgraph-io/dgo/v2"
+	"github.com/dgraph-io/dgo/v2/protos/api"
+)
+
+// ScheduleRepository ...
+type ScheduleRepository struct {
+	Repository
+}
+
+// NewScheduleRepository creates a new repository.
+func NewScheduleRepository(c *dgo.Dgraph) *ScheduleRepository {
+	return &ScheduleRepository{Repository: Repository{c}}
+}
+
+// scheduleFields are the fields we fetch for a schedule.
+const scheduleFields = `
+			uid
+			name
+			area_id
+			robot_ids
+			cron
+			time_zone
+			passes_needed
+			next_run_at
+			created_at
+`
+
+// jobRunFields are the fields we fetch for a job run.
+const jobRunFields = `
+			uid
+			schedule_id
+			robot_id
+			area_id
+			scheduled_at
+			dispatched_at
+			start_by
+			run_status
+			session_id
+			message
+			created_at
+`
+
+// List returns all schedules, oldest first.
+func (r *ScheduleRepository) List(ctx context.Context) ([]*entity.Schedule, error) {
+	qb := NewQB(`
+	{
+		schedules(func: type(Schedule), orderasc: created_at) @filter(NOT has(deleted_at)) {
+			` + scheduleFields + `
+		}
+	}
+	`)
+	query := qb.Query()
+
+	resp, err := r.c.NewTxn().Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Schedules []*entity.Schedule `json:"schedules"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Schedules == nil {
+		res.Schedules = []*entity.Schedule{}
+	}
+	return res.Schedules, nil
+}
+
+// Get returns a schedule by id. Returns nil if the schedule does not
+// exist or has been deleted.
+func (r *ScheduleRepository) Get(ctx context.Context, scheduleID string) (*entity.Schedule, error) {
+	qb := NewQB(`
+	query q($scheduleID: string) {
+		schedules(func: uid($scheduleID)) @filter(type(Schedule) AND NOT has(deleted_at)) {
+			` + scheduleFields + `
+		}
+	}
+	`)
+	query := qb.Query()
+
+	vars := map[string]string{
+		"$scheduleID": scheduleID,
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Schedules []*entity.Schedule `json:"schedules"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Schedules) == 0 {
+		return nil, nil
+	}
+	return res.Schedules[0], nil
+}
+
+// Update saves a schedule's fields, and any new runs, in a single
+// mutation and returns the uids of new nodes. Robot ids are replaced if given, rather than added to,
+// and a nil NextRunAt clears it.
+func (r *ScheduleRepository) Update(ctx context.Context, s *entity.Schedule) (map[string]string, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	mu := &api.Mutation{
+		CommitNow: true,
+		SetJson:   b,
+	}
+
+	// Deletes are applied before sets within a mutation.
+	del := ""
+	if len(s.RobotIDs) > 0 {
+		del += fmt.Sprintf("<%s> <robot_ids> * .\n", s.UID)
+	}
+	if s.NextRunAt == nil {
+		del += fmt.Sprintf("<%s> <next_run_at> * .\n", s.UID)
+	}
+	if del != "" {
+		mu.DelNquads = []byte(del)
+	}
+
+	res, err := r.c.NewTxn().Mutate(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	return res.Uids, nil
+}
+
+// Runs returns a schedule's runs, latest scheduled first.
+func (r *ScheduleRepository) Runs(ctx context.Context, a entity.ListJobRunsArgs) ([]*entity.JobRun, error) {
+	qb := NewQB(`
+	query q($scheduleID: string, $first: int) {
+		runs(func: eq(schedule_id, $scheduleID), first: $first, orderdesc: scheduled_at) @filter(type(JobRun)) {
+			` + jobRunFields + `
+		}
+	}
+	`)
+	query := qb.Query()
+
+	vars := map[string]string{
+		"$scheduleID": a.ScheduleID,
+		"$first":      strconv.Itoa(a.Limit),
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Runs []*entity.JobRun `json:"runs"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Runs == nil {
+		res.Runs = []*entity.JobRun{}
+	}
+	return res.Runs, nil
+}
+
+// Due returns schedules due to trigger, earliest first.
+func (r *ScheduleRepository) Due(ctx context.Context, now time.Time, limit int) ([]*entity.Schedule, error) {
+	qb := NewQB(`
+	query q($now: string, $first: int) {
+		schedules(func: le(next_run_at, $now), first: $first, orderasc: next_run_at) @filter(type(Schedule) AND NOT has(deleted_at)) {
+			` + scheduleFields + `
+		}
+	}
+	`)
+	query := qb.Query()
+
+	vars := map[string]string{
+		"$now":   now.Format(time.RFC3339Nano),
+		"$first": strconv.Itoa(limit),
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Schedules []*entity.Schedule `json:"schedules"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res.Schedules, nil
+}
+
+// Dispatched returns runs waiting for a session to start, earliest
+// scheduled first.
+func (r *ScheduleRepository) Dispatched(ctx context.Context, limit int) ([]*entity.JobRun, error) {
+	qb := NewQB(`
+	query q($dispatched: string, $first: int) {
+		runs(func: eq(run_status, $dispatched), first: $first, orderasc: scheduled_at) @filter(type(JobRun)) {
+			` + jobRunFields + `
+		}
+	}
+	`)
+	query := qb.Query()
+
+	vars := map[string]string{
+		"$dispatched": string(entity.JobRunDispatched),
+		"$first":      strconv.Itoa(limit),
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Runs []*entity.JobRun `json:"runs"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res.Runs, nil
+}
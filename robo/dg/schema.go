@@ -18,7 +18,33 @@ func CreateSchema(ctx context.Context, c *dgo.Dgraph) {
 	op := &api.Operation{}
 	op.Schema = `
 		# String fields
-		name: string @index(fulltext) .
+		name: string @index(exact, fulltext) .
+		anomaly_type: string @index(exact) .
+		message: string .
+		rule_type: string @index(exact) .
+		webhook_url: string .
+		secret: string .
+		alert_key: string @index(exact) @upsert .
+		rule_id: string @index(exact) .
+		session_id: string @index(exact) .
+		robot_id: string @index(exact) .
+		area_id: string @index(exact) .
+		delivery_status: string @index(exact) .
+		error: string .
+		event_type: string @index(exact) .
+		event_types: [string] @index(exact) .
+		webhook_id: string @index(exact) .
+		completion: string .
+		payload: string .
+		robot_ids: [string] @index(exact) .
+		cron: string .
+		time_zone: string .
+		schedule_id: string @index(exact) .
+		run_status: string @index(exact) .
+		parent_area_id: string @index(exact) .
+		robot_state: string @index(exact) .
+		error_codes: [string] @index(exact) .
+		dock_id: string @index(exact) .
 
 		# Int fields
 		size: int .
@@ -32,35 +58,99 @@ func CreateSchema(ctx context.Context, c *dgo.Dgraph) {
 		passes: int .
 		order: int @index(int) .
 		duration_sec: int .
+		time_to_complete_sec: int .
+		positions: int .
+		distance_mm: int .
+		active_sec: int .
+		idle_sec: int .
+		time_to_25_pct_sec: int .
+		time_to_50_pct_sec: int .
+		time_to_75_pct_sec: int .
+		time_to_90_pct_sec: int .
+		time_to_100_pct_sec: int .
+		attempts: int .
+		attempt: int .
+		status_code: int .
+		duration_ms: int .
+		offset_x: int .
+		offset_y: int .
+		robot_size: int .
+		squares: int .
+		battery: int .
+		brush_wear: int .
+		filter_wear: int .
+		paused_sec: int .
+
+		# Float fields
+		area_covered_m2: float .
+		coverage_pct: float .
+		cleaned_pct: float .
+		overlap_ratio: float .
+		avg_speed_mm_per_sec: float .
+		anchor_lat: float .
+		anchor_lon: float .
+		anchor_rotation: float .
+		threshold: float .
 
 		# Date fields
 		started_at: dateTime @index(hour) .
 		ended_at: dateTime @index(hour) .
+		completed_at: dateTime @index(hour) .
 		cleaned_at: dateTime @index(hour) .
 		created_at: dateTime @index(hour) .
 		passed_at: dateTime @index(hour) .
 		last_reported_at: dateTime .
+		computed_at: dateTime .
+		detected_at: dateTime @index(hour) .
+		fired_at: dateTime @index(hour) .
+		next_attempt_at: dateTime @index(hour) .
+		attempted_at: dateTime .
+		occurred_at: dateTime @index(hour) .
+		offline_since: dateTime .
+		next_run_at: dateTime @index(hour) .
+		scheduled_at: dateTime @index(hour) .
+		dispatched_at: dateTime .
+		start_by: dateTime .
+		deleted_at: dateTime @index(hour) .
+		reported_at: dateTime @index(hour) .
+		paused_at: dateTime .
+		resumed_at: dateTime .
 
 		# Boolean fields
 		is_active: bool @index(bool) .
+		not_cleaning: bool .
 
 		# Edges (joinable)
 		robot: [uid] @reverse .
 		grid: [uid] @reverse .
 		session: [uid] @reverse . 
 		area: [uid] @reverse . 
+		source_area: [uid] @reverse .
 		position_history: [uid] .
+		stats: [uid] .
+		anomaly: [uid] .
+		delivery: [uid] .
+		outbox: [uid] @reverse .
+		webhook_message: [uid] .
+		job_run: [uid] .
+		region: [uid] .
+		region_area: [uid] .
+		telemetry: [uid] .
+		last_telemetry: uid .
 
 		type Robot {
 			name
 			session
 			size
+			last_telemetry
 			created_at
+			deleted_at
 		}
 
 		type CleaningSession {
 			name
 			area
+			source_area
 			is_active
 			started_at
 			ended_at
@@ -70,6 +160,178 @@ func CreateSchema(ctx context.Context, c *dgo.Dgraph) {
 			last_reported_at
 			position_history
 			duration_sec
+			completed_at
+			time_to_complete_sec
+			stats
+			anomaly
+			offline_since
+			outbox
+			telemetry
+			paused_at
+			resumed_at
+			paused_sec
+			dock_id
+		}
+
+		type SessionStats {
+			positions
+			distance_mm
+			area_covered_m2
+			coverage_pct
+			cleaned_pct
+			overlap_ratio
+			active_sec
+			idle_sec
+			avg_speed_mm_per_sec
+			time_to_25_pct_sec
+			time_to_50_pct_sec
+			time_to_75_pct_sec
+			time_to_90_pct_sec
+			time_to_100_pct_sec
+			computed_at
+			created_at
+		}
+
+		type Anomaly {
+			anomaly_type
+			message
+			x
+			y
+			detected_at
+			created_at
+		}
+
+		type AlertRule {
+			name
+			rule_type
+			threshold
+			webhook_url
+			secret
+			created_at
+			deleted_at
+		}
+
+		type Alert {
+			alert_key
+			rule_id
+			rule_type
+			session_id
+			robot_id
+			area_id
+			message
+			fired_at
+			delivery_status
+			attempts
+			next_attempt_at
+			delivery
+			created_at
+		}
+
+		type Webhook {
+			name
+			webhook_url
+			secret
+			event_types
+			created_at
+			deleted_at
+		}
+
+		type OutboxEvent {
+			event_type
+			x
+			y
+			completion
+			occurred_at
+			delivery_status
+			webhook_message
+			created_at
+		}
+
+		type WebhookMessage {
+			webhook_id
+			event_type
+			payload
+			delivery_status
+			attempts
+			next_attempt_at
+			delivery
+			created_at
+		}
+
+		type Schedule {
+			name
+			area_id
+			robot_ids
+			cron
+			time_zone
+			passes_needed
+			next_run_at
+			job_run
+			created_at
+			deleted_at
+		}
+
+		type JobRun {
+			schedule_id
+			robot_id
+			area_id
+			scheduled_at
+			dispatched_at
+			start_by
+			run_status
+			session_id
+			message
+			created_at
+		}
+
+		type Dock {
+			name
+			area_id
+			x
+			y
+			created_at
+			deleted_at
+		}
+
+		type Telemetry {
+			robot_id
+			session_id
+			battery
+			robot_state
+			error_codes
+			brush_wear
+			filter_wear
+			reported_at
+			created_at
+		}
+
+		type Partition {
+			area_id
+			region
+			dispatched_at
+			created_at
+		}
+
+		type Region {
+			robot_id
+			robot_size
+			offset_x
+			offset_y
+			size_x
+			size_y
+			squares
+			order
+			region_area
+			created_at
+		}
+
+		type Delivery {
+			attempt
+			status_code
+			error
+			attempted_at
+			duration_ms
+			created_at
 		}
 
 		type Area {
@@ -77,7 +339,14 @@ func CreateSchema(ctx context.Context, c *dgo.Dgraph) {
 			size_x
 			size_y
 			passes_needed
+			anchor_lat
+			anchor_lon
+			anchor_rotation
+			parent_area_id
+			offset_x
+			offset_y
 			created_at
+			deleted_at
 		}
 		
 		type CleaningArea {
@@ -102,6 +371,7 @@ func CreateSchema(ctx context.Context, c *dgo.Dgraph) {
 			x
 			y
 			passed_at
+			not_cleaning
 			order
 		}
 	`
@@ -126,9 +396,6 @@ func CreateSimpleTestData(ctx context.Context, c *dgo.Dgraph) map[string]string
 	area1 := entity.NewArea("Tiny Room 1", 1000, 2000, 3)
 	area2 := entity.NewArea("Tiny Room 2", 2000, 2000, 2)
 
-	robo1.NewCleaningSession(area1)
-	robo2.NewCleaningSession(area2)
-
 	// Find existing test robots and use their UIDs.
 	{
 		repo := NewRobotRepository(c)
@@ -149,39 +416,48 @@ func CreateSimpleTestData(ctx context.Context, c *dgo.Dgraph) map[string]string
 	{
 		repo := NewAreaRepository(c)
 
-		list, err := repo.List(ctx)
-		if err != nil {
-			panic(err)
-		}
-
-		for _, a := range list.Areas {
-			if a.Name == area1.Name {
-				area1.UID = a.UID
-				log.Printf("found existing area: UID=%s name=%s", area1.UID, area1.Name)
+		for _, area := range []*entity.Area{area1, area2} {
+			list, err := repo.List(ctx, entity.ListAreasArgs{Name: area.Name, Limit: 100})
+			if err != nil {
+				panic(err)
 			}
-			if a.Name == area2.Name {
-				area2.UID = a.UID
-				log.Printf("found existing area: UID=%s name=%s", area2.UID, area2.Name)
+			for _, a := range list.Areas {
+				if a.Name == area.Name {
+					area.UID = a.UID
+					log.Printf("found existing area: UID=%s name=%s", area.UID, area.Name)
+					break
+				}
 			}
 		}
 	}
 
-	// spew.Dump(robo1)
-	// spew.Dump(robo2)
-
-	pk1, err := Store(ctx, c, robo1)
+	// Store areas first, sessions need to reference them by UID.
+	pk3, err := Store(ctx, c, area1)
 	if err != nil {
 		panic(err)
 	}
-	pk2, err := Store(ctx, c, robo2)
+	pk4, err := Store(ctx, c, area2)
 	if err != nil {
 		panic(err)
 	}
-	pk3, err := Store(ctx, c, area1)
+	if uid, ok := pk3[entity.AreaUID]; ok {
+		area1.UID = uid
+	}
+	if uid, ok := pk4[entity.AreaUID]; ok {
+		area2.UID = uid
+	}
+
+	robo1.NewCleaningSession(area1)
+	robo2.NewCleaningSession(area2)
+
+	// spew.Dump(robo1)
+	// spew.Dump(robo2)
+
+	pk1, err := Store(ctx, c, robo1)
 	if err != nil {
 		panic(err)
 	}
-	pk4, err := Store(ctx, c, area2)
+	pk2, err := Store(ctx, c, robo2)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,594 @@
+package dg
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/dgraph-io/dgo/v2"
+	"github.com/dgraph-io/dgo/v2/protos/api"
+)
+
+// SessionRepository ...
+type SessionRepository struct {
+	Repository
+}
+
+// NewSessionRepository creates a new repository.
+func NewSessionRepository(c *dgo.Dgraph) *SessionRepository {
+	return &SessionRepository{Repository: Repository{c}}
+}
+
+// activeSession filters out cleaning sessions that have ended. Ending
+// a session sets ended_at, whereas is_active is never cleared since
+// a false value is omitted when saved.
+const activeSession = `NOT has(ended_at)`
+
+// setActive marks sessions as active unless they have ended.
+func setActive(ss []*entity.CleaningSession) {
+	for _, s := range ss {
+		s.IsActive = s.EndedAt == nil
+	}
+}
+
+// sessionSummaryFields are the fields we fetch for a session summary.
+// Grid squares are counted by Dgraph rather than loaded.
+const sessionSummaryFields = `
+			uid
+			name
+			is_active
+			started_at
+			ended_at
+			last_x
+			last_y
+			last_reported_at
+			offline_since
+			paused_at
+			resumed_at
+			paused_sec
+			dock_id
+			duration_sec
+			completed_at
+			time_to_complete_sec
+			created_at
+			robot: ~session {
+				uid
+				name
+				size
+				last_telemetry {
+					` + telemetryFields + `
+				}
+			}
+			source_area {
+				uid
+			}
+			area {
+				uid
+				name
+				size_x
+				size_y
+				passes_needed
+				squares_total: count(grid)
+				squares_cleaned: count(grid @filter(has(cleaned_at)))
+			}
+`
+
+// sessionSummary is a session summary as returned by Dgraph.
+type sessionSummary struct {
+	entity.CleaningSession
+	Robot []*entity.Robot `json:"robot"`
+	Area  []*struct {
+		entity.CleaningArea
+		SquaresTotal   int `json:"squares_total"`
+		SquaresCleaned int `json:"squares_cleaned"`
+	} `json:"area"`
+}
+
+// toEntity converts a session summary as returned by Dgraph to an
+// entity.SessionSummary.
+func (s *sessionSummary) toEntity() *entity.SessionSummary {
+	sess := s.CleaningSession
+	sess.IsActive = sess.EndedAt == nil
+	res := &entity.SessionSummary{Session: &sess}
+	if len(s.Robot) > 0 {
+		res.Robot = s.Robot[0]
+	}
+	if len(s.Area) > 0 {
+		a := s.Area[0]
+		sess.Area = []*entity.CleaningArea{&a.CleaningArea}
+		res.Progress.SquaresTotal = a.SquaresTotal
+		res.Progress.SquaresCleaned = a.SquaresCleaned
+	}
+	res.Progress.Completion = entity.Completion(res.Progress.SquaresCleaned, res.Progress.SquaresTotal)
+	return res
+}
+
+// Get returns a summary of a cleaning session. Returns nil if the
+// session does not exist.
+func (r *SessionRepository) Get(ctx context.Context, sessionID string) (*entity.SessionSummary, error) {
+	qb := NewQB(`
+	query q($sessionID: string) {
+		sessions(func: uid($sessionID)) @filter(type(CleaningSession)) {
+			` + sessionSummaryFields + `
+		}
+	}
+	`)
+	query := qb.Query()
+	// println(query)
+
+	vars := map[string]string{
+		"$sessionID": sessionID,
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+	// println(string(resp.Json))
+
+	res := struct {
+		Sessions []*sessionSummary `json:"sessions"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Sessions) == 0 {
+		return nil, nil
+	}
+	return res.Sessions[0].toEntity(), nil
+}
+
+// GetWithGrid returns a cleaning session together with its cleaning
+// area and the area's entire grid, but without position history.
+// Returns nil if the session does not exist.
+func (r *SessionRepository) GetWithGrid(ctx context.Context, sessionID string) (*entity.CleaningSession, error) {
+	qb := NewQB(`
+	query q($sessionID: string) {
+		sessions(func: uid($sessionID)) @filter(type(CleaningSession)) {
+			uid
+			name
+			is_active
+			started_at
+			ended_at
+			last_x
+			last_y
+			last_reported_at
+			duration_sec
+			completed_at
+			time_to_complete_sec
+			created_at
+			source_area {
+				uid
+				anchor_lat
+				anchor_lon
+				anchor_rotation
+			}
+			area {
+				uid
+				name
+				size_x
+				size_y
+				passes_needed
+				created_at
+				grid (orderasc: order) {
+					uid
+					x
+					y
+					size
+					passes
+					cleaned_at
+					order
+				}
+			}
+		}
+	}
+	`)
+	query := qb.Query()
+	// println(query)
+
+	vars := map[string]string{
+		"$sessionID": sessionID,
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+	// println(string(resp.Json))
+
+	res := struct {
+		Sessions []*entity.CleaningSession `json:"sessions"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Sessions) == 0 {
+		return nil, nil
+	}
+	setActive(res.Sessions)
+	return res.Sessions[0], nil
+}
+
+// Coverage counts a session's grid squares and passes, and the squares
+// cleaned within the given window, without loading the grid. Returns
+// nil if the session does not exist.
+func (r *SessionRepository) Coverage(ctx context.Context, a entity.SessionCoverageArgs) (*entity.SessionCoverage, error) {
+	qb := NewQB(`
+	query q($sessionID: string, $from: string, $to: string) {
+		sessions(func: uid($sessionID)) @filter(type(CleaningSession)) {
+			uid
+			source_area {
+				uid
+			}
+			area {
+				passes_needed
+				squares_total: count(grid)
+				squares_cleaned: count(grid @filter(gt(cleaned_at, $from) AND le(cleaned_at, $to)))
+				passes: grid @groupby(passes) {
+					count(uid)
+				}
+			}
+		}
+	}
+	`)
+	query := qb.Query()
+	// println(query)
+
+	vars := map[string]string{
+		"$sessionID": a.SessionID,
+		"$from":      a.From.Format(time.RFC3339Nano),
+		"$to":        a.To.Format(time.RFC3339Nano),
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+	// println(string(resp.Json))
+
+	res := struct {
+		Sessions []struct {
+			UID        string `json:"uid"`
+			SourceArea []struct {
+				UID string `json:"uid"`
+			} `json:"source_area"`
+			Area []struct {
+				PassesNeeded   int `json:"passes_needed"`
+				SquaresTotal   int `json:"squares_total"`
+				SquaresCleaned int `json:"squares_cleaned"`
+				Passes         []struct {
+					GroupBy []struct {
+						Passes int `json:"passes"`
+						Count  int `json:"count"`
+					} `json:"@groupby"`
+				} `json:"passes"`
+			} `json:"area"`
+		} `json:"sessions"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Sessions) == 0 {
+		return nil, nil
+	}
+	sess := res.Sessions[0]
+
+	c := &entity.SessionCoverage{}
+	if len(sess.SourceArea) > 0 {
+		c.AreaID = sess.SourceArea[0].UID
+	}
+	if len(sess.Area) > 0 {
+		ca := sess.Area[0]
+		c.PassesNeeded = ca.PassesNeeded
+		c.SquaresTotal = ca.SquaresTotal
+		c.CleanedRecently = ca.SquaresCleaned
+
+		// Squares that have never been passed have no passes and
+		// aren't grouped.
+		unpassed := ca.SquaresTotal
+		for _, p := range ca.Passes {
+			for _, g := range p.GroupBy {
+				unpassed -= g.Count
+				if g.Passes < ca.PassesNeeded {
+					c.RemainingPasses += (ca.PassesNeeded - g.Passes) * g.Count
+				}
+			}
+		}
+		c.RemainingPasses += unpassed * ca.PassesNeeded
+	}
+	return c, nil
+}
+
+// Stats returns a session's persisted stats. Returns nil if the
+// session does not exist or has no persisted stats.
+func (r *SessionRepository) Stats(ctx context.Context, sessionID string) (*entity.SessionStats, error) {
+	qb := NewQB(`
+	query q($sessionID: string) {
+		sessions(func: uid($sessionID)) @filter(type(CleaningSession)) {
+			stats (first: 1) {
+				uid
+				positions
+				distance_mm
+				area_covered_m2
+				coverage_pct
+				cleaned_pct
+				overlap_ratio
+				active_sec
+				idle_sec
+				avg_speed_mm_per_sec
+				time_to_25_pct_sec
+				time_to_50_pct_sec
+				time_to_75_pct_sec
+				time_to_90_pct_sec
+				time_to_100_pct_sec
+				computed_at
+				created_at
+			}
+		}
+	}
+	`)
+	query := qb.Query()
+
+	vars := map[string]string{
+		"$sessionID": sessionID,
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Sessions []*entity.CleaningSession `json:"sessions"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Sessions) == 0 || len(res.Sessions[0].Stats) == 0 {
+		return nil, nil
+	}
+	return res.Sessions[0].Stats[0], nil
+}
+
+// Anomalies returns the anomalies detected during a session in the
+// order they were detected. Returns nil if the session does not exist.
+func (r *SessionRepository) Anomalies(ctx context.Context, sessionID string) ([]*entity.Anomaly, error) {
+	qb := NewQB(`
+	query q($sessionID: string) {
+		sessions(func: uid($sessionID)) @filter(type(CleaningSession)) {
+			uid
+			anomaly (orderasc: detected_at) {
+				uid
+				anomaly_type
+				message
+				x
+				y
+				detected_at
+				created_at
+			}
+		}
+	}
+	`)
+	query := qb.Query()
+
+	vars := map[string]string{
+		"$sessionID": sessionID,
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Sessions []*entity.CleaningSession `json:"sessions"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Sessions) == 0 {
+		return nil, nil
+	}
+	if res.Sessions[0].Anomalies == nil {
+		return []*entity.Anomaly{}, nil
+	}
+	return res.Sessions[0].Anomalies, nil
+}
+
+// List returns a page of session summaries, latest started first.
+func (r *SessionRepository) List(ctx context.Context, a entity.ListSessionsArgs) (*entity.ListSessionsResult, error) {
+	qb := NewQB(`
+	query q($robotID: string, $areaID: string, $from: string, $to: string, $endedAfter: string, $cursor: string, $skip: int, $first: int) {
+		<VARS>
+		sessions(func: type(CleaningSession), first: $first, offset: $skip, orderdesc: started_at) <FILTERS> {
+			` + sessionSummaryFields + `
+		}
+	}
+	`)
+
+	vars := map[string]string{
+		"$robotID": a.RobotID,
+		"$areaID":  a.AreaID,
+		"$skip":    "0",
+		"$first":   strconv.Itoa(a.Limit + 1), // Fetch one extra session to find out if there's a next page.
+	}
+
+	if a.RobotID != "" {
+		qb.Var(`var(func: uid($robotID)) { session { robotSessions as uid } }`)
+		qb.Filter(`uid(robotSessions)`)
+	}
+	if a.AreaID != "" {
+		qb.Var(`var(func: uid($areaID)) { ~source_area { areaSessions as uid } }`)
+		qb.Filter(`uid(areaSessions)`)
+	}
+	if a.From != nil {
+		qb.Filter(`ge(started_at, $from)`)
+		vars["$from"] = a.From.Format(time.RFC3339Nano)
+	}
+	if a.To != nil {
+		qb.Filter(`lt(started_at, $to)`)
+		vars["$to"] = a.To.Format(time.RFC3339Nano)
+	}
+	if a.EndedAfter != nil {
+		qb.Filter(`(NOT has(ended_at) OR ge(ended_at, $endedAfter))`)
+		vars["$endedAfter"] = a.EndedAfter.Format(time.RFC3339Nano)
+	}
+	if a.Active != nil {
+		if *a.Active {
+			qb.Filter(activeSession)
+		} else {
+			qb.Filter(`has(ended_at)`)
+		}
+	}
+	var cur *cursor
+	if a.Cursor != "" {
+		var err error
+		cur, err = decodeCursor(a.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		qb.Filter(`le(started_at, $cursor)`)
+		vars["$cursor"] = cur.at()
+		vars["$skip"] = strconv.Itoa(cur.Skip)
+	}
+	query := qb.Query()
+	// println(query)
+
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+	// println(string(resp.Json))
+
+	out := struct {
+		Sessions []*sessionSummary `json:"sessions"`
+	}{}
+	err = json.Unmarshal(resp.Json, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &entity.ListSessionsResult{}
+	var times []*time.Time
+	for i, s := range out.Sessions {
+		if i == a.Limit {
+			res.NextCursor = nextCursor(cur, times)
+			break
+		}
+		res.Sessions = append(res.Sessions, s.toEntity())
+		times = append(times, s.StartedAt)
+	}
+
+	return res, nil
+}
+
+// Positions returns a page of a session's position history in the
+// order the positions were passed. Returns nil if the session does
+// not exist.
+func (r *SessionRepository) Positions(ctx context.Context, a entity.ListPositionsArgs) (*entity.ListPositionsResult, error) {
+	qb := NewQB(`
+	query q($sessionID: string, $from: string, $to: string, $cursor: string, $skip: int, $first: int) {
+		sessions(func: uid($sessionID)) @filter(type(CleaningSession)) {
+			uid
+			position_history (orderasc: passed_at) (first: $first) (offset: $skip) <FILTERS> {
+				uid
+				x
+				y
+				passed_at
+				not_cleaning
+			}
+		}
+	}
+	`)
+
+	vars := map[string]string{
+		"$sessionID": a.SessionID,
+		"$skip":      "0",
+		"$first":     strconv.Itoa(a.Limit + 1), // Fetch one extra position to find out if there's a next page.
+	}
+
+	if a.From != nil {
+		qb.Filter(`ge(passed_at, $from)`)
+		vars["$from"] = a.From.Format(time.RFC3339Nano)
+	}
+	if a.To != nil {
+		qb.Filter(`lt(passed_at, $to)`)
+		vars["$to"] = a.To.Format(time.RFC3339Nano)
+	}
+	var cur *cursor
+	if a.Cursor != "" {
+		var err error
+		cur, err = decodeCursor(a.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		qb.Filter(`ge(passed_at, $cursor)`)
+		vars["$cursor"] = cur.at()
+		vars["$skip"] = strconv.Itoa(cur.Skip)
+	}
+	query := qb.Query()
+	// println(query)
+
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+	// println(string(resp.Json))
+
+	out := struct {
+		Sessions []*entity.CleaningSession `json:"sessions"`
+	}{}
+	err = json.Unmarshal(resp.Json, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out.Sessions) == 0 {
+		return nil, nil
+	}
+
+	res := &entity.ListPositionsResult{Positions: out.Sessions[0].PositionHistory}
+	if len(res.Positions) > a.Limit {
+		res.Positions = res.Positions[:a.Limit]
+		var times []*time.Time
+		for _, p := range res.Positions {
+			times = append(times, p.PassedAt)
+		}
+		res.NextCursor = nextCursor(cur, times)
+	}
+	if res.Positions == nil {
+		res.Positions = []*entity.Position{}
+	}
+
+	return res, nil
+}
+
+// Reopen saves a session's fields, and any new nodes, in a single
+// mutation and makes it active again by clearing when it ended, its
+// duration and the stats cached when it ended, which a plain Save
+// can't do.
+func (r *SessionRepository) Reopen(ctx context.Context, s *entity.CleaningSession) (map[string]string, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	mu := &api.Mutation{
+		CommitNow: true,
+		SetJson:   b,
+		// Deletes are applied before sets within a mutation.
+		DelNquads: []byte(fmt.Sprintf("<%s> <ended_at> * .\n<%s> <duration_sec> * .\n<%s> <stats> * .\n", s.UID, s.UID, s.UID)),
+	}
+
+	res, err := r.c.NewTxn().Mutate(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	return res.Uids, nil
+}
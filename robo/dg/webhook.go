@@ -0,0 +1,274 @@
+package dg
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/dgraph-io/dgo/v2"
+	"github.com/dgraph-io/dgo/v2/protos/api"
+)
+
+// WebhookRepository ...
+type WebhookRepository struct {
+	Repository
+}
+
+// NewWebhookRepository creates a new repository.
+func NewWebhookRepository(c *dgo.Dgraph) *WebhookRepository {
+	return &WebhookRepository{Repository: Repository{c}}
+}
+
+// webhookFields are the fields we fetch for a webhook.
+const webhookFields = `
+			uid
+			name
+			webhook_url
+			secret
+			event_types
+			created_at
+`
+
+// webhookMessageFields are the fields we fetch for a webhook message.
+const webhookMessageFields = `
+			uid
+			webhook_id
+			event_type
+			payload
+			delivery_status
+			attempts
+			next_attempt_at
+			created_at
+`
+
+// List returns all webhooks, oldest first.
+func (r *WebhookRepository) List(ctx context.Context) ([]*entity.Webhook, error) {
+	qb := NewQB(`
+	{
+		webhooks(func: type(Webhook), orderasc: created_at) @filter(NOT has(deleted_at)) {
+			` + webhookFields + `
+		}
+	}
+	`)
+	query := qb.Query()
+
+	resp, err := r.c.NewTxn().Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Webhooks []*entity.Webhook `json:"webhooks"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Webhooks == nil {
+		res.Webhooks = []*entity.Webhook{}
+	}
+	return res.Webhooks, nil
+}
+
+// Get returns a webhook by id. Returns nil if the webhook does not
+// exist or has been deleted.
+func (r *WebhookRepository) Get(ctx context.Context, webhookID string) (*entity.Webhook, error) {
+	qb := NewQB(`
+	query q($webhookID: string) {
+		webhooks(func: uid($webhookID)) @filter(type(Webhook) AND NOT has(deleted_at)) {
+			` + webhookFields + `
+		}
+	}
+	`)
+	query := qb.Query()
+
+	vars := map[string]string{
+		"$webhookID": webhookID,
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Webhooks []*entity.Webhook `json:"webhooks"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Webhooks) == 0 {
+		return nil, nil
+	}
+	return res.Webhooks[0], nil
+}
+
+// Update saves a webhook's fields. Event types are replaced rather
+// than added to, which a plain Save can't do for list predicates.
+func (r *WebhookRepository) Update(ctx context.Context, w *entity.Webhook) error {
+	b, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+	mu := &api.Mutation{
+		CommitNow: true,
+		SetJson:   b,
+	}
+	if len(w.EventTypes) > 0 {
+		// Deletes are applied before sets within a mutation.
+		mu.DelNquads = []byte(fmt.Sprintf("<%s> <event_types> * .", w.UID))
+	}
+
+	_, err = r.c.NewTxn().Mutate(ctx, mu)
+	return err
+}
+
+// Messages returns messages sent to a webhook including their
+// delivery log, latest first.
+func (r *WebhookRepository) Messages(ctx context.Context, a entity.ListWebhookMessagesArgs) ([]*entity.WebhookMessage, error) {
+	qb := NewQB(`
+	query q($webhookID: string, $first: int) {
+		messages(func: eq(webhook_id, $webhookID), first: $first, orderdesc: created_at) @filter(type(WebhookMessage)) {
+			` + webhookMessageFields + `
+			delivery (orderasc: attempt) {
+				uid
+				attempt
+				status_code
+				error
+				attempted_at
+				duration_ms
+			}
+		}
+	}
+	`)
+	query := qb.Query()
+
+	vars := map[string]string{
+		"$webhookID": a.WebhookID,
+		"$first":     strconv.Itoa(a.Limit),
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Messages []*entity.WebhookMessage `json:"messages"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Messages == nil {
+		res.Messages = []*entity.WebhookMessage{}
+	}
+	return res.Messages, nil
+}
+
+// outboxEvent is an outbox event as returned by Dgraph, with the
+// session it belongs to.
+type outboxEvent struct {
+	entity.OutboxEvent
+	Session []*struct {
+		UID        string          `json:"uid"`
+		Robot      []*entity.Robot `json:"robot"`
+		SourceArea []*entity.Area  `json:"source_area"`
+	} `json:"session"`
+}
+
+// Outbox returns events pending dispatch to webhooks, oldest first.
+func (r *WebhookRepository) Outbox(ctx context.Context, limit int) ([]*entity.OutboxEvent, error) {
+	qb := NewQB(`
+	query q($pending: string, $first: int) {
+		events(func: eq(delivery_status, $pending), first: $first, orderasc: occurred_at) @filter(type(OutboxEvent)) {
+			uid
+			event_type
+			x
+			y
+			completion
+			occurred_at
+			delivery_status
+			created_at
+			session: ~outbox {
+				uid
+				robot: ~session {
+					uid
+				}
+				source_area {
+					uid
+				}
+			}
+		}
+	}
+	`)
+	query := qb.Query()
+
+	vars := map[string]string{
+		"$pending": string(entity.DeliveryPending),
+		"$first":   strconv.Itoa(limit),
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Events []*outboxEvent `json:"events"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*entity.OutboxEvent, 0, len(res.Events))
+	for _, e := range res.Events {
+		ev := e.OutboxEvent
+		if len(e.Session) > 0 {
+			s := e.Session[0]
+			ev.SessionID = s.UID
+			if len(s.Robot) > 0 {
+				ev.RobotID = s.Robot[0].UID
+			}
+			if len(s.SourceArea) > 0 {
+				ev.AreaID = s.SourceArea[0].UID
+			}
+		}
+		events = append(events, &ev)
+	}
+	return events, nil
+}
+
+// Due returns messages pending delivery that are due to be attempted,
+// oldest first.
+func (r *WebhookRepository) Due(ctx context.Context, now time.Time, limit int) ([]*entity.WebhookMessage, error) {
+	qb := NewQB(`
+	query q($pending: string, $now: string, $first: int) {
+		messages(func: eq(delivery_status, $pending), first: $first, orderasc: next_attempt_at) @filter(type(WebhookMessage) AND le(next_attempt_at, $now)) {
+			` + webhookMessageFields + `
+		}
+	}
+	`)
+	query := qb.Query()
+
+	vars := map[string]string{
+		"$pending": string(entity.DeliveryPending),
+		"$now":     now.Format(time.RFC3339Nano),
+		"$first":   strconv.Itoa(limit),
+	}
+	resp, err := r.c.NewTxn().QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Messages []*entity.WebhookMessage `json:"messages"`
+	}{}
+	err = json.Unmarshal(resp.Json, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res.Messages, nil
+}
@@ -0,0 +1,190 @@
+package entity
+
+import "time"
+
+const (
+	// AlertRuleUID ...
+	AlertRuleUID = "ar"
+	// AlertUID ...
+	AlertUID = "al"
+	// DeliveryUID ...
+	DeliveryUID = "dl"
+)
+
+// AlertRuleType is the kind of condition an alert rule checks.
+type AlertRuleType string
+
+const (
+	// AlertSessionAbandoned fires when a session ends before every
+	// square was cleaned.
+	AlertSessionAbandoned AlertRuleType = "session_abandoned"
+	// AlertRobotOffline fires when a robot with an active session
+	// hasn't reported in Threshold minutes (default: 10).
+	AlertRobotOffline AlertRuleType = "robot_offline"
+	// AlertLowCompletion fires when a session ends with a completion
+	// below Threshold percent (default: 80).
+	AlertLowCompletion AlertRuleType = "low_completion"
+	// AlertRobotStuck fires when a robot with an active session is
+	// detected to be stuck, see AnomalyStuck.
+	AlertRobotStuck AlertRuleType = "robot_stuck"
+	// AlertLongSession fires when an active session has run for more
+	// than Threshold times (default: 1) the typical time it takes to
+	// clean its area.
+	AlertLongSession AlertRuleType = "long_session"
+	// AlertLowBattery fires when a robot with an active session reports
+	// a battery level below Threshold percent (default: 20).
+	AlertLowBattery AlertRuleType = "low_battery"
+	// AlertRobotError fires when a robot with an active session reports
+	// an error, see Telemetry.HasError.
+	AlertRobotError AlertRuleType = "robot_error"
+)
+
+// AlertRuleTypes are all valid alert rule types.
+var AlertRuleTypes = []AlertRuleType{
+	AlertSessionAbandoned,
+	AlertRobotOffline,
+	AlertLowCompletion,
+	AlertRobotStuck,
+	AlertLongSession,
+	AlertLowBattery,
+	AlertRobotError,
+}
+
+// DefaultAlertThreshold returns the default threshold for a rule
+// type, or zero if the rule type doesn't use one.
+func DefaultAlertThreshold(t AlertRuleType) float64 {
+	switch t {
+	case AlertRobotOffline:
+		return 10
+	case AlertLowCompletion:
+		return 80
+	case AlertLongSession:
+		return 1
+	case AlertLowBattery:
+		return 20
+	}
+	return 0
+}
+
+// DeliveryStatus is the status of an outgoing webhook.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending is waiting to be delivered, possibly retried.
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliveryDelivered has been delivered.
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryFailed has failed too many times, we've given up.
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// AlertRule is a condition checked against cleaning sessions, and a
+// webhook to call when it's met. Each rule fires at most once per
+// session.
+type AlertRule struct {
+	Name       string        `json:"name,omitempty"`
+	RuleType   AlertRuleType `json:"rule_type,omitempty"`
+	Threshold  float64       `json:"threshold,omitempty"` // Meaning depends on the rule type.
+	WebhookURL string        `json:"webhook_url,omitempty"`
+	Secret     string        `json:"secret,omitempty"` // Used to sign webhook requests.
+
+	// Rules are soft-deleted.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	Common
+}
+
+// NewAlertRule creates a new alert rule.
+func NewAlertRule(name string, t AlertRuleType, threshold float64, webhookURL, secret string) *AlertRule {
+	return &AlertRule{
+		Name:       name,
+		RuleType:   t,
+		Threshold:  threshold,
+		WebhookURL: webhookURL,
+		Secret:     secret,
+		Common: Common{
+			UID:       "_:" + AlertRuleUID,
+			DType:     []string{"AlertRule"},
+			CreatedAt: now(),
+		},
+	}
+}
+
+// Alert is an alert rule firing for a cleaning session, together with
+// the log of attempts to deliver it.
+type Alert struct {
+	Key           string         `json:"alert_key,omitempty"` // Rule and session id, unique.
+	RuleID        string         `json:"rule_id,omitempty"`
+	RuleType      AlertRuleType  `json:"rule_type,omitempty"`
+	SessionID     string         `json:"session_id,omitempty"`
+	RobotID       string         `json:"robot_id,omitempty"`
+	AreaID        string         `json:"area_id,omitempty"`
+	Message       string         `json:"message,omitempty"`
+	FiredAt       *time.Time     `json:"fired_at,omitempty"`
+	Status        DeliveryStatus `json:"delivery_status,omitempty"`
+	Attempts      int            `json:"attempts,omitempty"`
+	NextAttemptAt *time.Time     `json:"next_attempt_at,omitempty"`
+	Deliveries    []*Delivery    `json:"delivery,omitempty"` // One per attempt.
+	Common
+}
+
+// NewAlert creates a new alert, pending delivery.
+func NewAlert(rule *AlertRule, s *SessionSummary, message string, firedAt time.Time) *Alert {
+	a := &Alert{
+		Key:           AlertKey(rule.UID, s.Session.UID),
+		RuleID:        rule.UID,
+		RuleType:      rule.RuleType,
+		SessionID:     s.Session.UID,
+		Message:       message,
+		FiredAt:       &firedAt,
+		Status:        DeliveryPending,
+		NextAttemptAt: &firedAt,
+		Common: Common{
+			UID:       "_:" + AlertUID,
+			DType:     []string{"Alert"},
+			CreatedAt: now(),
+		},
+	}
+	if s.Robot != nil {
+		a.RobotID = s.Robot.UID
+	}
+	if len(s.Session.SourceArea) > 0 {
+		a.AreaID = s.Session.SourceArea[0].UID
+	}
+	return a
+}
+
+// AlertKey returns the key that makes sure a rule only fires once per
+// session.
+func AlertKey(ruleID, sessionID string) string {
+	return ruleID + ":" + sessionID
+}
+
+// Delivery is an attempt to deliver a webhook.
+type Delivery struct {
+	Attempt     int        `json:"attempt,omitempty"`
+	StatusCode  int        `json:"status_code,omitempty"` // Zero if we never got a response.
+	Error       string     `json:"error,omitempty"`
+	AttemptedAt *time.Time `json:"attempted_at,omitempty"`
+	DurationMS  int        `json:"duration_ms"`
+	Common
+}
+
+// NewDelivery creates a new delivery attempt.
+func NewDelivery(attempt, statusCode int, err error, attemptedAt time.Time, d time.Duration) *Delivery {
+	dl := &Delivery{
+		Attempt:     attempt,
+		StatusCode:  statusCode,
+		AttemptedAt: &attemptedAt,
+		DurationMS:  int(d / time.Millisecond),
+		Common: Common{
+			UID:       "_:" + DeliveryUID,
+			DType:     []string{"Delivery"},
+			CreatedAt: now(),
+		},
+	}
+	if err != nil {
+		dl.Error = err.Error()
+	}
+	return dl
+}
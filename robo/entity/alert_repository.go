@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+// AlertRepository defines data layer functionality related to alert
+// rules and alerts.
+type AlertRepository interface {
+	ListRules(ctx context.Context) ([]*AlertRule, error)
+	GetRule(ctx context.Context, ruleID string) (*AlertRule, error)
+	List(ctx context.Context, a ListAlertsArgs) ([]*Alert, error)
+	Fired(ctx context.Context, keys []string) (map[string]bool, error)
+	Due(ctx context.Context, now time.Time, limit int) ([]*Alert, error)
+	Repository
+}
+
+// ListAlertsArgs are the args we pass to AlertRepository.List().
+type ListAlertsArgs struct {
+	RuleID    string // Alerts fired by this rule (optional).
+	SessionID string // Alerts fired for this session (optional).
+	Limit     int    // Max number of alerts to return.
+}
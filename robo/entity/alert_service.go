@@ -0,0 +1,32 @@
+package entity
+
+import "context"
+
+// AlertService holds use cases related to alert rules and alerts.
+type AlertService interface {
+	ListRules(ctx context.Context) ([]*AlertRule, error)
+	GetRule(ctx context.Context, ruleID string) (*AlertRule, error)
+	CreateRule(ctx context.Context, a CreateAlertRuleArgs) (*AlertRule, error)
+	UpdateRule(ctx context.Context, a UpdateAlertRuleArgs) (*AlertRule, error)
+	DeleteRule(ctx context.Context, ruleID string) error
+	List(ctx context.Context, a ListAlertsArgs) ([]*Alert, error)
+}
+
+// CreateAlertRuleArgs are passed to AlertService.CreateRule.
+type CreateAlertRuleArgs struct {
+	Name       string        // Name of the rule.
+	RuleType   AlertRuleType // What to check.
+	Threshold  *float64      // Defaults depend on the rule type.
+	WebhookURL string        // Where to send alerts.
+	Secret     string        // Used to sign webhook requests, generated if empty.
+}
+
+// UpdateAlertRuleArgs are passed to AlertService.UpdateRule.
+// Only fields that are set (non-nil) are updated.
+type UpdateAlertRuleArgs struct {
+	RuleID     string   // RuleID of the rule to update.
+	Name       *string  // New name.
+	Threshold  *float64 // New threshold.
+	WebhookURL *string  // New webhook URL.
+	Secret     *string  // New secret.
+}
@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// AnomalyUID ...
+	AnomalyUID = "an"
+)
+
+// AnomalyType is the kind of anomaly detected.
+type AnomalyType string
+
+const (
+	// AnomalyStuck is detected when a robot keeps reporting without
+	// any net movement.
+	AnomalyStuck AnomalyType = "stuck"
+	// AnomalyOscillating is detected when a robot keeps moving back and
+	// forth between a few squares.
+	AnomalyOscillating AnomalyType = "oscillating"
+	// AnomalyOutOfBounds is detected when a robot reports a position
+	// outside of its area.
+	AnomalyOutOfBounds AnomalyType = "out_of_bounds"
+	// AnomalyJump is detected when a robot reports a position it can't
+	// possibly have reached since its last report.
+	AnomalyJump AnomalyType = "jump"
+)
+
+// Anomaly is something odd detected while a robot was reporting its
+// position during a cleaning session.
+type Anomaly struct {
+	Type       AnomalyType `json:"anomaly_type,omitempty"`
+	Message    string      `json:"message,omitempty"`
+	X          int         `json:"x"`
+	Y          int         `json:"y"`
+	DetectedAt *time.Time  `json:"detected_at,omitempty"` // When it happened according to the robot.
+	Common
+}
+
+// NewAnomaly creates a new anomaly.
+func NewAnomaly(t AnomalyType, x, y int, at time.Time, format string, args ...interface{}) *Anomaly {
+	return &Anomaly{
+		Type:       t,
+		Message:    fmt.Sprintf(format, args...),
+		X:          x,
+		Y:          y,
+		DetectedAt: &at,
+		Common: Common{
+			UID:       "_:" + AnomalyUID,
+			DType:     []string{"Anomaly"},
+			CreatedAt: now(),
+		},
+	}
+}
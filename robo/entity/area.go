@@ -1,5 +1,7 @@
 package entity
 
+import "time"
+
 // Area is a rectangular shaped area to clean.
 type Area struct {
 	Name  string `json:"name,omitempty"`   // Each cleaning area should definitely have a name to make reports nicer.
@@ -9,6 +11,20 @@ type Area struct {
 	// Number of grid square passes needed before the square can be considered clean.
 	PassesNeeded int `json:"passes_needed,omitempty"`
 
+	// Optional geo anchor, see GeoAnchor.
+	AnchorLat      *float64 `json:"anchor_lat,omitempty"`
+	AnchorLon      *float64 `json:"anchor_lon,omitempty"`
+	AnchorRotation *float64 `json:"anchor_rotation,omitempty"`
+
+	// Set for areas that are a region of a larger area, see Partition.
+	ParentAreaID string `json:"parent_area_id,omitempty"`
+	OffsetX      int    `json:"offset_x,omitempty"` // X offset within the parent area in millimeters.
+	OffsetY      int    `json:"offset_y,omitempty"` // Y offset within the parent area in millimeters.
+
+	// Areas are soft-deleted. Past cleaning sessions keep their own
+	// CleaningArea snapshot so they are unaffected either way.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
 	Common
 }
 
@@ -26,3 +42,22 @@ func NewArea(name string, sizeX, sizeY, passesNeeded int) *Area {
 		},
 	}
 }
+
+// Anchor returns the area's geo anchor, or nil if it has none.
+func (a *Area) Anchor() *GeoAnchor {
+	if a.AnchorLat == nil || a.AnchorLon == nil {
+		return nil
+	}
+	g := &GeoAnchor{Lat: *a.AnchorLat, Lon: *a.AnchorLon}
+	if a.AnchorRotation != nil {
+		g.Rotation = *a.AnchorRotation
+	}
+	return g
+}
+
+// SetAnchor sets the area's geo anchor.
+func (a *Area) SetAnchor(g *GeoAnchor) {
+	a.AnchorLat = &g.Lat
+	a.AnchorLon = &g.Lon
+	a.AnchorRotation = &g.Rotation
+}
@@ -4,12 +4,21 @@ import "context"
 
 // AreaRepository defines data layer functionality related to areas.
 type AreaRepository interface {
-	List(ctx context.Context) (*ListAreasResult, error)
+	List(ctx context.Context, a ListAreasArgs) (*ListAreasResult, error)
+	Get(ctx context.Context, areaID string) (*Area, error)
 	Repository
 }
 
-// ListAreasResult is a list of areas together with their active
-// cleaning session.
+// ListAreasArgs are the args we pass to AreaRepository.List().
+type ListAreasArgs struct {
+	Name    string // Fulltext search on area name (optional).
+	Regions bool   // Include the regions of partitioned areas (optional).
+	Cursor  string // Cursor returned by a previous call (optional).
+	Limit   int    // Max number of areas to return.
+}
+
+// ListAreasResult is a page of areas.
 type ListAreasResult struct {
-	Areas []*Area `json:"areas"`
+	Areas      []*Area `json:"areas"`
+	NextCursor string  `json:"next_cursor,omitempty"` // Empty if this is the last page.
 }
@@ -6,5 +6,29 @@ import (
 
 // AreaService holds various use cases related to areas.
 type AreaService interface {
-	List(ctx context.Context) ([]*Area, error)
+	List(ctx context.Context, a ListAreasArgs) (*ListAreasResult, error)
+	Get(ctx context.Context, areaID string) (*Area, error)
+	Create(ctx context.Context, a CreateAreaArgs) (*Area, error)
+	Update(ctx context.Context, a UpdateAreaArgs) (*Area, error)
+	Delete(ctx context.Context, areaID string) error
+}
+
+// CreateAreaArgs are passed to AreaService.Create.
+type CreateAreaArgs struct {
+	Name         string     // Name of the area.
+	SizeX        int        // X side size in millimeters.
+	SizeY        int        // Y side size in millimeters.
+	PassesNeeded int        // Passes needed before a grid square is clean.
+	Anchor       *GeoAnchor // Where the area is on the globe (optional).
+}
+
+// UpdateAreaArgs are passed to AreaService.Update.
+// Only fields that are set (non-nil) are updated.
+type UpdateAreaArgs struct {
+	AreaID       string     // AreaID of the area to update.
+	Name         *string    // New name of the area.
+	SizeX        *int       // New X side size in millimeters.
+	SizeY        *int       // New Y side size in millimeters.
+	PassesNeeded *int       // New number of passes needed.
+	Anchor       *GeoAnchor // New geo anchor.
 }
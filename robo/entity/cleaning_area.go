@@ -1,9 +1,7 @@
 package entity
 
 import (
-	"fmt"
 	"log"
-	"math"
 	"time"
 )
 
@@ -56,18 +54,38 @@ func (a *CleaningArea) Completion() string {
 			cleaned++
 		}
 	}
-	if len(a.Grid) == 0 {
-		return "0.00"
+	return Completion(cleaned, len(a.Grid))
+}
+
+// IsCleaned returns true if every square in the grid has been
+// cleaned.
+func (a *CleaningArea) IsCleaned() bool {
+	for _, s := range a.Grid {
+		if s.CleanedAt == nil {
+			return false
+		}
 	}
-	pct := float64(cleaned) / float64(len(a.Grid))
-	return fmt.Sprintf("%0.2f", math.Round(pct*10000)/100)
+	return len(a.Grid) > 0
 }
 
 // SetVisited marks a grid square as having been visited by
 // the robot.
 func (a *CleaningArea) SetVisited(x, y int) bool {
-	now := time.Now()
-	var registeredPass bool
+	return a.Visit(x, y) != nil
+}
+
+// Visit marks a grid square as having been visited by the robot
+// and returns the square if a new pass was registered, i.e. the
+// robot just entered it.
+func (a *CleaningArea) Visit(x, y int) *Square {
+	return a.VisitAt(x, y, time.Now())
+}
+
+// VisitAt works like Visit but marks squares that reach the passes
+// needed as cleaned at the given time, e.g. when replaying a session's
+// position history.
+func (a *CleaningArea) VisitAt(x, y int, now time.Time) *Square {
+	var passed *Square
 	for _, s := range a.Grid {
 		if s.IsInSquare(x, y) {
 			if !s.HasRobotPresent {
@@ -78,7 +96,7 @@ func (a *CleaningArea) SetVisited(x, y int) bool {
 					s.CleanedAt = &now
 				}
 				s.HasRobotPresent = true
-				registeredPass = true
+				passed = s
 			}
 		} else {
 			// Unlock this square since the robot is not
@@ -86,7 +104,7 @@ func (a *CleaningArea) SetVisited(x, y int) bool {
 			s.HasRobotPresent = false
 		}
 	}
-	return registeredPass
+	return passed
 }
 
 // Print prints an ASCII representation of the grid and
@@ -7,16 +7,28 @@ import (
 
 // CleaningSession is a robot cleaning session.
 type CleaningSession struct {
-	Name            string          `json:"name,omitempty"` // Optional.
-	Area            []*CleaningArea `json:"area,omitempty"`
-	IsActive        bool            `json:"is_active,omitempty"`
-	StartedAt       *time.Time      `json:"started_at,omitempty"`
-	EndedAt         *time.Time      `json:"ended_at,omitempty"`
-	LastX           int             `json:"last_x,omitempty"`
-	LastY           int             `json:"last_y,omitempty"`
-	LastReportedAt  *time.Time      `json:"last_reported_at,omitempty"`
-	PositionHistory []*Position     `json:"position_history,omitempty"`
-	DurationSec     int             `json:"duration_sec,omitempty"`
+	Name              string          `json:"name,omitempty"` // Optional.
+	Area              []*CleaningArea `json:"area,omitempty"`
+	SourceArea        []*Area         `json:"source_area,omitempty"` // The area that Area is a snapshot of.
+	IsActive          bool            `json:"is_active,omitempty"`
+	StartedAt         *time.Time      `json:"started_at,omitempty"`
+	EndedAt           *time.Time      `json:"ended_at,omitempty"`
+	LastX             int             `json:"last_x,omitempty"`
+	LastY             int             `json:"last_y,omitempty"`
+	LastReportedAt    *time.Time      `json:"last_reported_at,omitempty"`
+	PositionHistory   []*Position     `json:"position_history,omitempty"`
+	DurationSec       int             `json:"duration_sec,omitempty"`
+	CompletedAt       *time.Time      `json:"completed_at,omitempty"`         // When every square was cleaned.
+	TimeToCompleteSec int             `json:"time_to_complete_sec,omitempty"` // Seconds from start until every square was cleaned.
+	Stats             []*SessionStats `json:"stats,omitempty"`                // Cached once the session has ended.
+	Anomalies         []*Anomaly      `json:"anomaly,omitempty"`              // Detected while the robot was reporting.
+	OfflineSince      *time.Time      `json:"offline_since,omitempty"`        // Last report before the robot was detected offline.
+	Outbox            []*OutboxEvent  `json:"outbox,omitempty"`               // Events saved with the session, pending dispatch to webhooks.
+	Telemetry         []*Telemetry    `json:"telemetry,omitempty"`            // Reported together with positions.
+	PausedAt          *time.Time      `json:"paused_at,omitempty"`            // Latest time the robot docked, see IsPaused.
+	ResumedAt         *time.Time      `json:"resumed_at,omitempty"`           // Latest time the robot left its dock.
+	PausedSec         int             `json:"paused_sec,omitempty"`           // Seconds spent docked, excluded from DurationSec.
+	DockID            string          `json:"dock_id,omitempty"`              // Dock of the latest pause, if known.
 	Common
 }
 
@@ -33,6 +45,9 @@ func NewCleaningSession(r *Robot, a *Area, name string) *CleaningSession {
 		Area: []*CleaningArea{
 			NewCleaningArea(a, r),
 		},
+		SourceArea: []*Area{
+			{Common: Common{UID: a.UID}},
+		},
 		IsActive:  true,
 		StartedAt: now(),
 		Common: Common{
@@ -44,15 +59,104 @@ func NewCleaningSession(r *Robot, a *Area, name string) *CleaningSession {
 	return cs
 }
 
-// End ends this cleaning session if it was active.
+// End ends this cleaning session if it was active. Time spent docked
+// is excluded from the session's duration.
 func (cs *CleaningSession) End(endedAt time.Time) {
 	if cs.IsActive {
+		if cs.IsPaused() {
+			// Ended while docked.
+			cs.PausedSec += seconds(*cs.PausedAt, endedAt)
+		}
 		// Mark session as inactive.
 		cs.EndedAt = &endedAt
 		cs.IsActive = false
 		if cs.StartedAt != nil {
 			// Calculate session duration.
-			cs.DurationSec = int(cs.EndedAt.Sub(*cs.StartedAt).Seconds())
+			cs.DurationSec = cs.RunningSec(endedAt)
 		}
 	}
 }
+
+// IsPaused checks if the session is active but paused while the robot
+// is docked. Pauses are tracked by time rather than a flag, as fields
+// are only ever set, never cleared, when a session is saved.
+func (cs *CleaningSession) IsPaused() bool {
+	return cs.IsActive && cs.EndedAt == nil && cs.PausedAt != nil &&
+		(cs.ResumedAt == nil || cs.ResumedAt.Before(*cs.PausedAt))
+}
+
+// Pause pauses an active session while the robot is docked, at the
+// given dock if known. Does nothing if the session is already paused.
+func (cs *CleaningSession) Pause(pausedAt time.Time, d *Dock) {
+	if !cs.IsActive || cs.IsPaused() {
+		return
+	}
+	cs.PausedAt = &pausedAt
+	if d != nil {
+		cs.DockID = d.UID
+		cs.LastX = d.X
+		cs.LastY = d.Y
+	}
+}
+
+// Resume resumes a paused session, carrying on with the same grid.
+// Does nothing unless the session is paused.
+func (cs *CleaningSession) Resume(resumedAt time.Time) {
+	if !cs.IsPaused() {
+		return
+	}
+	cs.PausedSec += seconds(*cs.PausedAt, resumedAt)
+	cs.ResumedAt = &resumedAt
+}
+
+// Reopen continues an interrupted session, e.g. after the robot
+// rebooted, with the same grid and history. Time between the session
+// ending and reopening is excluded from its duration, like time spent
+// docked.
+func (cs *CleaningSession) Reopen(reopenedAt time.Time) {
+	if cs.EndedAt != nil {
+		cs.PausedSec += seconds(*cs.EndedAt, reopenedAt)
+		cs.EndedAt = nil
+		cs.DurationSec = 0
+	}
+	cs.IsActive = true
+	cs.ResumedAt = &reopenedAt
+}
+
+// RunningSec returns the number of seconds the session has been
+// running at the given time, excluding time spent docked.
+func (cs *CleaningSession) RunningSec(at time.Time) int {
+	if cs.StartedAt == nil {
+		return 0
+	}
+	sec := seconds(*cs.StartedAt, at) - cs.PausedSec
+	if cs.IsPaused() {
+		sec -= seconds(*cs.PausedAt, at)
+	}
+	if sec < 0 {
+		return 0
+	}
+	return sec
+}
+
+// seconds returns the whole number of seconds from one time to
+// another, or zero if the other time comes first.
+func seconds(from, to time.Time) int {
+	if to.Before(from) {
+		return 0
+	}
+	return int(to.Sub(from).Seconds())
+}
+
+// Complete marks the session as completed, i.e. every square in the
+// area has been cleaned, at the given time. Does nothing if the session
+// has already been completed.
+func (cs *CleaningSession) Complete(completedAt time.Time) {
+	if cs.CompletedAt != nil {
+		return
+	}
+	cs.CompletedAt = &completedAt
+	if cs.StartedAt != nil {
+		cs.TimeToCompleteSec = cs.RunningSec(completedAt)
+	}
+}
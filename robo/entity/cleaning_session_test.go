@@ -0,0 +1,89 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompleteCleaningSession(t *testing.T) {
+	robo1 := NewRobot("Johnny 5", 500)
+	area1 := NewArea("Tiny Room", 1000, 500, 1)
+	sess := NewCleaningSession(robo1, area1, "")
+
+	start := time.Date(2020, 2, 16, 10, 0, 0, 0, time.UTC)
+	sess.StartedAt = &start
+
+	sess.Area[0].VisitAt(250, 250, start.Add(10*time.Second))
+	require.False(t, sess.Area[0].IsCleaned(), "should have one square left")
+
+	sess.Area[0].VisitAt(750, 250, start.Add(20*time.Second))
+	require.True(t, sess.Area[0].IsCleaned())
+
+	sess.Complete(start.Add(20 * time.Second))
+	require.Equal(t, 20, sess.TimeToCompleteSec)
+
+	sess.Complete(start.Add(30 * time.Second))
+	require.Equal(t, 20, sess.TimeToCompleteSec, "should only complete once")
+}
+
+func TestPauseCleaningSession(t *testing.T) {
+	robo1 := NewRobot("Johnny 5", 500)
+	area1 := NewArea("Tiny Room", 1000, 500, 1)
+	sess := NewCleaningSession(robo1, area1, "")
+
+	start := time.Date(2020, 2, 16, 10, 0, 0, 0, time.UTC)
+	sess.StartedAt = &start
+
+	dock := NewDock("Dock", area1.UID, 0, 500)
+	dock.UID = "0xd"
+
+	sess.Area[0].VisitAt(250, 250, start.Add(10*time.Second))
+	sess.Pause(start.Add(60*time.Second), dock)
+	require.True(t, sess.IsPaused())
+	require.Equal(t, "0xd", sess.DockID)
+	require.Equal(t, 0, sess.LastX, "should be at the dock")
+	require.Equal(t, 500, sess.LastY)
+	require.Equal(t, 60, sess.RunningSec(start.Add(time.Hour)), "should not count time docked")
+
+	sess.Pause(start.Add(120*time.Second), nil)
+	require.Equal(t, start.Add(60*time.Second), *sess.PausedAt, "should only pause once")
+
+	sess.Resume(start.Add(time.Hour))
+	require.False(t, sess.IsPaused())
+	require.Equal(t, 3540, sess.PausedSec)
+	require.Equal(t, 1, sess.Area[0].Grid[0].Passes, "should keep the grid")
+
+	sess.Area[0].VisitAt(750, 250, start.Add(time.Hour+20*time.Second))
+	sess.Complete(start.Add(time.Hour + 20*time.Second))
+	require.Equal(t, 80, sess.TimeToCompleteSec)
+
+	// Ending while docked counts the final pause too.
+	sess.Pause(start.Add(time.Hour+30*time.Second), nil)
+	sess.End(start.Add(2 * time.Hour))
+	require.False(t, sess.IsPaused())
+	require.Equal(t, 90, sess.DurationSec)
+}
+
+func TestReopenCleaningSession(t *testing.T) {
+	robo1 := NewRobot("Johnny 5", 500)
+	area1 := NewArea("Tiny Room", 1000, 500, 1)
+	sess := NewCleaningSession(robo1, area1, "")
+
+	start := time.Date(2020, 2, 16, 10, 0, 0, 0, time.UTC)
+	sess.StartedAt = &start
+
+	sess.Area[0].VisitAt(250, 250, start.Add(10*time.Second))
+	sess.End(start.Add(60 * time.Second))
+	require.Equal(t, 60, sess.DurationSec)
+
+	sess.Reopen(start.Add(10 * time.Minute))
+	require.True(t, sess.IsActive)
+	require.Nil(t, sess.EndedAt)
+	require.Equal(t, 540, sess.PausedSec, "should not count time between ending and reopening")
+	require.Equal(t, 1, sess.Area[0].Grid[0].Passes, "should keep the grid")
+
+	sess.End(start.Add(11 * time.Minute))
+	require.Equal(t, 120, sess.DurationSec)
+}
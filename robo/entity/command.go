@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+// CommandType is the type of a command sent to a robot.
+type CommandType string
+
+const (
+	// CommandStartSession tells a robot to start a cleaning session.
+	CommandStartSession CommandType = "start_session"
+	// CommandFollowPlan tells a robot to follow a coverage plan, sent in
+	// reply to a plan request.
+	CommandFollowPlan CommandType = "follow_plan"
+)
+
+// Command is sent to a robot over the command channel, see
+// CommandPublisher.
+type Command struct {
+	Type         CommandType `json:"type"`
+	RobotID      string      `json:"robot_id"`
+	AreaID       string      `json:"area_id,omitempty"`
+	PassesNeeded int         `json:"passes_needed,omitempty"` // Zero to use the area's.
+	JobRunID     string      `json:"job_run_id,omitempty"`    // Set for scheduled commands.
+	SessionID    string      `json:"session_id,omitempty"`    // Set for plans.
+	PartitionID  string      `json:"partition_id,omitempty"`  // Set when cleaning a region of a partitioned area.
+	Region       *Region     `json:"region,omitempty"`
+	Plan         *Plan       `json:"plan,omitempty"`
+	IssuedAt     time.Time   `json:"issued_at"`
+}
+
+// CommandPublisher sends commands to robots.
+type CommandPublisher interface {
+	PublishCommand(c *Command) error
+}
@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// DockUID ...
+const DockUID = "dk"
+
+// Dock is a charging station in an area. Robots pause their cleaning
+// session while docked and resume it when they leave the dock.
+type Dock struct {
+	Name   string `json:"name,omitempty"`
+	AreaID string `json:"area_id,omitempty"`
+	X      int    `json:"x,omitempty"` // Position within the area in millimeters.
+	Y      int    `json:"y,omitempty"` // Position within the area in millimeters.
+
+	// Docks are soft-deleted so that sessions paused at them remain
+	// readable.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	Common
+}
+
+// NewDock creates a new dock at a position in an area.
+func NewDock(name, areaID string, x, y int) *Dock {
+	return &Dock{
+		Name:   name,
+		AreaID: areaID,
+		X:      x,
+		Y:      y,
+		Common: Common{
+			UID:       "_:" + DockUID,
+			DType:     []string{"Dock"},
+			CreatedAt: now(),
+		},
+	}
+}
@@ -0,0 +1,10 @@
+package entity
+
+import "context"
+
+// DockRepository defines data layer functionality related to docks.
+type DockRepository interface {
+	List(ctx context.Context, areaID string) ([]*Dock, error)
+	Get(ctx context.Context, dockID string) (*Dock, error)
+	Repository
+}
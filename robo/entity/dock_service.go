@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"context"
+)
+
+// DockService holds various use cases related to docks.
+type DockService interface {
+	List(ctx context.Context, areaID string) ([]*Dock, error)
+	Create(ctx context.Context, a CreateDockArgs) (*Dock, error)
+	Delete(ctx context.Context, dockID string) error
+}
+
+// CreateDockArgs are passed to DockService.Create.
+type CreateDockArgs struct {
+	AreaID string // AreaID of the area the dock is in.
+	Name   string // Name of the dock.
+	X      int    // X coordinate within the area in millimeters.
+	Y      int    // Y coordinate within the area in millimeters.
+}
@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// ETA is an estimate of when an active cleaning session will have
+// cleaned its entire area.
+type ETA struct {
+	At              time.Time `json:"at"`
+	Sec             int       `json:"sec"`              // Seconds from the last report until At.
+	Confidence      float64   `json:"confidence"`       // Between 0 (a wild guess) and 1.
+	RemainingPasses int       `json:"remaining_passes"` // Passes still needed across the grid.
+}
@@ -0,0 +1,108 @@
+package entity
+
+import (
+	"time"
+)
+
+// EventType is the type of a session event.
+type EventType string
+
+const (
+	// EventSessionStarted is published when a robot starts a cleaning session.
+	EventSessionStarted EventType = "session.started"
+	// EventPosition is published every time a robot reports its position.
+	EventPosition EventType = "session.position"
+	// EventSquarePassed is published when a robot enters a grid square.
+	EventSquarePassed EventType = "session.square_passed"
+	// EventSquareCleaned is published when a grid square reaches the
+	// number of passes needed.
+	EventSquareCleaned EventType = "session.square_cleaned"
+	// EventCompletionChanged is published when a session's completion
+	// percentage changes.
+	EventCompletionChanged EventType = "session.completion_changed"
+	// EventSessionPaused is published when a robot docks during a
+	// cleaning session.
+	EventSessionPaused EventType = "session.paused"
+	// EventSessionResumed is published when a robot leaves its dock and
+	// carries on with a paused cleaning session.
+	EventSessionResumed EventType = "session.resumed"
+	// EventSessionEnded is published when a cleaning session ends.
+	EventSessionEnded EventType = "session.ended"
+	// EventAnomaly is published when a robot is detected to be stuck
+	// or behaving oddly, see Anomaly.
+	EventAnomaly EventType = "session.anomaly"
+)
+
+// Event is something that happened during a cleaning session, as
+// processed by the RobotService.
+type Event struct {
+	ID         uint64    `json:"id"`    // Assigned by the event bus, increases with every event published.
+	Epoch      int64     `json:"epoch"` // When the event bus started, as IDs start over when the server restarts.
+	Type       EventType `json:"type"`
+	RobotID    string    `json:"robot_id"`
+	AreaID     string    `json:"area_id,omitempty"`
+	SessionID  string    `json:"session_id"`
+	X          int       `json:"x"`
+	Y          int       `json:"y"`
+	Square     *Square   `json:"square,omitempty"`     // Set for square events.
+	Anomaly    *Anomaly  `json:"anomaly,omitempty"`    // Set for anomaly events.
+	Completion string    `json:"completion,omitempty"` // Current session completion.
+	At         time.Time `json:"at"`                   // When it happened according to the robot.
+}
+
+// EventFilter selects events by robot and / or area. An empty
+// filter matches all events.
+type EventFilter struct {
+	RobotIDs []string `json:"robot_ids,omitempty"`
+	AreaIDs  []string `json:"area_ids,omitempty"`
+}
+
+// Match returns true if the event matches any of the filter's
+// robots or areas.
+func (f EventFilter) Match(e *Event) bool {
+	if len(f.RobotIDs) == 0 && len(f.AreaIDs) == 0 {
+		return true
+	}
+	for _, id := range f.RobotIDs {
+		if id == e.RobotID {
+			return true
+		}
+	}
+	for _, id := range f.AreaIDs {
+		if id == e.AreaID {
+			return true
+		}
+	}
+	return false
+}
+
+// EventPublisher publishes session events.
+type EventPublisher interface {
+	Publish(e *Event)
+}
+
+// EventBus is an in-process pub/sub for session events.
+type EventBus interface {
+	EventPublisher
+	Subscribe(f EventFilter) EventSubscription
+	// SubscribeAfter is like Subscribe but first replays recent events
+	// matching the filter with an ID greater than lastEventID, as far
+	// back as the bus remembers. If lastEventID is from another epoch,
+	// i.e. from before the server restarted, all remembered events are
+	// replayed instead. Returns false if events may have been missed,
+	// in which case the subscriber should resync its state.
+	SubscribeAfter(f EventFilter, epoch int64, lastEventID uint64) (EventSubscription, bool)
+}
+
+// EventSubscription receives events matching a filter from an
+// EventBus.
+type EventSubscription interface {
+	// Events returns the channel events are delivered on. The channel
+	// is closed when the subscription is closed, either by calling
+	// Close or by the bus dropping a subscriber that can't keep up.
+	Events() <-chan *Event
+	// SetFilter replaces the subscription's filter.
+	SetFilter(f EventFilter)
+	// Close unsubscribes from the bus.
+	Close()
+}
@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+// ExportService holds various use cases related to exporting raw
+// data. Results are passed to a callback one at a time so that they
+// can be streamed without holding everything in memory. Returning an
+// error from the callback stops the export.
+type ExportService interface {
+	Sessions(ctx context.Context, a ExportArgs, fn func(*SessionSummary) error) error
+	Positions(ctx context.Context, a ExportArgs, fn func(*PositionRecord) error) error
+}
+
+// ExportArgs are passed to ExportService. All filters are optional.
+type ExportArgs struct {
+	RobotID   string     // Only data from sessions run by this robot.
+	AreaID    string     // Only data from sessions cleaning this area.
+	SessionID string     // Only positions from this session.
+	From      *time.Time // Sessions started (positions passed) at or after this time.
+	To        *time.Time // Sessions started (positions passed) before this time.
+}
+
+// PositionRecord is a position together with the session, robot and
+// area it belongs to.
+type PositionRecord struct {
+	SessionID string     `json:"session_id"`
+	RobotID   string     `json:"robot_id"`
+	AreaID    string     `json:"area_id"`
+	X         int        `json:"x"`
+	Y         int        `json:"y"`
+	PassedAt  *time.Time `json:"passed_at"`
+}
@@ -0,0 +1,11 @@
+package entity
+
+// GeoAnchor places an area on the globe. The area's top-left corner
+// (x = 0, y = 0) is at Lat/Lon, and the area is rotated Rotation
+// degrees clockwise around it. With no rotation the x axis points east
+// and the y axis points south, i.e. north is up.
+type GeoAnchor struct {
+	Lat      float64 `json:"lat" validate:"gte=-90,lte=90"`
+	Lon      float64 `json:"lon" validate:"gte=-180,lte=180"`
+	Rotation float64 `json:"rotation" validate:"gte=-360,lte=360"` // Degrees clockwise.
+}
@@ -0,0 +1,25 @@
+package entity
+
+// GridDrift is the result of comparing a session's persisted grid with
+// a grid replayed from its position history.
+type GridDrift struct {
+	SessionID           string         `json:"session_id"`
+	Drift               bool           `json:"drift"` // True if any square differs.
+	SquaresTotal        int            `json:"squares_total"`
+	SquaresDrifted      int            `json:"squares_drifted"`
+	PositionsReplayed   int            `json:"positions_replayed"`
+	PersistedCompletion string         `json:"persisted_completion"`
+	ReplayedCompletion  string         `json:"replayed_completion"`
+	Squares             []*SquareDrift `json:"squares,omitempty"` // Squares that differ.
+}
+
+// SquareDrift describes how a persisted grid square differs from the
+// same square in a replayed grid.
+type SquareDrift struct {
+	X               int  `json:"x"`
+	Y               int  `json:"y"`
+	Passes          int  `json:"passes"`
+	ReplayedPasses  int  `json:"replayed_passes"`
+	Cleaned         bool `json:"cleaned"`
+	ReplayedCleaned bool `json:"replayed_cleaned"`
+}
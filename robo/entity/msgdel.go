@@ -10,4 +10,6 @@ type MessageDelegator interface {
 	HandleStartSession(mqtt.Client, mqtt.Message)
 	HandleUpdateSession(mqtt.Client, mqtt.Message)
 	HandleEndSession(mqtt.Client, mqtt.Message)
+	HandlePauseSession(mqtt.Client, mqtt.Message)
+	HandleResumeSession(mqtt.Client, mqtt.Message)
 }
@@ -0,0 +1,108 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// PartitionUID ...
+	PartitionUID = "pt"
+	// RegionUID ...
+	RegionUID = "rg"
+)
+
+// Partition splits a large area into regions, one per robot, so that
+// several robots can clean it together. Every region is an area of its
+// own, with the parent area's id and its offset within the parent
+// area, so each robot runs an ordinary cleaning session on its region.
+type Partition struct {
+	AreaID       string     `json:"area_id,omitempty"` // The parent area.
+	Regions      []*Region  `json:"region,omitempty"`
+	DispatchedAt *time.Time `json:"dispatched_at,omitempty"` // Last time start commands were sent to the robots.
+
+	Common
+}
+
+// Region is the part of a partitioned area assigned to a robot.
+type Region struct {
+	RobotID   string  `json:"robot_id,omitempty"`
+	RobotSize int     `json:"robot_size,omitempty"`
+	OffsetX   int     `json:"offset_x"` // X offset within the parent area in millimeters.
+	OffsetY   int     `json:"offset_y"` // Y offset within the parent area in millimeters.
+	SizeX     int     `json:"size_x,omitempty"`
+	SizeY     int     `json:"size_y,omitempty"`
+	Squares   int     `json:"squares,omitempty"` // Grid squares for the robot.
+	Order     int     `json:"order"`
+	Area      []*Area `json:"region_area,omitempty"` // The region as an area of its own.
+
+	Common
+}
+
+// NewPartition creates a new partition of an area. The regions and
+// their areas are created along with it.
+func NewPartition(areaID string, regions []*Region) *Partition {
+	return &Partition{
+		AreaID:  areaID,
+		Regions: regions,
+		Common: Common{
+			UID:       "_:" + PartitionUID,
+			DType:     []string{"Partition"},
+			CreatedAt: now(),
+		},
+	}
+}
+
+// NewRegion creates the i:th region of a parent area, a rectangle at
+// the given offset, together with its area.
+func NewRegion(parent *Area, r *Robot, i, n, offsetX, offsetY, sizeX, sizeY, squares int) *Region {
+	a := NewArea(fmt.Sprintf("%s (%d/%d)", parent.Name, i+1, n), sizeX, sizeY, parent.PassesNeeded)
+	a.UID = fmt.Sprintf("_:%s%d", AreaUID, i+1)
+	a.ParentAreaID = parent.UID
+	a.OffsetX = offsetX
+	a.OffsetY = offsetY
+
+	return &Region{
+		RobotID:   r.UID,
+		RobotSize: r.Size,
+		OffsetX:   offsetX,
+		OffsetY:   offsetY,
+		SizeX:     sizeX,
+		SizeY:     sizeY,
+		Squares:   squares,
+		Order:     i,
+		Area:      []*Area{a},
+		Common: Common{
+			UID:       fmt.Sprintf("_:%s%d", RegionUID, i+1),
+			DType:     []string{"Region"},
+			CreatedAt: now(),
+		},
+	}
+}
+
+// AreaID returns the id of the region's own area.
+func (r *Region) AreaID() string {
+	if len(r.Area) == 0 {
+		return ""
+	}
+	return r.Area[0].UID
+}
+
+// PartitionSummary is a partition with the progress of the latest
+// session in each region, rolled up to the parent area.
+type PartitionSummary struct {
+	Partition *Partition        `json:"partition"`
+	Area      *Area             `json:"area"` // The parent area.
+	Progress  SessionProgress   `json:"progress"`
+	Regions   []*RegionProgress `json:"regions"`
+}
+
+// RegionProgress is the progress of the latest session in a region.
+type RegionProgress struct {
+	RegionID  string          `json:"region_id"`
+	RobotID   string          `json:"robot_id"`
+	AreaID    string          `json:"area_id"`
+	SessionID string          `json:"session_id,omitempty"` // Empty until the robot starts a session.
+	IsActive  bool            `json:"is_active"`
+	Progress  SessionProgress `json:"progress"`
+}
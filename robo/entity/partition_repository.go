@@ -0,0 +1,11 @@
+package entity
+
+import "context"
+
+// PartitionRepository defines data layer functionality related to
+// area partitions.
+type PartitionRepository interface {
+	List(ctx context.Context, areaID string) ([]*Partition, error)
+	Get(ctx context.Context, partitionID string) (*Partition, error)
+	Repository
+}
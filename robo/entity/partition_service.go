@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"context"
+)
+
+// PartitionService holds various use cases related to splitting an
+// area between several robots.
+type PartitionService interface {
+	List(ctx context.Context, areaID string) ([]*Partition, error)
+	Get(ctx context.Context, partitionID string) (*PartitionSummary, error)
+	Create(ctx context.Context, a CreatePartitionArgs) (*Partition, error)
+	Dispatch(ctx context.Context, partitionID string) (*Partition, error)
+}
+
+// CreatePartitionArgs are passed to PartitionService.Create.
+type CreatePartitionArgs struct {
+	AreaID   string   // AreaID of the area to split.
+	RobotIDs []string // Robots to split the area between, defaults to all idle robots.
+}
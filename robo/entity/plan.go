@@ -0,0 +1,41 @@
+package entity
+
+// PlanKind is the kind of a coverage plan.
+type PlanKind string
+
+const (
+	// PlanFull covers the entire area the number of passes needed.
+	PlanFull PlanKind = "full"
+	// PlanResume covers only the squares not yet cleaned in a cleaning
+	// area, each the number of passes it still needs.
+	PlanResume PlanKind = "resume"
+)
+
+// Waypoint is a point along a planned path, in millimeters relative to
+// the area. Robots move in a straight line from one waypoint to the
+// next.
+type Waypoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// Plan is a boustrophedon coverage path for a robot, i.e. back and forth
+// along rows of grid squares, one robot diameter apart. Consecutive
+// waypoints always share an x or y coordinate, so a robot following
+// the plan never cuts diagonally across the area.
+type Plan struct {
+	Kind         PlanKind    `json:"kind"`
+	SizeX        int         `json:"size_x"`
+	SizeY        int         `json:"size_y"`
+	RobotSize    int         `json:"robot_size"`
+	PassesNeeded int         `json:"passes_needed"`
+	Squares      int         `json:"squares"`     // Squares covered by the plan.
+	DistanceMM   int         `json:"distance_mm"` // Length of the path from the first waypoint to the last.
+	Waypoints    []*Waypoint `json:"waypoints"`
+}
+
+// PlanArgs are the args we pass to SessionService.Plan().
+type PlanArgs struct {
+	SessionID string
+	Full      bool // Plan the entire area instead of resuming.
+}
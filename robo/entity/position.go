@@ -10,6 +10,11 @@ type Position struct {
 	X        int        `json:"x,omitempty"`
 	Y        int        `json:"y,omitempty"`
 	PassedAt *time.Time `json:"passed_at,omitempty"`
+
+	// The robot got here without cleaning, e.g. when docking or
+	// leaving its dock. Doesn't count as a pass.
+	NotCleaning bool `json:"not_cleaning,omitempty"`
+
 	Common
 }
 
@@ -26,3 +31,11 @@ func NewPosition(x int, y int, passedAt time.Time) *Position {
 		},
 	}
 }
+
+// NewNonCleaningPosition creates a new position the robot got to
+// without cleaning.
+func NewNonCleaningPosition(x int, y int, passedAt time.Time) *Position {
+	p := NewPosition(x, y, passedAt)
+	p.NotCleaning = true
+	return p
+}
@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+// Ways to group session reports.
+const (
+	GroupByRobot = "robot"
+	GroupByArea  = "area"
+	GroupByDay   = "day"
+)
+
+// SessionReport aggregates cleaning sessions started within a time
+// range.
+type SessionReport struct {
+	GroupBy string              `json:"group_by"`
+	From    time.Time           `json:"from"`
+	To      time.Time           `json:"to"`
+	Rows    []*SessionReportRow `json:"rows"`
+	Total   *SessionReportRow   `json:"total"` // All rows combined.
+}
+
+// SessionReportRow aggregates the cleaning sessions in a group.
+type SessionReportRow struct {
+	Group                 string  `json:"group"`          // Robot UID, area UID or day (YYYY-MM-DD).
+	Name                  string  `json:"name,omitempty"` // Robot or area name.
+	SessionsStarted       int     `json:"sessions_started"`
+	SessionsEnded         int     `json:"sessions_ended"`
+	SessionsCompleted     int     `json:"sessions_completed"` // Ended sessions where every square was cleaned.
+	SessionsAbandoned     int     `json:"sessions_abandoned"` // Ended sessions where some squares were not cleaned.
+	CleaningSec           int     `json:"cleaning_sec"`       // Total duration of ended sessions.
+	MeanCompletionPct     float64 `json:"mean_completion_pct"`
+	MeanTimeToCompleteSec float64 `json:"mean_time_to_complete_sec"` // Mean time to clean every square in completed sessions.
+}
@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+// ReportRepository defines data layer functionality related to
+// reports.
+type ReportRepository interface {
+	Sessions(ctx context.Context, a SessionReportArgs) ([]*SessionReportRow, error)
+}
+
+// SessionReportArgs are the args we pass to ReportRepository.Sessions().
+type SessionReportArgs struct {
+	GroupBy string    // One of GroupByRobot, GroupByArea or GroupByDay.
+	From    time.Time // Sessions started at or after this time.
+	To      time.Time // Sessions started before this time.
+}
@@ -0,0 +1,10 @@
+package entity
+
+import (
+	"context"
+)
+
+// ReportService holds various use cases related to reports.
+type ReportService interface {
+	Sessions(ctx context.Context, a SessionReportArgs) (*SessionReport, error)
+}
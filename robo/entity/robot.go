@@ -1,5 +1,7 @@
 package entity
 
+import "time"
+
 // Robot is a vacuum cleaning robot.
 type Robot struct {
 	// Each robot should have a name to make identification easier and reports nicer.
@@ -11,6 +13,13 @@ type Robot struct {
 	// The diameter of the robot in millimeters. We assume all robots are have a circle shape.
 	Size int `json:"size,omitempty"`
 
+	// The latest telemetry reported by the robot, see Telemetry.
+	LastTelemetry *Telemetry `json:"last_telemetry,omitempty"`
+
+	// Robots are soft-deleted so that their historical cleaning
+	// sessions remain queryable.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
 	Common
 }
 
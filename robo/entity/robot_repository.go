@@ -1,12 +1,19 @@
 package entity
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // RobotRepository defines data layer functionality related to robots.
 type RobotRepository interface {
 	List(ctx context.Context, a ListRobotsArgs) (*ListRobotsResult, error)
+	Get(ctx context.Context, robotID string) (*Robot, error)
+	ByName(ctx context.Context, name string) (*ListRobotsResult, error)
+	Status(ctx context.Context, robotID string) (*RobotStatus, error)
 	GetRobotAndArea(ctx context.Context, robotID, areaID string) (*GetRobotAndAreaResult, error)
-	History(ctx context.Context, robotID string, max int) (*Robot, error)
+	History(ctx context.Context, a HistoryArgs) (*HistoryResult, error)
+	Telemetry(ctx context.Context, a ListTelemetryArgs) ([]*Telemetry, error)
 	Repository
 }
 
@@ -28,3 +35,30 @@ type GetRobotAndAreaResult struct {
 	Robots []*Robot `json:"robots"`
 	Areas  []*Area  `json:"areas"`
 }
+
+// HistoryArgs are the args we pass to RobotRepository.History().
+type HistoryArgs struct {
+	RobotID          string     // Robot to fetch history for.
+	From             *time.Time // Sessions started at or after this time (optional).
+	To               *time.Time // Sessions started before this time (optional).
+	Cursor           string     // Cursor returned by a previous call (optional).
+	Max              int        // Max number of sessions to return.
+	IncludePositions bool       // Include each session's position history.
+	IncludeGrid      bool       // Include each session's grid.
+}
+
+// HistoryResult is a robot together with a page of its cleaning
+// sessions, latest started first.
+type HistoryResult struct {
+	Robot      *Robot `json:"robot"`
+	NextCursor string `json:"next_cursor,omitempty"` // Empty if this is the last page.
+}
+
+// ListTelemetryArgs are the args we pass to RobotRepository.Telemetry().
+type ListTelemetryArgs struct {
+	RobotID   string     // Robot that reported the telemetry.
+	SessionID string     // Only telemetry reported during this session (optional).
+	From      *time.Time // Reported at or after this time (optional).
+	To        *time.Time // Reported before this time (optional).
+	Limit     int        // Max number of reports to return.
+}
@@ -8,10 +8,33 @@ import (
 // RobotService holds various use cases related to robots.
 type RobotService interface {
 	List(ctx context.Context, id, name string) ([]*Robot, error)
+	Get(ctx context.Context, robotID string) (*Robot, error)
+	Create(ctx context.Context, a CreateRobotArgs) (*Robot, error)
+	Update(ctx context.Context, a UpdateRobotArgs) (*Robot, error)
+	Delete(ctx context.Context, robotID string) error
+	Status(ctx context.Context, robotID string) (*RobotStatus, error)
 	StartSession(ctx context.Context, a StartSessionArgs) (*CleaningSession, error)
 	UpdateSession(ctx context.Context, a UpdateSessionArgs) (*CleaningSession, error)
 	EndSession(ctx context.Context, a UpdateSessionArgs) (*CleaningSession, error)
-	History(ctx context.Context, robotID string, max int) (*Robot, error)
+	PauseSession(ctx context.Context, a PauseSessionArgs) (*CleaningSession, error)
+	ResumeSession(ctx context.Context, a UpdateSessionArgs) (*CleaningSession, error)
+	History(ctx context.Context, a HistoryArgs) (*HistoryResult, error)
+	DetectOffline(ctx context.Context, now time.Time) (int, error)
+	Telemetry(ctx context.Context, a ListTelemetryArgs) ([]*Telemetry, error)
+}
+
+// CreateRobotArgs are passed to RobotService.Create.
+type CreateRobotArgs struct {
+	Name string // Name of the robot, must be unique.
+	Size int    // Diameter of the robot in millimeters.
+}
+
+// UpdateRobotArgs are passed to RobotService.Update.
+// Only fields that are set (non-nil) are updated.
+type UpdateRobotArgs struct {
+	RobotID string  // RobotID of the robot to update.
+	Name    *string // New name of the robot, must be unique.
+	Size    *int    // New diameter of the robot in millimeters.
 }
 
 // StartSessionArgs are passed to RobotService.StartSession.
@@ -21,13 +44,32 @@ type StartSessionArgs struct {
 	RobotX    int       // Robot's initial X coordinate (optional).
 	RobotY    int       // Robot's initial Y coordinate (optional).
 	StartedAt time.Time // When the session started according to the robot.
+
+	// Passes needed before a square is clean, overriding the area's
+	// (optional), e.g. as given in a scheduled start command.
+	PassesNeeded int
+
+	// Continue the robot's latest session, or the session with the
+	// given id, with its grid and history rather than starting over,
+	// e.g. after the robot rebooted (optional). The session must have
+	// been run by the robot in the same area.
+	Resume    bool
+	SessionID string
 }
 
 // UpdateSessionArgs are passed to RobotService.StartSession.
 type UpdateSessionArgs struct {
-	RobotID    string    // RobotID of the robot to do the cleaning.
-	RobotX     int       // Robot's current X coordinate.
-	RobotY     int       // Robot's current Y coordinate.
-	ReportedAt time.Time // When this position was reported according to the robot.
-	EndSession bool      // Close the session.
+	RobotID    string     // RobotID of the robot to do the cleaning.
+	RobotX     int        // Robot's current X coordinate.
+	RobotY     int        // Robot's current Y coordinate.
+	ReportedAt time.Time  // When this position was reported according to the robot.
+	EndSession bool       // Close the session.
+	Telemetry  *Telemetry // Battery, state, errors and wear reported with the position (optional).
+}
+
+// PauseSessionArgs are passed to RobotService.PauseSession.
+type PauseSessionArgs struct {
+	RobotID  string    // RobotID of the robot that docked.
+	DockID   string    // Dock the robot docked at (optional).
+	PausedAt time.Time // When the robot docked according to the robot.
 }
@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// RobotStatus is a lightweight summary of a robot's last reported
+// position and the progress of its latest cleaning session.
+type RobotStatus struct {
+	RobotID        string     `json:"robot_id"`
+	SessionID      string     `json:"session_id,omitempty"`
+	IsActive       bool       `json:"is_active"`
+	IsPaused       bool       `json:"is_paused"`         // Docked during an active session.
+	DockID         string     `json:"dock_id,omitempty"` // Dock the robot is paused at, if known.
+	LastX          int        `json:"last_x"`
+	LastY          int        `json:"last_y"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	LastReportedAt *time.Time `json:"last_reported_at,omitempty"`
+	PausedSec      int        `json:"paused_sec"` // Time spent docked during the session so far.
+	Completion     string     `json:"completion"`
+	SquaresCleaned int        `json:"squares_cleaned"`
+	SquaresTotal   int        `json:"squares_total"`
+
+	// Estimated time of completion, only set for active sessions
+	// with something to base an estimate on, see ETA.
+	ETA           *time.Time `json:"eta,omitempty"`
+	ETASec        int        `json:"eta_sec,omitempty"`
+	ETAConfidence *float64   `json:"eta_confidence,omitempty"`
+}
+
+// SetETA sets the estimated time of completion.
+func (st *RobotStatus) SetETA(e *ETA) {
+	st.ETA = &e.At
+	st.ETASec = e.Sec
+	st.ETAConfidence = &e.Confidence
+}
+
+// Completion returns the completion percentage given the number of
+// cleaned squares and total squares as a 2-decimal string.
+func Completion(cleaned, total int) string {
+	if total == 0 {
+		return "0.00"
+	}
+	pct := float64(cleaned) / float64(total)
+	return fmt.Sprintf("%0.2f", math.Round(pct*10000)/100)
+}
@@ -0,0 +1,119 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/anrid/roboviewer/robo/pkg/cron"
+)
+
+const (
+	// ScheduleUID ...
+	ScheduleUID = "sc"
+	// JobRunUID ...
+	JobRunUID = "jr"
+)
+
+// Schedule is a recurring cleaning job. At every trigger time the
+// first idle robot of the schedule is told to clean the area.
+type Schedule struct {
+	Name         string     `json:"name,omitempty"`
+	AreaID       string     `json:"area_id,omitempty"`
+	RobotIDs     []string   `json:"robot_ids,omitempty"`     // A single robot or a pool of robots.
+	Cron         string     `json:"cron,omitempty"`          // Standard 5-field cron expression.
+	TimeZone     string     `json:"time_zone,omitempty"`     // IANA time zone the cron expression is in, e.g. Europe/Stockholm.
+	PassesNeeded int        `json:"passes_needed,omitempty"` // Zero to use the area's.
+	NextRunAt    *time.Time `json:"next_run_at,omitempty"`   // Nil if the schedule never triggers again.
+	Runs         []*JobRun  `json:"job_run,omitempty"`
+
+	// Schedules are soft-deleted.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	Common
+}
+
+// NewSchedule creates a new schedule.
+func NewSchedule(name, areaID string, robotIDs []string, cron, timeZone string, passesNeeded int) *Schedule {
+	return &Schedule{
+		Name:         name,
+		AreaID:       areaID,
+		RobotIDs:     robotIDs,
+		Cron:         cron,
+		TimeZone:     timeZone,
+		PassesNeeded: passesNeeded,
+		Common: Common{
+			UID:       "_:" + ScheduleUID,
+			DType:     []string{"Schedule"},
+			CreatedAt: now(),
+		},
+	}
+}
+
+// Next returns the first time after the given time that the schedule
+// triggers, or nil if it never triggers again.
+func (s *Schedule) Next(after time.Time) (*time.Time, error) {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	c, err := cron.Parse(s.Cron)
+	if err != nil {
+		return nil, err
+	}
+	next := c.Next(after.In(loc))
+	if next.IsZero() {
+		return nil, nil
+	}
+	return &next, nil
+}
+
+// JobRunStatus is the status of a job run.
+type JobRunStatus string
+
+const (
+	// JobRunDispatched has had a start command sent to a robot and is
+	// waiting for the session to start.
+	JobRunDispatched JobRunStatus = "dispatched"
+	// JobRunStarted has a cleaning session.
+	JobRunStarted JobRunStatus = "started"
+	// JobRunMissed never got a cleaning session, e.g. because no robot
+	// was idle or the robot didn't start a session in time.
+	JobRunMissed JobRunStatus = "missed"
+)
+
+// JobRun is a schedule triggering.
+type JobRun struct {
+	ScheduleID   string       `json:"schedule_id,omitempty"`
+	RobotID      string       `json:"robot_id,omitempty"` // Empty if no robot was idle.
+	AreaID       string       `json:"area_id,omitempty"`
+	ScheduledAt  *time.Time   `json:"scheduled_at,omitempty"`
+	DispatchedAt *time.Time   `json:"dispatched_at,omitempty"`
+	StartBy      *time.Time   `json:"start_by,omitempty"` // Missed unless a session starts before this.
+	Status       JobRunStatus `json:"run_status,omitempty"`
+	SessionID    string       `json:"session_id,omitempty"` // The session started by the run.
+	Message      string       `json:"message,omitempty"`    // Why the run was missed.
+	Common
+}
+
+// NewJobRun creates a new run of a schedule, dispatched to a robot.
+func NewJobRun(s *Schedule, robotID string, scheduledAt, dispatchedAt, startBy time.Time) *JobRun {
+	return &JobRun{
+		ScheduleID:   s.UID,
+		RobotID:      robotID,
+		AreaID:       s.AreaID,
+		ScheduledAt:  &scheduledAt,
+		DispatchedAt: &dispatchedAt,
+		StartBy:      &startBy,
+		Status:       JobRunDispatched,
+		Common: Common{
+			UID:       "_:" + JobRunUID,
+			DType:     []string{"JobRun"},
+			CreatedAt: now(),
+		},
+	}
+}
+
+// Miss marks the run as missed.
+func (r *JobRun) Miss(message string) {
+	r.Status = JobRunMissed
+	r.Message = message
+}
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+// ScheduleRepository defines data layer functionality related to
+// schedules and their runs.
+type ScheduleRepository interface {
+	List(ctx context.Context) ([]*Schedule, error)
+	Get(ctx context.Context, scheduleID string) (*Schedule, error)
+	Update(ctx context.Context, s *Schedule) (map[string]string, error)
+	Runs(ctx context.Context, a ListJobRunsArgs) ([]*JobRun, error)
+	Due(ctx context.Context, now time.Time, limit int) ([]*Schedule, error)
+	Dispatched(ctx context.Context, limit int) ([]*JobRun, error)
+	Repository
+}
+
+// ListJobRunsArgs are the args we pass to ScheduleRepository.Runs().
+type ListJobRunsArgs struct {
+	ScheduleID string // Runs of this schedule.
+	Limit      int    // Max number of runs to return.
+}
@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"context"
+)
+
+// ScheduleService holds various use cases related to cleaning
+// schedules.
+type ScheduleService interface {
+	List(ctx context.Context) ([]*Schedule, error)
+	Get(ctx context.Context, scheduleID string) (*Schedule, error)
+	Create(ctx context.Context, a CreateScheduleArgs) (*Schedule, error)
+	Update(ctx context.Context, a UpdateScheduleArgs) (*Schedule, error)
+	Delete(ctx context.Context, scheduleID string) error
+	Runs(ctx context.Context, a ListJobRunsArgs) ([]*JobRun, error)
+}
+
+// CreateScheduleArgs are passed to ScheduleService.Create.
+type CreateScheduleArgs struct {
+	Name         string   // Name of the schedule.
+	AreaID       string   // AreaID of the area to clean.
+	RobotIDs     []string // A single robot or a pool of robots.
+	Cron         string   // Standard 5-field cron expression.
+	TimeZone     string   // IANA time zone, defaults to UTC.
+	PassesNeeded int      // Passes needed, zero to use the area's.
+}
+
+// UpdateScheduleArgs are passed to ScheduleService.Update.
+// Only fields that are set (non-nil) are updated.
+type UpdateScheduleArgs struct {
+	ScheduleID   string   // ScheduleID of the schedule to update.
+	Name         *string  // New name.
+	AreaID       *string  // New area to clean.
+	RobotIDs     []string // New robots, replacing the old ones.
+	Cron         *string  // New cron expression.
+	TimeZone     *string  // New time zone.
+	PassesNeeded *int     // New passes needed.
+}
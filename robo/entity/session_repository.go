@@ -0,0 +1,101 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+// SessionRepository defines data layer functionality related to
+// cleaning sessions.
+type SessionRepository interface {
+	Get(ctx context.Context, sessionID string) (*SessionSummary, error)
+	GetWithGrid(ctx context.Context, sessionID string) (*CleaningSession, error)
+	Coverage(ctx context.Context, a SessionCoverageArgs) (*SessionCoverage, error)
+	List(ctx context.Context, a ListSessionsArgs) (*ListSessionsResult, error)
+	Positions(ctx context.Context, a ListPositionsArgs) (*ListPositionsResult, error)
+	Stats(ctx context.Context, sessionID string) (*SessionStats, error)
+	Anomalies(ctx context.Context, sessionID string) ([]*Anomaly, error)
+	Reopen(ctx context.Context, s *CleaningSession) (map[string]string, error)
+	Repository
+}
+
+// SessionSummary is a cleaning session without its grid and position
+// history, together with the robot that ran it and its progress.
+type SessionSummary struct {
+	Session  *CleaningSession `json:"session"`
+	Robot    *Robot           `json:"robot,omitempty"`
+	Progress SessionProgress  `json:"progress"`
+}
+
+// SessionProgress is the progress of a cleaning session.
+type SessionProgress struct {
+	Completion     string `json:"completion"`
+	SquaresCleaned int    `json:"squares_cleaned"`
+	SquaresTotal   int    `json:"squares_total"`
+
+	// Estimated time of completion, only set for active sessions
+	// with something to base an estimate on, see ETA.
+	ETA           *time.Time `json:"eta,omitempty"`
+	ETASec        int        `json:"eta_sec,omitempty"`
+	ETAConfidence *float64   `json:"eta_confidence,omitempty"`
+}
+
+// SetETA sets the estimated time of completion.
+func (p *SessionProgress) SetETA(e *ETA) {
+	p.ETA = &e.At
+	p.ETASec = e.Sec
+	p.ETAConfidence = &e.Confidence
+}
+
+// SessionCoverageArgs are the args we pass to
+// SessionRepository.Coverage().
+type SessionCoverageArgs struct {
+	SessionID string    // Session to count squares and passes for.
+	From      time.Time // Count squares cleaned after this time,
+	To        time.Time // up until and including this time.
+}
+
+// SessionCoverage counts a session's grid squares and passes without
+// loading the grid, e.g. to estimate when the session will complete.
+type SessionCoverage struct {
+	AreaID          string // Area the session is cleaning.
+	PassesNeeded    int    // Passes needed before a square is clean.
+	SquaresTotal    int
+	RemainingPasses int // Passes still needed across the grid, extra passes don't count.
+	CleanedRecently int // Squares cleaned within the given window.
+}
+
+// ListSessionsArgs are the args we pass to SessionRepository.List().
+// All filters are optional.
+type ListSessionsArgs struct {
+	RobotID    string     // Sessions run by this robot.
+	AreaID     string     // Sessions cleaning this area.
+	From       *time.Time // Sessions started at or after this time.
+	To         *time.Time // Sessions started before this time.
+	EndedAfter *time.Time // Sessions still active or ended at or after this time.
+	Active     *bool      // Only active or inactive sessions.
+	Cursor     string     // Cursor returned by a previous call.
+	Limit      int        // Max number of sessions to return.
+}
+
+// ListSessionsResult is a page of sessions, latest started first.
+type ListSessionsResult struct {
+	Sessions   []*SessionSummary `json:"sessions"`
+	NextCursor string            `json:"next_cursor,omitempty"` // Empty if this is the last page.
+}
+
+// ListPositionsArgs are the args we pass to SessionRepository.Positions().
+type ListPositionsArgs struct {
+	SessionID string     // Session to list positions for.
+	From      *time.Time // Positions passed at or after this time (optional).
+	To        *time.Time // Positions passed before this time (optional).
+	Cursor    string     // Cursor returned by a previous call (optional).
+	Limit     int        // Max number of positions to return.
+}
+
+// ListPositionsResult is a page of positions in the order they
+// were passed.
+type ListPositionsResult struct {
+	Positions  []*Position `json:"positions"`
+	NextCursor string      `json:"next_cursor,omitempty"` // Empty if this is the last page.
+}
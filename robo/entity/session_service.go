@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+// SessionService holds various use cases related to cleaning sessions.
+type SessionService interface {
+	Get(ctx context.Context, sessionID string) (*SessionSummary, error)
+	Grid(ctx context.Context, sessionID string) (*CleaningArea, error)
+	GridAt(ctx context.Context, sessionID string, at time.Time) (*CleaningArea, error)
+	CheckGrid(ctx context.Context, sessionID string) (*GridDrift, error)
+	Stats(ctx context.Context, sessionID string) (*SessionStats, error)
+	Anomalies(ctx context.Context, sessionID string) ([]*Anomaly, error)
+	Plan(ctx context.Context, a PlanArgs) (*Plan, error)
+	WithGrid(ctx context.Context, sessionID string) (*CleaningSession, error)
+	WithPositions(ctx context.Context, a WithPositionsArgs) (*CleaningSession, error)
+	List(ctx context.Context, a ListSessionsArgs) (*ListSessionsResult, error)
+	Positions(ctx context.Context, a ListPositionsArgs) (*ListPositionsResult, error)
+}
+
+// WithPositionsArgs are the args we pass to SessionService.WithPositions().
+type WithPositionsArgs struct {
+	SessionID string     // Session to load.
+	From      *time.Time // Positions passed at or after this time (optional).
+	To        *time.Time // Positions passed before this time (optional).
+}
@@ -0,0 +1,47 @@
+package entity
+
+import "time"
+
+const (
+	// SessionStatsUID ...
+	SessionStatsUID = "st"
+)
+
+// SessionStats are coverage and efficiency analytics for a cleaning
+// session. They're computed from the session's grid and position
+// history, and persisted once the session has ended.
+type SessionStats struct {
+	Positions        int     `json:"positions"`            // Number of positions reported while cleaning.
+	DistanceMM       int     `json:"distance_mm"`          // Distance travelled in millimeters.
+	AreaCoveredM2    float64 `json:"area_covered_m2"`      // Area of all squares passed at least once.
+	CoveragePct      float64 `json:"coverage_pct"`         // Percentage of squares passed at least once.
+	CleanedPct       float64 `json:"cleaned_pct"`          // Percentage of squares cleaned.
+	OverlapRatio     float64 `json:"overlap_ratio"`        // Total passes relative to passes needed across the grid.
+	ActiveSec        int     `json:"active_sec"`           // Time between the first and last position.
+	IdleSec          int     `json:"idle_sec"`             // Time spent without moving.
+	AvgSpeedMMPerSec float64 `json:"avg_speed_mm_per_sec"` // Average speed while moving.
+
+	// Time from the start of the session until a certain completion
+	// percentage was reached, not counting time spent docked. Nil if
+	// never reached.
+	TimeTo25PctSec  *int `json:"time_to_25_pct_sec,omitempty"`
+	TimeTo50PctSec  *int `json:"time_to_50_pct_sec,omitempty"`
+	TimeTo75PctSec  *int `json:"time_to_75_pct_sec,omitempty"`
+	TimeTo90PctSec  *int `json:"time_to_90_pct_sec,omitempty"`
+	TimeTo100PctSec *int `json:"time_to_100_pct_sec,omitempty"`
+
+	ComputedAt *time.Time `json:"computed_at,omitempty"`
+	Common
+}
+
+// NewSessionStats creates new empty session stats.
+func NewSessionStats() *SessionStats {
+	return &SessionStats{
+		ComputedAt: now(),
+		Common: Common{
+			UID:       "_:" + SessionStatsUID,
+			DType:     []string{"SessionStats"},
+			CreatedAt: now(),
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package entity
+
+import "time"
+
+// TelemetryUID ...
+const TelemetryUID = "tm"
+
+// RobotState is what a robot reports it's doing.
+type RobotState string
+
+const (
+	// RobotCleaning is cleaning.
+	RobotCleaning RobotState = "cleaning"
+	// RobotDocking is on its way back to its dock.
+	RobotDocking RobotState = "docking"
+	// RobotCharging is charging at its dock.
+	RobotCharging RobotState = "charging"
+	// RobotError has stopped because of an error, see
+	// Telemetry.ErrorCodes.
+	RobotError RobotState = "error"
+)
+
+// RobotStates are all valid robot states.
+var RobotStates = []RobotState{
+	RobotCleaning,
+	RobotDocking,
+	RobotCharging,
+	RobotError,
+}
+
+// Telemetry is a robot's health as reported together with its position
+// during a cleaning session. Every field is optional. Telemetry is kept
+// as a time series of its own, separate from the position history, and
+// the latest telemetry is kept on the robot.
+type Telemetry struct {
+	RobotID    string     `json:"robot_id,omitempty"`
+	SessionID  string     `json:"session_id,omitempty"`
+	Battery    *int       `json:"battery,omitempty"` // Percent, 0 - 100.
+	State      RobotState `json:"robot_state,omitempty"`
+	ErrorCodes []string   `json:"error_codes,omitempty"`
+	BrushWear  *int       `json:"brush_wear,omitempty"`  // Wear counter as reported by the robot, e.g. brush hours.
+	FilterWear *int       `json:"filter_wear,omitempty"` // Wear counter as reported by the robot, e.g. filter hours.
+	ReportedAt *time.Time `json:"reported_at,omitempty"`
+
+	Common
+}
+
+// NewTelemetry creates new telemetry for a robot's session.
+func NewTelemetry(robotID, sessionID string, t *Telemetry, reportedAt time.Time) *Telemetry {
+	return &Telemetry{
+		RobotID:    robotID,
+		SessionID:  sessionID,
+		Battery:    t.Battery,
+		State:      t.State,
+		ErrorCodes: t.ErrorCodes,
+		BrushWear:  t.BrushWear,
+		FilterWear: t.FilterWear,
+		ReportedAt: &reportedAt,
+		Common: Common{
+			UID:       "_:" + TelemetryUID,
+			DType:     []string{"Telemetry"},
+			CreatedAt: now(),
+		},
+	}
+}
+
+// HasError checks if the robot reported an error.
+func (t *Telemetry) HasError() bool {
+	return t.State == RobotError || len(t.ErrorCodes) > 0
+}
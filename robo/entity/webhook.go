@@ -0,0 +1,147 @@
+package entity
+
+import "time"
+
+const (
+	// WebhookUID ...
+	WebhookUID = "wh"
+	// OutboxEventUID ...
+	OutboxEventUID = "ob"
+	// WebhookMessageUID ...
+	WebhookMessageUID = "wm"
+)
+
+const (
+	// EventAreaCleaned is emitted to webhooks when a session reaches
+	// 100% completion.
+	EventAreaCleaned EventType = "area.cleaned"
+	// EventRobotOffline is emitted to webhooks when a robot with an
+	// active session stops reporting, see RobotOfflineAfter.
+	EventRobotOffline EventType = "robot.offline"
+)
+
+// RobotOfflineAfter is how long a robot with an active session can go
+// without reporting before it's considered offline.
+const RobotOfflineAfter = 10 * time.Minute
+
+// WebhookEventTypes are the event types webhooks can subscribe to.
+var WebhookEventTypes = []EventType{
+	EventSessionStarted,
+	EventSessionPaused,
+	EventSessionResumed,
+	EventSessionEnded,
+	EventAreaCleaned,
+	EventRobotOffline,
+}
+
+// Webhook is a subscription to session lifecycle events, delivered as
+// signed HTTP POST requests to a URL.
+type Webhook struct {
+	Name       string      `json:"name,omitempty"`
+	URL        string      `json:"webhook_url,omitempty"`
+	Secret     string      `json:"secret,omitempty"` // Used to sign webhook requests.
+	EventTypes []EventType `json:"event_types,omitempty"`
+
+	// Webhooks are soft-deleted.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	Common
+}
+
+// NewWebhook creates a new webhook subscription.
+func NewWebhook(name, url, secret string, eventTypes []EventType) *Webhook {
+	return &Webhook{
+		Name:       name,
+		URL:        url,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		Common: Common{
+			UID:       "_:" + WebhookUID,
+			DType:     []string{"Webhook"},
+			CreatedAt: now(),
+		},
+	}
+}
+
+// Subscribes returns true if the webhook subscribes to the given event
+// type.
+func (w *Webhook) Subscribes(t EventType) bool {
+	for _, et := range w.EventTypes {
+		if et == t {
+			return true
+		}
+	}
+	return false
+}
+
+// OutboxEvent is a session lifecycle event waiting to be dispatched to
+// webhooks. Outbox events are saved together with the session they
+// belong to, so that an event is recorded if and only if the change
+// that caused it is.
+type OutboxEvent struct {
+	EventType  EventType         `json:"event_type,omitempty"`
+	X          int               `json:"x,omitempty"`
+	Y          int               `json:"y,omitempty"`
+	Completion string            `json:"completion,omitempty"`
+	OccurredAt *time.Time        `json:"occurred_at,omitempty"`
+	Status     DeliveryStatus    `json:"delivery_status,omitempty"` // Delivered once dispatched to all webhooks.
+	Messages   []*WebhookMessage `json:"webhook_message,omitempty"` // One per subscribed webhook.
+
+	// The session the event belongs to, its robot and area. Set when
+	// read back from the outbox, never persisted on the event.
+	SessionID string `json:"-"`
+	RobotID   string `json:"-"`
+	AreaID    string `json:"-"`
+
+	Common
+}
+
+// NewOutboxEvent creates a new outbox event, pending dispatch,
+// describing the current state of the given session.
+func NewOutboxEvent(t EventType, sess *CleaningSession, occurredAt time.Time) *OutboxEvent {
+	e := &OutboxEvent{
+		EventType:  t,
+		X:          sess.LastX,
+		Y:          sess.LastY,
+		OccurredAt: &occurredAt,
+		Status:     DeliveryPending,
+		Common: Common{
+			UID:       "_:" + OutboxEventUID,
+			DType:     []string{"OutboxEvent"},
+			CreatedAt: now(),
+		},
+	}
+	if len(sess.Area) > 0 {
+		e.Completion = sess.Area[0].Completion()
+	}
+	return e
+}
+
+// WebhookMessage is an outbox event to be delivered to a webhook,
+// together with the log of attempts to deliver it.
+type WebhookMessage struct {
+	WebhookID     string         `json:"webhook_id,omitempty"`
+	EventType     EventType      `json:"event_type,omitempty"`
+	Payload       string         `json:"payload,omitempty"` // JSON body, fixed when the event is dispatched.
+	Status        DeliveryStatus `json:"delivery_status,omitempty"`
+	Attempts      int            `json:"attempts,omitempty"`
+	NextAttemptAt *time.Time     `json:"next_attempt_at,omitempty"`
+	Deliveries    []*Delivery    `json:"delivery,omitempty"` // One per attempt.
+	Common
+}
+
+// NewWebhookMessage creates a new message, pending delivery.
+func NewWebhookMessage(w *Webhook, t EventType, payload string, at time.Time) *WebhookMessage {
+	return &WebhookMessage{
+		WebhookID:     w.UID,
+		EventType:     t,
+		Payload:       payload,
+		Status:        DeliveryPending,
+		NextAttemptAt: &at,
+		Common: Common{
+			UID:       "_:" + WebhookMessageUID,
+			DType:     []string{"WebhookMessage"},
+			CreatedAt: now(),
+		},
+	}
+}
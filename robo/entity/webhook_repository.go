@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+// WebhookRepository defines data layer functionality related to
+// webhooks and the event outbox.
+type WebhookRepository interface {
+	List(ctx context.Context) ([]*Webhook, error)
+	Get(ctx context.Context, webhookID string) (*Webhook, error)
+	Update(ctx context.Context, w *Webhook) error
+	Messages(ctx context.Context, a ListWebhookMessagesArgs) ([]*WebhookMessage, error)
+	Outbox(ctx context.Context, limit int) ([]*OutboxEvent, error)
+	Due(ctx context.Context, now time.Time, limit int) ([]*WebhookMessage, error)
+	Repository
+}
+
+// ListWebhookMessagesArgs are the args we pass to
+// WebhookRepository.Messages().
+type ListWebhookMessagesArgs struct {
+	WebhookID string // Messages sent to this webhook.
+	Limit     int    // Max number of messages to return.
+}
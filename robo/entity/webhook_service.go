@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"context"
+)
+
+// WebhookService holds various use cases related to webhooks.
+type WebhookService interface {
+	List(ctx context.Context) ([]*Webhook, error)
+	Get(ctx context.Context, webhookID string) (*Webhook, error)
+	Create(ctx context.Context, a CreateWebhookArgs) (*Webhook, error)
+	Update(ctx context.Context, a UpdateWebhookArgs) (*Webhook, error)
+	Delete(ctx context.Context, webhookID string) error
+	Messages(ctx context.Context, a ListWebhookMessagesArgs) ([]*WebhookMessage, error)
+}
+
+// CreateWebhookArgs are passed to WebhookService.Create.
+type CreateWebhookArgs struct {
+	Name       string      // Name of the webhook.
+	URL        string      // Absolute http(s) URL to POST events to.
+	Secret     string      // Used to sign requests, generated if empty.
+	EventTypes []EventType // Event types to subscribe to, see WebhookEventTypes.
+}
+
+// UpdateWebhookArgs are passed to WebhookService.Update.
+// Only fields that are set (non-nil) are updated.
+type UpdateWebhookArgs struct {
+	WebhookID  string      // WebhookID of the webhook to update.
+	Name       *string     // New name.
+	URL        *string     // New URL.
+	Secret     *string     // New secret.
+	EventTypes []EventType // New event types, replacing the old ones.
+}
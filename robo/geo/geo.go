@@ -0,0 +1,79 @@
+// Package geo maps areas and cleaning sessions onto the globe as
+// GeoJSON (RFC 7946), e.g. to overlay them on building floor plans.
+package geo
+
+import (
+	"math"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/pkg/errors"
+)
+
+// EarthRadius is the WGS84 equatorial radius in meters.
+const EarthRadius = 6378137.0
+
+// Projection maps millimeter coordinates measured from the top-left
+// corner of an area to WGS84 longitude / latitude using a local
+// equirectangular projection around the area's anchor. This is more
+// than accurate enough at building scale.
+type Projection struct {
+	anchor   entity.GeoAnchor
+	sin, cos float64
+	mPerLat  float64 // Meters per degree of latitude.
+	mPerLon  float64 // Meters per degree of longitude at the anchor.
+}
+
+// NewProjection creates a new projection around the given anchor.
+func NewProjection(g *entity.GeoAnchor) (*Projection, error) {
+	if g == nil {
+		return nil, errors.Wrap(cerr.ErrValidationFailed, "area has no geo anchor")
+	}
+	if g.Lat < -90 || g.Lat > 90 || g.Lon < -180 || g.Lon > 180 {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "geo anchor must be a valid WGS84 coordinate, got lat = %g lon = %g", g.Lat, g.Lon)
+	}
+	rad := g.Rotation * math.Pi / 180
+	mPerLat := EarthRadius * math.Pi / 180
+	return &Projection{
+		anchor:  *g,
+		sin:     math.Sin(rad),
+		cos:     math.Cos(rad),
+		mPerLat: mPerLat,
+		mPerLon: mPerLat * math.Cos(g.Lat*math.Pi/180),
+	}, nil
+}
+
+// Point returns the [longitude, latitude] of the given millimeter
+// coordinates. With no rotation x points east and y points south.
+func (p *Projection) Point(x, y float64) Position {
+	// Rotate clockwise, then turn y (pointing south) into north.
+	east := (x*p.cos - y*p.sin) / 1000
+	north := -(x*p.sin + y*p.cos) / 1000
+
+	lat := p.anchor.Lat + north/p.mPerLat
+	lon := p.anchor.Lon
+	if p.mPerLon > 0 {
+		lon += east / p.mPerLon
+	}
+	return Position{round(lon), round(lat)}
+}
+
+// Rect returns a closed polygon ring for the given rectangle in
+// millimeters, counterclockwise as required by RFC 7946.
+func (p *Projection) Rect(x, y, w, h float64) [][]Position {
+	// Counterclockwise on the map is clockwise in area coordinates
+	// since y points down.
+	return [][]Position{{
+		p.Point(x, y),
+		p.Point(x, y+h),
+		p.Point(x+w, y+h),
+		p.Point(x+w, y),
+		p.Point(x, y),
+	}}
+}
+
+// round rounds a coordinate to 9 decimals (~0.1 mm), enough for our
+// purposes and keeps the output small.
+func round(f float64) float64 {
+	return math.Round(f*1e9) / 1e9
+}
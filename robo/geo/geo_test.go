@@ -0,0 +1,125 @@
+package geo
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// distance returns the approximate distance in meters between two
+// nearby positions.
+func distance(a, b Position) float64 {
+	lat := (a[1] + b[1]) / 2 * math.Pi / 180
+	dx := (b[0] - a[0]) * math.Pi / 180 * EarthRadius * math.Cos(lat)
+	dy := (b[1] - a[1]) * math.Pi / 180 * EarthRadius
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+func TestProjection(t *testing.T) {
+	_, err := NewProjection(nil)
+	require.Equal(t, cerr.ErrValidationFailed, errors.Cause(err), "should fail without an anchor")
+	_, err = NewProjection(&entity.GeoAnchor{Lat: 91})
+	require.Equal(t, cerr.ErrValidationFailed, errors.Cause(err), "should fail on an invalid anchor")
+
+	p, err := NewProjection(&entity.GeoAnchor{Lat: 35.6812, Lon: 139.7671})
+	require.NoError(t, err)
+
+	origin := p.Point(0, 0)
+	require.Equal(t, Position{139.7671, 35.6812}, origin, "should put the top-left corner at the anchor")
+
+	east := p.Point(10000, 0)
+	require.InDelta(t, 10, distance(origin, east), 0.001, "should keep distances")
+	require.Greater(t, east[0], origin[0], "x should point east without rotation")
+	require.Equal(t, origin[1], east[1])
+
+	south := p.Point(0, 10000)
+	require.InDelta(t, 10, distance(origin, south), 0.001)
+	require.Less(t, south[1], origin[1], "y should point south without rotation")
+
+	// Rotated 90 degrees clockwise x points south and y points west.
+	p, err = NewProjection(&entity.GeoAnchor{Lat: 35.6812, Lon: 139.7671, Rotation: 90})
+	require.NoError(t, err)
+	x := p.Point(10000, 0)
+	require.InDelta(t, 10, distance(origin, x), 0.001)
+	require.Less(t, x[1], origin[1], "x should point south when rotated 90 degrees")
+	require.InDelta(t, origin[0], x[0], 1e-9)
+	y := p.Point(0, 10000)
+	require.Less(t, y[0], origin[0], "y should point west when rotated 90 degrees")
+}
+
+func testSession() *entity.CleaningSession {
+	r := entity.NewRobot("Johnny 5", 500)
+	a := entity.NewArea("Work Room #1", 2000, 1000, 1)
+	a.UID = "0x1"
+	a.SetAnchor(&entity.GeoAnchor{Lat: 59.3293, Lon: 18.0686, Rotation: 30})
+	sess := entity.NewCleaningSession(r, a, "test")
+	sess.UID = "0x2"
+	sess.SourceArea = []*entity.Area{a}
+	return sess
+}
+
+func TestArea(t *testing.T) {
+	a := entity.NewArea("Work Room #1", 2000, 1000, 1)
+	_, err := Area(a)
+	require.Equal(t, cerr.ErrValidationFailed, errors.Cause(err), "should fail without an anchor")
+
+	a.SetAnchor(&entity.GeoAnchor{Lat: 59.3293, Lon: 18.0686})
+	fc, err := Area(a)
+	require.NoError(t, err)
+	require.Len(t, fc.Features, 1)
+
+	f := fc.Features[0]
+	require.Equal(t, "Polygon", f.Geometry.Type)
+	ring := f.Geometry.Coordinates.([][]Position)[0]
+	require.Len(t, ring, 5)
+	require.Equal(t, ring[0], ring[4], "should close the ring")
+	require.InDelta(t, 1, distance(ring[0], ring[1]), 0.001)
+	require.InDelta(t, 2, distance(ring[1], ring[2]), 0.001)
+
+	// Counterclockwise: the signed area (shoelace) must be positive.
+	var sum float64
+	for i := 0; i < 4; i++ {
+		sum += ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+	}
+	require.Greater(t, sum, 0.0, "should wind the outer ring counterclockwise")
+}
+
+func TestSession(t *testing.T) {
+	sess := testSession()
+	sess.SourceArea[0].AnchorLat = nil
+	_, err := Session(sess, SessionOptions{})
+	require.Equal(t, cerr.ErrValidationFailed, errors.Cause(err), "should fail without an anchor")
+
+	sess = testSession()
+	start := time.Date(2020, 2, 16, 10, 0, 0, 0, time.UTC)
+	for i, x := range []int{250, 750, 1250} {
+		at := start.Add(time.Duration(i) * time.Second)
+		sess.PositionHistory = append(sess.PositionHistory, &entity.Position{X: x, Y: 250, PassedAt: &at})
+		sess.Area[0].VisitAt(x, 250, at)
+	}
+
+	fc, err := Session(sess, SessionOptions{})
+	require.NoError(t, err)
+	require.Len(t, fc.Features, 2, "should have an outline and a path")
+	require.Equal(t, "area", fc.Features[0].Properties["kind"])
+
+	path := fc.Features[1]
+	require.Equal(t, "LineString", path.Geometry.Type)
+	require.Len(t, path.Geometry.Coordinates, 3)
+	require.Len(t, path.Properties["passed_at"], 3, "should have a timestamp per coordinate")
+	coords := path.Geometry.Coordinates.([]Position)
+	require.InDelta(t, 0.5, distance(coords[0], coords[1]), 0.001)
+
+	fc, err = Session(sess, SessionOptions{Grid: true})
+	require.NoError(t, err)
+	require.Len(t, fc.Features, 2+len(sess.Area[0].Grid), "should have a polygon per grid square")
+	sq := fc.Features[1]
+	require.Equal(t, "square", sq.Properties["kind"])
+	require.Equal(t, 1, sq.Properties["passes"])
+	require.NotNil(t, sq.Properties["cleaned_at"])
+}
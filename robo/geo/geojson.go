@@ -0,0 +1,143 @@
+package geo
+
+import (
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/pkg/errors"
+)
+
+// Position is a GeoJSON position, i.e. [longitude, latitude].
+type Position [2]float64
+
+// FeatureCollection is a GeoJSON feature collection.
+type FeatureCollection struct {
+	Type     string     `json:"type"`
+	Features []*Feature `json:"features"`
+}
+
+// Feature is a GeoJSON feature.
+type Feature struct {
+	Type       string                 `json:"type"`
+	Geometry   *Geometry              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Geometry is a GeoJSON geometry. Coordinates is a Position, a
+// []Position or a [][]Position depending on Type.
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+func newCollection() *FeatureCollection {
+	return &FeatureCollection{Type: "FeatureCollection", Features: []*Feature{}}
+}
+
+func (fc *FeatureCollection) add(kind, typ string, coords interface{}, props map[string]interface{}) {
+	if props == nil {
+		props = map[string]interface{}{}
+	}
+	props["kind"] = kind
+	fc.Features = append(fc.Features, &Feature{
+		Type:       "Feature",
+		Geometry:   &Geometry{Type: typ, Coordinates: coords},
+		Properties: props,
+	})
+}
+
+// Area returns an area's outline as a feature collection. Areas
+// don't have grids or obstacles of their own, those only exist in
+// the cleaning areas of sessions.
+func Area(a *entity.Area) (*FeatureCollection, error) {
+	p, err := NewProjection(a.Anchor())
+	if err != nil {
+		return nil, errors.Wrapf(err, "area %s", a.UID)
+	}
+
+	fc := newCollection()
+	fc.add("area", "Polygon", p.Rect(0, 0, float64(a.SizeX), float64(a.SizeY)), map[string]interface{}{
+		"area_id":       a.UID,
+		"name":          a.Name,
+		"size_x":        a.SizeX,
+		"size_y":        a.SizeY,
+		"passes_needed": a.PassesNeeded,
+	})
+	return fc, nil
+}
+
+// SessionOptions are options for Session.
+type SessionOptions struct {
+	Grid bool // Include a polygon per grid square.
+}
+
+// Session returns a session's area outline, optionally its grid
+// squares, and the path taken by the robot as a feature collection.
+// The session's area is placed using the anchor of the area the
+// session was started in.
+func Session(sess *entity.CleaningSession, o SessionOptions) (*FeatureCollection, error) {
+	if len(sess.Area) == 0 {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find a cleaning area for session %s", sess.UID)
+	}
+	if len(sess.SourceArea) == 0 {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "session %s has no source area to take a geo anchor from", sess.UID)
+	}
+	p, err := NewProjection(sess.SourceArea[0].Anchor())
+	if err != nil {
+		return nil, errors.Wrapf(err, "area %s", sess.SourceArea[0].UID)
+	}
+	a := sess.Area[0]
+
+	fc := newCollection()
+	fc.add("area", "Polygon", p.Rect(0, 0, float64(a.SizeX), float64(a.SizeY)), map[string]interface{}{
+		"area_id":       sess.SourceArea[0].UID,
+		"session_id":    sess.UID,
+		"name":          a.Name,
+		"size_x":        a.SizeX,
+		"size_y":        a.SizeY,
+		"passes_needed": a.PassesNeeded,
+		"completion":    a.Completion(),
+	})
+
+	if o.Grid {
+		for _, s := range a.Grid {
+			props := map[string]interface{}{
+				"x":      s.X,
+				"y":      s.Y,
+				"passes": s.Passes,
+			}
+			if s.CleanedAt != nil {
+				props["cleaned_at"] = s.CleanedAt.Format(time.RFC3339Nano)
+			}
+			fc.add("square", "Polygon", p.Rect(float64(s.X), float64(s.Y), float64(s.Size), float64(s.Size)), props)
+		}
+	}
+
+	// A LineString needs at least two positions.
+	if len(sess.PositionHistory) >= 2 {
+		coords := make([]Position, 0, len(sess.PositionHistory))
+		times := make([]string, 0, len(sess.PositionHistory))
+		for _, pos := range sess.PositionHistory {
+			coords = append(coords, p.Point(float64(pos.X), float64(pos.Y)))
+			var at string
+			if pos.PassedAt != nil {
+				at = pos.PassedAt.Format(time.RFC3339Nano)
+			}
+			times = append(times, at)
+		}
+		fc.add("path", "LineString", coords, map[string]interface{}{
+			"session_id": sess.UID,
+			"passed_at":  times, // One per coordinate.
+		})
+	} else if len(sess.PositionHistory) == 1 {
+		pos := sess.PositionHistory[0]
+		props := map[string]interface{}{"session_id": sess.UID}
+		if pos.PassedAt != nil {
+			props["passed_at"] = []string{pos.PassedAt.Format(time.RFC3339Nano)}
+		}
+		fc.add("path", "Point", p.Point(float64(pos.X), float64(pos.Y)), props)
+	}
+
+	return fc, nil
+}
@@ -0,0 +1,244 @@
+// Package outbox dispatches session lifecycle events from the event
+// outbox to subscribed webhooks in the background, and delivers them
+// as signed webhooks with retries.
+//
+// Events are saved to the outbox by the RobotService together with
+// the session change that caused them, so an event is never lost
+// between the change being saved and it being delivered. Dispatching
+// an event creates one message per subscribed webhook in the same
+// mutation that marks the event as dispatched, so each event reaches
+// each webhook exactly once, with retries delivering it at least once.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/webhook"
+	"github.com/pkg/errors"
+)
+
+// Config holds the worker settings.
+type Config struct {
+	// Interval is how often we look for offline robots and dispatch
+	// and deliver events.
+	Interval time.Duration
+	// MaxAttempts is the number of delivery attempts before we give
+	// up on a message.
+	MaxAttempts int
+	// BackoffBase is the time to wait before the first retry, doubled
+	// for every retry up to BackoffMax.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// Timeout is the timeout for a single webhook request.
+	Timeout time.Duration
+	// BatchSize is the max number of events dispatched and messages
+	// delivered per tick.
+	BatchSize int
+}
+
+// DefaultConfig returns the default worker settings.
+func DefaultConfig() Config {
+	return Config{
+		Interval:    5 * time.Second,
+		MaxAttempts: 8,
+		BackoffBase: 30 * time.Second,
+		BackoffMax:  time.Hour,
+		Timeout:     webhook.DefaultTimeout,
+		BatchSize:   100,
+	}
+}
+
+// Payload is the JSON body of an event webhook.
+type Payload struct {
+	EventID    string           `json:"event_id"` // The same for every webhook the event is sent to.
+	Type       entity.EventType `json:"type"`
+	RobotID    string           `json:"robot_id,omitempty"`
+	AreaID     string           `json:"area_id,omitempty"`
+	SessionID  string           `json:"session_id"`
+	X          int              `json:"x"`
+	Y          int              `json:"y"`
+	Completion string           `json:"completion,omitempty"`
+	At         *time.Time       `json:"at"`
+}
+
+// Worker dispatches and delivers outbox events. Only one worker
+// should run against a database at a time.
+type Worker struct {
+	w      entity.WebhookRepository
+	r      entity.RobotService
+	c      Config
+	client *http.Client
+}
+
+// NewWorker creates a new worker. The robot service is used to look
+// for robots that went offline.
+func NewWorker(w entity.WebhookRepository, r entity.RobotService, c Config) *Worker {
+	return &Worker{w, r, c, &http.Client{Timeout: c.Timeout}}
+}
+
+// Run looks for offline robots and dispatches and delivers events
+// every interval until the context is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	t := time.NewTicker(w.c.Interval)
+	defer t.Stop()
+	for {
+		if err := w.Tick(ctx, time.Now()); err != nil {
+			log.Printf("outbox: %s", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Tick looks for offline robots, dispatches pending events and then
+// delivers all messages due.
+func (w *Worker) Tick(ctx context.Context, now time.Time) error {
+	if _, err := w.r.DetectOffline(ctx, now); err != nil {
+		return errors.Wrap(err, "could not detect offline robots")
+	}
+	if _, err := w.Dispatch(ctx, now); err != nil {
+		return errors.Wrap(err, "could not dispatch events")
+	}
+	if _, err := w.Deliver(ctx, now); err != nil {
+		return errors.Wrap(err, "could not deliver events")
+	}
+	return nil
+}
+
+// Dispatch creates a message for every webhook subscribed to each
+// pending event, and returns the number of events dispatched. Events
+// no webhook subscribes to are dispatched without messages.
+func (w *Worker) Dispatch(ctx context.Context, now time.Time) (int, error) {
+	events, err := w.w.Outbox(ctx, w.c.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	hooks, err := w.w.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for _, e := range events {
+		body, err := json.Marshal(Payload{
+			EventID:    e.UID,
+			Type:       e.EventType,
+			RobotID:    e.RobotID,
+			AreaID:     e.AreaID,
+			SessionID:  e.SessionID,
+			X:          e.X,
+			Y:          e.Y,
+			Completion: e.Completion,
+			At:         e.OccurredAt,
+		})
+		if err != nil {
+			return n, err
+		}
+
+		update := &entity.OutboxEvent{
+			Status: entity.DeliveryDelivered,
+			Common: entity.Common{UID: e.UID},
+		}
+		for _, h := range hooks {
+			if !h.Subscribes(e.EventType) {
+				continue
+			}
+			m := entity.NewWebhookMessage(h, e.EventType, string(body), now)
+			m.UID = fmt.Sprintf("_:%s%d", entity.WebhookMessageUID, len(update.Messages)+1)
+			update.Messages = append(update.Messages, m)
+		}
+
+		if _, err := w.w.Save(ctx, update); err != nil {
+			return n, errors.Wrapf(err, "could not persist dispatch of event %s", e.UID)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Deliver attempts to deliver all messages due and returns the number
+// delivered. Failed attempts are retried with exponential backoff
+// until MaxAttempts, and every attempt is logged on the message.
+func (w *Worker) Deliver(ctx context.Context, now time.Time) (int, error) {
+	due, err := w.w.Due(ctx, now, w.c.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(due) == 0 {
+		return 0, nil
+	}
+
+	hooks, err := w.hooks(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var delivered int
+	for _, m := range due {
+		update := &entity.WebhookMessage{
+			Attempts: m.Attempts + 1,
+			Common:   entity.Common{UID: m.UID},
+		}
+
+		h, ok := hooks[m.WebhookID]
+		if !ok {
+			// The webhook was deleted after the event was dispatched.
+			update.Status = entity.DeliveryFailed
+			update.Deliveries = []*entity.Delivery{
+				entity.NewDelivery(update.Attempts, 0, errors.New("webhook has been deleted"), now, 0),
+			}
+		} else {
+			t := time.Now()
+			status, err := webhook.Send(ctx, w.client, webhook.Request{
+				URL:        h.URL,
+				Secret:     h.Secret,
+				Event:      string(m.EventType),
+				DeliveryID: m.UID,
+				Body:       []byte(m.Payload),
+			})
+			update.Deliveries = []*entity.Delivery{entity.NewDelivery(update.Attempts, status, err, now, time.Since(t))}
+			switch {
+			case err == nil:
+				update.Status = entity.DeliveryDelivered
+				delivered++
+			case update.Attempts >= w.c.MaxAttempts:
+				update.Status = entity.DeliveryFailed
+			default:
+				next := now.Add(webhook.Backoff(update.Attempts, w.c.BackoffBase, w.c.BackoffMax))
+				update.Status = entity.DeliveryPending
+				update.NextAttemptAt = &next
+			}
+		}
+
+		if _, err := w.w.Save(ctx, update); err != nil {
+			return delivered, errors.Wrapf(err, "could not persist delivery of message %s", m.UID)
+		}
+	}
+	return delivered, nil
+}
+
+// hooks returns all webhooks by id.
+func (w *Worker) hooks(ctx context.Context) (map[string]*entity.Webhook, error) {
+	list, err := w.w.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hooks := make(map[string]*entity.Webhook, len(list))
+	for _, h := range list {
+		hooks[h.UID] = h
+	}
+	return hooks, nil
+}
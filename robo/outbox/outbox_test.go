@@ -0,0 +1,242 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/webhook"
+	"github.com/stretchr/testify/require"
+)
+
+// stubWebhooks is an in-memory entity.WebhookRepository.
+type stubWebhooks struct {
+	hooks    []*entity.Webhook
+	events   []*entity.OutboxEvent
+	messages []*entity.WebhookMessage
+	nextID   int
+}
+
+func (r *stubWebhooks) List(ctx context.Context) ([]*entity.Webhook, error) {
+	return r.hooks, nil
+}
+
+func (r *stubWebhooks) Get(ctx context.Context, webhookID string) (*entity.Webhook, error) {
+	for _, h := range r.hooks {
+		if h.UID == webhookID {
+			return h, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *stubWebhooks) Update(ctx context.Context, w *entity.Webhook) error {
+	return nil
+}
+
+func (r *stubWebhooks) Messages(ctx context.Context, a entity.ListWebhookMessagesArgs) ([]*entity.WebhookMessage, error) {
+	return r.messages, nil
+}
+
+func (r *stubWebhooks) Outbox(ctx context.Context, limit int) ([]*entity.OutboxEvent, error) {
+	var pending []*entity.OutboxEvent
+	for _, e := range r.events {
+		if e.Status == entity.DeliveryPending {
+			pending = append(pending, e)
+		}
+	}
+	return pending, nil
+}
+
+func (r *stubWebhooks) Due(ctx context.Context, now time.Time, limit int) ([]*entity.WebhookMessage, error) {
+	var due []*entity.WebhookMessage
+	for _, m := range r.messages {
+		if m.Status == entity.DeliveryPending && !m.NextAttemptAt.After(now) {
+			due = append(due, m)
+		}
+	}
+	return due, nil
+}
+
+// Save merges updates into existing events and messages, and stores
+// new messages, like Dgraph would.
+func (r *stubWebhooks) Save(ctx context.Context, o interface{}) (map[string]string, error) {
+	switch u := o.(type) {
+	case *entity.OutboxEvent:
+		for _, e := range r.events {
+			if e.UID == u.UID {
+				e.Status = u.Status
+				for _, m := range u.Messages {
+					r.nextID++
+					m.UID = "0xm" + strconv.Itoa(r.nextID)
+					r.messages = append(r.messages, m)
+				}
+			}
+		}
+	case *entity.WebhookMessage:
+		for _, m := range r.messages {
+			if m.UID == u.UID {
+				m.Status = u.Status
+				m.Attempts = u.Attempts
+				if u.NextAttemptAt != nil {
+					m.NextAttemptAt = u.NextAttemptAt
+				}
+				m.Deliveries = append(m.Deliveries, u.Deliveries...)
+			}
+		}
+	}
+	return map[string]string{}, nil
+}
+
+// stubRobots is an entity.RobotService that never finds offline
+// robots.
+type stubRobots struct {
+	entity.RobotService
+	ticks int
+}
+
+func (r *stubRobots) DetectOffline(ctx context.Context, now time.Time) (int, error) {
+	r.ticks++
+	return 0, nil
+}
+
+var now = time.Date(2020, 2, 16, 12, 0, 0, 0, time.UTC)
+
+func event(id string, t entity.EventType) *entity.OutboxEvent {
+	e := entity.NewOutboxEvent(t, &entity.CleaningSession{LastX: 250, LastY: 750}, now)
+	e.UID = id
+	e.SessionID = "s1"
+	e.RobotID = "r1"
+	e.AreaID = "a1"
+	return e
+}
+
+func hook(id, url string, types ...entity.EventType) *entity.Webhook {
+	h := entity.NewWebhook(id, url, "s3cr3t", types)
+	h.UID = id
+	return h
+}
+
+func TestDispatch(t *testing.T) {
+	hooks := &stubWebhooks{
+		hooks: []*entity.Webhook{
+			hook("w1", "", entity.EventSessionStarted, entity.EventSessionEnded),
+			hook("w2", "", entity.EventSessionEnded, entity.EventAreaCleaned),
+		},
+		events: []*entity.OutboxEvent{
+			event("e1", entity.EventSessionStarted),
+			event("e2", entity.EventSessionEnded),
+			event("e3", entity.EventRobotOffline),
+		},
+	}
+	w := NewWorker(hooks, &stubRobots{}, DefaultConfig())
+
+	n, err := w.Dispatch(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	var sent []string
+	for _, m := range hooks.messages {
+		sent = append(sent, m.WebhookID+":"+string(m.EventType))
+		require.Equal(t, entity.DeliveryPending, m.Status)
+	}
+	require.Equal(t, []string{"w1:session.started", "w1:session.ended", "w2:session.ended"}, sent)
+	for _, e := range hooks.events {
+		require.Equal(t, entity.DeliveryDelivered, e.Status, "should dispatch events without subscribers too")
+	}
+
+	var p Payload
+	require.NoError(t, json.Unmarshal([]byte(hooks.messages[1].Payload), &p))
+	require.Equal(t, Payload{
+		EventID:   "e2",
+		Type:      entity.EventSessionEnded,
+		RobotID:   "r1",
+		AreaID:    "a1",
+		SessionID: "s1",
+		X:         250,
+		Y:         750,
+		At:        p.At,
+	}, p)
+	require.True(t, now.Equal(*p.At))
+	require.Equal(t, hooks.messages[1].Payload, hooks.messages[2].Payload, "should send the same payload to every webhook")
+
+	n, err = w.Dispatch(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 0, n, "should dispatch each event once")
+}
+
+func TestDeliver(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	fail := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		body, _ := ioutil.ReadAll(r.Body)
+		if !webhook.Verify("s3cr3t", body, r.Header.Get(webhook.SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if fail {
+			fail = false
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		got = append(got, r.Header.Get(webhook.EventHeader))
+	}))
+	defer srv.Close()
+
+	hooks := &stubWebhooks{
+		hooks:  []*entity.Webhook{hook("w1", srv.URL, entity.EventSessionEnded)},
+		events: []*entity.OutboxEvent{event("e1", entity.EventSessionEnded)},
+	}
+	robots := &stubRobots{}
+	c := DefaultConfig()
+	c.MaxAttempts = 2
+	w := NewWorker(hooks, robots, c)
+	ctx := context.Background()
+
+	require.NoError(t, w.Tick(ctx, now))
+	require.Equal(t, 1, robots.ticks, "should look for offline robots")
+	require.Equal(t, 1, len(hooks.messages))
+	m := hooks.messages[0]
+	require.Equal(t, entity.DeliveryPending, m.Status, "should retry after a failed attempt")
+	require.Equal(t, 1, m.Attempts)
+	require.Equal(t, now.Add(c.BackoffBase), *m.NextAttemptAt, "should back off")
+	require.Equal(t, http.StatusServiceUnavailable, m.Deliveries[0].StatusCode, "should log the attempt")
+
+	n, err := w.Deliver(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, 0, n, "should wait for the backoff")
+
+	n, err = w.Deliver(ctx, now.Add(c.BackoffBase))
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, entity.DeliveryDelivered, m.Status)
+	require.Equal(t, 2, len(m.Deliveries), "should log every attempt")
+	require.Equal(t, []string{"session.ended"}, got, "should deliver a signed request")
+
+	// Give up after max attempts.
+	srv.Close()
+	hooks.events = []*entity.OutboxEvent{event("e2", entity.EventSessionEnded)}
+	require.NoError(t, w.Tick(ctx, now))
+	require.NoError(t, w.Tick(ctx, now.Add(time.Hour)))
+	require.Equal(t, entity.DeliveryFailed, hooks.messages[1].Status, "should give up after max attempts")
+	require.Equal(t, 2, len(hooks.messages[1].Deliveries))
+
+	// Drop messages to deleted webhooks.
+	hooks.hooks = nil
+	hooks.events = []*entity.OutboxEvent{event("e3", entity.EventSessionEnded)}
+	hooks.messages = append(hooks.messages, entity.NewWebhookMessage(hook("w1", srv.URL), entity.EventSessionEnded, "{}", now))
+	hooks.messages[2].UID = "0xm9"
+	require.NoError(t, w.Tick(ctx, now))
+	require.Equal(t, entity.DeliveryFailed, hooks.messages[2].Status, "should not deliver to deleted webhooks")
+}
@@ -21,6 +21,10 @@ var (
 
 	// ErrNotFound means that a resource could not be found.
 	ErrNotFound = stderr.New("not_found")
+
+	// ErrConflict means that a request conflicts with the current
+	// state of a resource, e.g. deleting a robot that is cleaning.
+	ErrConflict = stderr.New("conflict")
 )
 
 // ErrorResponse is an error response.
@@ -63,6 +67,9 @@ func FromError(err error) (e ErrorResponse, status int) {
 	case ErrNotFound:
 		e.Code = c.Error()
 		status = http.StatusNotFound
+	case ErrConflict:
+		e.Code = c.Error()
+		status = http.StatusConflict
 	default:
 		log.Printf("unhandled error cause '%s'", c.Error())
 	}
@@ -0,0 +1,217 @@
+// Package cron parses standard 5-field cron expressions and finds the
+// next time they trigger.
+//
+// Fields are minute (0-59), hour (0-23), day of month (1-31), month
+// (1-12 or JAN-DEC) and day of week (0-7 or SUN-SAT, both 0 and 7 are
+// Sunday). Each field is a comma separated list of "*", values, ranges
+// ("1-5") and steps ("*/15", "1-30/5"). If both day of month and day
+// of week are restricted, either one matching is enough, like in Vixie
+// cron. The descriptors @yearly, @annually, @monthly, @weekly, @daily,
+// @midnight and @hourly are also supported.
+//
+// Times are matched on the wall clock of the location of the time
+// passed to Next. Times skipped when clocks go forward for daylight
+// saving never trigger.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxYears is how far ahead Next looks before giving up, e.g. for
+// "0 0 30 2 *" which never triggers.
+const maxYears = 5
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	months = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	days   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// field describes the allowed values of a field.
+type field struct {
+	name     string
+	min, max int
+	names    []string // Names for values from min and up.
+}
+
+var fields = []field{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, months},
+	{"day of week", 0, 7, days},
+}
+
+// Schedule is a parsed cron expression. Each field is a bit set of
+// the values it matches.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+// Parse parses a cron expression.
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if d, ok := descriptors[strings.ToLower(expr)]; ok {
+		expr = d
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron expression '%s' should have %d fields, got %d", expr, len(fields), len(parts))
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, f := range fields {
+		b, err := f.parse(parts[i])
+		if err != nil {
+			return nil, err
+		}
+		bits[i] = b
+	}
+
+	s := &Schedule{
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: strings.HasPrefix(parts[2], "*"),
+		dowStar: strings.HasPrefix(parts[4], "*"),
+	}
+	// Sunday is both 0 and 7.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// parse parses a comma separated list of values, ranges and steps.
+func (f field) parse(s string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step in %s field '%s'", f.name, s)
+			}
+			rng, step = part[:i], n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			i := strings.Index(rng, "-")
+			var err error
+			if lo, err = f.value(rng[:i]); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(rng[i+1:]); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range in %s field '%s'", f.name, s)
+			}
+		default:
+			v, err := f.value(rng)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if step == 1 {
+				// A single value, unless it's the start of a step
+				// like "5/15".
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// value parses a single number or name.
+func (f field) value(s string) (int, error) {
+	for i, n := range f.names {
+		if strings.EqualFold(s, n) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s '%s', must be between %d and %d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next returns the first time after t that the schedule triggers, in
+// t's location. Returns the zero time if it never triggers within
+// the next 5 years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	limit := t.Year() + maxYears
+
+wrap:
+	if t.Year() > limit {
+		return time.Time{}
+	}
+	for !has(s.month, int(t.Month())) {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		if t.Year() > limit {
+			return time.Time{}
+		}
+	}
+	for !s.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+	for !has(s.hour, t.Hour()) {
+		prev := t.Day()
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		if t.Day() != prev {
+			goto wrap
+		}
+	}
+	for !has(s.minute, t.Minute()) {
+		prev := t.Hour()
+		t = t.Add(time.Minute)
+		if t.Hour() != prev {
+			goto wrap
+		}
+	}
+	return t
+}
+
+// dayMatches returns true if the day of month and day of week match.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := has(s.dom, t.Day())
+	dow := has(s.dow, int(t.Weekday()))
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func has(bits uint64, v int) bool {
+	return bits&(1<<uint(v)) != 0
+}
@@ -0,0 +1,82 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for _, expr := range []string{
+		"* * * * *",
+		"*/15 8-17 * * MON-FRI",
+		"0 9,12,18 1 jan,jul *",
+		"5/10 * * * 7",
+		"@daily",
+	} {
+		_, err := Parse(expr)
+		require.NoError(t, err, expr)
+	}
+
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"* * * foo *",
+		"@often",
+	} {
+		_, err := Parse(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestNext(t *testing.T) {
+	stockholm, err := time.LoadLocation("Europe/Stockholm")
+	require.NoError(t, err)
+
+	at := func(s string) time.Time {
+		tm, err := time.ParseInLocation("2006-01-02 15:04", s, stockholm)
+		require.NoError(t, err)
+		return tm
+	}
+
+	cases := []struct {
+		expr, from, want string
+	}{
+		{"* * * * *", "2020-02-16 12:00", "2020-02-16 12:01"},
+		{"*/15 * * * *", "2020-02-16 12:07", "2020-02-16 12:15"},
+		{"0 9 * * *", "2020-02-16 09:00", "2020-02-17 09:00"},
+		{"30 8 * * MON-FRI", "2020-02-14 09:00", "2020-02-17 08:30"}, // Friday to Monday.
+		{"0 0 1 * *", "2020-02-16 12:00", "2020-03-01 00:00"},
+		{"0 0 29 2 *", "2020-03-01 00:00", "2024-02-29 00:00"},
+		{"0 12 13 * 5", "2020-02-16 12:00", "2020-02-21 12:00"}, // Day of month or Friday.
+		{"0 0 * * 7", "2020-02-16 12:00", "2020-02-23 00:00"},   // Sunday as 7.
+		{"@hourly", "2020-12-31 23:30", "2021-01-01 00:00"},
+		// Clocks go forward from 02:00 to 03:00 on 29 March 2020.
+		{"30 2 * * *", "2020-03-28 12:00", "2020-03-30 02:30"},
+		{"0 3 * * *", "2020-03-28 12:00", "2020-03-29 03:00"},
+	}
+	for _, c := range cases {
+		s, err := Parse(c.expr)
+		require.NoError(t, err, c.expr)
+		require.Equal(t, at(c.want).String(), s.Next(at(c.from)).String(), c.expr)
+	}
+
+	s, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	require.True(t, s.Next(at("2020-02-16 12:00")).IsZero(), "should never trigger")
+
+	// The same schedule triggers at different UTC times across zones.
+	s, err = Parse("0 9 * * *")
+	require.NoError(t, err)
+	from := time.Date(2020, 2, 16, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Date(2020, 2, 16, 8, 0, 0, 0, time.UTC), s.Next(from.In(stockholm)).UTC())
+	require.Equal(t, time.Date(2020, 2, 16, 9, 0, 0, 0, time.UTC), s.Next(from).UTC())
+}
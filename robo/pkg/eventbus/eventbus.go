@@ -0,0 +1,178 @@
+// Package eventbus is an in-process pub/sub for session events,
+// used to stream events to API clients.
+package eventbus
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+)
+
+var _ entity.EventBus = &Bus{}
+
+const (
+	// DefaultBuffer is the number of events buffered per subscriber.
+	DefaultBuffer = 256
+	// DefaultHistory is the number of recent events kept around for
+	// subscribers resuming from a previous event.
+	DefaultHistory = 4096
+)
+
+// Bus implements entity.EventBus. Publishing never blocks: a
+// subscriber that can't keep up is dropped, i.e. its subscription
+// is closed.
+type Bus struct {
+	mu     sync.Mutex
+	epoch  int64 // When the bus was created, telling event IDs apart across restarts.
+	nextID uint64
+	subs   map[*Subscription]struct{}
+	buffer int
+
+	// Ring buffer holding the most recent events.
+	history []*entity.Event
+	next    int // Index of the next event to write in history.
+}
+
+// New creates a new Bus instance.
+func New() *Bus {
+	return &Bus{
+		epoch:   time.Now().UnixNano(),
+		subs:    make(map[*Subscription]struct{}),
+		buffer:  DefaultBuffer,
+		history: make([]*entity.Event, DefaultHistory),
+	}
+}
+
+// Publish assigns the event an id and delivers it to all matching
+// subscribers.
+func (b *Bus) Publish(e *entity.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	e.ID = b.nextID
+	e.Epoch = b.epoch
+
+	b.history[b.next] = e
+	b.next = (b.next + 1) % len(b.history)
+
+	for s := range b.subs {
+		if !s.match(e) {
+			continue
+		}
+		select {
+		case s.c <- e:
+		default:
+			log.Printf("dropping slow event subscriber after %d buffered events", b.buffer)
+			b.remove(s)
+		}
+	}
+}
+
+// Subscribe returns a new subscription receiving events matching
+// the given filter.
+func (b *Bus) Subscribe(f entity.EventFilter) entity.EventSubscription {
+	s := &Subscription{
+		b:      b,
+		c:      make(chan *entity.Event, b.buffer),
+		filter: f,
+	}
+
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+
+	return s
+}
+
+// SubscribeAfter returns a new subscription receiving events matching
+// the given filter, starting with any remembered events published
+// after lastEventID. All remembered events are replayed if
+// lastEventID is from another epoch. Returns false if events
+// published after lastEventID may have been missed, either because
+// the bus has restarted or has forgotten them.
+func (b *Bus) SubscribeAfter(f entity.EventFilter, epoch int64, lastEventID uint64) (entity.EventSubscription, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	resumed := epoch == b.epoch
+	if !resumed {
+		lastEventID = 0
+	}
+	if oldest := b.history[b.next]; oldest != nil && oldest.ID > lastEventID+1 {
+		// The ring buffer is full and has forgotten events we need.
+		resumed = false
+	}
+
+	// Collect remembered events in the order they were published.
+	var replay []*entity.Event
+	for i := 0; i < len(b.history); i++ {
+		e := b.history[(b.next+i)%len(b.history)]
+		if e != nil && e.ID > lastEventID && f.Match(e) {
+			replay = append(replay, e)
+		}
+	}
+
+	s := &Subscription{
+		b:      b,
+		c:      make(chan *entity.Event, b.buffer+len(replay)),
+		filter: f,
+	}
+	for _, e := range replay {
+		s.c <- e
+	}
+	b.subs[s] = struct{}{}
+
+	return s, resumed
+}
+
+// Subscribers returns the current number of subscribers.
+func (b *Bus) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// remove closes and removes a subscription. Caller must hold b.mu.
+func (b *Bus) remove(s *Subscription) {
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.c)
+	}
+}
+
+// Subscription implements entity.EventSubscription.
+type Subscription struct {
+	b      *Bus
+	c      chan *entity.Event
+	fmu    sync.RWMutex
+	filter entity.EventFilter
+}
+
+// Events returns the channel events are delivered on.
+func (s *Subscription) Events() <-chan *entity.Event {
+	return s.c
+}
+
+// SetFilter replaces the subscription's filter.
+func (s *Subscription) SetFilter(f entity.EventFilter) {
+	s.fmu.Lock()
+	s.filter = f
+	s.fmu.Unlock()
+}
+
+// Close unsubscribes from the bus. It's safe to call Close more
+// than once.
+func (s *Subscription) Close() {
+	s.b.mu.Lock()
+	s.b.remove(s)
+	s.b.mu.Unlock()
+}
+
+func (s *Subscription) match(e *entity.Event) bool {
+	s.fmu.RLock()
+	defer s.fmu.RUnlock()
+	return s.filter.Match(e)
+}
@@ -0,0 +1,103 @@
+package eventbus
+
+import (
+	"testing"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventBus(t *testing.T) {
+	b := New()
+
+	all := b.Subscribe(entity.EventFilter{})
+	robo1 := b.Subscribe(entity.EventFilter{RobotIDs: []string{"0x1"}})
+	area2 := b.Subscribe(entity.EventFilter{AreaIDs: []string{"0x2"}})
+
+	b.Publish(&entity.Event{Type: entity.EventPosition, RobotID: "0x1", AreaID: "0x3"})
+	b.Publish(&entity.Event{Type: entity.EventPosition, RobotID: "0x4", AreaID: "0x2"})
+
+	require.Equal(t, 2, len(all.Events()), "should receive all events")
+	require.Equal(t, 1, len(robo1.Events()), "should receive robot 0x1 events")
+	require.Equal(t, 1, len(area2.Events()), "should receive area 0x2 events")
+
+	e := <-robo1.Events()
+	require.Equal(t, uint64(1), e.ID, "should assign ids in order")
+	e = <-area2.Events()
+	require.Equal(t, uint64(2), e.ID, "should assign ids in order")
+
+	// Change filter.
+	robo1.SetFilter(entity.EventFilter{RobotIDs: []string{"0x4"}})
+	b.Publish(&entity.Event{Type: entity.EventPosition, RobotID: "0x4"})
+	e = <-robo1.Events()
+	require.Equal(t, "0x4", e.RobotID, "should receive events matching new filter")
+
+	// Close subscription.
+	robo1.Close()
+	robo1.Close()
+	_, ok := <-robo1.Events()
+	require.False(t, ok, "should close channel")
+}
+
+func TestEventBusDropsSlowSubscribers(t *testing.T) {
+	b := New()
+
+	slow := b.Subscribe(entity.EventFilter{})
+	for i := 0; i < DefaultBuffer+1; i++ {
+		b.Publish(&entity.Event{Type: entity.EventPosition, RobotID: "0x1"})
+	}
+
+	var received int
+	for range slow.Events() {
+		received++
+	}
+	require.Equal(t, DefaultBuffer, received, "should drop subscriber once its buffer is full")
+	require.Empty(t, b.subs, "should remove dropped subscriber")
+}
+
+func TestEventBusResume(t *testing.T) {
+	b := New()
+
+	for i := 0; i < DefaultHistory+10; i++ {
+		robotID := "0x1"
+		if i%2 == 1 {
+			robotID = "0x2"
+		}
+		b.Publish(&entity.Event{Type: entity.EventPosition, RobotID: robotID})
+	}
+
+	// Resume from a recent event.
+	s, resumed := b.SubscribeAfter(entity.EventFilter{RobotIDs: []string{"0x1"}}, b.epoch, uint64(DefaultHistory))
+	require.True(t, resumed)
+	require.Equal(t, 5, len(s.Events()), "should replay remembered events after the last event id")
+	e := <-s.Events()
+	require.Equal(t, uint64(DefaultHistory+1), e.ID, "should replay in order")
+
+	// Resume from an event that has been forgotten.
+	s, resumed = b.SubscribeAfter(entity.EventFilter{}, b.epoch, 1)
+	require.False(t, resumed, "should have missed forgotten events")
+	require.Equal(t, DefaultHistory, len(s.Events()), "should replay as far back as remembered")
+	e = <-s.Events()
+	require.Equal(t, uint64(11), e.ID, "should start with the oldest remembered event")
+
+	// Continue with live events after replay.
+	b.Publish(&entity.Event{Type: entity.EventSessionEnded, RobotID: "0x1"})
+	require.Equal(t, DefaultHistory, len(s.Events()))
+}
+
+func TestEventBusResumeAfterRestart(t *testing.T) {
+	b := New()
+
+	for i := 0; i < 3; i++ {
+		b.Publish(&entity.Event{Type: entity.EventPosition, RobotID: "0x1"})
+	}
+
+	// Resume from an event published before the bus restarted, which
+	// happens to have an id that has been reused since.
+	s, resumed := b.SubscribeAfter(entity.EventFilter{}, b.epoch-1, 2)
+	require.False(t, resumed, "should have missed events from before the restart")
+	require.Equal(t, 3, len(s.Events()), "should replay all remembered events")
+	e := <-s.Events()
+	require.Equal(t, uint64(1), e.ID)
+	require.Equal(t, b.epoch, e.Epoch, "should assign the bus epoch")
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/anrid/roboviewer/robo/pkg/cerr"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/pkg/errors"
 	"gopkg.in/go-playground/validator.v9"
 )
 
@@ -69,12 +70,17 @@ func Fail(c echo.Context, err error) error {
 }
 
 // Bind binds the incoming request body to a struct and validates.
+// Any error returned is wrapped as a validation error.
 func Bind(c echo.Context, payload interface{}) (err error) {
 	err = c.Bind(payload)
 	if err != nil {
-		return
+		return errors.Wrap(cerr.ErrValidationFailed, err.Error())
 	}
-	return c.Validate(payload)
+	err = c.Validate(payload)
+	if err != nil {
+		return errors.Wrap(cerr.ErrValidationFailed, err.Error())
+	}
+	return nil
 }
 
 func rootHandler(c echo.Context) error {
@@ -0,0 +1,33 @@
+package mqtt
+
+import (
+	"encoding/json"
+
+	"github.com/anrid/roboviewer/robo/entity"
+)
+
+var _ entity.CommandPublisher = &CommandPublisher{}
+
+// CommandPublisher implements entity.CommandPublisher. Commands are
+// published as JSON, each robot on its own topic "<topic>/<robotID>".
+type CommandPublisher struct {
+	c     *Client
+	topic string
+}
+
+// NewCommandPublisher creates a new CommandPublisher instance.
+func NewCommandPublisher(c *Client, topic string) *CommandPublisher {
+	return &CommandPublisher{c, topic}
+}
+
+// PublishCommand publishes a command to its robot. Commands are sent
+// at least once.
+func (p *CommandPublisher) PublishCommand(cmd *entity.Command) error {
+	b, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	token := p.c.c.Publish(p.topic+"/"+cmd.RobotID, 1, false, b)
+	token.Wait()
+	return token.Error()
+}
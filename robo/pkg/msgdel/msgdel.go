@@ -30,9 +30,9 @@ func NewMessageDelegator(svc entity.RobotService) *MessageDelegator {
 func (md *MessageDelegator) HandleStartSession(c mqtt.Client, m mqtt.Message) {
 	msg := string(m.Payload())
 
-	parts := strings.SplitN(msg, "/", 5)
-	if len(parts) != 5 {
-		log.Printf("invalid message '%s', should contain 'robotID/areaID/robotX/robotY/unixTimestamp'", msg)
+	parts := strings.SplitN(msg, "/", 7)
+	if len(parts) < 5 {
+		log.Printf("invalid message '%s', should contain 'robotID/areaID/robotX/robotY/unixTimestamp[/passesNeeded[/resume|sessionID]]'", msg)
 		return
 	}
 
@@ -52,14 +52,38 @@ func (md *MessageDelegator) HandleStartSession(c mqtt.Client, m mqtt.Message) {
 		log.Printf("invalid timestamp coordinate in message: '%s'", msg)
 	}
 
+	var passes int
+	if len(parts) >= 6 && parts[5] != "" {
+		// Passes needed as given in a start command.
+		passes, err = strconv.Atoi(parts[5])
+		if err != nil {
+			log.Printf("invalid passes needed in message: '%s'", msg)
+		}
+	}
+
+	// Resume the latest session, or a given session, e.g. after the
+	// robot rebooted.
+	var resume bool
+	var sessionID string
+	if len(parts) == 7 {
+		if parts[6] == "resume" {
+			resume = true
+		} else {
+			sessionID = parts[6]
+		}
+	}
+
 	startedAt := time.Unix(ts, 0)
 
 	sess, err := md.svc.StartSession(context.Background(), entity.StartSessionArgs{
-		RobotID:   robotID,
-		AreaID:    areaID,
-		RobotX:    x,
-		RobotY:    y,
-		StartedAt: startedAt,
+		RobotID:      robotID,
+		AreaID:       areaID,
+		RobotX:       x,
+		RobotY:       y,
+		StartedAt:    startedAt,
+		PassesNeeded: passes,
+		Resume:       resume,
+		SessionID:    sessionID,
 	})
 	if err != nil {
 		log.Printf("could not start session: %s", err.Error())
@@ -74,9 +98,9 @@ func (md *MessageDelegator) HandleStartSession(c mqtt.Client, m mqtt.Message) {
 func (md *MessageDelegator) HandleUpdateSession(c mqtt.Client, m mqtt.Message) {
 	msg := string(m.Payload())
 
-	parts := strings.SplitN(msg, "/", 4)
-	if len(parts) != 4 {
-		log.Printf("invalid message '%s', should contain 'robotID/robotX/robotY/unixTimestamp'", msg)
+	parts := strings.SplitN(msg, "/", 5)
+	if len(parts) < 4 {
+		log.Printf("invalid message '%s', should contain 'robotID/robotX/robotY/unixTimestamp[/telemetry]'", msg)
 		return
 	}
 
@@ -97,11 +121,17 @@ func (md *MessageDelegator) HandleUpdateSession(c mqtt.Client, m mqtt.Message) {
 
 	startedAt := time.Unix(ts, 0)
 
+	var t *entity.Telemetry
+	if len(parts) == 5 {
+		t = parseTelemetry(parts[4], msg)
+	}
+
 	sess, err := md.svc.UpdateSession(context.Background(), entity.UpdateSessionArgs{
 		RobotID:    robotID,
 		RobotX:     x,
 		RobotY:     y,
 		ReportedAt: startedAt,
+		Telemetry:  t,
 	})
 	if err != nil {
 		log.Printf("could not update session: %s", err.Error())
@@ -116,9 +146,9 @@ func (md *MessageDelegator) HandleUpdateSession(c mqtt.Client, m mqtt.Message) {
 func (md *MessageDelegator) HandleEndSession(c mqtt.Client, m mqtt.Message) {
 	msg := string(m.Payload())
 
-	parts := strings.SplitN(msg, "/", 4)
-	if len(parts) != 4 {
-		log.Printf("invalid message '%s', should contain 'robotID/robotX/robotY/unixTimestamp'", msg)
+	parts := strings.SplitN(msg, "/", 5)
+	if len(parts) < 4 {
+		log.Printf("invalid message '%s', should contain 'robotID/robotX/robotY/unixTimestamp[/telemetry]'", msg)
 		return
 	}
 
@@ -139,11 +169,17 @@ func (md *MessageDelegator) HandleEndSession(c mqtt.Client, m mqtt.Message) {
 
 	startedAt := time.Unix(ts, 0)
 
+	var t *entity.Telemetry
+	if len(parts) == 5 {
+		t = parseTelemetry(parts[4], msg)
+	}
+
 	sess, err := md.svc.EndSession(context.Background(), entity.UpdateSessionArgs{
 		RobotID:    robotID,
 		RobotX:     x,
 		RobotY:     y,
 		ReportedAt: startedAt,
+		Telemetry:  t,
 	})
 	if err != nil {
 		log.Printf("could not end session: %s", err.Error())
@@ -152,3 +188,125 @@ func (md *MessageDelegator) HandleEndSession(c mqtt.Client, m mqtt.Message) {
 
 	log.Printf("ended cleaning session: %s %s", sess.UID, sess.Name)
 }
+
+// HandlePauseSession handles incoming messages from robots that have
+// docked mid-session, e.g. to recharge.
+func (md *MessageDelegator) HandlePauseSession(c mqtt.Client, m mqtt.Message) {
+	msg := string(m.Payload())
+
+	parts := strings.SplitN(msg, "/", 3)
+	if len(parts) < 2 {
+		log.Printf("invalid message '%s', should contain 'robotID/unixTimestamp[/dockID]'", msg)
+		return
+	}
+
+	robotID := parts[0]
+
+	ts, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		log.Printf("invalid timestamp coordinate in message: '%s'", msg)
+	}
+
+	var dockID string
+	if len(parts) == 3 {
+		dockID = parts[2]
+	}
+
+	sess, err := md.svc.PauseSession(context.Background(), entity.PauseSessionArgs{
+		RobotID:  robotID,
+		DockID:   dockID,
+		PausedAt: time.Unix(ts, 0),
+	})
+	if err != nil {
+		log.Printf("could not pause session: %s", err.Error())
+		return
+	}
+
+	log.Printf("paused cleaning session: %s %s", sess.UID, sess.Name)
+}
+
+// HandleResumeSession handles incoming messages from robots that have
+// left their dock and carry on cleaning.
+func (md *MessageDelegator) HandleResumeSession(c mqtt.Client, m mqtt.Message) {
+	msg := string(m.Payload())
+
+	parts := strings.SplitN(msg, "/", 4)
+	if len(parts) != 4 {
+		log.Printf("invalid message '%s', should contain 'robotID/robotX/robotY/unixTimestamp'", msg)
+		return
+	}
+
+	robotID := parts[0]
+
+	x, err := strconv.Atoi(parts[1])
+	if err != nil {
+		log.Printf("invalid x coordinate in message: '%s'", msg)
+	}
+	y, err := strconv.Atoi(parts[2])
+	if err != nil {
+		log.Printf("invalid y coordinate in message: '%s'", msg)
+	}
+	ts, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		log.Printf("invalid timestamp coordinate in message: '%s'", msg)
+	}
+
+	sess, err := md.svc.ResumeSession(context.Background(), entity.UpdateSessionArgs{
+		RobotID:    robotID,
+		RobotX:     x,
+		RobotY:     y,
+		ReportedAt: time.Unix(ts, 0),
+	})
+	if err != nil {
+		log.Printf("could not resume session: %s", err.Error())
+		return
+	}
+
+	log.Printf("resumed cleaning session: %s %s", sess.UID, sess.Name)
+}
+
+// parseTelemetry parses telemetry given as 'key=value' pairs separated
+// by ';', e.g. 'battery=80;state=error;errors=E12,E31;brush=120;filter=40'.
+// Unknown keys and invalid values are logged and skipped.
+func parseTelemetry(s, msg string) *entity.Telemetry {
+	t := &entity.Telemetry{}
+	for _, kv := range strings.Split(s, ";") {
+		if kv == "" {
+			continue
+		}
+		pair := strings.SplitN(kv, "=", 2)
+		if len(pair) != 2 {
+			log.Printf("invalid telemetry '%s' in message: '%s'", kv, msg)
+			continue
+		}
+		key, val := pair[0], pair[1]
+
+		switch key {
+		case "battery", "brush", "filter":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				log.Printf("invalid %s in message: '%s'", key, msg)
+				continue
+			}
+			switch key {
+			case "battery":
+				t.Battery = &n
+			case "brush":
+				t.BrushWear = &n
+			case "filter":
+				t.FilterWear = &n
+			}
+		case "state":
+			t.State = entity.RobotState(val)
+		case "errors":
+			for _, code := range strings.Split(val, ",") {
+				if code != "" {
+					t.ErrorCodes = append(t.ErrorCodes, code)
+				}
+			}
+		default:
+			log.Printf("unknown telemetry '%s' in message: '%s'", key, msg)
+		}
+	}
+	return t
+}
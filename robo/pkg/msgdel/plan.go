@@ -0,0 +1,77 @@
+package msgdel
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// PlanDelegator takes coverage plan requests from robots and sends the
+// plan for their active cleaning session back over the command channel.
+type PlanDelegator struct {
+	svc entity.SessionService
+	p   entity.CommandPublisher
+}
+
+// NewPlanDelegator creates a new PlanDelegator instance.
+func NewPlanDelegator(svc entity.SessionService, p entity.CommandPublisher) *PlanDelegator {
+	return &PlanDelegator{svc, p}
+}
+
+// HandlePlanRequest handles incoming coverage plan requests from
+// robots. By default the plan resumes the robot's active session,
+// unless the robot asks for a full plan.
+func (pd *PlanDelegator) HandlePlanRequest(c mqtt.Client, m mqtt.Message) {
+	msg := string(m.Payload())
+
+	parts := strings.SplitN(msg, "/", 2)
+	if parts[0] == "" || (len(parts) == 2 && parts[1] != "full" && parts[1] != "resume") {
+		log.Printf("invalid message '%s', should contain 'robotID[/full|resume]'", msg)
+		return
+	}
+
+	robotID := parts[0]
+	full := len(parts) == 2 && parts[1] == "full"
+
+	ctx := context.Background()
+
+	active := true
+	res, err := pd.svc.List(ctx, entity.ListSessionsArgs{
+		RobotID: robotID,
+		Active:  &active,
+		Limit:   1,
+	})
+	if err != nil {
+		log.Printf("could not find active session for robot %s: %s", robotID, err.Error())
+		return
+	}
+	if len(res.Sessions) == 0 {
+		log.Printf("could not plan for robot %s: no active session", robotID)
+		return
+	}
+	sessionID := res.Sessions[0].Session.UID
+
+	p, err := pd.svc.Plan(ctx, entity.PlanArgs{SessionID: sessionID, Full: full})
+	if err != nil {
+		log.Printf("could not plan session %s: %s", sessionID, err.Error())
+		return
+	}
+
+	err = pd.p.PublishCommand(&entity.Command{
+		Type:      entity.CommandFollowPlan,
+		RobotID:   robotID,
+		SessionID: sessionID,
+		Plan:      p,
+		IssuedAt:  time.Now(),
+	})
+	if err != nil {
+		log.Printf("could not send plan to robot %s: %s", robotID, err.Error())
+		return
+	}
+
+	log.Printf("sent %s plan with %d waypoints to robot %s", p.Kind, len(p.Waypoints), robotID)
+}
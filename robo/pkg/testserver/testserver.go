@@ -7,6 +7,7 @@ import (
 	"github.com/anrid/roboviewer/robo/config"
 	"github.com/anrid/roboviewer/robo/dg"
 	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/eventbus"
 	"github.com/anrid/roboviewer/robo/pkg/httpserver"
 	"github.com/anrid/roboviewer/robo/service"
 )
@@ -19,13 +20,30 @@ var (
 // TS is a test server.
 type TS struct {
 	Server     *httpserver.Server
+	EventBus   entity.EventBus
+	Commands   *CommandRecorder
 	Repository struct {
-		Robot entity.RobotRepository
-		Area  entity.AreaRepository
+		Robot     entity.RobotRepository
+		Area      entity.AreaRepository
+		Session   entity.SessionRepository
+		Report    entity.ReportRepository
+		Alert     entity.AlertRepository
+		Webhook   entity.WebhookRepository
+		Schedule  entity.ScheduleRepository
+		Partition entity.PartitionRepository
+		Dock      entity.DockRepository
 	}
 	Service struct {
-		Robot entity.RobotService
-		Area  entity.AreaService
+		Robot     entity.RobotService
+		Area      entity.AreaService
+		Session   entity.SessionService
+		Report    entity.ReportService
+		Export    entity.ExportService
+		Alert     entity.AlertService
+		Webhook   entity.WebhookService
+		Schedule  entity.ScheduleService
+		Partition entity.PartitionService
+		Dock      entity.DockService
 	}
 }
 
@@ -35,7 +53,9 @@ func Get() *TS {
 		c := config.GetConfig()
 
 		ts = &TS{
-			Server: httpserver.NewServer(),
+			Server:   httpserver.NewServer(),
+			EventBus: eventbus.New(),
+			Commands: &CommandRecorder{},
 		}
 
 		conn, _ := dg.Connect(c.DgraphURL)
@@ -44,9 +64,46 @@ func Get() *TS {
 
 		ts.Repository.Robot = dg.NewRobotRepository(conn)
 		ts.Repository.Area = dg.NewAreaRepository(conn)
+		ts.Repository.Session = dg.NewSessionRepository(conn)
+		ts.Repository.Report = dg.NewReportRepository(conn)
+		ts.Repository.Alert = dg.NewAlertRepository(conn)
+		ts.Repository.Webhook = dg.NewWebhookRepository(conn)
+		ts.Repository.Schedule = dg.NewScheduleRepository(conn)
+		ts.Repository.Partition = dg.NewPartitionRepository(conn)
+		ts.Repository.Dock = dg.NewDockRepository(conn)
 
-		ts.Service.Robot = service.NewRobotService(ts.Repository.Robot)
+		ts.Service.Robot = service.NewRobotService(ts.Repository.Robot, ts.Repository.Session, ts.Repository.Dock, ts.EventBus)
 		ts.Service.Area = service.NewAreaService(ts.Repository.Area)
+		ts.Service.Session = service.NewSessionService(ts.Repository.Session)
+		ts.Service.Report = service.NewReportService(ts.Repository.Report)
+		ts.Service.Export = service.NewExportService(ts.Repository.Session)
+		ts.Service.Alert = service.NewAlertService(ts.Repository.Alert)
+		ts.Service.Webhook = service.NewWebhookService(ts.Repository.Webhook)
+		ts.Service.Schedule = service.NewScheduleService(ts.Repository.Schedule, ts.Repository.Robot, ts.Repository.Area)
+		ts.Service.Partition = service.NewPartitionService(ts.Repository.Partition, ts.Repository.Area, ts.Repository.Robot, ts.Repository.Session, ts.Commands)
+		ts.Service.Dock = service.NewDockService(ts.Repository.Dock, ts.Repository.Area)
 	})
 	return ts
 }
+
+// CommandRecorder is an entity.CommandPublisher that records commands
+// instead of sending them to robots.
+type CommandRecorder struct {
+	mu       sync.Mutex
+	commands []*entity.Command
+}
+
+// PublishCommand records a command.
+func (r *CommandRecorder) PublishCommand(c *entity.Command) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands = append(r.commands, c)
+	return nil
+}
+
+// Commands returns all commands recorded so far.
+func (r *CommandRecorder) Commands() []*entity.Command {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*entity.Command{}, r.commands...)
+}
@@ -0,0 +1,108 @@
+// Package webhook sends signed JSON payloads to HTTP endpoints.
+//
+// Every request carries an HMAC-SHA256 signature of the body in the
+// X-Roboviewer-Signature header, formatted as "sha256=<hex>", so that
+// receivers can verify it was sent by us using the shared secret.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const (
+	// SignatureHeader holds the HMAC signature of the body.
+	SignatureHeader = "X-Roboviewer-Signature"
+	// EventHeader holds the type of event delivered.
+	EventHeader = "X-Roboviewer-Event"
+	// DeliveryHeader holds a unique id for the delivery, the same
+	// across retries.
+	DeliveryHeader = "X-Roboviewer-Delivery"
+	// DefaultTimeout is the default timeout for a single request.
+	DefaultTimeout = 10 * time.Second
+)
+
+// Request is a webhook request.
+type Request struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID string
+	Body       []byte // JSON.
+}
+
+// Sign returns the signature of a body given a secret.
+func Sign(secret string, body []byte) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write(body)
+	return "sha256=" + hex.EncodeToString(m.Sum(nil))
+}
+
+// Verify returns true if the signature matches the body.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// NewSecret returns a new random secret.
+func NewSecret() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Send posts a request and returns the response status code. Returns
+// an error if the request failed or the receiver did not respond
+// with a 2xx status code.
+func Send(ctx context.Context, c *http.Client, r Request) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, r.URL, bytes.NewReader(r.Body))
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Roboviewer-Webhook/1.0")
+	req.Header.Set(SignatureHeader, Sign(r.Secret, r.Body))
+	if r.Event != "" {
+		req.Header.Set(EventHeader, r.Event)
+	}
+	if r.DeliveryID != "" {
+		req.Header.Set(DeliveryHeader, r.DeliveryID)
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	// Drain the body so that the connection can be reused.
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, 64*1024))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+// Backoff returns how long to wait before the given retry attempt
+// (starting at 1), doubling from base up to max.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
@@ -0,0 +1,50 @@
+package webhook
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSend(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	fail := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = ioutil.ReadAll(r.Body)
+		if fail {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	req := Request{URL: srv.URL, Secret: "s3cr3t", Event: "test", DeliveryID: "0x1", Body: []byte(`{"hello":"world"}`)}
+
+	status, err := Send(context.Background(), srv.Client(), req)
+	require.Error(t, err, "should fail on non-2xx status")
+	require.Equal(t, http.StatusBadGateway, status)
+
+	fail = false
+	status, err = Send(context.Background(), srv.Client(), req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	require.Equal(t, req.Body, body)
+	require.Equal(t, "test", got.Header.Get(EventHeader))
+	require.Equal(t, "0x1", got.Header.Get(DeliveryHeader))
+	require.True(t, Verify("s3cr3t", body, got.Header.Get(SignatureHeader)), "should sign the body")
+	require.False(t, Verify("wrong", body, got.Header.Get(SignatureHeader)))
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, 30*time.Second, Backoff(1, 30*time.Second, time.Hour))
+	require.Equal(t, 60*time.Second, Backoff(2, 30*time.Second, time.Hour))
+	require.Equal(t, 240*time.Second, Backoff(4, 30*time.Second, time.Hour))
+	require.Equal(t, time.Hour, Backoff(20, 30*time.Second, time.Hour), "should cap at max")
+}
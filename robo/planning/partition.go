@@ -0,0 +1,107 @@
+package planning
+
+// Rect is a rectangular part of an area, in millimeters relative to
+// the area.
+type Rect struct {
+	X     int `json:"x"`
+	Y     int `json:"y"`
+	SizeX int `json:"size_x"`
+	SizeY int `json:"size_y"`
+}
+
+// Partition splits an area into one rectangle per robot, in the order
+// the robot sizes are given, so that every robot gets roughly the same
+// number of grid squares to clean. A robot's grid squares are the size
+// of the robot, so a robot twice the size of another gets an area four
+// times as large.
+//
+// The area is split recursively, each time cutting the longest side in
+// two between two halves of the robots, so regions stay close to
+// square. Cuts are snapped to multiples of the robot size on the near
+// side of the cut, so regions are made up of whole squares wherever
+// possible.
+func Partition(sizeX, sizeY int, robotSizes []int) []Rect {
+	rects := make([]Rect, len(robotSizes))
+	if len(robotSizes) == 0 {
+		return rects
+	}
+	robots := make([]int, len(robotSizes))
+	for i := range robots {
+		robots[i] = i
+	}
+	split(Rect{SizeX: sizeX, SizeY: sizeY}, robots, robotSizes, rects)
+	return rects
+}
+
+// Squares returns the number of grid squares in a rectangle for a robot
+// of the given size.
+func Squares(r Rect, robotSize int) int {
+	if robotSize <= 0 {
+		return 0
+	}
+	return ceilDiv(r.SizeX, robotSize) * ceilDiv(r.SizeY, robotSize)
+}
+
+// split assigns a rectangle to a group of robots, splitting it further
+// if there's more than one.
+func split(r Rect, robots, sizes []int, out []Rect) {
+	if len(robots) == 1 {
+		out[robots[0]] = r
+		return
+	}
+	first, second := robots[:len(robots)/2], robots[len(robots)/2:]
+
+	// Squares are as large as the robot, so the area a robot needs
+	// grows with the square of its size.
+	var w1, w2 int
+	for _, i := range first {
+		w1 += sizes[i] * sizes[i]
+	}
+	for _, i := range second {
+		w2 += sizes[i] * sizes[i]
+	}
+	var snap int
+	for _, i := range first {
+		snap = max(snap, sizes[i])
+	}
+
+	length := r.SizeX
+	if r.SizeY > r.SizeX {
+		length = r.SizeY
+	}
+	cut := length
+	if w1+w2 > 0 {
+		cut = length * w1 / (w1 + w2)
+	}
+	if snap > 0 {
+		cut = (cut + snap/2) / snap * snap
+	}
+	if cut < 1 {
+		cut = 1
+	}
+	if cut > length-1 {
+		cut = length - 1
+	}
+
+	a, b := r, r
+	if r.SizeX >= r.SizeY {
+		a.SizeX = cut
+		b.X, b.SizeX = r.X+cut, r.SizeX-cut
+	} else {
+		a.SizeY = cut
+		b.Y, b.SizeY = r.Y+cut, r.SizeY-cut
+	}
+	split(a, first, sizes, out)
+	split(b, second, sizes, out)
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
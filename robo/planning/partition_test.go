@@ -0,0 +1,65 @@
+package planning
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// covers checks that rectangles tile an area without overlapping.
+func covers(t *testing.T, sizeX, sizeY int, rects []Rect) {
+	var total int
+	for _, r := range rects {
+		require.True(t, r.X >= 0 && r.Y >= 0 && r.X+r.SizeX <= sizeX && r.Y+r.SizeY <= sizeY, "should stay within the area: %+v", r)
+		require.True(t, r.SizeX > 0 && r.SizeY > 0, "should not be empty: %+v", r)
+		total += r.SizeX * r.SizeY
+	}
+	require.Equal(t, sizeX*sizeY, total, "should cover the entire area")
+
+	for i := range rects {
+		for j := i + 1; j < len(rects); j++ {
+			a, b := rects[i], rects[j]
+			overlapX := a.X < b.X+b.SizeX && b.X < a.X+a.SizeX
+			overlapY := a.Y < b.Y+b.SizeY && b.Y < a.Y+a.SizeY
+			require.False(t, overlapX && overlapY, "should not overlap: %+v %+v", a, b)
+		}
+	}
+}
+
+func TestPartition(t *testing.T) {
+	rects := Partition(10000, 6000, []int{500, 500, 500, 500})
+	covers(t, 10000, 6000, rects)
+	require.Equal(t, []Rect{
+		{X: 0, Y: 0, SizeX: 5000, SizeY: 3000},
+		{X: 0, Y: 3000, SizeX: 5000, SizeY: 3000},
+		{X: 5000, Y: 0, SizeX: 5000, SizeY: 3000},
+		{X: 5000, Y: 3000, SizeX: 5000, SizeY: 3000},
+	}, rects, "should split equal robots into equal parts")
+
+	// A robot twice the size should get an area four times as large
+	// and about the same number of squares.
+	sizes := []int{300, 600, 300}
+	rects = Partition(12000, 9000, sizes)
+	covers(t, 12000, 9000, rects)
+	min, max := -1, 0
+	for i, r := range rects {
+		n := Squares(r, sizes[i])
+		if min < 0 || n < min {
+			min = n
+		}
+		if n > max {
+			max = n
+		}
+	}
+	require.True(t, float64(max-min)/float64(max) < 0.1, "should balance squares, got between %d and %d", min, max)
+
+	rects = Partition(7000, 5000, []int{350})
+	require.Equal(t, []Rect{{SizeX: 7000, SizeY: 5000}}, rects)
+
+	require.Equal(t, 0, len(Partition(7000, 5000, nil)))
+}
+
+func TestSquares(t *testing.T) {
+	require.Equal(t, 6, Squares(Rect{SizeX: 1000, SizeY: 500}, 400))
+	require.Equal(t, 0, Squares(Rect{SizeX: 1000, SizeY: 500}, 0))
+}
@@ -0,0 +1,313 @@
+// Package planning generates coverage paths for robots, i.e. the
+// waypoints a robot should follow to clean an area.
+package planning
+
+import (
+	"sort"
+
+	"github.com/anrid/roboviewer/robo/entity"
+)
+
+// cell is a grid square to visit.
+type cell struct {
+	sq        *entity.Square
+	at        *entity.Waypoint // The point within the square we aim for.
+	remaining int              // Passes the square still needs.
+}
+
+// Boustrophedon plans a path that covers an entire area the given
+// number of passes, starting in the top-left corner. The area is split
+// into a grid of squares the size of the robot, the same grid used by
+// cleaning sessions, and the robot sweeps back and forth along its
+// rows. Every pass runs back over the rows in reverse.
+func Boustrophedon(sizeX, sizeY, robotSize, passes int) *entity.Plan {
+	if passes < 1 {
+		passes = 1
+	}
+	p := &entity.Plan{
+		Kind:         entity.PlanFull,
+		SizeX:        sizeX,
+		SizeY:        sizeY,
+		RobotSize:    robotSize,
+		PassesNeeded: passes,
+		Waypoints:    []*entity.Waypoint{},
+	}
+	if sizeX <= 0 || sizeY <= 0 || robotSize <= 0 {
+		return p
+	}
+
+	grid := entity.NewGrid(sizeX, sizeY, robotSize)
+	rows := toRows(grid, sizeX, sizeY, func(*entity.Square) int { return passes })
+	p.Waypoints, p.Squares = route(rows, sizeX, sizeY, nil)
+	p.DistanceMM = Distance(p.Waypoints)
+	return p
+}
+
+// Resume plans a path that covers only the squares not yet cleaned in
+// a cleaning area, each the number of passes it still needs, starting
+// from the end of the area closest to the robot's current position.
+// Rows without any squares left to clean are skipped, and so are the
+// cleaned squares at either end of a row.
+func Resume(a *entity.CleaningArea, from *entity.Waypoint) *entity.Plan {
+	passes := a.PassesNeeded
+	if passes < 1 {
+		passes = 1
+	}
+	p := &entity.Plan{
+		Kind:         entity.PlanResume,
+		SizeX:        a.SizeX,
+		SizeY:        a.SizeY,
+		PassesNeeded: passes,
+		Waypoints:    []*entity.Waypoint{},
+	}
+	if len(a.Grid) == 0 {
+		return p
+	}
+	p.RobotSize = a.Grid[0].Size
+
+	rows := toRows(a.Grid, a.SizeX, a.SizeY, func(s *entity.Square) int {
+		if s.CleanedAt != nil {
+			return 0
+		}
+		return passes - s.Passes
+	})
+	p.Waypoints, p.Squares = route(rows, a.SizeX, a.SizeY, from)
+	p.DistanceMM = Distance(p.Waypoints)
+	return p
+}
+
+// Distance returns the length of a path in millimeters.
+func Distance(ws []*entity.Waypoint) int {
+	var d int
+	for i := 1; i < len(ws); i++ {
+		d += abs(ws[i].X-ws[i-1].X) + abs(ws[i].Y-ws[i-1].Y)
+	}
+	return d
+}
+
+// Walk returns the positions of a robot following a path, moving at
+// most stepMM between positions. Every waypoint is included, so the
+// robot never cuts a corner.
+func Walk(ws []*entity.Waypoint, stepMM int) []*entity.Waypoint {
+	if len(ws) == 0 {
+		return []*entity.Waypoint{}
+	}
+	if stepMM < 1 {
+		stepMM = 1
+	}
+	out := []*entity.Waypoint{ws[0]}
+	for i := 1; i < len(ws); i++ {
+		a, b := ws[i-1], ws[i]
+		d := abs(b.X-a.X) + abs(b.Y-a.Y)
+		for moved := stepMM; moved < d; moved += stepMM {
+			out = append(out, &entity.Waypoint{
+				X: a.X + (b.X-a.X)*moved/d,
+				Y: a.Y + (b.Y-a.Y)*moved/d,
+			})
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// toRows groups grid squares into rows, top to bottom and left to
+// right.
+func toRows(grid []*entity.Square, sizeX, sizeY int, remaining func(*entity.Square) int) [][]*cell {
+	sorted := make([]*entity.Square, len(grid))
+	copy(sorted, grid)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Y != sorted[j].Y {
+			return sorted[i].Y < sorted[j].Y
+		}
+		return sorted[i].X < sorted[j].X
+	})
+
+	var rows [][]*cell
+	for i, s := range sorted {
+		if i == 0 || s.Y != sorted[i-1].Y {
+			rows = append(rows, nil)
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], &cell{
+			sq:        s,
+			at:        center(s, sizeX, sizeY),
+			remaining: remaining(s),
+		})
+	}
+	return rows
+}
+
+// center returns the center of the part of a square that lies within
+// the area. Squares along the right and bottom edges are cut short
+// when the area isn't a multiple of the robot size.
+func center(s *entity.Square, sizeX, sizeY int) *entity.Waypoint {
+	w := min(s.Size, sizeX-s.X)
+	h := min(s.Size, sizeY-s.Y)
+	return &entity.Waypoint{X: s.X + w/2, Y: s.Y + h/2}
+}
+
+// route plans one sweep per pass needed and returns the waypoints and
+// the number of squares covered. A square only counts as passed when
+// the robot enters it, so whenever a sweep starts in the square the
+// robot is already in, the robot first steps out and back in again.
+func route(rows [][]*cell, sizeX, sizeY int, from *entity.Waypoint) ([]*entity.Waypoint, int) {
+	var squares, maxPasses int
+	for _, row := range rows {
+		for _, c := range row {
+			if c.remaining > 0 {
+				squares++
+			}
+			if c.remaining > maxPasses {
+				maxPasses = c.remaining
+			}
+		}
+	}
+
+	var path []*entity.Waypoint
+	cur := from
+	for pass := 1; pass <= maxPasses; pass++ {
+		seq := sweep(rows, pass, cur)
+		if len(seq) == 0 {
+			break
+		}
+		if cur != nil && seq[0].sq.IsInSquare(cur.X, cur.Y) {
+			path = moveTo(path, cur, step(seq, sizeX, sizeY))
+			cur = path[len(path)-1]
+		}
+		for _, c := range seq {
+			path = moveTo(path, cur, c.at)
+			cur = path[len(path)-1]
+		}
+	}
+	return simplify(path), squares
+}
+
+// sweep returns the squares that need at least the given number of
+// passes in boustrophedon order. Of the four corners we could start
+// from, we pick the one closest to the robot.
+func sweep(rows [][]*cell, pass int, from *entity.Waypoint) []*cell {
+	var todo [][]*cell
+	for _, row := range rows {
+		var r []*cell
+		for _, c := range row {
+			if c.remaining >= pass {
+				r = append(r, c)
+			}
+		}
+		if len(r) > 0 {
+			todo = append(todo, r)
+		}
+	}
+	if len(todo) == 0 {
+		return nil
+	}
+
+	var best []*cell
+	bestDist := -1
+	for _, topDown := range []bool{true, false} {
+		for _, leftToRight := range []bool{true, false} {
+			seq := snake(todo, topDown, leftToRight)
+			if from == nil {
+				return seq
+			}
+			d := abs(seq[0].at.X-from.X) + abs(seq[0].at.Y-from.Y)
+			if bestDist < 0 || d < bestDist {
+				best, bestDist = seq, d
+			}
+		}
+	}
+	return best
+}
+
+// snake flattens rows into a single sequence, alternating direction
+// every row.
+func snake(rows [][]*cell, topDown, leftToRight bool) []*cell {
+	var seq []*cell
+	for i := range rows {
+		row := rows[i]
+		if !topDown {
+			row = rows[len(rows)-1-i]
+		}
+		forward := leftToRight == (i%2 == 0)
+		for j := range row {
+			if forward {
+				seq = append(seq, row[j])
+			} else {
+				seq = append(seq, row[len(row)-1-j])
+			}
+		}
+	}
+	return seq
+}
+
+// step returns a point in a neighboring square that a robot can step
+// into and come back from to pass the first square in a sequence again.
+func step(seq []*cell, sizeX, sizeY int) *entity.Waypoint {
+	if len(seq) > 1 {
+		return seq[1].at
+	}
+	s, at := seq[0].sq, seq[0].at
+	switch {
+	case s.X+s.Size < sizeX:
+		return &entity.Waypoint{X: min(s.X+s.Size+s.Size/2, sizeX-1), Y: at.Y}
+	case s.X > 0:
+		return &entity.Waypoint{X: s.X - s.Size/2, Y: at.Y}
+	case s.Y+s.Size < sizeY:
+		return &entity.Waypoint{X: at.X, Y: min(s.Y+s.Size+s.Size/2, sizeY-1)}
+	case s.Y > 0:
+		return &entity.Waypoint{X: at.X, Y: s.Y - s.Size/2}
+	}
+	// The area is a single square, there's nowhere to go.
+	return at
+}
+
+// moveTo appends a move to the given point. Moves that would cut
+// diagonally go along the y axis first, then the x axis.
+func moveTo(path []*entity.Waypoint, cur, to *entity.Waypoint) []*entity.Waypoint {
+	if cur != nil && cur.X != to.X && cur.Y != to.Y {
+		path = append(path, &entity.Waypoint{X: cur.X, Y: to.Y})
+	}
+	return append(path, to)
+}
+
+// simplify drops duplicate waypoints and waypoints halfway along a
+// straight line.
+func simplify(path []*entity.Waypoint) []*entity.Waypoint {
+	out := []*entity.Waypoint{}
+	for _, w := range path {
+		n := len(out)
+		if n > 0 && *out[n-1] == *w {
+			continue
+		}
+		if n > 1 && between(out[n-2], out[n-1], w) {
+			out[n-1] = w
+			continue
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+// between checks if b lies on a straight line between a and c.
+func between(a, b, c *entity.Waypoint) bool {
+	if a.X == b.X && b.X == c.X {
+		return (a.Y < b.Y && b.Y < c.Y) || (a.Y > b.Y && b.Y > c.Y)
+	}
+	if a.Y == b.Y && b.Y == c.Y {
+		return (a.X < b.X && b.X < c.X) || (a.X > b.X && b.X > c.X)
+	}
+	return false
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,114 @@
+package planning
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/stretchr/testify/require"
+)
+
+// follow has a robot walk along a plan within a cleaning area, starting
+// at the given position.
+func follow(a *entity.CleaningArea, from *entity.Waypoint, p *entity.Plan) {
+	now := time.Date(2020, 2, 16, 12, 0, 0, 0, time.UTC)
+	if from != nil {
+		a.VisitAt(from.X, from.Y, now)
+	}
+	for i, w := range Walk(p.Waypoints, 50) {
+		a.VisitAt(w.X, w.Y, now.Add(time.Duration(i)*time.Second))
+	}
+}
+
+func newCleaningArea(sizeX, sizeY, robotSize, passes int) *entity.CleaningArea {
+	return entity.NewCleaningArea(
+		&entity.Area{SizeX: sizeX, SizeY: sizeY, PassesNeeded: passes},
+		&entity.Robot{Size: robotSize},
+	)
+}
+
+func TestBoustrophedon(t *testing.T) {
+	p := Boustrophedon(1000, 600, 200, 1)
+	require.Equal(t, entity.PlanFull, p.Kind)
+	require.Equal(t, 15, p.Squares)
+	require.Equal(t, []*entity.Waypoint{
+		{X: 100, Y: 100}, {X: 900, Y: 100},
+		{X: 900, Y: 300}, {X: 100, Y: 300},
+		{X: 100, Y: 500}, {X: 900, Y: 500},
+	}, p.Waypoints, "should sweep back and forth, moving down between rows")
+	require.Equal(t, 3*800+2*200, p.DistanceMM)
+
+	for i := 1; i < len(p.Waypoints); i++ {
+		a, b := p.Waypoints[i-1], p.Waypoints[i]
+		require.True(t, a.X == b.X || a.Y == b.Y, "should never move diagonally")
+	}
+
+	// Areas that aren't a multiple of the robot size, over several
+	// passes.
+	for passes := 1; passes <= 3; passes++ {
+		p := Boustrophedon(1050, 650, 200, passes)
+		a := newCleaningArea(1050, 650, 200, passes)
+		follow(a, nil, p)
+		require.True(t, a.IsCleaned(), "should clean the entire area in %d passes", passes)
+
+		for _, w := range p.Waypoints {
+			require.True(t, w.X >= 0 && w.X < 1050 && w.Y >= 0 && w.Y < 650, "should stay within the area")
+		}
+	}
+
+	require.Equal(t, 0, len(Boustrophedon(0, 600, 200, 1).Waypoints))
+}
+
+func TestResume(t *testing.T) {
+	a := newCleaningArea(1000, 1000, 200, 2)
+	now := time.Now()
+	for _, s := range a.Grid {
+		switch {
+		case s.Y < 400:
+			// The top two rows are done.
+			s.Passes = 2
+			s.CleanedAt = &now
+		case s.Y < 600 && s.X < 600:
+			// Half of the middle row has been passed once.
+			s.Passes = 1
+		}
+	}
+
+	// The robot is just outside the bottom edge of the area.
+	from := &entity.Waypoint{X: 900, Y: 1100}
+	p := Resume(a, from)
+	require.Equal(t, entity.PlanResume, p.Kind)
+	require.Equal(t, 15, p.Squares)
+	require.Equal(t, 200, p.RobotSize)
+	require.Equal(t, &entity.Waypoint{X: 900, Y: 900}, p.Waypoints[0], "should start at the corner closest to the robot")
+	for _, w := range p.Waypoints {
+		require.True(t, w.Y >= 400, "should skip cleaned rows")
+	}
+
+	follow(a, from, p)
+	require.True(t, a.IsCleaned())
+
+	full := Boustrophedon(1000, 1000, 200, 2)
+	require.True(t, p.DistanceMM < full.DistanceMM)
+
+	// Nothing left to do.
+	p = Resume(a, from)
+	require.Equal(t, 0, p.Squares)
+	require.Equal(t, 0, len(p.Waypoints))
+}
+
+func TestResumeInFirstSquare(t *testing.T) {
+	a := newCleaningArea(200, 400, 200, 1)
+	from := &entity.Waypoint{X: 100, Y: 100}
+
+	p := Resume(a, from)
+	follow(a, from, p)
+	require.True(t, a.IsCleaned(), "should step out and back into the square the robot is in")
+}
+
+func TestWalk(t *testing.T) {
+	ws := Walk([]*entity.Waypoint{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 30}}, 40)
+	require.Equal(t, []*entity.Waypoint{
+		{X: 0, Y: 0}, {X: 40, Y: 0}, {X: 80, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 30},
+	}, ws)
+}
@@ -0,0 +1,104 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/pkg/errors"
+)
+
+// HeatmapPNG draws a session's cleaning area to scale as a PNG with
+// each grid square colored by its number of passes relative to the
+// passes needed, cleaned squares highlighted and the robot's last
+// position on top.
+func HeatmapPNG(w io.Writer, sess *entity.CleaningSession, o Options) error {
+	img, err := Heatmap(sess, o)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// Heatmap draws a session's cleaning area as an image. See HeatmapPNG.
+func Heatmap(sess *entity.CleaningSession, o Options) (*image.RGBA, error) {
+	if len(sess.Area) == 0 {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find a cleaning area for session %s", sess.UID)
+	}
+	a := sess.Area[0]
+
+	c, err := newCanvas(a, o)
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, c.w, c.h))
+	draw.Draw(img, img.Bounds(), &image.Uniform{colorBackground}, image.Point{}, draw.Src)
+
+	drawGrid(img, c, a)
+	drawOutline(img)
+
+	if len(a.Grid) > 0 {
+		drawRobot(img, c, sess.LastX, sess.LastY, a.Grid[0].Size)
+	}
+	return img, nil
+}
+
+// drawGrid fills each grid square by its passes and outlines it.
+// Squares along the right and bottom edges may extend past the area
+// and are clipped.
+func drawGrid(img *image.RGBA, c *canvas, a *entity.CleaningArea) {
+	for _, s := range a.Grid {
+		r := image.Rect(c.px(s.X), c.px(s.Y), c.px(s.X+s.Size), c.px(s.Y+s.Size)).Intersect(img.Bounds())
+		draw.Draw(img, r, &image.Uniform{squareColor(s, a.PassesNeeded)}, image.Point{}, draw.Src)
+
+		if r.Dx() > 4 && r.Dy() > 4 {
+			// Only draw grid lines when squares are big enough to see.
+			hline(img, r.Min.X, r.Max.X, r.Min.Y, colorGridLine)
+			vline(img, r.Min.X, r.Min.Y, r.Max.Y, colorGridLine)
+		}
+	}
+}
+
+// drawOutline draws a border around the image.
+func drawOutline(img *image.RGBA) {
+	b := img.Bounds()
+	hline(img, b.Min.X, b.Max.X, b.Min.Y, colorOutline)
+	hline(img, b.Min.X, b.Max.X, b.Max.Y-1, colorOutline)
+	vline(img, b.Min.X, b.Min.Y, b.Max.Y, colorOutline)
+	vline(img, b.Max.X-1, b.Min.Y, b.Max.Y, colorOutline)
+}
+
+// drawRobot draws the robot as a filled circle with the given
+// diameter in millimeters centered on x,y.
+func drawRobot(img *image.RGBA, c *canvas, x, y, diameter int) {
+	cx, cy := c.px(x), c.px(y)
+	r := c.px(diameter / 2)
+	if r < 2 {
+		r = 2
+	}
+	for py := cy - r; py <= cy+r; py++ {
+		for px := cx - r; px <= cx+r; px++ {
+			dx, dy := px-cx, py-cy
+			if dx*dx+dy*dy <= r*r && image.Pt(px, py).In(img.Bounds()) {
+				img.SetRGBA(px, py, colorRobot)
+			}
+		}
+	}
+}
+
+func hline(img *image.RGBA, x1, x2, y int, col color.RGBA) {
+	for x := x1; x < x2; x++ {
+		img.SetRGBA(x, y, col)
+	}
+}
+
+func vline(img *image.RGBA, x, y1, y2 int, col color.RGBA) {
+	for y := y1; y < y2; y++ {
+		img.SetRGBA(x, y, col)
+	}
+}
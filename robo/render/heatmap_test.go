@@ -0,0 +1,73 @@
+package render
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func testSession() *entity.CleaningSession {
+	r := entity.NewRobot("Johnny 5", 500)
+	a := entity.NewArea("Work Room #1", 10000, 5000, 2)
+	sess := entity.NewCleaningSession(r, a, "test")
+	return sess
+}
+
+func TestHeatmap(t *testing.T) {
+	sess := testSession()
+	ca := sess.Area[0]
+
+	// One pass on the first square, two passes (clean) on the second.
+	ca.Visit(250, 250)
+	ca.Grid[0].HasRobotPresent = false
+	ca.Visit(750, 250)
+	ca.Grid[1].HasRobotPresent = false
+	ca.Visit(750, 250)
+	sess.LastX, sess.LastY = 9750, 4750
+
+	img, err := Heatmap(sess, Options{PixelSize: 10})
+	require.NoError(t, err)
+	require.Equal(t, 1000, img.Bounds().Dx(), "should draw area to scale")
+	require.Equal(t, 500, img.Bounds().Dy(), "should draw area to scale")
+
+	require.Equal(t, colorPassLow, img.RGBAAt(25, 25), "should color a square with one pass")
+	require.Equal(t, colorCleaned, img.RGBAAt(75, 25), "should highlight a cleaned square")
+	require.Equal(t, colorBackground, img.RGBAAt(125, 25), "should leave unvisited squares blank")
+	require.Equal(t, colorRobot, img.RGBAAt(975, 475), "should draw the robot at its last position")
+
+	// Default pixel size.
+	img, err = Heatmap(sess, Options{})
+	require.NoError(t, err)
+	require.Equal(t, 770, img.Bounds().Dx(), "should fit the longest side within the default size")
+
+	var buf bytes.Buffer
+	require.NoError(t, HeatmapPNG(&buf, sess, Options{PixelSize: 50}))
+	dec, err := png.Decode(&buf)
+	require.NoError(t, err, "should encode a valid PNG")
+	require.Equal(t, 200, dec.Bounds().Dx())
+}
+
+func TestHeatmapValidation(t *testing.T) {
+	sess := testSession()
+
+	_, err := Heatmap(sess, Options{PixelSize: -1})
+	require.Equal(t, cerr.ErrValidationFailed, errors.Cause(err), "should fail on negative pixel size")
+
+	_, err = Heatmap(sess, Options{PixelSize: 1})
+	require.Equal(t, cerr.ErrValidationFailed, errors.Cause(err), "should fail when the image would be too big")
+
+	sess.Area = nil
+	_, err = Heatmap(sess, Options{})
+	require.Equal(t, cerr.ErrNotFound, errors.Cause(err), "should fail without a cleaning area")
+}
+
+func rgba(c color.Color) color.RGBA {
+	r, g, b, a := c.RGBA()
+	return color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+}
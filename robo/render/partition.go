@@ -0,0 +1,71 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/anrid/roboviewer/robo/entity"
+)
+
+// PartitionSVG draws a partitioned area to scale as an SVG with every
+// region outlined and filled by the completion of its latest session,
+// from the background color when untouched to green once cleaned. Each
+// region shows its robot's grid and is labelled with its number, robot
+// and completion.
+//
+// The SVG uses millimeters as user units, so Options.PixelSize only
+// affects its width and height.
+func PartitionSVG(w io.Writer, s *entity.PartitionSummary, o Options) error {
+	a := s.Area
+	c, err := newCanvas(&entity.CleaningArea{SizeX: a.SizeX, SizeY: a.SizeY}, o)
+	if err != nil {
+		return err
+	}
+
+	progress := make(map[string]*entity.RegionProgress, len(s.Regions))
+	for _, rp := range s.Regions {
+		progress[rp.RegionID] = rp
+	}
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", c.w, c.h, a.SizeX, a.SizeY)
+	fmt.Fprintf(bw, "<title>%s (%s%%)</title>\n", escape(a.Name), s.Progress.Completion)
+	fmt.Fprintf(bw, `<rect x="0" y="0" width="%d" height="%d" fill="%s"/>`+"\n", a.SizeX, a.SizeY, hex(colorBackground))
+
+	for i, r := range s.Partition.Regions {
+		completion := "0.00"
+		if rp, ok := progress[r.UID]; ok {
+			completion = rp.Progress.Completion
+		}
+		pct, _ := strconv.ParseFloat(completion, 64)
+		label := fmt.Sprintf("%d: robot %s, %s%%", i+1, r.RobotID, completion)
+
+		fmt.Fprintf(bw, `<g transform="translate(%d %d)">`+"\n", r.OffsetX, r.OffsetY)
+		fmt.Fprintf(bw, `<rect x="0" y="0" width="%d" height="%d" fill="%s"><title>%s</title></rect>`+"\n", r.SizeX, r.SizeY, hex(blend(colorBackground, colorCleaned, pct/100)), escape(label))
+
+		// Grid, one square per robot diameter.
+		if r.RobotSize > 0 {
+			fmt.Fprintf(bw, `<g fill="none" stroke="%s" stroke-width="%d">`+"\n", hex(colorGridLine), max(c.pixelSize, 1))
+			for x := r.RobotSize; x < r.SizeX; x += r.RobotSize {
+				fmt.Fprintf(bw, `<line x1="%d" y1="0" x2="%d" y2="%d"/>`+"\n", x, x, r.SizeY)
+			}
+			for y := r.RobotSize; y < r.SizeY; y += r.RobotSize {
+				fmt.Fprintf(bw, `<line x1="0" y1="%d" x2="%d" y2="%d"/>`+"\n", y, r.SizeX, y)
+			}
+			bw.WriteString("</g>\n")
+		}
+
+		fmt.Fprintf(bw, `<rect x="0" y="0" width="%d" height="%d" fill="none" stroke="%s" stroke-width="%d"/>`+"\n", r.SizeX, r.SizeY, hex(colorOutline), max(c.pixelSize, 1))
+		fmt.Fprintf(bw, `<text x="%d" y="%d" font-size="%d" text-anchor="middle" fill="%s">%s</text>`+"\n", r.SizeX/2, r.SizeY/2, max(12*c.pixelSize, 1), hex(colorOutline), escape(label))
+		bw.WriteString("</g>\n")
+	}
+
+	// Outline.
+	fmt.Fprintf(bw, `<rect x="0" y="0" width="%d" height="%d" fill="none" stroke="%s" stroke-width="%d"/>`+"\n", a.SizeX, a.SizeY, hex(colorOutline), max(2*c.pixelSize, 1))
+	bw.WriteString("</svg>\n")
+
+	return bw.Flush()
+}
@@ -0,0 +1,53 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionSVG(t *testing.T) {
+	s := &entity.PartitionSummary{
+		Area: &entity.Area{Name: "Hall <A>", SizeX: 10000, SizeY: 5000},
+		Partition: &entity.Partition{
+			Regions: []*entity.Region{
+				{RobotID: "0x1", RobotSize: 500, SizeX: 5000, SizeY: 5000, Common: entity.Common{UID: "0xa"}},
+				{RobotID: "0x2", RobotSize: 1000, OffsetX: 5000, SizeX: 5000, SizeY: 5000, Common: entity.Common{UID: "0xb"}},
+			},
+		},
+		Progress: entity.SessionProgress{Completion: "25.00"},
+		Regions: []*entity.RegionProgress{
+			{RegionID: "0xa", Progress: entity.SessionProgress{Completion: "50.00"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, PartitionSVG(&buf, s, Options{PixelSize: 10}))
+	svg := buf.String()
+
+	require.Contains(t, svg, `width="1000" height="500" viewBox="0 0 10000 5000"`, "should draw area to scale")
+	require.Contains(t, svg, "Hall &lt;A&gt; (25.00%)", "should escape the area name and show completion")
+	require.Contains(t, svg, `translate(5000 0)`, "should draw regions at their offset")
+	require.Contains(t, svg, "1: robot 0x1, 50.00%")
+	require.Contains(t, svg, "2: robot 0x2, 0.00%", "should show regions without a session as untouched")
+
+	counts := make(map[string]int)
+	d := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err, "should be well-formed XML")
+		if se, ok := tok.(xml.StartElement); ok {
+			counts[se.Name.Local]++
+		}
+	}
+	require.Equal(t, (9+9)+(4+4), counts["line"], "should draw each robot's grid")
+	require.Equal(t, 2, counts["text"])
+}
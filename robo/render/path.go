@@ -0,0 +1,163 @@
+package render
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/pkg/errors"
+)
+
+// pathColorSteps is the number of distinct colors used to show the
+// passing of time along a path. Consecutive segments with the same
+// color are drawn as a single polyline to keep the SVG small.
+const pathColorSteps = 32
+
+var (
+	colorPathStart = color.RGBA{0x1e, 0x88, 0xe5, 0xff} // Start of the path.
+	colorPathEnd   = color.RGBA{0xd8, 0x1b, 0x60, 0xff} // End of the path.
+	colorMarkStart = color.RGBA{0x43, 0xa0, 0x47, 0xff}
+	colorMarkEnd   = color.RGBA{0xd3, 0x2f, 0x2f, 0xff}
+)
+
+// PathSVG draws a session's cleaning area to scale as an SVG with the
+// area's outline and grid, and the robot's path on top as a polyline
+// colored by time with start and end markers. The path is drawn using
+// the robot's diameter as stroke width.
+//
+// The SVG uses millimeters as user units, so Options.PixelSize only
+// affects its width and height.
+func PathSVG(w io.Writer, sess *entity.CleaningSession, o Options) error {
+	if len(sess.Area) == 0 {
+		return errors.Wrapf(cerr.ErrNotFound, "could not find a cleaning area for session %s", sess.UID)
+	}
+	a := sess.Area[0]
+
+	c, err := newCanvas(a, o)
+	if err != nil {
+		return err
+	}
+
+	diameter := 0
+	if len(a.Grid) > 0 {
+		diameter = a.Grid[0].Size
+	}
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", c.w, c.h, a.SizeX, a.SizeY)
+	fmt.Fprintf(bw, "<title>%s</title>\n", escape(a.Name))
+	fmt.Fprintf(bw, `<rect x="0" y="0" width="%d" height="%d" fill="%s"/>`+"\n", a.SizeX, a.SizeY, hex(colorBackground))
+
+	// Grid, clipped to the area.
+	fmt.Fprintf(bw, `<clipPath id="area"><rect x="0" y="0" width="%d" height="%d"/></clipPath>`+"\n", a.SizeX, a.SizeY)
+	fmt.Fprintf(bw, `<g clip-path="url(#area)" fill="none" stroke="%s" stroke-width="%d">`+"\n", hex(colorGridLine), max(c.pixelSize, 1))
+	for _, s := range a.Grid {
+		fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d"/>`+"\n", s.X, s.Y, s.Size, s.Size)
+	}
+	bw.WriteString("</g>\n")
+
+	// Path.
+	ps := sess.PositionHistory
+	if len(ps) > 0 {
+		fmt.Fprintf(bw, `<g fill="none" stroke-width="%d" stroke-linecap="round" stroke-linejoin="round" stroke-opacity="0.5">`+"\n", max(diameter, c.pixelSize))
+		for _, seg := range pathSegments(ps) {
+			fmt.Fprintf(bw, `<polyline stroke="%s" points="%s"/>`+"\n", hex(seg.color), seg.points)
+		}
+		bw.WriteString("</g>\n")
+
+		// Start and end markers.
+		r := max(diameter/4, 2*c.pixelSize)
+		first, last := ps[0], ps[len(ps)-1]
+		fmt.Fprintf(bw, `<circle cx="%d" cy="%d" r="%d" fill="%s"><title>start</title></circle>`+"\n", first.X, first.Y, r, hex(colorMarkStart))
+		fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"><title>end</title></rect>`+"\n", last.X-r, last.Y-r, 2*r, 2*r, hex(colorMarkEnd))
+	}
+
+	// Outline.
+	fmt.Fprintf(bw, `<rect x="0" y="0" width="%d" height="%d" fill="none" stroke="%s" stroke-width="%d"/>`+"\n", a.SizeX, a.SizeY, hex(colorOutline), max(2*c.pixelSize, 1))
+	bw.WriteString("</svg>\n")
+
+	return bw.Flush()
+}
+
+// pathSegment is a part of a path drawn in a single color.
+type pathSegment struct {
+	color  color.RGBA
+	points string
+}
+
+// pathSegments splits a path into segments colored by time, from
+// colorPathStart for the first position to colorPathEnd for the last.
+// Falls back to the order of positions if they lack timestamps.
+func pathSegments(ps []*entity.Position) []*pathSegment {
+	if len(ps) == 1 {
+		return []*pathSegment{{colorPathStart, point(ps[0])}}
+	}
+
+	first, last := ps[0].PassedAt, ps[len(ps)-1].PassedAt
+	useTime := first != nil && last != nil && last.After(*first)
+
+	step := func(i int) int {
+		var t float64
+		if useTime && ps[i].PassedAt != nil {
+			t = float64(ps[i].PassedAt.Sub(*first)) / float64(last.Sub(*first))
+		} else {
+			t = float64(i) / float64(len(ps)-1)
+		}
+		s := int(t * pathColorSteps)
+		if s >= pathColorSteps {
+			s = pathColorSteps - 1
+		}
+		return s
+	}
+
+	var segs []*pathSegment
+	var sb strings.Builder
+	cur := -1
+	for i := 1; i < len(ps); i++ {
+		s := step(i)
+		if s != cur {
+			if cur >= 0 {
+				segs = append(segs, &pathSegment{stepColor(cur), sb.String()})
+				sb.Reset()
+			}
+			// Start each segment at the end of the previous one.
+			sb.WriteString(point(ps[i-1]))
+			cur = s
+		}
+		sb.WriteString(" ")
+		sb.WriteString(point(ps[i]))
+	}
+	segs = append(segs, &pathSegment{stepColor(cur), sb.String()})
+	return segs
+}
+
+func stepColor(step int) color.RGBA {
+	return blend(colorPathStart, colorPathEnd, float64(step)/float64(pathColorSteps-1))
+}
+
+func point(p *entity.Position) string {
+	return fmt.Sprintf("%d,%d", p.X, p.Y)
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func escape(s string) string {
+	var sb strings.Builder
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
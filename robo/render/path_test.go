@@ -0,0 +1,65 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathSVG(t *testing.T) {
+	sess := testSession()
+	sess.Area[0].Name = "Work Room <1> & 2"
+
+	start := time.Date(2020, 2, 16, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 100; i++ {
+		sess.PositionHistory = append(sess.PositionHistory, entity.NewPosition(250+i*90, 250, start.Add(time.Duration(i)*time.Second)))
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, PathSVG(&buf, sess, Options{PixelSize: 10}))
+	svg := buf.String()
+
+	require.Contains(t, svg, `width="1000" height="500" viewBox="0 0 10000 5000"`, "should draw area to scale")
+	require.Contains(t, svg, "Work Room &lt;1&gt; &amp; 2", "should escape the area name")
+	require.Contains(t, svg, `stroke-width="500"`, "should use the robot's diameter as stroke width")
+	require.Contains(t, svg, "<title>start</title>")
+	require.Contains(t, svg, "<title>end</title>")
+
+	// Count elements and make sure the SVG is well-formed.
+	counts := make(map[string]int)
+	d := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err, "should be well-formed XML")
+		if se, ok := tok.(xml.StartElement); ok {
+			counts[se.Name.Local]++
+		}
+	}
+	require.Equal(t, pathColorSteps, counts["polyline"], "should color the path by time")
+	require.Equal(t, len(sess.Area[0].Grid)+4, counts["rect"], "should draw background, clip path, grid squares, end marker and outline")
+
+	// First segment starts with the first position and the last ends
+	// with the last position.
+	segs := pathSegments(sess.PositionHistory)
+	require.True(t, strings.HasPrefix(segs[0].points, "250,250 "))
+	require.True(t, strings.HasSuffix(segs[len(segs)-1].points, " 9160,250"))
+	require.Equal(t, colorPathStart, segs[0].color)
+	require.Equal(t, colorPathEnd, segs[len(segs)-1].color)
+}
+
+func TestPathSVGWithoutPositions(t *testing.T) {
+	sess := testSession()
+
+	var buf bytes.Buffer
+	require.NoError(t, PathSVG(&buf, sess, Options{}))
+	require.NotContains(t, buf.String(), "<polyline", "should only draw the area")
+}
@@ -0,0 +1,101 @@
+// Package render draws cleaning sessions as images, e.g. for
+// reports.
+package render
+
+import (
+	"image/color"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultMaxSide is the default length in pixels of the longest
+	// side of a rendered area.
+	DefaultMaxSide = 800
+	// MaxSide is the max length in pixels of any side of a rendered
+	// area.
+	MaxSide = 8000
+)
+
+var (
+	colorBackground = color.RGBA{0xee, 0xee, 0xee, 0xff}
+	colorGridLine   = color.RGBA{0xcc, 0xcc, 0xcc, 0xff}
+	colorOutline    = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	colorPassLow    = color.RGBA{0xff, 0xf1, 0x76, 0xff} // First pass.
+	colorPassHigh   = color.RGBA{0xff, 0x8f, 0x00, 0xff} // Last pass before clean.
+	colorCleaned    = color.RGBA{0x43, 0xa0, 0x47, 0xff}
+	colorRobot      = color.RGBA{0xd3, 0x2f, 0x2f, 0xff}
+)
+
+// Options are common render options.
+type Options struct {
+	// PixelSize is the number of millimeters per pixel. Defaults to the
+	// smallest size that fits the longest side of the area within
+	// DefaultMaxSide pixels.
+	PixelSize int
+}
+
+// canvas holds the dimensions of an area drawn to scale.
+type canvas struct {
+	pixelSize int // Millimeters per pixel.
+	w         int // Width in pixels.
+	h         int // Height in pixels.
+}
+
+// newCanvas calculates the dimensions of an area drawn to scale.
+func newCanvas(a *entity.CleaningArea, o Options) (*canvas, error) {
+	if a.SizeX <= 0 || a.SizeY <= 0 {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "cannot render area with invalid size: size_x = %d size_y = %d", a.SizeX, a.SizeY)
+	}
+	ps := o.PixelSize
+	if ps < 0 {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "pixel size must be greater than 0, got %d", ps)
+	}
+	if ps == 0 {
+		longest := a.SizeX
+		if a.SizeY > longest {
+			longest = a.SizeY
+		}
+		ps = (longest + DefaultMaxSide - 1) / DefaultMaxSide
+	}
+	c := &canvas{
+		pixelSize: ps,
+		w:         (a.SizeX + ps - 1) / ps,
+		h:         (a.SizeY + ps - 1) / ps,
+	}
+	if c.w > MaxSide || c.h > MaxSide {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "pixel size %d mm gives a %dx%d image, max side is %d pixels", ps, c.w, c.h, MaxSide)
+	}
+	return c, nil
+}
+
+// px converts millimeters to pixels.
+func (c *canvas) px(mm int) int {
+	return mm / c.pixelSize
+}
+
+// squareColor returns the color of a grid square based on its number
+// of passes relative to the passes needed.
+func squareColor(s *entity.Square, passesNeeded int) color.RGBA {
+	switch {
+	case s.CleanedAt != nil || (passesNeeded > 0 && s.Passes >= passesNeeded):
+		return colorCleaned
+	case s.Passes == 0:
+		return colorBackground
+	case passesNeeded <= 1:
+		return colorPassHigh
+	}
+	// Blend from low to high as passes approach passes needed.
+	t := float64(s.Passes-1) / float64(passesNeeded-1)
+	return blend(colorPassLow, colorPassHigh, t)
+}
+
+// blend linearly interpolates between two colors.
+func blend(from, to color.RGBA, t float64) color.RGBA {
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t)
+	}
+	return color.RGBA{mix(from.R, to.R), mix(from.G, to.G), mix(from.B, to.B), 0xff}
+}
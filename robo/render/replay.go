@@ -0,0 +1,182 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/anrid/roboviewer/robo/replay"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultReplaySpeedUp is the default replay speed relative to
+	// real time.
+	DefaultReplaySpeedUp = 60
+	// DefaultReplayFPS is the default replay frame rate.
+	DefaultReplayFPS = 10
+	// MaxReplayFPS is the max replay frame rate.
+	MaxReplayFPS = 50
+	// MaxReplayFrames is the max number of frames in a replay.
+	MaxReplayFrames = 3000
+	// MaxReplaySide is the max length in pixels of any side of a
+	// replay frame.
+	MaxReplaySide = 2000
+	// MaxReplayPixels is the max number of pixels across all frames
+	// of a replay. Every frame is held in memory until the GIF is
+	// encoded, at a byte per pixel.
+	MaxReplayPixels = 1 << 28
+	// replayEndDelay is how long the last frame is shown before the
+	// replay loops.
+	replayEndDelay = 2 * time.Second
+)
+
+// ReplayOptions are options for rendering an animated replay.
+type ReplayOptions struct {
+	Options
+	// SpeedUp is how many times faster than real time the replay runs,
+	// e.g. 60 replays a minute per second. Defaults to DefaultReplaySpeedUp.
+	SpeedUp float64
+	// FPS is the number of frames per second. Defaults to DefaultReplayFPS.
+	FPS int
+}
+
+// ReplayGIF renders an animated GIF that replays how the robot moved
+// and how the grid filled up during a session. Each frame is a heatmap
+// (see Heatmap) of a fresh grid with the session's position history
+// re-applied up to the time of that frame using
+// entity.CleaningArea.SetVisited (see replay.Replayer).
+func ReplayGIF(w io.Writer, sess *entity.CleaningSession, o ReplayOptions) error {
+	if o.SpeedUp == 0 {
+		o.SpeedUp = DefaultReplaySpeedUp
+	}
+	if o.FPS == 0 {
+		o.FPS = DefaultReplayFPS
+	}
+	if o.SpeedUp < 0 {
+		return errors.Wrapf(cerr.ErrValidationFailed, "speed up must be greater than 0, got %g", o.SpeedUp)
+	}
+	if o.FPS < 0 || o.FPS > MaxReplayFPS {
+		return errors.Wrapf(cerr.ErrValidationFailed, "fps must be between 1 and %d, got %d", MaxReplayFPS, o.FPS)
+	}
+	if len(sess.Area) == 0 {
+		return errors.Wrapf(cerr.ErrNotFound, "could not find a cleaning area for session %s", sess.UID)
+	}
+	c, err := newCanvas(sess.Area[0], o.Options)
+	if err != nil {
+		return err
+	}
+	if c.w > MaxReplaySide || c.h > MaxReplaySide {
+		return errors.Wrapf(cerr.ErrValidationFailed, "pixel size %d mm gives %dx%d frames, max side is %d pixels", c.pixelSize, c.w, c.h, MaxReplaySide)
+	}
+
+	ps := sess.PositionHistory
+	times := frameTimes(ps, o)
+	if len(times) > MaxReplayFrames {
+		return errors.Wrapf(cerr.ErrValidationFailed, "replay would have %d frames, max is %d, increase the speed up or lower the frame rate", len(times), MaxReplayFrames)
+	}
+	if pixels := len(times) * c.w * c.h; pixels > MaxReplayPixels {
+		return errors.Wrapf(cerr.ErrValidationFailed, "replay would have %d frames of %dx%d pixels, max is %d pixels in total, increase the speed up or pixel size or lower the frame rate", len(times), c.w, c.h, MaxReplayPixels)
+	}
+
+	r, err := replay.New(sess.Area[0], ps)
+	if err != nil {
+		return err
+	}
+	a := r.Area()
+	frame := &entity.CleaningSession{Area: []*entity.CleaningArea{a}}
+	pal := replayPalette(a.PassesNeeded)
+	delay := int(100 / o.FPS) // In 100ths of a second.
+
+	out := &gif.GIF{}
+	for i, t := range times {
+		// Apply every position passed up until this frame.
+		if t.IsZero() {
+			r.Finish()
+		} else {
+			r.AdvanceTo(t)
+		}
+		if last := r.Last(); last != nil {
+			frame.LastX, frame.LastY = last.X, last.Y
+		}
+
+		img, err := Heatmap(frame, o.Options)
+		if err != nil {
+			return err
+		}
+		p := image.NewPaletted(img.Bounds(), pal)
+		draw.Draw(p, p.Bounds(), img, image.Point{}, draw.Src)
+
+		d := delay
+		if i == len(times)-1 {
+			d = int(replayEndDelay / (10 * time.Millisecond))
+		}
+		out.Image = append(out.Image, p)
+		out.Delay = append(out.Delay, d)
+	}
+
+	return gif.EncodeAll(w, out)
+}
+
+// frameTimes returns the point in time of each frame in a replay of
+// the given positions. The last frame is always at the time of the
+// last position.
+func frameTimes(ps []*entity.Position, o ReplayOptions) []time.Time {
+	var first, last *time.Time
+	for _, p := range ps {
+		if p.PassedAt == nil {
+			continue
+		}
+		if first == nil {
+			first = p.PassedAt
+		}
+		last = p.PassedAt
+	}
+	if first == nil {
+		// Nothing to replay, show the final state.
+		return []time.Time{{}}
+	}
+
+	step := time.Duration(o.SpeedUp * float64(time.Second) / float64(o.FPS))
+	if step <= 0 {
+		step = 1
+	}
+	dur := last.Sub(*first)
+	n := int(dur/step) + 1
+
+	var times []time.Time
+	for i := 0; i < n && i <= MaxReplayFrames; i++ {
+		times = append(times, first.Add(time.Duration(i)*step))
+	}
+	if times[len(times)-1].Before(*last) {
+		times = append(times, *last)
+	}
+	return times
+}
+
+// replayPalette returns a palette with every color a heatmap can
+// have for the given number of passes needed.
+func replayPalette(passesNeeded int) color.Palette {
+	pal := color.Palette{
+		colorBackground,
+		colorGridLine,
+		colorOutline,
+		colorCleaned,
+		colorRobot,
+		colorPassLow,
+		colorPassHigh,
+	}
+	// Leave room for the fixed colors above (a GIF palette holds 256).
+	if passesNeeded > 248 {
+		passesNeeded = 248
+	}
+	for passes := 1; passes < passesNeeded; passes++ {
+		pal = append(pal, squareColor(&entity.Square{Passes: passes}, passesNeeded))
+	}
+	return pal
+}
@@ -0,0 +1,76 @@
+package render
+
+import (
+	"bytes"
+	"image/gif"
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayGIF(t *testing.T) {
+	sess := testSession()
+
+	// Robot moves along the top row, one square every 10 seconds, and
+	// then back again.
+	start := time.Date(2020, 2, 16, 10, 0, 0, 0, time.UTC)
+	at := start
+	for x := 250; x < 10000; x += 500 {
+		sess.PositionHistory = append(sess.PositionHistory, entity.NewPosition(x, 250, at))
+		at = at.Add(10 * time.Second)
+	}
+	for x := 9750; x > 0; x -= 500 {
+		sess.PositionHistory = append(sess.PositionHistory, entity.NewPosition(x, 250, at))
+		at = at.Add(10 * time.Second)
+	}
+	// 40 positions over 390 seconds.
+
+	var buf bytes.Buffer
+	require.NoError(t, ReplayGIF(&buf, sess, ReplayOptions{Options: Options{PixelSize: 50}, SpeedUp: 20, FPS: 1}))
+
+	g, err := gif.DecodeAll(&buf)
+	require.NoError(t, err, "should encode a valid GIF")
+	require.Equal(t, 21, len(g.Image), "should render one frame per 20 seconds and one for the last position")
+	require.Equal(t, 100, g.Delay[0], "should show each frame for 1 second")
+	require.Equal(t, 200, g.Delay[len(g.Delay)-1], "should pause on the last frame")
+
+	// Top left square (0-500mm) is 0-10 pixels.
+	first, last := g.Image[0], g.Image[len(g.Image)-1]
+	require.Equal(t, colorPassLow, rgba(first.At(1, 9)), "should have passed the first square once in the first frame")
+	require.Equal(t, colorBackground, rgba(first.At(195, 2)), "should not have reached the last square in the first frame")
+	require.Equal(t, colorCleaned, rgba(last.At(185, 2)), "should have cleaned the top row in the last frame")
+
+	require.Equal(t, entity.Completion(0, len(sess.Area[0].Grid)), sess.Area[0].Completion(), "should not touch the session's grid")
+}
+
+func TestReplayGIFValidation(t *testing.T) {
+	sess := testSession()
+	start := time.Date(2020, 2, 16, 10, 0, 0, 0, time.UTC)
+	sess.PositionHistory = []*entity.Position{
+		entity.NewPosition(250, 250, start),
+		entity.NewPosition(750, 250, start.Add(24*time.Hour)),
+	}
+
+	var buf bytes.Buffer
+	err := ReplayGIF(&buf, sess, ReplayOptions{SpeedUp: 1, FPS: 10})
+	require.Equal(t, cerr.ErrValidationFailed, errors.Cause(err), "should fail on too many frames")
+
+	err = ReplayGIF(&buf, sess, ReplayOptions{FPS: 100})
+	require.Equal(t, cerr.ErrValidationFailed, errors.Cause(err), "should fail on too high frame rate")
+
+	err = ReplayGIF(&buf, sess, ReplayOptions{SpeedUp: -1})
+	require.Equal(t, cerr.ErrValidationFailed, errors.Cause(err), "should fail on negative speed up")
+
+	err = ReplayGIF(&buf, sess, ReplayOptions{Options: Options{PixelSize: 1}})
+	require.Equal(t, cerr.ErrValidationFailed, errors.Cause(err), "should fail on too large frames")
+
+	// 781 frames of 2000x1000 pixels.
+	sess.PositionHistory[1] = entity.NewPosition(750, 250, start.Add(390*time.Second))
+	err = ReplayGIF(&buf, sess, ReplayOptions{Options: Options{PixelSize: 5}, SpeedUp: 1, FPS: 2})
+	require.Equal(t, cerr.ErrValidationFailed, errors.Cause(err), "should fail on too many pixels in total")
+	require.Contains(t, err.Error(), "in total")
+}
@@ -0,0 +1,196 @@
+// Package replay rebuilds a cleaning session's grid from its position
+// history, e.g. to find out what the coverage was at a certain point in
+// time or to verify the persisted grid.
+package replay
+
+import (
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/pkg/errors"
+)
+
+// Replayer re-applies a session's position history, in order, to a
+// fresh grid using the same rules as entity.CleaningArea.SetVisited.
+// Like a robot's updates, each position is applied to the grid as it
+// is loaded from the database, i.e. without the robot being present
+// on any square, so every position registers a pass. Positions the
+// robot got to without cleaning are skipped.
+type Replayer struct {
+	area *entity.CleaningArea
+	ps   []*entity.Position
+	next int
+	last *entity.Position
+}
+
+// New creates a replayer for the given positions on a fresh copy of
+// the given cleaning area. The cleaning area must include its grid
+// since the grid's square size is the size of the robot that ran the
+// session. Positions must be in the order they were passed.
+func New(a *entity.CleaningArea, ps []*entity.Position) (*Replayer, error) {
+	if len(a.Grid) == 0 {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find a grid for cleaning area %s", a.UID)
+	}
+
+	fresh := &entity.CleaningArea{
+		Name:         a.Name,
+		SizeX:        a.SizeX,
+		SizeY:        a.SizeY,
+		PassesNeeded: a.PassesNeeded,
+		Grid:         entity.NewGrid(a.SizeX, a.SizeY, a.Grid[0].Size),
+		Common:       a.Common,
+	}
+
+	// Keep the identity of persisted squares.
+	persisted := make(map[[2]int]*entity.Square, len(a.Grid))
+	for _, s := range a.Grid {
+		persisted[[2]int{s.X, s.Y}] = s
+	}
+	for _, s := range fresh.Grid {
+		if p, ok := persisted[[2]int{s.X, s.Y}]; ok {
+			s.Common = p.Common
+		}
+	}
+
+	return &Replayer{area: fresh, ps: ps}, nil
+}
+
+// AdvanceTo applies every position passed at or before the given time.
+// Positions without a timestamp are applied as soon as they are
+// reached. Returns the number of positions applied.
+func (r *Replayer) AdvanceTo(t time.Time) int {
+	var n int
+	for ; r.next < len(r.ps); r.next++ {
+		p := r.ps[r.next]
+		if p.PassedAt != nil && p.PassedAt.After(t) {
+			break
+		}
+		r.apply(p)
+		n++
+	}
+	return n
+}
+
+// Finish applies all remaining positions. Returns the number of
+// positions applied.
+func (r *Replayer) Finish() int {
+	var n int
+	for ; r.next < len(r.ps); r.next++ {
+		r.apply(r.ps[r.next])
+		n++
+	}
+	return n
+}
+
+// Step applies the next position. Returns the position and the square
+// passed, if a new pass was registered. Returns a nil position once
+// all positions have been applied.
+func (r *Replayer) Step() (*entity.Position, *entity.Square) {
+	if r.next >= len(r.ps) {
+		return nil, nil
+	}
+	p := r.ps[r.next]
+	r.next++
+	return p, r.apply(p)
+}
+
+func (r *Replayer) apply(p *entity.Position) *entity.Square {
+	at := time.Now()
+	if p.PassedAt != nil {
+		at = *p.PassedAt
+	}
+	r.last = p
+	if p.NotCleaning {
+		return nil
+	}
+	// Robot presence isn't persisted between updates.
+	for _, s := range r.area.Grid {
+		s.HasRobotPresent = false
+	}
+	return r.area.VisitAt(p.X, p.Y, at)
+}
+
+// Area returns the replayed cleaning area.
+func (r *Replayer) Area() *entity.CleaningArea {
+	return r.area
+}
+
+// Last returns the last position applied, or nil if no positions
+// have been applied yet.
+func (r *Replayer) Last() *entity.Position {
+	return r.last
+}
+
+// Applied returns the number of positions applied so far.
+func (r *Replayer) Applied() int {
+	return r.next
+}
+
+// At rebuilds a cleaning area as it was at the given point in time.
+func At(a *entity.CleaningArea, ps []*entity.Position, t time.Time) (*entity.CleaningArea, error) {
+	r, err := New(a, ps)
+	if err != nil {
+		return nil, err
+	}
+	r.AdvanceTo(t)
+	return r.Area(), nil
+}
+
+// Check replays an entire session and compares the final state with
+// the session's persisted grid. Squares are considered to have
+// drifted if their number of passes or cleaned state differ; the time
+// a square was cleaned is not compared since older sessions persisted
+// when the update was processed rather than when the position was
+// passed.
+func Check(sess *entity.CleaningSession) (*entity.GridDrift, error) {
+	if len(sess.Area) == 0 {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find a cleaning area for session %s", sess.UID)
+	}
+	a := sess.Area[0]
+
+	r, err := New(a, sess.PositionHistory)
+	if err != nil {
+		return nil, err
+	}
+	r.Finish()
+	replayed := r.Area()
+
+	d := &entity.GridDrift{
+		SessionID:           sess.UID,
+		SquaresTotal:        len(replayed.Grid),
+		PositionsReplayed:   r.Applied(),
+		PersistedCompletion: a.Completion(),
+		ReplayedCompletion:  replayed.Completion(),
+	}
+
+	persisted := make(map[[2]int]*entity.Square, len(a.Grid))
+	for _, s := range a.Grid {
+		persisted[[2]int{s.X, s.Y}] = s
+	}
+	for _, rs := range replayed.Grid {
+		ps, ok := persisted[[2]int{rs.X, rs.Y}]
+		if !ok {
+			// Persisted grid is missing a square, treat it as
+			// untouched.
+			ps = &entity.Square{X: rs.X, Y: rs.Y}
+		}
+		if ps.Passes != rs.Passes || (ps.CleanedAt != nil) != (rs.CleanedAt != nil) {
+			d.Squares = append(d.Squares, &entity.SquareDrift{
+				X:               rs.X,
+				Y:               rs.Y,
+				Passes:          ps.Passes,
+				ReplayedPasses:  rs.Passes,
+				Cleaned:         ps.CleanedAt != nil,
+				ReplayedCleaned: rs.CleanedAt != nil,
+			})
+		}
+	}
+	if len(a.Grid) != len(replayed.Grid) {
+		d.Drift = true
+	}
+	d.SquaresDrifted = len(d.Squares)
+	d.Drift = d.Drift || d.SquaresDrifted > 0
+
+	return d, nil
+}
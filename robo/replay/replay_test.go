@@ -0,0 +1,143 @@
+package replay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2020, 2, 16, 10, 0, 0, 0, time.UTC)
+
+// testSession returns a session on a 2x1 grid (1000x500 mm area) with
+// passes needed 2, where the robot reports from the left square twice,
+// the right square once and the left square again, one position every
+// 10 seconds.
+func testSession() *entity.CleaningSession {
+	r := entity.NewRobot("Johnny 5", 500)
+	a := entity.NewArea("Tiny Room", 1000, 500, 2)
+	sess := entity.NewCleaningSession(r, a, "test")
+
+	xs := []int{250, 300, 750, 250}
+	for i, x := range xs {
+		sess.PositionHistory = append(sess.PositionHistory, entity.NewPosition(x, 250, start.Add(time.Duration(i)*10*time.Second)))
+	}
+
+	// Persist the same result as we'd get by processing each update.
+	for _, p := range sess.PositionHistory {
+		update(sess.Area[0], p.X, p.Y, *p.PassedAt)
+	}
+	return sess
+}
+
+// update applies a position to the grid the way a robot's update is
+// applied, i.e. to a grid loaded without the robot being present.
+func update(a *entity.CleaningArea, x, y int, at time.Time) {
+	for _, s := range a.Grid {
+		s.HasRobotPresent = false
+	}
+	a.VisitAt(x, y, at)
+}
+
+func TestAt(t *testing.T) {
+	sess := testSession()
+
+	a, err := At(sess.Area[0], sess.PositionHistory, start.Add(-time.Second))
+	require.NoError(t, err)
+	require.Equal(t, "0.00", a.Completion(), "should have a fresh grid before the session started")
+
+	a, err = At(sess.Area[0], sess.PositionHistory, start.Add(5*time.Second))
+	require.NoError(t, err)
+	require.Equal(t, 1, a.Grid[0].Passes)
+	require.Equal(t, 0, a.Grid[1].Passes)
+	require.Nil(t, a.Grid[0].CleanedAt)
+
+	a, err = At(sess.Area[0], sess.PositionHistory, start.Add(10*time.Second))
+	require.NoError(t, err)
+	require.Equal(t, 2, a.Grid[0].Passes, "should count every update as a pass, also from the same square")
+	require.Equal(t, "50.00", a.Completion())
+	require.True(t, start.Add(10*time.Second).Equal(*a.Grid[0].CleanedAt), "should clean squares at the time the position was passed")
+	require.Equal(t, sess.Area[0].Grid[0].UID, a.Grid[0].UID, "should keep the identity of persisted squares")
+
+	require.Equal(t, 3, sess.Area[0].Grid[0].Passes, "should not touch the persisted grid")
+}
+
+func TestReplayer(t *testing.T) {
+	sess := testSession()
+
+	r, err := New(sess.Area[0], sess.PositionHistory)
+	require.NoError(t, err)
+	require.Nil(t, r.Last())
+
+	require.Equal(t, 2, r.AdvanceTo(start.Add(15*time.Second)))
+	require.Equal(t, 300, r.Last().X)
+	require.Equal(t, 0, r.AdvanceTo(start.Add(15*time.Second)), "should not apply positions twice")
+	require.Equal(t, 2, r.Finish())
+	require.Equal(t, 4, r.Applied())
+
+	r, err = New(sess.Area[0], sess.PositionHistory)
+	require.NoError(t, err)
+	var passes int
+	for p, sq := r.Step(); p != nil; p, sq = r.Step() {
+		if sq != nil {
+			passes++
+		}
+	}
+	require.Equal(t, 4, passes, "should register a pass for each position")
+
+	_, err = New(&entity.CleaningArea{}, nil)
+	require.Error(t, err, "should fail without a grid")
+}
+
+func TestCheck(t *testing.T) {
+	sess := testSession()
+
+	d, err := Check(sess)
+	require.NoError(t, err)
+	require.False(t, d.Drift, "should match the persisted grid")
+	require.Equal(t, 2, d.SquaresTotal)
+	require.Equal(t, 4, d.PositionsReplayed)
+	require.Equal(t, "50.00", d.ReplayedCompletion)
+
+	// Persisted grid only counted a pass when the robot entered a
+	// square.
+	sess.Area[0].Grid[0].Passes = 2
+	sess.Area[0].Grid[1].CleanedAt = &start
+
+	d, err = Check(sess)
+	require.NoError(t, err)
+	require.True(t, d.Drift, "should flag drift")
+	require.Equal(t, 2, d.SquaresDrifted)
+	require.Equal(t, 2, d.Squares[0].Passes)
+	require.Equal(t, 3, d.Squares[0].ReplayedPasses)
+	require.True(t, d.Squares[1].Cleaned)
+	require.False(t, d.Squares[1].ReplayedCleaned)
+	require.Equal(t, "100.00", d.PersistedCompletion)
+}
+
+func TestNotCleaning(t *testing.T) {
+	r := entity.NewRobot("Johnny 5", 500)
+	a := entity.NewArea("Tiny Room", 1000, 500, 2)
+	sess := entity.NewCleaningSession(r, a, "test")
+
+	// Robot cleans the left square, docks in the right square and
+	// leaves the dock into the left square again.
+	sess.PositionHistory = []*entity.Position{
+		entity.NewPosition(250, 250, start),
+		entity.NewNonCleaningPosition(750, 250, start.Add(10*time.Second)),
+		entity.NewNonCleaningPosition(250, 250, start.Add(20*time.Second)),
+		entity.NewPosition(300, 250, start.Add(30*time.Second)),
+	}
+	update(sess.Area[0], 250, 250, start)
+	update(sess.Area[0], 300, 250, start.Add(30*time.Second))
+
+	replayed, err := At(sess.Area[0], sess.PositionHistory, start.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 2, replayed.Grid[0].Passes, "should not count leaving the dock as a pass")
+	require.Equal(t, 0, replayed.Grid[1].Passes, "should not count docking as a pass")
+
+	d, err := Check(sess)
+	require.NoError(t, err)
+	require.False(t, d.Drift, "should match the persisted grid")
+}
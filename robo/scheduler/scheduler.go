@@ -0,0 +1,215 @@
+// Package scheduler triggers cleaning schedules in the background. At
+// every trigger time it sends a start command to the first idle robot
+// of the schedule, and records the run. Runs are marked started once
+// the robot starts a cleaning session in the area, or missed if no
+// robot was idle or no session started in time.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/pkg/errors"
+)
+
+// Config holds the worker settings.
+type Config struct {
+	// Interval is how often we look for schedules to trigger and runs
+	// to reconcile.
+	Interval time.Duration
+	// StartTimeout is how long a robot has to start a session after
+	// being sent a start command. Runs triggered later than this after
+	// their scheduled time, e.g. because the server was down, are
+	// missed.
+	StartTimeout time.Duration
+	// ClockSkew is how much earlier than the command was sent a
+	// session may start according to the robot's clock.
+	ClockSkew time.Duration
+	// BatchSize is the max number of schedules triggered and runs
+	// reconciled per tick.
+	BatchSize int
+}
+
+// DefaultConfig returns the default worker settings.
+func DefaultConfig() Config {
+	return Config{
+		Interval:     15 * time.Second,
+		StartTimeout: 5 * time.Minute,
+		ClockSkew:    time.Minute,
+		BatchSize:    100,
+	}
+}
+
+// Worker triggers schedules and reconciles their runs. Only one worker
+// should run against a database at a time.
+type Worker struct {
+	s entity.ScheduleRepository
+	r entity.RobotRepository
+	t entity.SessionRepository
+	p entity.CommandPublisher
+	c Config
+}
+
+// NewWorker creates a new worker. Start commands are sent with the
+// given publisher.
+func NewWorker(s entity.ScheduleRepository, r entity.RobotRepository, t entity.SessionRepository, p entity.CommandPublisher, c Config) *Worker {
+	return &Worker{s, r, t, p, c}
+}
+
+// Run triggers schedules and reconciles runs every interval until the
+// context is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	t := time.NewTicker(w.c.Interval)
+	defer t.Stop()
+	for {
+		if err := w.Tick(ctx, time.Now()); err != nil {
+			log.Printf("scheduler: %s", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Tick triggers all schedules due and then reconciles dispatched runs.
+func (w *Worker) Tick(ctx context.Context, now time.Time) error {
+	if _, err := w.Trigger(ctx, now); err != nil {
+		return errors.Wrap(err, "could not trigger schedules")
+	}
+	if _, err := w.Reconcile(ctx, now); err != nil {
+		return errors.Wrap(err, "could not reconcile job runs")
+	}
+	return nil
+}
+
+// Trigger records a run for every schedule due, sends start commands
+// and returns the number of runs dispatched. A schedule's next run
+// time is moved forward in the same mutation that records its run, so
+// each trigger time gets exactly one run. Trigger times missed while
+// the worker wasn't running are recorded as a single missed run.
+func (w *Worker) Trigger(ctx context.Context, now time.Time) (int, error) {
+	due, err := w.s.Due(ctx, now, w.c.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for _, sc := range due {
+		next, err := sc.Next(now)
+		if err != nil {
+			return n, errors.Wrapf(err, "invalid schedule %s", sc.UID)
+		}
+
+		scheduledAt := *sc.NextRunAt
+		var run *entity.JobRun
+		if now.Sub(scheduledAt) > w.c.StartTimeout {
+			run = entity.NewJobRun(sc, "", scheduledAt, now, now)
+			run.Miss(fmt.Sprintf("triggered %s late", now.Sub(scheduledAt).Round(time.Second)))
+		} else {
+			robotID, err := w.idleRobot(ctx, sc.RobotIDs)
+			if err != nil {
+				return n, err
+			}
+			run = entity.NewJobRun(sc, robotID, scheduledAt, now, now.Add(w.c.StartTimeout))
+			if robotID == "" {
+				run.Miss("no idle robot")
+			}
+		}
+
+		uids, err := w.s.Update(ctx, &entity.Schedule{
+			NextRunAt: next,
+			Runs:      []*entity.JobRun{run},
+			Common:    entity.Common{UID: sc.UID},
+		})
+		if err != nil {
+			return n, errors.Wrapf(err, "could not persist run of schedule %s", sc.UID)
+		}
+		if run.Status != entity.JobRunDispatched {
+			continue
+		}
+		run.UID = uids[entity.JobRunUID]
+
+		err = w.p.PublishCommand(&entity.Command{
+			Type:         entity.CommandStartSession,
+			RobotID:      run.RobotID,
+			AreaID:       sc.AreaID,
+			PassesNeeded: sc.PassesNeeded,
+			JobRunID:     run.UID,
+			IssuedAt:     now,
+		})
+		if err != nil {
+			missed := &entity.JobRun{Common: entity.Common{UID: run.UID}}
+			missed.Miss("could not send start command: " + err.Error())
+			if _, err := w.s.Save(ctx, missed); err != nil {
+				return n, errors.Wrapf(err, "could not persist run %s", run.UID)
+			}
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
+
+// idleRobot returns the first robot without an active session, or an
+// empty string if all are busy or have been deleted.
+func (w *Worker) idleRobot(ctx context.Context, robotIDs []string) (string, error) {
+	for _, id := range robotIDs {
+		robot, err := w.r.Get(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		if robot == nil {
+			continue
+		}
+		if len(robot.Session) == 0 || robot.Session[0].EndedAt != nil {
+			return robot.UID, nil
+		}
+	}
+	return "", nil
+}
+
+// Reconcile looks for the sessions started by dispatched runs, marks
+// them started or, once their start timeout has passed, missed, and
+// returns the number of runs started.
+func (w *Worker) Reconcile(ctx context.Context, now time.Time) (int, error) {
+	runs, err := w.s.Dispatched(ctx, w.c.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for _, run := range runs {
+		from := run.DispatchedAt.Add(-w.c.ClockSkew)
+		res, err := w.t.List(ctx, entity.ListSessionsArgs{
+			RobotID: run.RobotID,
+			AreaID:  run.AreaID,
+			From:    &from,
+			Limit:   1,
+		})
+		if err != nil {
+			return n, err
+		}
+
+		update := &entity.JobRun{Common: entity.Common{UID: run.UID}}
+		switch {
+		case len(res.Sessions) > 0:
+			update.Status = entity.JobRunStarted
+			update.SessionID = res.Sessions[0].Session.UID
+			n++
+		case now.After(*run.StartBy):
+			update.Miss(fmt.Sprintf("robot did not start a session within %s", run.StartBy.Sub(*run.DispatchedAt)))
+		default:
+			continue
+		}
+
+		if _, err := w.s.Save(ctx, update); err != nil {
+			return n, errors.Wrapf(err, "could not persist run %s", run.UID)
+		}
+	}
+	return n, nil
+}
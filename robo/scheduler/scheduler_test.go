@@ -0,0 +1,257 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/stretchr/testify/require"
+)
+
+// stubSchedules is an in-memory entity.ScheduleRepository.
+type stubSchedules struct {
+	entity.ScheduleRepository
+	schedules []*entity.Schedule
+	runs      []*entity.JobRun
+}
+
+func (r *stubSchedules) Due(ctx context.Context, now time.Time, limit int) ([]*entity.Schedule, error) {
+	var due []*entity.Schedule
+	for _, s := range r.schedules {
+		if s.NextRunAt != nil && !s.NextRunAt.After(now) {
+			due = append(due, s)
+		}
+	}
+	return due, nil
+}
+
+func (r *stubSchedules) Dispatched(ctx context.Context, limit int) ([]*entity.JobRun, error) {
+	var runs []*entity.JobRun
+	for _, run := range r.runs {
+		if run.Status == entity.JobRunDispatched {
+			runs = append(runs, run)
+		}
+	}
+	return runs, nil
+}
+
+// Update merges the next run time and stores new runs, like Dgraph
+// would.
+func (r *stubSchedules) Update(ctx context.Context, u *entity.Schedule) (map[string]string, error) {
+	uids := map[string]string{}
+	for _, s := range r.schedules {
+		if s.UID == u.UID {
+			s.NextRunAt = u.NextRunAt
+			for _, run := range u.Runs {
+				run.UID = "0xj" + strconv.Itoa(len(r.runs)+1)
+				uids[entity.JobRunUID] = run.UID
+				r.runs = append(r.runs, run)
+			}
+		}
+	}
+	return uids, nil
+}
+
+// Save merges run updates.
+func (r *stubSchedules) Save(ctx context.Context, o interface{}) (map[string]string, error) {
+	u := o.(*entity.JobRun)
+	for _, run := range r.runs {
+		if run.UID == u.UID {
+			run.Status = u.Status
+			if u.SessionID != "" {
+				run.SessionID = u.SessionID
+			}
+			if u.Message != "" {
+				run.Message = u.Message
+			}
+		}
+	}
+	return map[string]string{}, nil
+}
+
+// stubRobots is an entity.RobotRepository with canned robots.
+type stubRobots struct {
+	entity.RobotRepository
+	robots map[string]*entity.Robot
+}
+
+func (r *stubRobots) Get(ctx context.Context, robotID string) (*entity.Robot, error) {
+	return r.robots[robotID], nil
+}
+
+// stubSessions is an entity.SessionRepository with canned sessions.
+type stubSessions struct {
+	entity.SessionRepository
+	sessions []*entity.SessionSummary
+}
+
+func (r *stubSessions) List(ctx context.Context, a entity.ListSessionsArgs) (*entity.ListSessionsResult, error) {
+	res := &entity.ListSessionsResult{}
+	for _, s := range r.sessions {
+		if s.Robot.UID == a.RobotID && s.Session.SourceArea[0].UID == a.AreaID && !s.Session.StartedAt.Before(*a.From) {
+			res.Sessions = append(res.Sessions, s)
+		}
+	}
+	return res, nil
+}
+
+// stubPublisher records commands.
+type stubPublisher struct {
+	commands []*entity.Command
+	err      error
+}
+
+func (p *stubPublisher) PublishCommand(c *entity.Command) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.commands = append(p.commands, c)
+	return nil
+}
+
+var now = time.Date(2020, 2, 17, 8, 0, 0, 0, time.UTC) // Monday 09:00 in Stockholm.
+
+func robot(id string, busy bool) *entity.Robot {
+	r := &entity.Robot{Common: entity.Common{UID: id}}
+	if busy {
+		r.Session = []*entity.CleaningSession{{IsActive: true}}
+	}
+	return r
+}
+
+func schedule(id string, robotIDs ...string) *entity.Schedule {
+	s := entity.NewSchedule(id, "a1", robotIDs, "0 9 * * MON-FRI", "Europe/Stockholm", 2)
+	s.UID = id
+	s.NextRunAt = &now
+	return s
+}
+
+func TestTrigger(t *testing.T) {
+	schedules := &stubSchedules{
+		schedules: []*entity.Schedule{
+			schedule("s1", "r1", "r2"), // r1 is busy.
+			schedule("s2", "r1"),       // No robot idle.
+			schedule("s3", "r3"),       // Deleted robot.
+		},
+	}
+	robots := &stubRobots{robots: map[string]*entity.Robot{
+		"r1": robot("r1", true),
+		"r2": robot("r2", false),
+	}}
+	p := &stubPublisher{}
+	w := NewWorker(schedules, robots, &stubSessions{}, p, DefaultConfig())
+
+	n, err := w.Trigger(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	require.Equal(t, []*entity.Command{{
+		Type:         entity.CommandStartSession,
+		RobotID:      "r2",
+		AreaID:       "a1",
+		PassesNeeded: 2,
+		JobRunID:     "0xj1",
+		IssuedAt:     now,
+	}}, p.commands, "should send a start command to the first idle robot")
+
+	require.Equal(t, 3, len(schedules.runs))
+	require.Equal(t, entity.JobRunDispatched, schedules.runs[0].Status)
+	require.Equal(t, now.Add(DefaultConfig().StartTimeout), *schedules.runs[0].StartBy)
+	require.Equal(t, entity.JobRunMissed, schedules.runs[1].Status, "should miss runs without idle robots")
+	require.Equal(t, entity.JobRunMissed, schedules.runs[2].Status)
+
+	for _, s := range schedules.schedules {
+		require.Equal(t, now.Add(24*time.Hour).String(), s.NextRunAt.UTC().String(), "should move to the next trigger time")
+	}
+
+	n, err = w.Trigger(context.Background(), now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 0, n, "should trigger once per trigger time")
+
+	// Trigger times missed while not running are recorded as missed.
+	late := now.Add(24*time.Hour + time.Hour)
+	n, err = w.Trigger(context.Background(), late)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+	require.Equal(t, entity.JobRunMissed, schedules.runs[3].Status)
+	require.Equal(t, "triggered 1h0m0s late", schedules.runs[3].Message)
+
+	// Friday to Monday.
+	fri := time.Date(2020, 2, 21, 8, 0, 0, 0, time.UTC)
+	schedules.schedules = []*entity.Schedule{schedule("s4", "r2")}
+	schedules.schedules[0].NextRunAt = &fri
+	_, err = w.Trigger(context.Background(), fri)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2020, 2, 24, 8, 0, 0, 0, time.UTC).String(), schedules.schedules[0].NextRunAt.UTC().String())
+
+	// Runs are missed if the command can't be sent.
+	p.err = errors.New("broker is down")
+	schedules.schedules[0].NextRunAt = &fri
+	_, err = w.Trigger(context.Background(), fri)
+	require.NoError(t, err)
+	last := schedules.runs[len(schedules.runs)-1]
+	require.Equal(t, entity.JobRunMissed, last.Status)
+	require.Contains(t, last.Message, "broker is down")
+}
+
+func TestTriggerAfterEndedSession(t *testing.T) {
+	// The robot's previous session has ended, but is_active is never
+	// cleared in Dgraph.
+	endedAt := now.Add(-time.Hour)
+	r1 := robot("r1", true)
+	r1.Session[0].EndedAt = &endedAt
+
+	schedules := &stubSchedules{schedules: []*entity.Schedule{schedule("s1", "r1")}}
+	robots := &stubRobots{robots: map[string]*entity.Robot{"r1": r1}}
+	p := &stubPublisher{}
+	w := NewWorker(schedules, robots, &stubSessions{}, p, DefaultConfig())
+
+	n, err := w.Trigger(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, 1, len(p.commands), "should schedule onto a robot whose session has ended")
+	require.Equal(t, "r1", p.commands[0].RobotID)
+	require.Equal(t, entity.JobRunDispatched, schedules.runs[0].Status)
+}
+
+func TestReconcile(t *testing.T) {
+	c := DefaultConfig()
+	startBy := now.Add(c.StartTimeout)
+	dispatched := func(id, robotID string) *entity.JobRun {
+		r := entity.NewJobRun(schedule("s1"), robotID, now, now, startBy)
+		r.UID = id
+		return r
+	}
+	schedules := &stubSchedules{
+		runs: []*entity.JobRun{
+			dispatched("j1", "r1"),
+			dispatched("j2", "r2"),
+		},
+	}
+
+	startedAt := now.Add(-10 * time.Second) // The robot's clock is slightly behind.
+	sessions := &stubSessions{sessions: []*entity.SessionSummary{{
+		Session: &entity.CleaningSession{
+			StartedAt:  &startedAt,
+			SourceArea: []*entity.Area{{Common: entity.Common{UID: "a1"}}},
+			Common:     entity.Common{UID: "cs1"},
+		},
+		Robot: &entity.Robot{Common: entity.Common{UID: "r1"}},
+	}}}
+	w := NewWorker(schedules, &stubRobots{}, sessions, &stubPublisher{}, c)
+
+	n, err := w.Reconcile(context.Background(), now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, entity.JobRunStarted, schedules.runs[0].Status)
+	require.Equal(t, "cs1", schedules.runs[0].SessionID, "should record the session started")
+	require.Equal(t, entity.JobRunDispatched, schedules.runs[1].Status, "should wait for the start timeout")
+
+	_, err = w.Reconcile(context.Background(), startBy.Add(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, entity.JobRunMissed, schedules.runs[1].Status, "should miss runs without a session in time")
+	require.Equal(t, "robot did not start a session within 5m0s", schedules.runs[1].Message)
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/anrid/roboviewer/robo/pkg/webhook"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultAlertsLimit is the default number of alerts returned.
+	DefaultAlertsLimit = 100
+	// MaxAlertsLimit is the max number of alerts returned.
+	MaxAlertsLimit = 1000
+)
+
+// AlertService holds use cases related to alert rules and alerts.
+// Rules are evaluated by a background worker, see package alerting.
+type AlertService struct {
+	r entity.AlertRepository
+}
+
+// NewAlertService creates a new alert service instance.
+func NewAlertService(r entity.AlertRepository) *AlertService {
+	return &AlertService{r}
+}
+
+// ListRules returns all alert rules.
+func (co *AlertService) ListRules(ctx context.Context) ([]*entity.AlertRule, error) {
+	return co.r.ListRules(ctx)
+}
+
+// GetRule returns an alert rule by id.
+func (co *AlertService) GetRule(ctx context.Context, ruleID string) (*entity.AlertRule, error) {
+	rule, err := co.r.GetRule(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find alert rule with id %s", ruleID)
+	}
+	return rule, nil
+}
+
+// CreateRule creates a new alert rule. A secret used to sign webhook
+// requests is generated unless one is given.
+func (co *AlertService) CreateRule(ctx context.Context, a entity.CreateAlertRuleArgs) (*entity.AlertRule, error) {
+	threshold := entity.DefaultAlertThreshold(a.RuleType)
+	if a.Threshold != nil {
+		threshold = *a.Threshold
+	}
+	secret := a.Secret
+	if secret == "" {
+		secret = webhook.NewSecret()
+	}
+
+	rule := entity.NewAlertRule(strings.TrimSpace(a.Name), a.RuleType, threshold, strings.TrimSpace(a.WebhookURL), secret)
+	if err := validateAlertRule(rule); err != nil {
+		return nil, err
+	}
+
+	uids, err := co.r.Save(ctx, rule)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not persist alert rule")
+	}
+	rule.UID = uids[entity.AlertRuleUID]
+
+	return rule, nil
+}
+
+// UpdateRule updates an alert rule. The rule type cannot be changed.
+func (co *AlertService) UpdateRule(ctx context.Context, a entity.UpdateAlertRuleArgs) (*entity.AlertRule, error) {
+	rule, err := co.GetRule(ctx, a.RuleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Name != nil {
+		rule.Name = strings.TrimSpace(*a.Name)
+	}
+	if a.Threshold != nil {
+		rule.Threshold = *a.Threshold
+	}
+	if a.WebhookURL != nil {
+		rule.WebhookURL = strings.TrimSpace(*a.WebhookURL)
+	}
+	if a.Secret != nil {
+		rule.Secret = *a.Secret
+	}
+	if err := validateAlertRule(rule); err != nil {
+		return nil, err
+	}
+
+	_, err = co.r.Save(ctx, &entity.AlertRule{
+		Name:       rule.Name,
+		Threshold:  rule.Threshold,
+		WebhookURL: rule.WebhookURL,
+		Secret:     rule.Secret,
+		Common:     entity.Common{UID: rule.UID},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not persist alert rule")
+	}
+
+	return rule, nil
+}
+
+// DeleteRule soft-deletes an alert rule. Alerts pending delivery are
+// dropped.
+func (co *AlertService) DeleteRule(ctx context.Context, ruleID string) error {
+	rule, err := co.GetRule(ctx, ruleID)
+	if err != nil {
+		return err
+	}
+
+	deletedAt := time.Now()
+	_, err = co.r.Save(ctx, &entity.AlertRule{
+		DeletedAt: &deletedAt,
+		Common:    entity.Common{UID: rule.UID},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete alert rule")
+	}
+	return nil
+}
+
+// List returns fired alerts including their delivery log, latest
+// first.
+func (co *AlertService) List(ctx context.Context, a entity.ListAlertsArgs) ([]*entity.Alert, error) {
+	if a.Limit == 0 {
+		a.Limit = DefaultAlertsLimit
+	}
+	if a.Limit < 0 || a.Limit > MaxAlertsLimit {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "limit must be between 1 and %d, got %d", MaxAlertsLimit, a.Limit)
+	}
+	return co.r.List(ctx, a)
+}
+
+// validateAlertRule checks that an alert rule is valid.
+func validateAlertRule(r *entity.AlertRule) error {
+	if r.Name == "" {
+		return errors.Wrap(cerr.ErrValidationFailed, "alert rule name cannot be empty")
+	}
+	var known bool
+	for _, t := range entity.AlertRuleTypes {
+		known = known || t == r.RuleType
+	}
+	if !known {
+		return errors.Wrapf(cerr.ErrValidationFailed, "alert rule type must be one of %v, got '%s'", entity.AlertRuleTypes, r.RuleType)
+	}
+	if r.Threshold < 0 {
+		return errors.Wrapf(cerr.ErrValidationFailed, "alert rule threshold cannot be negative, got %g", r.Threshold)
+	}
+	if (r.RuleType == entity.AlertLowCompletion || r.RuleType == entity.AlertLowBattery) && r.Threshold > 100 {
+		return errors.Wrapf(cerr.ErrValidationFailed, "alert rule threshold must be a percentage, got %g", r.Threshold)
+	}
+	return validateWebhookURL(r.WebhookURL)
+}
+
+// validateWebhookURL checks that a webhook URL is an absolute HTTP(S)
+// URL.
+func validateWebhookURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Wrapf(cerr.ErrValidationFailed, "webhook url must be an absolute http or https url, got '%s'", s)
+	}
+	return nil
+}
@@ -2,8 +2,19 @@ package service
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultAreasLimit is the default page size when listing areas.
+	DefaultAreasLimit = 100
+	// MaxAreasLimit is the max page size when listing areas.
+	MaxAreasLimit = 1000
 )
 
 // AreaService holds all the route handlers (endpoints)
@@ -17,11 +28,129 @@ func NewAreaService(r entity.AreaRepository) *AreaService {
 	return &AreaService{r}
 }
 
-// List returns a list of all robots.
-func (co *AreaService) List(ctx context.Context) ([]*entity.Area, error) {
-	res, err := co.r.List(ctx)
+// List returns a page of areas, optionally filtered by name.
+func (co *AreaService) List(ctx context.Context, a entity.ListAreasArgs) (*entity.ListAreasResult, error) {
+	if a.Limit == 0 {
+		a.Limit = DefaultAreasLimit
+	}
+	if a.Limit < 0 || a.Limit > MaxAreasLimit {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "limit must be between 1 and %d, got %d", MaxAreasLimit, a.Limit)
+	}
+	return co.r.List(ctx, a)
+}
+
+// Get returns an area by id.
+func (co *AreaService) Get(ctx context.Context, areaID string) (*entity.Area, error) {
+	area, err := co.r.Get(ctx, areaID)
 	if err != nil {
 		return nil, err
 	}
-	return res.Areas, nil
+	if area == nil {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find area with id %s", areaID)
+	}
+	return area, nil
+}
+
+// Create creates a new area.
+func (co *AreaService) Create(ctx context.Context, a entity.CreateAreaArgs) (*entity.Area, error) {
+	area := entity.NewArea(strings.TrimSpace(a.Name), a.SizeX, a.SizeY, a.PassesNeeded)
+	if a.Anchor != nil {
+		area.SetAnchor(a.Anchor)
+	}
+	if err := validateArea(area); err != nil {
+		return nil, err
+	}
+
+	uids, err := co.r.Save(ctx, area)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not persist new area")
+	}
+	area.UID = uids[entity.AreaUID]
+
+	return area, nil
+}
+
+// Update updates an area. Cleaning sessions keep their own copy of
+// the area (a CleaningArea), so past sessions are not affected.
+func (co *AreaService) Update(ctx context.Context, a entity.UpdateAreaArgs) (*entity.Area, error) {
+	area, err := co.Get(ctx, a.AreaID)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Name != nil {
+		area.Name = strings.TrimSpace(*a.Name)
+	}
+	if a.SizeX != nil {
+		area.SizeX = *a.SizeX
+	}
+	if a.SizeY != nil {
+		area.SizeY = *a.SizeY
+	}
+	if a.PassesNeeded != nil {
+		area.PassesNeeded = *a.PassesNeeded
+	}
+	if a.Anchor != nil {
+		area.SetAnchor(a.Anchor)
+	}
+	if err := validateArea(area); err != nil {
+		return nil, err
+	}
+
+	_, err = co.r.Save(ctx, &entity.Area{
+		Name:           area.Name,
+		SizeX:          area.SizeX,
+		SizeY:          area.SizeY,
+		PassesNeeded:   area.PassesNeeded,
+		AnchorLat:      area.AnchorLat,
+		AnchorLon:      area.AnchorLon,
+		AnchorRotation: area.AnchorRotation,
+		Common:         entity.Common{UID: area.UID},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not persist area")
+	}
+
+	return area, nil
+}
+
+// Delete soft-deletes an area.
+func (co *AreaService) Delete(ctx context.Context, areaID string) error {
+	area, err := co.Get(ctx, areaID)
+	if err != nil {
+		return err
+	}
+
+	deletedAt := time.Now()
+	_, err = co.r.Save(ctx, &entity.Area{
+		DeletedAt: &deletedAt,
+		Common:    entity.Common{UID: area.UID},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete area")
+	}
+	return nil
+}
+
+// validateArea checks that an area has a name, positive sizes and
+// needs at least one pass per grid square.
+func validateArea(a *entity.Area) error {
+	if a.Name == "" {
+		return errors.Wrap(cerr.ErrValidationFailed, "area name cannot be empty")
+	}
+	if a.SizeX <= 0 || a.SizeY <= 0 {
+		return errors.Wrapf(cerr.ErrValidationFailed, "area sizes must be greater than 0, got size_x = %d size_y = %d", a.SizeX, a.SizeY)
+	}
+	if a.PassesNeeded <= 0 {
+		return errors.Wrapf(cerr.ErrValidationFailed, "area passes needed must be greater than 0, got %d", a.PassesNeeded)
+	}
+	if g := a.Anchor(); g != nil {
+		if g.Lat < -90 || g.Lat > 90 || g.Lon < -180 || g.Lon > 180 {
+			return errors.Wrapf(cerr.ErrValidationFailed, "area anchor must be a valid WGS84 coordinate, got lat = %g lon = %g", g.Lat, g.Lon)
+		}
+		if g.Rotation < -360 || g.Rotation > 360 {
+			return errors.Wrapf(cerr.ErrValidationFailed, "area anchor rotation must be between -360 and 360 degrees, got %g", g.Rotation)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/pkg/errors"
+)
+
+// DockService holds use cases related to docks, the charging stations
+// robots return to mid-session.
+type DockService struct {
+	r entity.DockRepository
+	a entity.AreaRepository
+}
+
+// NewDockService creates a new dock service instance.
+func NewDockService(r entity.DockRepository, a entity.AreaRepository) *DockService {
+	return &DockService{r, a}
+}
+
+// List returns the docks in an area.
+func (co *DockService) List(ctx context.Context, areaID string) ([]*entity.Dock, error) {
+	if _, err := co.area(ctx, areaID); err != nil {
+		return nil, err
+	}
+	return co.r.List(ctx, areaID)
+}
+
+// Create places a new dock in an area. Docks must be within the area.
+func (co *DockService) Create(ctx context.Context, a entity.CreateDockArgs) (*entity.Dock, error) {
+	area, err := co.area(ctx, a.AreaID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(a.Name)
+	if name == "" {
+		return nil, errors.Wrap(cerr.ErrValidationFailed, "dock name cannot be empty")
+	}
+	if a.X < 0 || a.X > area.SizeX || a.Y < 0 || a.Y > area.SizeY {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "dock position %d,%d is outside area %s (%dx%d)", a.X, a.Y, area.UID, area.SizeX, area.SizeY)
+	}
+
+	d := entity.NewDock(name, area.UID, a.X, a.Y)
+
+	uids, err := co.r.Save(ctx, d)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not persist new dock")
+	}
+	d.UID = uids[entity.DockUID]
+
+	return d, nil
+}
+
+// Delete soft-deletes a dock.
+func (co *DockService) Delete(ctx context.Context, dockID string) error {
+	d, err := co.r.Get(ctx, dockID)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		return errors.Wrapf(cerr.ErrNotFound, "could not find dock with id %s", dockID)
+	}
+
+	deletedAt := time.Now()
+	_, err = co.r.Save(ctx, &entity.Dock{
+		DeletedAt: &deletedAt,
+		Common:    entity.Common{UID: d.UID},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete dock")
+	}
+	return nil
+}
+
+// area returns an area by id.
+func (co *DockService) area(ctx context.Context, areaID string) (*entity.Area, error) {
+	area, err := co.a.Get(ctx, areaID)
+	if err != nil {
+		return nil, err
+	}
+	if area == nil {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find area with id %s", areaID)
+	}
+	return area, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/analytics"
+	"github.com/anrid/roboviewer/robo/entity"
+)
+
+// ETAHistorySessions is the max number of past sessions by the same
+// robot in the same area we base an ETA on.
+const ETAHistorySessions = 20
+
+// estimateETA estimates when an active session will complete based on
+// how far it has come and on past sessions by the same robot in the
+// same area. Squares and passes are counted by the repository rather
+// than loading the grid. The session must have a UID and a start
+// time, and time spent docked to leave it out. Returns nil if there is
+// nothing to base an estimate on.
+func estimateETA(ctx context.Context, r entity.SessionRepository, robotID string, sess *entity.CleaningSession) (*entity.ETA, error) {
+	if sess.StartedAt == nil {
+		return nil, nil
+	}
+	now := sess.StartedAt
+	if sess.LastReportedAt != nil {
+		now = sess.LastReportedAt
+	}
+
+	c, err := r.Coverage(ctx, entity.SessionCoverageArgs{
+		SessionID: sess.UID,
+		From:      analytics.RecentSince(*sess.StartedAt, *now),
+		To:        *now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, nil
+	}
+
+	var history []time.Duration
+	if robotID != "" && c.AreaID != "" {
+		ended := false
+		res, err := r.List(ctx, entity.ListSessionsArgs{
+			RobotID: robotID,
+			AreaID:  c.AreaID,
+			Active:  &ended,
+			Limit:   ETAHistorySessions,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range res.Sessions {
+			// Skip sessions that never completed or ran on a differently
+			// sized grid, e.g. before the area was resized.
+			if s.Session.CompletedAt == nil || s.Progress.SquaresTotal != c.SquaresTotal {
+				continue
+			}
+			history = append(history, time.Duration(s.Session.TimeToCompleteSec)*time.Second)
+		}
+	}
+
+	return analytics.ETA(analytics.ETAArgs{
+		Coverage:   c,
+		StartedAt:  *sess.StartedAt,
+		Now:        *now,
+		RunningSec: sess.RunningSec(*now),
+		History:    history,
+	}), nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/stretchr/testify/require"
+)
+
+// stubETARepository is an entity.SessionRepository with canned
+// coverage and past sessions.
+type stubETARepository struct {
+	entity.SessionRepository
+	coverage *entity.SessionCoverage
+	sessions []*entity.SessionSummary
+	args     entity.SessionCoverageArgs
+	list     entity.ListSessionsArgs
+}
+
+func (r *stubETARepository) Coverage(ctx context.Context, a entity.SessionCoverageArgs) (*entity.SessionCoverage, error) {
+	r.args = a
+	return r.coverage, nil
+}
+
+func (r *stubETARepository) List(ctx context.Context, a entity.ListSessionsArgs) (*entity.ListSessionsResult, error) {
+	r.list = a
+	return &entity.ListSessionsResult{Sessions: r.sessions}, nil
+}
+
+func TestEstimateETA(t *testing.T) {
+	ctx := context.Background()
+	startedAt := time.Date(2020, 2, 16, 10, 0, 0, 0, time.UTC)
+	reportedAt := startedAt.Add(10 * time.Minute)
+	sess := &entity.CleaningSession{
+		StartedAt:      &startedAt,
+		LastReportedAt: &reportedAt,
+		Common:         entity.Common{UID: "0x1"},
+	}
+
+	repo := &stubETARepository{
+		coverage: &entity.SessionCoverage{
+			AreaID:          "0x2",
+			PassesNeeded:    1,
+			SquaresTotal:    40,
+			RemainingPasses: 30,
+			CleanedRecently: 10,
+		},
+	}
+	eta, err := estimateETA(ctx, repo, "0x3", sess)
+	require.NoError(t, err)
+	require.Equal(t, 30*60, eta.Sec, "should need 30 more minutes at 1 square per minute")
+	require.Equal(t, reportedAt.Add(30*time.Minute), eta.At)
+	require.Equal(t, startedAt, repo.args.From, "should count squares cleaned since the session started")
+	require.Equal(t, reportedAt, repo.args.To, "should count squares cleaned until the last report")
+	require.False(t, *repo.list.Active, "should base history on ended sessions")
+	require.Equal(t, "0x2", repo.list.AreaID)
+
+	// Past sessions on a differently sized grid are skipped.
+	completedAt := startedAt
+	repo.sessions = []*entity.SessionSummary{
+		{Session: &entity.CleaningSession{CompletedAt: &completedAt, TimeToCompleteSec: 40 * 60}, Progress: entity.SessionProgress{SquaresTotal: 40}},
+		{Session: &entity.CleaningSession{CompletedAt: &completedAt, TimeToCompleteSec: 10 * 60}, Progress: entity.SessionProgress{SquaresTotal: 20}},
+		{Session: &entity.CleaningSession{TimeToCompleteSec: 10 * 60}, Progress: entity.SessionProgress{SquaresTotal: 40}},
+	}
+	eta, err = estimateETA(ctx, repo, "0x3", sess)
+	require.NoError(t, err)
+	require.Equal(t, 30*60, eta.Sec, "should agree with the one comparable past session")
+
+	repo = &stubETARepository{
+		coverage: &entity.SessionCoverage{
+			PassesNeeded:    1,
+			SquaresTotal:    40,
+			RemainingPasses: 40,
+		},
+	}
+	eta, err = estimateETA(ctx, repo, "0x3", sess)
+	require.NoError(t, err)
+	require.Nil(t, eta, "should not estimate without any cleaned squares")
+
+	// Half the session was spent docked.
+	sess.PausedSec = 5 * 60
+	repo = &stubETARepository{
+		coverage: &entity.SessionCoverage{
+			AreaID:          "0x2",
+			PassesNeeded:    1,
+			SquaresTotal:    40,
+			RemainingPasses: 30,
+			CleanedRecently: 10,
+		},
+	}
+	eta, err = estimateETA(ctx, repo, "0x3", sess)
+	require.NoError(t, err)
+	require.Equal(t, 25*60, eta.Sec, "should not count time docked as time spent cleaning")
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/pkg/errors"
+)
+
+// ExportService holds use cases related to exporting raw data.
+// Data is paged through internally so that exports of any size can
+// be streamed.
+type ExportService struct {
+	r entity.SessionRepository
+}
+
+// NewExportService creates a new export service instance.
+func NewExportService(r entity.SessionRepository) *ExportService {
+	return &ExportService{r}
+}
+
+// Sessions passes every session matching the given filters to fn,
+// latest started first.
+func (co *ExportService) Sessions(ctx context.Context, a entity.ExportArgs, fn func(*entity.SessionSummary) error) error {
+	if err := validateExport(a); err != nil {
+		return err
+	}
+	if a.SessionID != "" {
+		return errors.Wrap(cerr.ErrValidationFailed, "cannot filter exported sessions by session id")
+	}
+
+	return co.eachSession(ctx, entity.ListSessionsArgs{
+		RobotID: a.RobotID,
+		AreaID:  a.AreaID,
+		From:    a.From,
+		To:      a.To,
+	}, fn)
+}
+
+// Positions passes every position matching the given filters to fn.
+// Positions are grouped by session, latest started session first, and
+// in the order they were passed within each session.
+func (co *ExportService) Positions(ctx context.Context, a entity.ExportArgs, fn func(*entity.PositionRecord) error) error {
+	if err := validateExport(a); err != nil {
+		return err
+	}
+
+	each := func(s *entity.SessionSummary) error {
+		return co.eachPosition(ctx, s, a, fn)
+	}
+
+	if a.SessionID != "" {
+		s, err := co.r.Get(ctx, a.SessionID)
+		if err != nil {
+			return err
+		}
+		if s == nil {
+			return errors.Wrapf(cerr.ErrNotFound, "could not find session with id %s", a.SessionID)
+		}
+		return each(s)
+	}
+
+	// Find every session that may have positions within the time range,
+	// i.e. sessions that started before it ends and ended after it
+	// starts.
+	return co.eachSession(ctx, entity.ListSessionsArgs{
+		RobotID:    a.RobotID,
+		AreaID:     a.AreaID,
+		To:         a.To,
+		EndedAfter: a.From,
+	}, each)
+}
+
+// eachSession pages through sessions.
+func (co *ExportService) eachSession(ctx context.Context, a entity.ListSessionsArgs, fn func(*entity.SessionSummary) error) error {
+	a.Limit = MaxSessionsLimit
+	for {
+		res, err := co.r.List(ctx, a)
+		if err != nil {
+			return err
+		}
+		for _, s := range res.Sessions {
+			if err := fn(s); err != nil {
+				return err
+			}
+		}
+		if res.NextCursor == "" {
+			return nil
+		}
+		a.Cursor = res.NextCursor
+	}
+}
+
+// eachPosition pages through a session's positions.
+func (co *ExportService) eachPosition(ctx context.Context, s *entity.SessionSummary, a entity.ExportArgs, fn func(*entity.PositionRecord) error) error {
+	rec := entity.PositionRecord{SessionID: s.Session.UID}
+	if s.Robot != nil {
+		rec.RobotID = s.Robot.UID
+	}
+	if len(s.Session.SourceArea) > 0 {
+		rec.AreaID = s.Session.SourceArea[0].UID
+	}
+
+	pa := entity.ListPositionsArgs{
+		SessionID: s.Session.UID,
+		From:      a.From,
+		To:        a.To,
+		Limit:     MaxPositionsLimit,
+	}
+	for {
+		res, err := co.r.Positions(ctx, pa)
+		if err != nil {
+			return err
+		}
+		if res == nil {
+			// Session was removed while exporting.
+			return nil
+		}
+		for _, p := range res.Positions {
+			r := rec
+			r.X, r.Y, r.PassedAt = p.X, p.Y, p.PassedAt
+			if err := fn(&r); err != nil {
+				return err
+			}
+		}
+		if res.NextCursor == "" {
+			return nil
+		}
+		pa.Cursor = res.NextCursor
+	}
+}
+
+func validateExport(a entity.ExportArgs) error {
+	if a.From != nil && a.To != nil && !a.From.Before(*a.To) {
+		return errors.Wrapf(cerr.ErrValidationFailed, "from (%s) must be before to (%s)", a.From, a.To)
+	}
+	return nil
+}
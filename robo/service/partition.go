@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/anrid/roboviewer/robo/planning"
+	"github.com/pkg/errors"
+)
+
+// MaxPartitionRobots is the max number of robots an area can be split
+// between.
+const MaxPartitionRobots = 100
+
+// PartitionService holds use cases related to splitting an area
+// between several robots.
+type PartitionService struct {
+	r entity.PartitionRepository
+	a entity.AreaRepository
+	b entity.RobotRepository
+	s entity.SessionRepository
+	p entity.CommandPublisher
+}
+
+// NewPartitionService creates a new partition service instance.
+func NewPartitionService(r entity.PartitionRepository, a entity.AreaRepository, b entity.RobotRepository, s entity.SessionRepository, p entity.CommandPublisher) *PartitionService {
+	return &PartitionService{r, a, b, s, p}
+}
+
+// List returns the partitions of an area, latest first.
+func (co *PartitionService) List(ctx context.Context, areaID string) ([]*entity.Partition, error) {
+	if _, err := co.area(ctx, areaID); err != nil {
+		return nil, err
+	}
+	return co.r.List(ctx, areaID)
+}
+
+// Get returns a partition together with the progress of the current
+// session in each region. Completion rolls up to the parent area as
+// the share of all squares across all regions that are cleaned.
+func (co *PartitionService) Get(ctx context.Context, partitionID string) (*entity.PartitionSummary, error) {
+	p, err := co.get(ctx, partitionID)
+	if err != nil {
+		return nil, err
+	}
+	area, err := co.area(ctx, p.AreaID)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := &entity.PartitionSummary{
+		Partition: p,
+		Area:      area,
+		Regions:   []*entity.RegionProgress{},
+	}
+	for _, r := range p.Regions {
+		rp := &entity.RegionProgress{
+			RegionID: r.UID,
+			RobotID:  r.RobotID,
+			AreaID:   r.AreaID(),
+			Progress: entity.SessionProgress{SquaresTotal: r.Squares},
+		}
+
+		latest, err := co.current(ctx, rp.AreaID)
+		if err != nil {
+			return nil, err
+		}
+		if latest != nil {
+			rp.SessionID = latest.Session.UID
+			rp.IsActive = latest.Session.EndedAt == nil
+			rp.Progress = latest.Progress
+		}
+		rp.Progress.Completion = entity.Completion(rp.Progress.SquaresCleaned, rp.Progress.SquaresTotal)
+
+		sum.Progress.SquaresCleaned += rp.Progress.SquaresCleaned
+		sum.Progress.SquaresTotal += rp.Progress.SquaresTotal
+		sum.Regions = append(sum.Regions, rp)
+	}
+	sum.Progress.Completion = entity.Completion(sum.Progress.SquaresCleaned, sum.Progress.SquaresTotal)
+
+	return sum, nil
+}
+
+// current returns the active session in an area, or the latest one if
+// none is active, e.g. when an older session has been resumed after a
+// newer one ended. Returns nil if the area has no sessions.
+func (co *PartitionService) current(ctx context.Context, areaID string) (*entity.SessionSummary, error) {
+	active := true
+	res, err := co.s.List(ctx, entity.ListSessionsArgs{AreaID: areaID, Active: &active, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Sessions) == 0 {
+		res, err = co.s.List(ctx, entity.ListSessionsArgs{AreaID: areaID, Limit: 1})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(res.Sessions) == 0 {
+		return nil, nil
+	}
+	return res.Sessions[0], nil
+}
+
+// Create splits an area into regions balanced by square count, one per
+// robot. Each region is created as an area of its own. Defaults to all
+// robots without an active session.
+func (co *PartitionService) Create(ctx context.Context, a entity.CreatePartitionArgs) (*entity.Partition, error) {
+	area, err := co.area(ctx, a.AreaID)
+	if err != nil {
+		return nil, err
+	}
+	if area.ParentAreaID != "" {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "area %s is already a region of area %s", area.UID, area.ParentAreaID)
+	}
+
+	robots, err := co.robots(ctx, a.RobotIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make([]int, len(robots))
+	for i, r := range robots {
+		sizes[i] = r.Size
+	}
+	rects := planning.Partition(area.SizeX, area.SizeY, sizes)
+
+	regions := make([]*entity.Region, len(robots))
+	for i, r := range robots {
+		rect := rects[i]
+		if rect.SizeX < r.Size || rect.SizeY < r.Size {
+			return nil, errors.Wrapf(cerr.ErrValidationFailed, "area %s is too small to split between %d robots", area.UID, len(robots))
+		}
+		squares := planning.Squares(rect, r.Size)
+		regions[i] = entity.NewRegion(area, r, i, len(robots), rect.X, rect.Y, rect.SizeX, rect.SizeY, squares)
+	}
+
+	p := entity.NewPartition(area.UID, regions)
+	uids, err := co.r.Save(ctx, p)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not persist partition")
+	}
+	p.UID = uids[entity.PartitionUID]
+	for _, r := range p.Regions {
+		r.UID = uids[r.UID[2:]]
+		r.Area[0].UID = uids[r.Area[0].UID[2:]]
+	}
+
+	return p, nil
+}
+
+// Dispatch sends a start command to every robot in a partition, telling
+// it to clean its own region.
+func (co *PartitionService) Dispatch(ctx context.Context, partitionID string) (*entity.Partition, error) {
+	p, err := co.get(ctx, partitionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, r := range p.Regions {
+		err := co.p.PublishCommand(&entity.Command{
+			Type:        entity.CommandStartSession,
+			RobotID:     r.RobotID,
+			AreaID:      r.AreaID(),
+			PartitionID: p.UID,
+			Region:      r,
+			IssuedAt:    now,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not send start command to robot %s", r.RobotID)
+		}
+	}
+
+	_, err = co.r.Save(ctx, &entity.Partition{
+		DispatchedAt: &now,
+		Common:       entity.Common{UID: p.UID},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not persist partition")
+	}
+	p.DispatchedAt = &now
+
+	return p, nil
+}
+
+// get returns a partition by id.
+func (co *PartitionService) get(ctx context.Context, partitionID string) (*entity.Partition, error) {
+	p, err := co.r.Get(ctx, partitionID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find partition with id %s", partitionID)
+	}
+	return p, nil
+}
+
+// area returns an area by id.
+func (co *PartitionService) area(ctx context.Context, areaID string) (*entity.Area, error) {
+	area, err := co.a.Get(ctx, areaID)
+	if err != nil {
+		return nil, err
+	}
+	if area == nil {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find area with id %s", areaID)
+	}
+	return area, nil
+}
+
+// robots returns the robots with the given ids, or all robots without
+// an active session if none are given.
+func (co *PartitionService) robots(ctx context.Context, robotIDs []string) ([]*entity.Robot, error) {
+	var robots []*entity.Robot
+	if len(robotIDs) == 0 {
+		res, err := co.b.List(ctx, entity.ListRobotsArgs{})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range res.Robots {
+			if len(r.Session) == 0 || r.Session[0].EndedAt != nil {
+				robots = append(robots, r)
+			}
+		}
+		if len(robots) == 0 {
+			return nil, errors.Wrap(cerr.ErrValidationFailed, "there are no idle robots to split the area between")
+		}
+	} else {
+		seen := make(map[string]bool)
+		for _, id := range robotIDs {
+			if seen[id] {
+				return nil, errors.Wrapf(cerr.ErrValidationFailed, "robot id %s given more than once", id)
+			}
+			seen[id] = true
+
+			r, err := co.b.Get(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			if r == nil {
+				return nil, errors.Wrapf(cerr.ErrValidationFailed, "could not find robot with id %s", id)
+			}
+			robots = append(robots, r)
+		}
+	}
+
+	if len(robots) > MaxPartitionRobots {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "an area can be split between at most %d robots, got %d", MaxPartitionRobots, len(robots))
+	}
+	for _, r := range robots {
+		if r.Size <= 0 {
+			return nil, errors.Wrapf(cerr.ErrValidationFailed, "robot %s has no size", r.UID)
+		}
+	}
+	return robots, nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultReportPeriod is the default time range of a report,
+	// counting back from now.
+	DefaultReportPeriod = 7 * 24 * time.Hour
+	// MaxReportDays is the max number of days in a report grouped by
+	// day.
+	MaxReportDays = 92
+)
+
+// ReportService holds use cases related to reports.
+type ReportService struct {
+	r entity.ReportRepository
+}
+
+// NewReportService creates a new report service instance.
+func NewReportService(r entity.ReportRepository) *ReportService {
+	return &ReportService{r}
+}
+
+// Sessions aggregates sessions started within a time range, grouped
+// by robot, area or day. Defaults to sessions started within the last
+// week grouped by robot.
+func (co *ReportService) Sessions(ctx context.Context, a entity.SessionReportArgs) (*entity.SessionReport, error) {
+	if a.GroupBy == "" {
+		a.GroupBy = entity.GroupByRobot
+	}
+	if a.GroupBy != entity.GroupByRobot && a.GroupBy != entity.GroupByArea && a.GroupBy != entity.GroupByDay {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "group_by must be one of %s, %s or %s, got '%s'", entity.GroupByRobot, entity.GroupByArea, entity.GroupByDay, a.GroupBy)
+	}
+	if a.To.IsZero() {
+		a.To = time.Now()
+	}
+	if a.From.IsZero() {
+		a.From = a.To.Add(-DefaultReportPeriod)
+	}
+	if !a.From.Before(a.To) {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "from (%s) must be before to (%s)", a.From, a.To)
+	}
+	if a.GroupBy == entity.GroupByDay && a.To.Sub(a.From) > MaxReportDays*24*time.Hour {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "cannot group more than %d days by day", MaxReportDays)
+	}
+
+	rows, err := co.r.Sessions(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	if rows == nil {
+		rows = []*entity.SessionReportRow{}
+	}
+
+	total := &entity.SessionReportRow{Group: "total"}
+	var sumCompletion, sumTimeToComplete float64
+	for _, row := range rows {
+		total.SessionsStarted += row.SessionsStarted
+		total.SessionsEnded += row.SessionsEnded
+		total.SessionsCompleted += row.SessionsCompleted
+		total.SessionsAbandoned += row.SessionsAbandoned
+		total.CleaningSec += row.CleaningSec
+
+		// Weigh means by the number of sessions they're based on.
+		sumCompletion += row.MeanCompletionPct * float64(row.SessionsEnded)
+		sumTimeToComplete += row.MeanTimeToCompleteSec * float64(row.SessionsCompleted)
+
+		row.MeanCompletionPct = round2(row.MeanCompletionPct)
+		row.MeanTimeToCompleteSec = round2(row.MeanTimeToCompleteSec)
+	}
+	if total.SessionsEnded > 0 {
+		total.MeanCompletionPct = round2(sumCompletion / float64(total.SessionsEnded))
+	}
+	if total.SessionsCompleted > 0 {
+		total.MeanTimeToCompleteSec = round2(sumTimeToComplete / float64(total.SessionsCompleted))
+	}
+
+	return &entity.SessionReport{
+		GroupBy: a.GroupBy,
+		From:    a.From,
+		To:      a.To,
+		Rows:    rows,
+		Total:   total,
+	}, nil
+}
+
+// round2 rounds to 2 decimals.
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
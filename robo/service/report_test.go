@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type stubReportRepository struct {
+	args entity.SessionReportArgs
+	rows []*entity.SessionReportRow
+}
+
+func (r *stubReportRepository) Sessions(ctx context.Context, a entity.SessionReportArgs) ([]*entity.SessionReportRow, error) {
+	r.args = a
+	return r.rows, nil
+}
+
+func TestSessionReport(t *testing.T) {
+	ctx := context.Background()
+	repo := &stubReportRepository{
+		rows: []*entity.SessionReportRow{
+			{Group: "0x1", SessionsStarted: 3, SessionsEnded: 2, SessionsCompleted: 1, SessionsAbandoned: 1, CleaningSec: 100, MeanCompletionPct: 75, MeanTimeToCompleteSec: 60},
+			{Group: "0x2", SessionsStarted: 2, SessionsEnded: 2, SessionsCompleted: 2, CleaningSec: 50, MeanCompletionPct: 100, MeanTimeToCompleteSec: 30.333},
+		},
+	}
+	svc := NewReportService(repo)
+
+	rep, err := svc.Sessions(ctx, entity.SessionReportArgs{})
+	require.NoError(t, err)
+	require.Equal(t, entity.GroupByRobot, rep.GroupBy, "should group by robot by default")
+	require.Equal(t, DefaultReportPeriod, rep.To.Sub(rep.From), "should default to the last week")
+
+	require.Equal(t, 5, rep.Total.SessionsStarted)
+	require.Equal(t, 4, rep.Total.SessionsEnded)
+	require.Equal(t, 3, rep.Total.SessionsCompleted)
+	require.Equal(t, 1, rep.Total.SessionsAbandoned)
+	require.Equal(t, 150, rep.Total.CleaningSec)
+	require.Equal(t, 87.5, rep.Total.MeanCompletionPct, "should weigh mean completion by ended sessions")
+	require.Equal(t, 40.22, rep.Total.MeanTimeToCompleteSec, "should weigh mean time to complete by completed sessions")
+	require.Equal(t, 30.33, rep.Rows[1].MeanTimeToCompleteSec)
+
+	from := time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+	_, err = svc.Sessions(ctx, entity.SessionReportArgs{GroupBy: "week"})
+	require.Equal(t, cerr.ErrValidationFailed, errors.Cause(err), "should fail on unknown group")
+
+	_, err = svc.Sessions(ctx, entity.SessionReportArgs{From: from, To: from})
+	require.Equal(t, cerr.ErrValidationFailed, errors.Cause(err), "should fail on empty time range")
+
+	_, err = svc.Sessions(ctx, entity.SessionReportArgs{GroupBy: entity.GroupByDay, From: from, To: from.AddDate(1, 0, 0)})
+	require.Equal(t, cerr.ErrValidationFailed, errors.Cause(err), "should fail on too many days")
+}
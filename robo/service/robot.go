@@ -2,21 +2,43 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/anrid/roboviewer/robo/anomaly"
 	"github.com/anrid/roboviewer/robo/entity"
 	"github.com/anrid/roboviewer/robo/pkg/cerr"
 	"github.com/pkg/errors"
 )
 
+// MaxHistoryLimit is the max number of sessions returned by a single
+// call to RobotService.History.
+const MaxHistoryLimit = 100
+
+const (
+	// DefaultTelemetryLimit is the number of telemetry reports returned
+	// by RobotService.Telemetry unless a limit is given.
+	DefaultTelemetryLimit = 1000
+	// MaxTelemetryLimit is the max number of telemetry reports returned
+	// by a single call to RobotService.Telemetry.
+	MaxTelemetryLimit = 10000
+)
+
 // RobotService holds all the route handlers (endpoints)
 // related to robots.
 type RobotService struct {
 	r entity.RobotRepository
+	s entity.SessionRepository
+	k entity.DockRepository
+	p entity.EventPublisher
+	d *anomaly.Detector
 }
 
 // NewRobotService creates a new robot controller instance.
-func NewRobotService(r entity.RobotRepository) *RobotService {
-	return &RobotService{r}
+// Session events are published to the given publisher.
+func NewRobotService(r entity.RobotRepository, s entity.SessionRepository, k entity.DockRepository, p entity.EventPublisher) *RobotService {
+	return &RobotService{r, s, k, p, anomaly.New(anomaly.DefaultConfig())}
 }
 
 // List returns a list of all robots.
@@ -31,12 +53,151 @@ func (co *RobotService) List(ctx context.Context, id, name string) ([]*entity.Ro
 	return res.Robots, nil
 }
 
+// Get returns a robot by id.
+func (co *RobotService) Get(ctx context.Context, robotID string) (*entity.Robot, error) {
+	robot, err := co.r.Get(ctx, robotID)
+	if err != nil {
+		return nil, err
+	}
+	if robot == nil {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find robot with id %s", robotID)
+	}
+	return robot, nil
+}
+
+// Status returns a robot's last reported position and the progress
+// of its latest cleaning session.
+func (co *RobotService) Status(ctx context.Context, robotID string) (*entity.RobotStatus, error) {
+	st, err := co.r.Status(ctx, robotID)
+	if err != nil {
+		return nil, err
+	}
+	if st == nil {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find robot with id %s", robotID)
+	}
+	if st.LastReportedAt == nil {
+		// The robot hasn't moved since it started the session.
+		st.LastReportedAt = st.StartedAt
+	}
+	if st.IsActive && st.SessionID != "" {
+		eta, err := estimateETA(ctx, co.s, robotID, &entity.CleaningSession{
+			StartedAt:      st.StartedAt,
+			LastReportedAt: st.LastReportedAt,
+			PausedSec:      st.PausedSec,
+			Common:         entity.Common{UID: st.SessionID},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if eta != nil {
+			st.SetETA(eta)
+		}
+	}
+	return st, nil
+}
+
+// Create registers a new robot.
+func (co *RobotService) Create(ctx context.Context, a entity.CreateRobotArgs) (*entity.Robot, error) {
+	name := strings.TrimSpace(a.Name)
+	if err := co.validate(ctx, "", name, a.Size); err != nil {
+		return nil, err
+	}
+
+	robot := entity.NewRobot(name, a.Size)
+
+	uids, err := co.r.Save(ctx, robot)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not persist new robot")
+	}
+	robot.UID = uids[entity.RobotUID]
+
+	return robot, nil
+}
+
+// Update updates a robot's name and / or size.
+func (co *RobotService) Update(ctx context.Context, a entity.UpdateRobotArgs) (*entity.Robot, error) {
+	robot, err := co.Get(ctx, a.RobotID)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Name != nil {
+		robot.Name = strings.TrimSpace(*a.Name)
+	}
+	if a.Size != nil {
+		robot.Size = *a.Size
+	}
+	if err := co.validate(ctx, robot.UID, robot.Name, robot.Size); err != nil {
+		return nil, err
+	}
+
+	// Only persist the robot's own fields, leave sessions
+	// untouched.
+	_, err = co.r.Save(ctx, &entity.Robot{
+		Name:   robot.Name,
+		Size:   robot.Size,
+		Common: entity.Common{UID: robot.UID},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not persist robot")
+	}
+
+	return robot, nil
+}
+
+// Delete soft-deletes a robot. Robots with an active cleaning
+// session cannot be deleted.
+func (co *RobotService) Delete(ctx context.Context, robotID string) error {
+	robot, err := co.Get(ctx, robotID)
+	if err != nil {
+		return err
+	}
+	if len(robot.Session) > 0 && robot.Session[0].EndedAt == nil {
+		return errors.Wrapf(cerr.ErrConflict, "robot %s id %s has an active cleaning session %s", robot.Name, robot.UID, robot.Session[0].UID)
+	}
+
+	deletedAt := time.Now()
+	_, err = co.r.Save(ctx, &entity.Robot{
+		DeletedAt: &deletedAt,
+		Common:    entity.Common{UID: robot.UID},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete robot")
+	}
+	return nil
+}
+
+// validate checks that a robot has a valid size and a name that
+// isn't already taken by another robot.
+func (co *RobotService) validate(ctx context.Context, robotID, name string, size int) error {
+	if name == "" {
+		return errors.Wrap(cerr.ErrValidationFailed, "robot name cannot be empty")
+	}
+	if size <= 0 {
+		return errors.Wrapf(cerr.ErrValidationFailed, "robot size must be greater than 0, got %d", size)
+	}
+
+	res, err := co.r.ByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	for _, r := range res.Robots {
+		if r.UID != robotID {
+			return errors.Wrapf(cerr.ErrValidationFailed, "robot name '%s' is already taken by robot id %s", name, r.UID)
+		}
+	}
+	return nil
+}
+
 // StartSession starts a new cleaning session for the given
 // robot and area.
 func (co *RobotService) StartSession(ctx context.Context, a entity.StartSessionArgs) (*entity.CleaningSession, error) {
 	if a.StartedAt.IsZero() {
 		return nil, errors.Wrapf(cerr.ErrValidationFailed, "did not get a valid StartedAt value: %s", a.StartedAt)
 	}
+	if a.PassesNeeded < 0 {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "passes needed cannot be negative, got %d", a.PassesNeeded)
+	}
 
 	res, err := co.r.GetRobotAndArea(ctx, a.RobotID, a.AreaID)
 	if err != nil {
@@ -51,14 +212,30 @@ func (co *RobotService) StartSession(ctx context.Context, a entity.StartSessionA
 
 	robot := res.Robots[0]
 	area := res.Areas[0]
+	if a.PassesNeeded > 0 {
+		area.PassesNeeded = a.PassesNeeded
+	}
+
+	if a.Resume || a.SessionID != "" {
+		return co.reopen(ctx, robot, area, a)
+	}
 
 	if len(robot.Session) > 0 {
-		// End the ongoing session.
 		prevSess := robot.Session[0]
-		prevSess.End(a.StartedAt)
-		_, err := co.r.Save(ctx, prevSess)
-		if err != nil {
-			return nil, errors.Wrap(err, "could not persist previous session")
+		if prevSess.IsPaused() && len(prevSess.SourceArea) > 0 && prevSess.SourceArea[0].UID == area.UID {
+			// The robot is back from its dock, carry on with the
+			// same grid rather than starting over.
+			return co.resume(ctx, robot, prevSess, entity.UpdateSessionArgs{
+				RobotID:    robot.UID,
+				RobotX:     a.RobotX,
+				RobotY:     a.RobotY,
+				ReportedAt: a.StartedAt,
+			})
+		}
+
+		// End the ongoing session.
+		if err := co.end(ctx, robot, prevSess, a.StartedAt); err != nil {
+			return nil, err
 		}
 		robot.Session = nil
 	}
@@ -67,7 +244,10 @@ func (co *RobotService) StartSession(ctx context.Context, a entity.StartSessionA
 	newSess.LastX = a.RobotX
 	newSess.LastY = a.RobotY
 	newSess.StartedAt = &a.StartedAt
-	newSess.PositionHistory = []*entity.Position{entity.NewPosition(a.RobotX, a.RobotY, a.StartedAt)}
+	// Passes are registered as the robot reports where it's cleaning,
+	// not where it starts.
+	newSess.PositionHistory = []*entity.Position{entity.NewNonCleaningPosition(a.RobotX, a.RobotY, a.StartedAt)}
+	newSess.Outbox = outbox(entity.NewOutboxEvent(entity.EventSessionStarted, newSess, a.StartedAt))
 
 	uids, err := co.r.Save(ctx, robot)
 	if err != nil {
@@ -80,9 +260,114 @@ func (co *RobotService) StartSession(ctx context.Context, a entity.StartSessionA
 	// dg.Repository.Save().
 	newSess.UID = uids[entity.CleaningSessionUID]
 
+	co.p.Publish(sessionEvent(entity.EventSessionStarted, robot.UID, newSess, a.StartedAt))
+
 	return newSess, nil
 }
 
+// end ends a robot's ongoing session when it starts another one.
+func (co *RobotService) end(ctx context.Context, robot *entity.Robot, sess *entity.CleaningSession, endedAt time.Time) error {
+	sess.End(endedAt)
+	sess.Outbox = outbox(entity.NewOutboxEvent(entity.EventSessionEnded, sess, endedAt))
+	_, err := co.r.Save(ctx, sess)
+	if err != nil {
+		return errors.Wrap(err, "could not persist previous session")
+	}
+	co.d.Forget(sess.UID)
+
+	co.p.Publish(sessionEvent(entity.EventSessionEnded, robot.UID, sess, endedAt))
+	return nil
+}
+
+// reopen continues a robot's latest session, or the session with the
+// given id, with its grid and history instead of starting over, e.g.
+// after the robot rebooted. The session must have been run by the
+// robot in the same area.
+func (co *RobotService) reopen(ctx context.Context, robot *entity.Robot, area *entity.Area, a entity.StartSessionArgs) (*entity.CleaningSession, error) {
+	var sum *entity.SessionSummary
+	if a.SessionID != "" {
+		s, err := co.s.Get(ctx, a.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		if s == nil {
+			return nil, errors.Wrapf(cerr.ErrNotFound, "could not find session with id %s to resume", a.SessionID)
+		}
+		if s.Robot == nil || s.Robot.UID != robot.UID {
+			return nil, errors.Wrapf(cerr.ErrValidationFailed, "cannot resume session %s, it was not run by robot %s id %s", a.SessionID, robot.Name, robot.UID)
+		}
+		sum = s
+	} else {
+		res, err := co.s.List(ctx, entity.ListSessionsArgs{RobotID: robot.UID, Limit: 1})
+		if err != nil {
+			return nil, err
+		}
+		if len(res.Sessions) == 0 {
+			return nil, errors.Wrapf(cerr.ErrValidationFailed, "robot %s id %s has no session to resume", robot.Name, robot.UID)
+		}
+		sum = res.Sessions[0]
+	}
+
+	sess := sum.Session
+	if len(sess.SourceArea) == 0 || sess.SourceArea[0].UID != area.UID {
+		var started string
+		if len(sess.SourceArea) > 0 {
+			started = sess.SourceArea[0].UID
+		}
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "cannot resume session %s in area %s, it was started in area %s", sess.UID, area.UID, started)
+	}
+
+	if len(robot.Session) > 0 && robot.Session[0].UID != sess.UID {
+		// End the ongoing session, it's not the one we're resuming.
+		if err := co.end(ctx, robot, robot.Session[0], a.StartedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	upd := entity.UpdateSessionArgs{
+		RobotID:    robot.UID,
+		RobotX:     a.RobotX,
+		RobotY:     a.RobotY,
+		ReportedAt: a.StartedAt,
+	}
+	if sess.IsPaused() {
+		return co.resume(ctx, robot, sess, upd)
+	}
+
+	sess.Reopen(a.StartedAt)
+	sess.LastX = a.RobotX
+	sess.LastY = a.RobotY
+	sess.LastReportedAt = &a.StartedAt
+
+	// Summaries come without grids, use their progress.
+	e := entity.NewOutboxEvent(entity.EventSessionResumed, sess, a.StartedAt)
+	e.Completion = sum.Progress.Completion
+
+	// The grid carries on from where the session was interrupted. The
+	// robot may have been moved since, so its position only moves it.
+	// Clearing ended_at is what makes the session active again.
+	_, err := co.s.Reopen(ctx, &entity.CleaningSession{
+		ResumedAt:       sess.ResumedAt,
+		PausedSec:       sess.PausedSec,
+		LastX:           sess.LastX,
+		LastY:           sess.LastY,
+		LastReportedAt:  sess.LastReportedAt,
+		PositionHistory: []*entity.Position{entity.NewNonCleaningPosition(a.RobotX, a.RobotY, a.StartedAt)},
+		Outbox:          outbox(e),
+		Common:          entity.Common{UID: sess.UID},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not persist resumed session")
+	}
+	co.d.Forget(sess.UID)
+
+	ev := sessionEvent(entity.EventSessionResumed, robot.UID, sess, a.StartedAt)
+	ev.Completion = sum.Progress.Completion
+	co.p.Publish(ev)
+
+	return sess, nil
+}
+
 // UpdateSession updates a robot's current cleaning
 // session, called every time a robot moves.
 // It also allow us to close the session.
@@ -90,53 +375,402 @@ func (co *RobotService) UpdateSession(ctx context.Context, a entity.UpdateSessio
 	if a.ReportedAt.IsZero() {
 		return nil, errors.Wrapf(cerr.ErrValidationFailed, "did not get a valid ReportedAt value: %s", a.ReportedAt)
 	}
+	if a.Telemetry != nil {
+		if err := validateTelemetry(a.Telemetry); err != nil {
+			return nil, err
+		}
+	}
 
-	res, err := co.r.List(ctx, entity.ListRobotsArgs{
-		RobotID: a.RobotID,
-	})
+	robot, sess, err := co.activeSession(ctx, a.RobotID)
 	if err != nil {
 		return nil, err
 	}
-	if len(res.Robots) == 0 {
-		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find robot with id %s", a.RobotID)
-	}
+	prevCompletion := sess.Area[0].Completion()
 
-	robot := res.Robots[0]
-
-	if len(robot.Session) == 0 {
-		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find any sessions for robot %s id %s", robot.Name, robot.UID)
-	}
-	if !robot.Session[0].IsActive {
-		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find an active session for robot %s id %s", robot.Name, robot.UID)
+	// A robot that reports a new position has left its dock. Positions
+	// aren't checked for anomalies until the next report, as the robot
+	// hasn't reported while docked.
+	var resumed bool
+	if sess.IsPaused() && !a.EndSession {
+		sess.Resume(a.ReportedAt)
+		co.d.Forget(sess.UID)
+		resumed = true
 	}
 
-	sess := robot.Session[0]
+	var anomalies []*entity.Anomaly
+	if !resumed {
+		anomalies = co.detect(robot, sess, a)
+	}
+	sess.Anomalies = anomalies
 
 	sess.LastX = a.RobotX
 	sess.LastY = a.RobotY
 	sess.LastReportedAt = &a.ReportedAt
-	sess.Area[0].SetVisited(a.RobotX, a.RobotY)
+	passed := sess.Area[0].VisitAt(a.RobotX, a.RobotY, a.ReportedAt)
+
+	// Events for webhooks are saved together with the session so
+	// that none are lost if we crash before they are delivered.
+	var events []*entity.OutboxEvent
+	if resumed {
+		events = append(events, entity.NewOutboxEvent(entity.EventSessionResumed, sess, a.ReportedAt))
+	}
+	if passed != nil && sess.CompletedAt == nil && sess.Area[0].IsCleaned() {
+		sess.Complete(a.ReportedAt)
+		events = append(events, entity.NewOutboxEvent(entity.EventAreaCleaned, sess, a.ReportedAt))
+	}
 	sess.PositionHistory = []*entity.Position{entity.NewPosition(a.RobotX, a.RobotY, a.ReportedAt)}
 
 	if a.EndSession {
 		sess.End(a.ReportedAt)
+		events = append(events, entity.NewOutboxEvent(entity.EventSessionEnded, sess, a.ReportedAt))
+	}
+	sess.Outbox = outbox(events...)
+
+	// Telemetry is added to the session's time series and kept as
+	// the robot's latest telemetry, both in a single mutation.
+	var obj interface{} = sess
+	if a.Telemetry != nil {
+		t := entity.NewTelemetry(robot.UID, sess.UID, a.Telemetry, a.ReportedAt)
+		sess.Telemetry = []*entity.Telemetry{t}
+		obj = &entity.Robot{
+			LastTelemetry: t,
+			Session:       []*entity.CleaningSession{sess},
+			Common:        entity.Common{UID: robot.UID},
+		}
 	}
 
-	_, err = co.r.Save(ctx, sess)
+	uids, err := co.r.Save(ctx, obj)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not persist current session")
 	}
+	if a.Telemetry != nil {
+		sess.Telemetry[0].UID = uids[entity.TelemetryUID]
+	}
+
+	// Let subscribers know what happened.
+	if resumed {
+		co.p.Publish(sessionEvent(entity.EventSessionResumed, robot.UID, sess, a.ReportedAt))
+	}
+	co.p.Publish(sessionEvent(entity.EventPosition, robot.UID, sess, a.ReportedAt))
+	if passed != nil {
+		e := sessionEvent(entity.EventSquarePassed, robot.UID, sess, a.ReportedAt)
+		e.Square = passed
+		co.p.Publish(e)
+
+		if passed.CleanedAt != nil && passed.Passes == sess.Area[0].PassesNeeded {
+			e := sessionEvent(entity.EventSquareCleaned, robot.UID, sess, a.ReportedAt)
+			e.Square = passed
+			co.p.Publish(e)
+		}
+	}
+	if sess.Area[0].Completion() != prevCompletion {
+		co.p.Publish(sessionEvent(entity.EventCompletionChanged, robot.UID, sess, a.ReportedAt))
+	}
+	for _, an := range anomalies {
+		e := sessionEvent(entity.EventAnomaly, robot.UID, sess, a.ReportedAt)
+		e.Anomaly = an
+		co.p.Publish(e)
+	}
+	if a.EndSession {
+		co.d.Forget(sess.UID)
+		co.p.Publish(sessionEvent(entity.EventSessionEnded, robot.UID, sess, a.ReportedAt))
+	}
+
+	return sess, nil
+}
+
+// PauseSession pauses a robot's active session while it's docked, e.g.
+// to recharge. Time spent docked doesn't count towards the session's
+// duration, and the robot isn't considered offline while docked.
+func (co *RobotService) PauseSession(ctx context.Context, a entity.PauseSessionArgs) (*entity.CleaningSession, error) {
+	if a.PausedAt.IsZero() {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "did not get a valid PausedAt value: %s", a.PausedAt)
+	}
+
+	robot, sess, err := co.activeSession(ctx, a.RobotID)
+	if err != nil {
+		return nil, err
+	}
+	if sess.IsPaused() {
+		return nil, errors.Wrapf(cerr.ErrConflict, "session %s of robot %s id %s is already paused", sess.UID, robot.Name, robot.UID)
+	}
+
+	var dock *entity.Dock
+	if a.DockID != "" {
+		dock, err = co.k.Get(ctx, a.DockID)
+		if err != nil {
+			return nil, err
+		}
+		if dock == nil {
+			return nil, errors.Wrapf(cerr.ErrValidationFailed, "could not find dock with id %s", a.DockID)
+		}
+		if len(sess.SourceArea) > 0 && dock.AreaID != sess.SourceArea[0].UID {
+			return nil, errors.Wrapf(cerr.ErrValidationFailed, "dock %s is not in area %s of session %s", dock.UID, sess.SourceArea[0].UID, sess.UID)
+		}
+	}
+
+	sess.Pause(a.PausedAt, dock)
+
+	// Docking doesn't clean, so the grid stays as it is and the dock
+	// position only moves the robot.
+	upd := &entity.CleaningSession{
+		PausedAt: sess.PausedAt,
+		DockID:   sess.DockID,
+		Outbox:   outbox(entity.NewOutboxEvent(entity.EventSessionPaused, sess, a.PausedAt)),
+		Common:   entity.Common{UID: sess.UID},
+	}
+	if dock != nil {
+		upd.LastX = sess.LastX
+		upd.LastY = sess.LastY
+		upd.PositionHistory = []*entity.Position{entity.NewNonCleaningPosition(dock.X, dock.Y, a.PausedAt)}
+	}
+	if _, err := co.r.Save(ctx, upd); err != nil {
+		return nil, errors.Wrap(err, "could not persist paused session")
+	}
+	co.d.Forget(sess.UID)
+
+	co.p.Publish(sessionEvent(entity.EventSessionPaused, robot.UID, sess, a.PausedAt))
 
 	return sess, nil
 }
 
+// ResumeSession resumes a robot's paused session when it leaves its
+// dock, carrying on with the same grid.
+func (co *RobotService) ResumeSession(ctx context.Context, a entity.UpdateSessionArgs) (*entity.CleaningSession, error) {
+	if a.ReportedAt.IsZero() {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "did not get a valid ReportedAt value: %s", a.ReportedAt)
+	}
+
+	robot, sess, err := co.activeSession(ctx, a.RobotID)
+	if err != nil {
+		return nil, err
+	}
+	if !sess.IsPaused() {
+		return nil, errors.Wrapf(cerr.ErrConflict, "session %s of robot %s id %s is not paused", sess.UID, robot.Name, robot.UID)
+	}
+	return co.resume(ctx, robot, sess, a)
+}
+
+// resume resumes a paused session at the robot's reported position.
+func (co *RobotService) resume(ctx context.Context, robot *entity.Robot, sess *entity.CleaningSession, a entity.UpdateSessionArgs) (*entity.CleaningSession, error) {
+	sess.Resume(a.ReportedAt)
+	sess.LastX = a.RobotX
+	sess.LastY = a.RobotY
+	sess.LastReportedAt = &a.ReportedAt
+
+	// The robot hasn't passed any squares on its way out of the dock
+	// yet; the next update picks up from here.
+	_, err := co.r.Save(ctx, &entity.CleaningSession{
+		ResumedAt:       sess.ResumedAt,
+		PausedSec:       sess.PausedSec,
+		LastX:           sess.LastX,
+		LastY:           sess.LastY,
+		LastReportedAt:  sess.LastReportedAt,
+		PositionHistory: []*entity.Position{entity.NewNonCleaningPosition(a.RobotX, a.RobotY, a.ReportedAt)},
+		Outbox:          outbox(entity.NewOutboxEvent(entity.EventSessionResumed, sess, a.ReportedAt)),
+		Common:          entity.Common{UID: sess.UID},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not persist resumed session")
+	}
+	co.d.Forget(sess.UID)
+
+	co.p.Publish(sessionEvent(entity.EventSessionResumed, robot.UID, sess, a.ReportedAt))
+
+	return sess, nil
+}
+
+// activeSession returns a robot and its active session, including
+// its grid.
+func (co *RobotService) activeSession(ctx context.Context, robotID string) (*entity.Robot, *entity.CleaningSession, error) {
+	res, err := co.r.List(ctx, entity.ListRobotsArgs{
+		RobotID: robotID,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(res.Robots) == 0 {
+		return nil, nil, errors.Wrapf(cerr.ErrNotFound, "could not find robot with id %s", robotID)
+	}
+
+	robot := res.Robots[0]
+
+	if len(robot.Session) == 0 {
+		return nil, nil, errors.Wrapf(cerr.ErrNotFound, "could not find any sessions for robot %s id %s", robot.Name, robot.UID)
+	}
+	if !robot.Session[0].IsActive {
+		return nil, nil, errors.Wrapf(cerr.ErrNotFound, "could not find an active session for robot %s id %s", robot.Name, robot.UID)
+	}
+	return robot, robot.Session[0], nil
+}
+
+// validateTelemetry checks that reported telemetry is within range.
+func validateTelemetry(t *entity.Telemetry) error {
+	if t.Battery != nil && (*t.Battery < 0 || *t.Battery > 100) {
+		return errors.Wrapf(cerr.ErrValidationFailed, "battery must be between 0 and 100, got %d", *t.Battery)
+	}
+	if t.State != "" {
+		valid := false
+		for _, s := range entity.RobotStates {
+			if t.State == s {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return errors.Wrapf(cerr.ErrValidationFailed, "unknown robot state '%s', must be one of %v", t.State, entity.RobotStates)
+		}
+	}
+	if t.BrushWear != nil && *t.BrushWear < 0 {
+		return errors.Wrapf(cerr.ErrValidationFailed, "brush wear cannot be negative, got %d", *t.BrushWear)
+	}
+	if t.FilterWear != nil && *t.FilterWear < 0 {
+		return errors.Wrapf(cerr.ErrValidationFailed, "filter wear cannot be negative, got %d", *t.FilterWear)
+	}
+	return nil
+}
+
+// detect checks a robot's reported position for anomalies before the
+// session is updated. Anomalies get unique blank node UIDs so that
+// they can be saved together with the session.
+func (co *RobotService) detect(robot *entity.Robot, sess *entity.CleaningSession, a entity.UpdateSessionArgs) []*entity.Anomaly {
+	s := anomaly.Sample{
+		SessionID: sess.UID,
+		RobotSize: robot.Size,
+		SizeX:     sess.Area[0].SizeX,
+		SizeY:     sess.Area[0].SizeY,
+		Point:     anomaly.Point{X: a.RobotX, Y: a.RobotY, At: a.ReportedAt},
+	}
+	last := sess.LastReportedAt
+	if last == nil {
+		last = sess.StartedAt
+	}
+	if last != nil {
+		s.Last = &anomaly.Point{X: sess.LastX, Y: sess.LastY, At: *last}
+	}
+
+	found := co.d.Check(s)
+	for i, an := range found {
+		an.UID = fmt.Sprintf("_:%s%d", entity.AnomalyUID, i+1)
+	}
+	return found
+}
+
+// outbox gives outbox events unique blank node UIDs so that they can
+// be saved together with a session.
+func outbox(events ...*entity.OutboxEvent) []*entity.OutboxEvent {
+	for i, e := range events {
+		e.UID = fmt.Sprintf("_:%s%d", entity.OutboxEventUID, i+1)
+	}
+	return events
+}
+
+// DetectOffline emits a robot.offline event for every active session
+// whose robot hasn't reported in entity.RobotOfflineAfter. Each robot
+// goes offline at most once until it reports again. Returns the number
+// of robots detected offline.
+func (co *RobotService) DetectOffline(ctx context.Context, now time.Time) (int, error) {
+	// Only sessions that haven't ended, i.e. without ended_at.
+	active := true
+	a := entity.ListSessionsArgs{Active: &active, Limit: 1000}
+
+	var n int
+	for {
+		res, err := co.s.List(ctx, a)
+		if err != nil {
+			return n, err
+		}
+		for _, s := range res.Sessions {
+			sess := s.Session
+			last := sess.LastReportedAt
+			if last == nil {
+				last = sess.StartedAt
+			}
+			if last == nil || now.Sub(*last) < entity.RobotOfflineAfter {
+				continue
+			}
+			if sess.IsPaused() {
+				// Robots don't report while docked.
+				continue
+			}
+			if sess.OfflineSince != nil && !last.After(*sess.OfflineSince) {
+				// Already offline since the last report.
+				continue
+			}
+
+			// Summaries come without grids, use their progress.
+			e := entity.NewOutboxEvent(entity.EventRobotOffline, sess, now)
+			e.Completion = s.Progress.Completion
+
+			_, err := co.s.Save(ctx, &entity.CleaningSession{
+				OfflineSince: last,
+				Outbox:       outbox(e),
+				Common:       entity.Common{UID: sess.UID},
+			})
+			if err != nil {
+				return n, errors.Wrapf(err, "could not persist offline robot for session %s", sess.UID)
+			}
+			n++
+		}
+		if res.NextCursor == "" {
+			return n, nil
+		}
+		a.Cursor = res.NextCursor
+	}
+}
+
+// sessionEvent creates a new event describing the current state of
+// the given session.
+func sessionEvent(t entity.EventType, robotID string, sess *entity.CleaningSession, at time.Time) *entity.Event {
+	e := &entity.Event{
+		Type:      t,
+		RobotID:   robotID,
+		SessionID: sess.UID,
+		X:         sess.LastX,
+		Y:         sess.LastY,
+		At:        at,
+	}
+	if len(sess.SourceArea) > 0 {
+		e.AreaID = sess.SourceArea[0].UID
+	}
+	if len(sess.Area) > 0 {
+		e.Completion = sess.Area[0].Completion()
+	}
+	return e
+}
+
 // EndSession ends an active session for a given robot.
 func (co *RobotService) EndSession(ctx context.Context, a entity.UpdateSessionArgs) (*entity.CleaningSession, error) {
 	a.EndSession = true
 	return co.UpdateSession(ctx, a)
 }
 
-// History gets all cleaning session and position history for a robot.
-func (co *RobotService) History(ctx context.Context, robotID string, max int) (*entity.Robot, error) {
-	return co.r.History(ctx, robotID, max)
+// History gets a page of cleaning sessions, optionally including
+// position history and grids, for a robot.
+func (co *RobotService) History(ctx context.Context, a entity.HistoryArgs) (*entity.HistoryResult, error) {
+	if a.Max < 1 || a.Max > MaxHistoryLimit {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "max must be between 1 and %d, got %d", MaxHistoryLimit, a.Max)
+	}
+	if a.From != nil && a.To != nil && !a.From.Before(*a.To) {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "from (%s) must be before to (%s)", a.From, a.To)
+	}
+	return co.r.History(ctx, a)
+}
+
+// Telemetry returns the telemetry a robot has reported, latest first,
+// optionally limited to a session and a time range.
+func (co *RobotService) Telemetry(ctx context.Context, a entity.ListTelemetryArgs) ([]*entity.Telemetry, error) {
+	if a.Limit == 0 {
+		a.Limit = DefaultTelemetryLimit
+	}
+	if a.Limit < 1 || a.Limit > MaxTelemetryLimit {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "limit must be between 1 and %d, got %d", MaxTelemetryLimit, a.Limit)
+	}
+	if a.From != nil && a.To != nil && !a.From.Before(*a.To) {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "from (%s) must be before to (%s)", a.From, a.To)
+	}
+	if _, err := co.Get(ctx, a.RobotID); err != nil {
+		return nil, err
+	}
+	return co.r.Telemetry(ctx, a)
 }
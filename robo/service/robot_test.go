@@ -28,8 +28,9 @@ func (s *RobotTestSuite) TestStartSession() {
 	require.NoError(s.T(), err)
 	require.Equal(s.T(), 2, len(robots))
 
-	areas, err := s.th.Service.Area.List(s.ctx)
+	res, err := s.th.Service.Area.List(s.ctx, entity.ListAreasArgs{})
 	require.NoError(s.T(), err)
+	areas := res.Areas
 	require.Equal(s.T(), 2, len(areas))
 
 	sess, err := s.th.Service.Robot.StartSession(s.ctx, entity.StartSessionArgs{
@@ -50,8 +51,9 @@ func (s *RobotTestSuite) TestUpdateSession() {
 	require.NoError(s.T(), err)
 	require.Equal(s.T(), 2, len(robots))
 
-	areas, err := s.th.Service.Area.List(s.ctx)
+	res, err := s.th.Service.Area.List(s.ctx, entity.ListAreasArgs{})
 	require.NoError(s.T(), err)
+	areas := res.Areas
 	require.Equal(s.T(), 2, len(areas))
 
 	// Create a new session.
@@ -103,12 +105,187 @@ func (s *RobotTestSuite) TestUpdateSession() {
 	require.GreaterOrEqual(s.T(), updSess.DurationSec, 24, "should have a duration of at least 24 seconds")
 
 	// Fetch all history.
-	history, err := s.th.Service.Robot.History(s.ctx, robots[0].UID, 10) // Fetch only the 10 latest cleaning sessions.
+	hr, err := s.th.Service.Robot.History(s.ctx, entity.HistoryArgs{
+		RobotID:          robots[0].UID,
+		Max:              10, // Fetch only the 10 latest cleaning sessions.
+		IncludePositions: true,
+	})
+	require.NoError(s.T(), err)
+	history := hr.Robot
 	require.GreaterOrEqual(s.T(), len(history.Session), 1, "should have at least one session")
 	require.GreaterOrEqual(s.T(), len(history.Session[0].PositionHistory), 1, "should have at least one historical position")
 	require.Equal(s.T(), endedAt.Unix(), history.Session[0].EndedAt.Unix(), "should have latest session ended at a predicatable time")
 }
 
+func (s *RobotTestSuite) TestSessionEvents() {
+	robots, err := s.th.Service.Robot.List(s.ctx, "", "")
+	require.NoError(s.T(), err)
+
+	res, err := s.th.Service.Area.List(s.ctx, entity.ListAreasArgs{})
+	require.NoError(s.T(), err)
+
+	sub := s.th.EventBus.Subscribe(entity.EventFilter{RobotIDs: []string{robots[0].UID}})
+	defer sub.Close()
+
+	startedAt := time.Now()
+	sess, err := s.th.Service.Robot.StartSession(s.ctx, entity.StartSessionArgs{
+		RobotID:   robots[0].UID,
+		AreaID:    res.Areas[0].UID,
+		StartedAt: startedAt,
+	})
+	require.NoError(s.T(), err)
+
+	center := robots[0].Size / 2
+	_, err = s.th.Service.Robot.EndSession(s.ctx, entity.UpdateSessionArgs{
+		RobotID:    robots[0].UID,
+		RobotX:     center,
+		RobotY:     center,
+		ReportedAt: startedAt.Add(time.Second),
+	})
+	require.NoError(s.T(), err)
+
+	var types []entity.EventType
+	for len(sub.Events()) > 0 {
+		e := <-sub.Events()
+		if e.SessionID == sess.UID {
+			require.Equal(s.T(), res.Areas[0].UID, e.AreaID, "should include area id")
+			types = append(types, e.Type)
+		}
+	}
+	require.Equal(s.T(), []entity.EventType{
+		entity.EventSessionStarted,
+		entity.EventPosition,
+		entity.EventSquarePassed,
+		entity.EventSessionEnded,
+	}, types, "should publish session events in order")
+}
+
+func (s *RobotTestSuite) TestSessionAnomalies() {
+	robots, err := s.th.Service.Robot.List(s.ctx, "", "")
+	require.NoError(s.T(), err)
+
+	res, err := s.th.Service.Area.List(s.ctx, entity.ListAreasArgs{})
+	require.NoError(s.T(), err)
+	area := res.Areas[0]
+
+	sub := s.th.EventBus.Subscribe(entity.EventFilter{RobotIDs: []string{robots[0].UID}})
+	defer sub.Close()
+
+	startedAt := time.Now()
+	sess, err := s.th.Service.Robot.StartSession(s.ctx, entity.StartSessionArgs{
+		RobotID:   robots[0].UID,
+		AreaID:    area.UID,
+		StartedAt: startedAt,
+	})
+	require.NoError(s.T(), err)
+
+	// Jump outside of the area within a second.
+	_, err = s.th.Service.Robot.EndSession(s.ctx, entity.UpdateSessionArgs{
+		RobotID:    robots[0].UID,
+		RobotX:     area.SizeX + 10000,
+		RobotY:     0,
+		ReportedAt: startedAt.Add(time.Second),
+	})
+	require.NoError(s.T(), err)
+
+	var types []entity.AnomalyType
+	for len(sub.Events()) > 0 {
+		e := <-sub.Events()
+		if e.SessionID == sess.UID && e.Type == entity.EventAnomaly {
+			types = append(types, e.Anomaly.Type)
+		}
+	}
+	require.Equal(s.T(), []entity.AnomalyType{entity.AnomalyOutOfBounds, entity.AnomalyJump}, types, "should publish an alert per anomaly")
+
+	as, err := s.th.Service.Session.Anomalies(s.ctx, sess.UID)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 2, len(as), "should record anomalies on the session")
+}
+
+func (s *RobotTestSuite) TestSessionOutbox() {
+	robots, err := s.th.Service.Robot.List(s.ctx, "", "")
+	require.NoError(s.T(), err)
+
+	res, err := s.th.Service.Area.List(s.ctx, entity.ListAreasArgs{})
+	require.NoError(s.T(), err)
+
+	// Start a session and let the robot go quiet.
+	startedAt := time.Now().Add(-time.Hour)
+	sess, err := s.th.Service.Robot.StartSession(s.ctx, entity.StartSessionArgs{
+		RobotID:   robots[0].UID,
+		AreaID:    res.Areas[0].UID,
+		StartedAt: startedAt,
+	})
+	require.NoError(s.T(), err)
+
+	n, err := s.th.Service.Robot.DetectOffline(s.ctx, time.Now())
+	require.NoError(s.T(), err)
+	require.GreaterOrEqual(s.T(), n, 1, "should detect the robot as offline")
+
+	center := robots[0].Size / 2
+	_, err = s.th.Service.Robot.EndSession(s.ctx, entity.UpdateSessionArgs{
+		RobotID:    robots[0].UID,
+		RobotX:     center,
+		RobotY:     center,
+		ReportedAt: time.Now(),
+	})
+	require.NoError(s.T(), err)
+
+	events, err := s.th.Repository.Webhook.Outbox(s.ctx, 1000)
+	require.NoError(s.T(), err)
+
+	var types []entity.EventType
+	for _, e := range events {
+		if e.SessionID == sess.UID {
+			require.Equal(s.T(), robots[0].UID, e.RobotID, "should include robot id")
+			require.Equal(s.T(), res.Areas[0].UID, e.AreaID, "should include area id")
+			types = append(types, e.EventType)
+		}
+	}
+	require.Equal(s.T(), []entity.EventType{
+		entity.EventSessionStarted,
+		entity.EventRobotOffline,
+		entity.EventSessionEnded,
+	}, types, "should save events to the outbox with the session")
+}
+
+func (s *RobotTestSuite) TestDetectOfflineSkipsEndedSessions() {
+	robots, err := s.th.Service.Robot.List(s.ctx, "", "")
+	require.NoError(s.T(), err)
+
+	res, err := s.th.Service.Area.List(s.ctx, entity.ListAreasArgs{})
+	require.NoError(s.T(), err)
+
+	// Run a session that ended an hour ago.
+	startedAt := time.Now().Add(-2 * time.Hour)
+	sess, err := s.th.Service.Robot.StartSession(s.ctx, entity.StartSessionArgs{
+		RobotID:   robots[1].UID,
+		AreaID:    res.Areas[0].UID,
+		StartedAt: startedAt,
+	})
+	require.NoError(s.T(), err)
+
+	center := robots[1].Size / 2
+	_, err = s.th.Service.Robot.EndSession(s.ctx, entity.UpdateSessionArgs{
+		RobotID:    robots[1].UID,
+		RobotX:     center,
+		RobotY:     center,
+		ReportedAt: startedAt.Add(time.Hour),
+	})
+	require.NoError(s.T(), err)
+
+	_, err = s.th.Service.Robot.DetectOffline(s.ctx, time.Now())
+	require.NoError(s.T(), err)
+
+	events, err := s.th.Repository.Webhook.Outbox(s.ctx, 1000)
+	require.NoError(s.T(), err)
+	for _, e := range events {
+		if e.SessionID == sess.UID {
+			require.NotEqual(s.T(), entity.EventRobotOffline, e.EventType, "should not detect robots offline after their session has ended")
+		}
+	}
+}
+
 // In order for 'go test' to run this suite, we need to create
 // a normal test function and pass our suite to suite.Run
 func TestRobotTestSuite(t *testing.T) {
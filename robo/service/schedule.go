@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/anrid/roboviewer/robo/pkg/cron"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultJobRunsLimit is the default number of job runs returned.
+	DefaultJobRunsLimit = 100
+	// MaxJobRunsLimit is the max number of job runs returned.
+	MaxJobRunsLimit = 1000
+)
+
+// ScheduleService holds use cases related to cleaning schedules.
+// Schedules are triggered by a background worker, see package
+// scheduler.
+type ScheduleService struct {
+	r entity.ScheduleRepository
+	b entity.RobotRepository
+	a entity.AreaRepository
+}
+
+// NewScheduleService creates a new schedule service instance.
+func NewScheduleService(r entity.ScheduleRepository, b entity.RobotRepository, a entity.AreaRepository) *ScheduleService {
+	return &ScheduleService{r, b, a}
+}
+
+// List returns all schedules.
+func (co *ScheduleService) List(ctx context.Context) ([]*entity.Schedule, error) {
+	return co.r.List(ctx)
+}
+
+// Get returns a schedule by id.
+func (co *ScheduleService) Get(ctx context.Context, scheduleID string) (*entity.Schedule, error) {
+	s, err := co.r.Get(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find schedule with id %s", scheduleID)
+	}
+	return s, nil
+}
+
+// Create creates a new schedule.
+func (co *ScheduleService) Create(ctx context.Context, a entity.CreateScheduleArgs) (*entity.Schedule, error) {
+	tz := strings.TrimSpace(a.TimeZone)
+	if tz == "" {
+		tz = "UTC"
+	}
+
+	s := entity.NewSchedule(strings.TrimSpace(a.Name), a.AreaID, a.RobotIDs, strings.TrimSpace(a.Cron), tz, a.PassesNeeded)
+	if err := co.validate(ctx, s); err != nil {
+		return nil, err
+	}
+
+	var err error
+	s.NextRunAt, err = s.Next(time.Now())
+	if err != nil {
+		return nil, errors.Wrap(cerr.ErrValidationFailed, err.Error())
+	}
+	if s.NextRunAt == nil {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "cron expression '%s' never triggers", s.Cron)
+	}
+
+	uids, err := co.r.Save(ctx, s)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not persist schedule")
+	}
+	s.UID = uids[entity.ScheduleUID]
+
+	return s, nil
+}
+
+// Update updates a schedule. The next run time is recalculated from
+// now.
+func (co *ScheduleService) Update(ctx context.Context, a entity.UpdateScheduleArgs) (*entity.Schedule, error) {
+	s, err := co.Get(ctx, a.ScheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Name != nil {
+		s.Name = strings.TrimSpace(*a.Name)
+	}
+	if a.AreaID != nil {
+		s.AreaID = *a.AreaID
+	}
+	if a.RobotIDs != nil {
+		s.RobotIDs = a.RobotIDs
+	}
+	if a.Cron != nil {
+		s.Cron = strings.TrimSpace(*a.Cron)
+	}
+	if a.TimeZone != nil {
+		s.TimeZone = strings.TrimSpace(*a.TimeZone)
+	}
+	if a.PassesNeeded != nil {
+		s.PassesNeeded = *a.PassesNeeded
+	}
+	if err := co.validate(ctx, s); err != nil {
+		return nil, err
+	}
+
+	s.NextRunAt, err = s.Next(time.Now())
+	if err != nil {
+		return nil, errors.Wrap(cerr.ErrValidationFailed, err.Error())
+	}
+	if s.NextRunAt == nil {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "cron expression '%s' never triggers", s.Cron)
+	}
+
+	_, err = co.r.Update(ctx, &entity.Schedule{
+		Name:         s.Name,
+		AreaID:       s.AreaID,
+		RobotIDs:     s.RobotIDs,
+		Cron:         s.Cron,
+		TimeZone:     s.TimeZone,
+		PassesNeeded: s.PassesNeeded,
+		NextRunAt:    s.NextRunAt,
+		Common:       entity.Common{UID: s.UID},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not persist schedule")
+	}
+
+	return s, nil
+}
+
+// Delete soft-deletes a schedule. It won't trigger again.
+func (co *ScheduleService) Delete(ctx context.Context, scheduleID string) error {
+	s, err := co.Get(ctx, scheduleID)
+	if err != nil {
+		return err
+	}
+
+	deletedAt := time.Now()
+	_, err = co.r.Save(ctx, &entity.Schedule{
+		DeletedAt: &deletedAt,
+		Common:    entity.Common{UID: s.UID},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete schedule")
+	}
+	return nil
+}
+
+// Runs returns a schedule's runs, latest first.
+func (co *ScheduleService) Runs(ctx context.Context, a entity.ListJobRunsArgs) ([]*entity.JobRun, error) {
+	if _, err := co.Get(ctx, a.ScheduleID); err != nil {
+		return nil, err
+	}
+	if a.Limit == 0 {
+		a.Limit = DefaultJobRunsLimit
+	}
+	if a.Limit < 0 || a.Limit > MaxJobRunsLimit {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "limit must be between 1 and %d, got %d", MaxJobRunsLimit, a.Limit)
+	}
+	return co.r.Runs(ctx, a)
+}
+
+// validate checks that a schedule is valid and that its area and
+// robots exist.
+func (co *ScheduleService) validate(ctx context.Context, s *entity.Schedule) error {
+	if s.Name == "" {
+		return errors.Wrap(cerr.ErrValidationFailed, "schedule name cannot be empty")
+	}
+	if _, err := cron.Parse(s.Cron); err != nil {
+		return errors.Wrap(cerr.ErrValidationFailed, err.Error())
+	}
+	if _, err := time.LoadLocation(s.TimeZone); err != nil {
+		return errors.Wrapf(cerr.ErrValidationFailed, "unknown time zone '%s'", s.TimeZone)
+	}
+	if s.PassesNeeded < 0 {
+		return errors.Wrapf(cerr.ErrValidationFailed, "passes needed cannot be negative, got %d", s.PassesNeeded)
+	}
+
+	area, err := co.a.Get(ctx, s.AreaID)
+	if err != nil {
+		return err
+	}
+	if area == nil {
+		return errors.Wrapf(cerr.ErrValidationFailed, "could not find area with id %s", s.AreaID)
+	}
+
+	if len(s.RobotIDs) == 0 {
+		return errors.Wrap(cerr.ErrValidationFailed, "schedule needs at least one robot")
+	}
+	seen := make(map[string]bool)
+	for _, id := range s.RobotIDs {
+		if seen[id] {
+			return errors.Wrapf(cerr.ErrValidationFailed, "robot id %s given more than once", id)
+		}
+		seen[id] = true
+
+		robot, err := co.b.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if robot == nil {
+			return errors.Wrapf(cerr.ErrValidationFailed, "could not find robot with id %s", id)
+		}
+	}
+	return nil
+}
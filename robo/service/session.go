@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/analytics"
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/anrid/roboviewer/robo/planning"
+	"github.com/anrid/roboviewer/robo/replay"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultSessionsLimit is the default page size when listing sessions.
+	DefaultSessionsLimit = 100
+	// MaxSessionsLimit is the max page size when listing sessions.
+	MaxSessionsLimit = 1000
+	// DefaultPositionsLimit is the default page size when listing positions.
+	DefaultPositionsLimit = 1000
+	// MaxPositionsLimit is the max page size when listing positions.
+	MaxPositionsLimit = 10000
+	// MaxSessionPositions is the max number of positions we load into
+	// memory for a single session, e.g. when rendering its path.
+	MaxSessionPositions = 1000000
+)
+
+// SessionService holds use cases related to cleaning sessions.
+type SessionService struct {
+	r entity.SessionRepository
+}
+
+// NewSessionService creates a new session service instance.
+func NewSessionService(r entity.SessionRepository) *SessionService {
+	return &SessionService{r}
+}
+
+// Get returns a summary of a cleaning session, including an ETA if
+// the session is active.
+func (co *SessionService) Get(ctx context.Context, sessionID string) (*entity.SessionSummary, error) {
+	s, err := co.r.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find session with id %s", sessionID)
+	}
+	if s.Session.IsActive {
+		var robotID string
+		if s.Robot != nil {
+			robotID = s.Robot.UID
+		}
+		eta, err := estimateETA(ctx, co.r, robotID, s.Session)
+		if err != nil {
+			return nil, err
+		}
+		if eta != nil {
+			s.Progress.SetETA(eta)
+		}
+	}
+	return s, nil
+}
+
+// Grid returns a session's cleaning area including the entire grid.
+func (co *SessionService) Grid(ctx context.Context, sessionID string) (*entity.CleaningArea, error) {
+	sess, err := co.WithGrid(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return sess.Area[0], nil
+}
+
+// GridAt returns a session's cleaning area as it was at the given
+// point in time, rebuilt by replaying the session's position history.
+func (co *SessionService) GridAt(ctx context.Context, sessionID string, at time.Time) (*entity.CleaningArea, error) {
+	// Include positions passed at the given time.
+	to := at.Add(time.Nanosecond)
+
+	sess, err := co.WithPositions(ctx, entity.WithPositionsArgs{
+		SessionID: sessionID,
+		To:        &to,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return replay.At(sess.Area[0], sess.PositionHistory, at)
+}
+
+// CheckGrid replays a session's entire position history and compares
+// the result with the session's persisted grid.
+func (co *SessionService) CheckGrid(ctx context.Context, sessionID string) (*entity.GridDrift, error) {
+	sess, err := co.WithPositions(ctx, entity.WithPositionsArgs{SessionID: sessionID})
+	if err != nil {
+		return nil, err
+	}
+	return replay.Check(sess)
+}
+
+// Stats returns coverage and efficiency analytics for a session.
+// Stats are persisted the first time they're computed after the
+// session has ended, since they can no longer change.
+func (co *SessionService) Stats(ctx context.Context, sessionID string) (*entity.SessionStats, error) {
+	st, err := co.r.Stats(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if st != nil {
+		return st, nil
+	}
+
+	sess, err := co.WithPositions(ctx, entity.WithPositionsArgs{SessionID: sessionID})
+	if err != nil {
+		return nil, err
+	}
+
+	st, err = analytics.SessionStats(sess)
+	if err != nil {
+		return nil, err
+	}
+
+	if sess.EndedAt != nil {
+		uids, err := co.r.Save(ctx, &entity.CleaningSession{
+			Stats:  []*entity.SessionStats{st},
+			Common: entity.Common{UID: sess.UID},
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "could not persist session stats")
+		}
+		st.UID = uids[entity.SessionStatsUID]
+	}
+	return st, nil
+}
+
+// Anomalies returns the anomalies detected during a session.
+func (co *SessionService) Anomalies(ctx context.Context, sessionID string) ([]*entity.Anomaly, error) {
+	as, err := co.r.Anomalies(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if as == nil {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find session with id %s", sessionID)
+	}
+	return as, nil
+}
+
+// Plan returns a coverage plan for a session. By default the plan
+// resumes from the robot's last reported position and covers only the
+// squares not yet cleaned, otherwise it covers the entire area.
+func (co *SessionService) Plan(ctx context.Context, a entity.PlanArgs) (*entity.Plan, error) {
+	sess, err := co.WithGrid(ctx, a.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	ca := sess.Area[0]
+	if len(ca.Grid) == 0 {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find a grid for session %s", a.SessionID)
+	}
+
+	if a.Full {
+		return planning.Boustrophedon(ca.SizeX, ca.SizeY, ca.Grid[0].Size, ca.PassesNeeded), nil
+	}
+	return planning.Resume(ca, &entity.Waypoint{X: sess.LastX, Y: sess.LastY}), nil
+}
+
+// WithGrid returns a cleaning session including its cleaning area and
+// the entire grid.
+func (co *SessionService) WithGrid(ctx context.Context, sessionID string) (*entity.CleaningSession, error) {
+	sess, err := co.r.GetWithGrid(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if sess == nil {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find session with id %s", sessionID)
+	}
+	if len(sess.Area) == 0 {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find a cleaning area for session %s", sessionID)
+	}
+	return sess, nil
+}
+
+// WithPositions returns a cleaning session including its cleaning area,
+// the entire grid and its position history within the given time
+// window. Positions are loaded page by page.
+func (co *SessionService) WithPositions(ctx context.Context, a entity.WithPositionsArgs) (*entity.CleaningSession, error) {
+	if a.From != nil && a.To != nil && !a.From.Before(*a.To) {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "from (%s) must be before to (%s)", a.From, a.To)
+	}
+
+	sess, err := co.WithGrid(ctx, a.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []*entity.Position
+	var cursor string
+	for {
+		res, err := co.r.Positions(ctx, entity.ListPositionsArgs{
+			SessionID: a.SessionID,
+			From:      a.From,
+			To:        a.To,
+			Cursor:    cursor,
+			Limit:     MaxPositionsLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if res == nil {
+			return nil, errors.Wrapf(cerr.ErrNotFound, "could not find session with id %s", a.SessionID)
+		}
+
+		positions = append(positions, res.Positions...)
+		if len(positions) > MaxSessionPositions {
+			return nil, errors.Wrapf(cerr.ErrValidationFailed, "session %s has more than %d positions, use a shorter time window", a.SessionID, MaxSessionPositions)
+		}
+
+		if res.NextCursor == "" {
+			break
+		}
+		cursor = res.NextCursor
+	}
+
+	sess.PositionHistory = positions
+	return sess, nil
+}
+
+// List returns a page of sessions across all robots.
+func (co *SessionService) List(ctx context.Context, a entity.ListSessionsArgs) (*entity.ListSessionsResult, error) {
+	if a.Limit == 0 {
+		a.Limit = DefaultSessionsLimit
+	}
+	if a.Limit < 0 || a.Limit > MaxSessionsLimit {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "limit must be between 1 and %d, got %d", MaxSessionsLimit, a.Limit)
+	}
+	if a.From != nil && a.To != nil && !a.From.Before(*a.To) {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "from (%s) must be before to (%s)", a.From, a.To)
+	}
+	return co.r.List(ctx, a)
+}
+
+// Positions returns a page of a session's position history.
+func (co *SessionService) Positions(ctx context.Context, a entity.ListPositionsArgs) (*entity.ListPositionsResult, error) {
+	if a.Limit == 0 {
+		a.Limit = DefaultPositionsLimit
+	}
+	if a.Limit < 0 || a.Limit > MaxPositionsLimit {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "limit must be between 1 and %d, got %d", MaxPositionsLimit, a.Limit)
+	}
+	if a.From != nil && a.To != nil && !a.From.Before(*a.To) {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "from (%s) must be before to (%s)", a.From, a.To)
+	}
+
+	res, err := co.r.Positions(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find session with id %s", a.SessionID)
+	}
+	return res, nil
+}
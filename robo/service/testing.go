@@ -7,6 +7,7 @@ import (
 	"github.com/anrid/roboviewer/robo/config"
 	"github.com/anrid/roboviewer/robo/dg"
 	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/eventbus"
 )
 
 var (
@@ -15,13 +16,18 @@ var (
 )
 
 type testHelper struct {
+	EventBus   entity.EventBus
 	Repository struct {
-		Robot entity.RobotRepository
-		Area  entity.AreaRepository
+		Robot   entity.RobotRepository
+		Area    entity.AreaRepository
+		Session entity.SessionRepository
+		Webhook entity.WebhookRepository
+		Dock    entity.DockRepository
 	}
 	Service struct {
-		Robot entity.RobotService
-		Area  entity.AreaService
+		Robot   entity.RobotService
+		Area    entity.AreaService
+		Session entity.SessionService
 	}
 }
 
@@ -30,7 +36,9 @@ func newTestHelper() *testHelper {
 	once.Do(func() {
 		c := config.GetConfig()
 
-		th = &testHelper{}
+		th = &testHelper{
+			EventBus: eventbus.New(),
+		}
 
 		conn, _ := dg.Connect(c.DgraphURL)
 
@@ -38,9 +46,13 @@ func newTestHelper() *testHelper {
 
 		th.Repository.Robot = dg.NewRobotRepository(conn)
 		th.Repository.Area = dg.NewAreaRepository(conn)
+		th.Repository.Session = dg.NewSessionRepository(conn)
+		th.Repository.Webhook = dg.NewWebhookRepository(conn)
+		th.Repository.Dock = dg.NewDockRepository(conn)
 
-		th.Service.Robot = NewRobotService(th.Repository.Robot)
+		th.Service.Robot = NewRobotService(th.Repository.Robot, th.Repository.Session, th.Repository.Dock, th.EventBus)
 		th.Service.Area = NewAreaService(th.Repository.Area)
+		th.Service.Session = NewSessionService(th.Repository.Session)
 	})
 	return th
 }
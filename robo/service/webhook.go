@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/anrid/roboviewer/robo/entity"
+	"github.com/anrid/roboviewer/robo/pkg/cerr"
+	"github.com/anrid/roboviewer/robo/pkg/webhook"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultWebhookMessagesLimit is the default number of webhook
+	// messages returned.
+	DefaultWebhookMessagesLimit = 100
+	// MaxWebhookMessagesLimit is the max number of webhook messages
+	// returned.
+	MaxWebhookMessagesLimit = 1000
+)
+
+// WebhookService holds use cases related to webhook subscriptions.
+// Events are written to an outbox by the RobotService and delivered
+// by a background worker, see package outbox.
+type WebhookService struct {
+	r entity.WebhookRepository
+}
+
+// NewWebhookService creates a new webhook service instance.
+func NewWebhookService(r entity.WebhookRepository) *WebhookService {
+	return &WebhookService{r}
+}
+
+// List returns all webhooks.
+func (co *WebhookService) List(ctx context.Context) ([]*entity.Webhook, error) {
+	return co.r.List(ctx)
+}
+
+// Get returns a webhook by id.
+func (co *WebhookService) Get(ctx context.Context, webhookID string) (*entity.Webhook, error) {
+	w, err := co.r.Get(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return nil, errors.Wrapf(cerr.ErrNotFound, "could not find webhook with id %s", webhookID)
+	}
+	return w, nil
+}
+
+// Create creates a new webhook. A secret used to sign requests is
+// generated unless one is given.
+func (co *WebhookService) Create(ctx context.Context, a entity.CreateWebhookArgs) (*entity.Webhook, error) {
+	secret := a.Secret
+	if secret == "" {
+		secret = webhook.NewSecret()
+	}
+
+	w := entity.NewWebhook(strings.TrimSpace(a.Name), strings.TrimSpace(a.URL), secret, a.EventTypes)
+	if err := validateWebhook(w); err != nil {
+		return nil, err
+	}
+
+	uids, err := co.r.Save(ctx, w)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not persist webhook")
+	}
+	w.UID = uids[entity.WebhookUID]
+
+	return w, nil
+}
+
+// Update updates a webhook.
+func (co *WebhookService) Update(ctx context.Context, a entity.UpdateWebhookArgs) (*entity.Webhook, error) {
+	w, err := co.Get(ctx, a.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Name != nil {
+		w.Name = strings.TrimSpace(*a.Name)
+	}
+	if a.URL != nil {
+		w.URL = strings.TrimSpace(*a.URL)
+	}
+	if a.Secret != nil {
+		w.Secret = *a.Secret
+	}
+	if a.EventTypes != nil {
+		w.EventTypes = a.EventTypes
+	}
+	if err := validateWebhook(w); err != nil {
+		return nil, err
+	}
+
+	err = co.r.Update(ctx, &entity.Webhook{
+		Name:       w.Name,
+		URL:        w.URL,
+		Secret:     w.Secret,
+		EventTypes: a.EventTypes,
+		Common:     entity.Common{UID: w.UID},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not persist webhook")
+	}
+
+	return w, nil
+}
+
+// Delete soft-deletes a webhook. Messages pending delivery to it are
+// dropped.
+func (co *WebhookService) Delete(ctx context.Context, webhookID string) error {
+	w, err := co.Get(ctx, webhookID)
+	if err != nil {
+		return err
+	}
+
+	deletedAt := time.Now()
+	_, err = co.r.Save(ctx, &entity.Webhook{
+		DeletedAt: &deletedAt,
+		Common:    entity.Common{UID: w.UID},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete webhook")
+	}
+	return nil
+}
+
+// Messages returns messages sent to a webhook including their
+// delivery log, latest first.
+func (co *WebhookService) Messages(ctx context.Context, a entity.ListWebhookMessagesArgs) ([]*entity.WebhookMessage, error) {
+	if _, err := co.Get(ctx, a.WebhookID); err != nil {
+		return nil, err
+	}
+	if a.Limit == 0 {
+		a.Limit = DefaultWebhookMessagesLimit
+	}
+	if a.Limit < 0 || a.Limit > MaxWebhookMessagesLimit {
+		return nil, errors.Wrapf(cerr.ErrValidationFailed, "limit must be between 1 and %d, got %d", MaxWebhookMessagesLimit, a.Limit)
+	}
+	return co.r.Messages(ctx, a)
+}
+
+// validateWebhook checks that a webhook is valid.
+func validateWebhook(w *entity.Webhook) error {
+	if w.Name == "" {
+		return errors.Wrap(cerr.ErrValidationFailed, "webhook name cannot be empty")
+	}
+	if len(w.EventTypes) == 0 {
+		return errors.Wrapf(cerr.ErrValidationFailed, "webhook must subscribe to at least one of %v", entity.WebhookEventTypes)
+	}
+	seen := make(map[entity.EventType]bool)
+	for _, t := range w.EventTypes {
+		var known bool
+		for _, wt := range entity.WebhookEventTypes {
+			known = known || t == wt
+		}
+		if !known {
+			return errors.Wrapf(cerr.ErrValidationFailed, "webhook event type must be one of %v, got '%s'", entity.WebhookEventTypes, t)
+		}
+		if seen[t] {
+			return errors.Wrapf(cerr.ErrValidationFailed, "webhook event type '%s' given more than once", t)
+		}
+		seen[t] = true
+	}
+	return validateWebhookURL(w.URL)
+}